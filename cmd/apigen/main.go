@@ -0,0 +1,44 @@
+// apigen 由 go-zero 的 .api 定义生成 OpenAPI 3 文档，通过 go generate ./internal/apidoc 执行
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/p-program/Fenrir/internal/apispec"
+)
+
+var (
+	apiFile = flag.String("api", "api/restaurant.api", "the go-zero api definition")
+	output  = flag.String("o", "internal/apidoc/openapi.json", "the openapi document to write, - for stdout")
+)
+
+func main() {
+	flag.Parse()
+
+	src, err := os.ReadFile(*apiFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "读取 API 定义失败:", err)
+		os.Exit(1)
+	}
+	spec, err := apispec.Parse(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解析 %s 失败: %v\n", *apiFile, err)
+		os.Exit(1)
+	}
+	out, err := apispec.OpenAPI(spec).Marshal()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "生成 OpenAPI 文档失败:", err)
+		os.Exit(1)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "写入 OpenAPI 文档失败:", err)
+		os.Exit(1)
+	}
+}
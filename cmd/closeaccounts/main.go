@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/settlement"
+	"github.com/p-program/Fenrir/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	listFile   = flag.String("list", "", "the graduation list csv file, with a user_id column")
+	reason     = flag.String("reason", "毕业离校", "closure reason for rows without a reason column")
+	dryRun     = flag.Bool("dry-run", false, "compute the settlement only, do not close accounts")
+	certDir    = flag.String("certs", "", "write a pdf closure certificate for each closed account to this directory")
+	jsonOut    = flag.Bool("json", false, "print the result as json")
+)
+
+func main() {
+	flag.Parse()
+
+	if *listFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: closeaccounts -list graduates.csv [-reason 毕业离校] [-dry-run] [-certs dir] [-json] [-f etc/restaurant-api.yaml]")
+		os.Exit(2)
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	file, err := os.Open(*listFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "打开文件失败:", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	svcCtx := svc.NewServiceContext(c)
+	batch, err := logic.NewRestaurantLogic(svcCtx.DB).CloseAccounts(context.Background(), file, *reason, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "批量销户失败:", err)
+		os.Exit(1)
+	}
+
+	failed := batch.Failed
+	if *certDir != "" && !*dryRun {
+		if err := os.MkdirAll(*certDir, 0o755); err != nil {
+			fmt.Fprintln(os.Stderr, "创建目录失败:", err)
+			os.Exit(1)
+		}
+		// 之前已销户的用户也重新输出证明
+		for _, row := range batch.Rows {
+			if row.Closure == nil {
+				continue
+			}
+			path := filepath.Join(*certDir, fmt.Sprintf("closure-%s-%s.pdf", row.UserID, row.Closure.No))
+			if err := os.WriteFile(path, settlement.PDF(logic.ClosureCertificate(row.Closure)), 0o644); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "写入销户证明 %s 失败: %v\n", path, err)
+			}
+		}
+	}
+
+	if *jsonOut {
+		out, _ := json.MarshalIndent(batch, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printBatch(batch)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func printBatch(b *logic.AccountClosureBatch) {
+	fmt.Printf("%-6s %-20s %-15s %10s %10s %10s %s\n", "row", "user_id", "status", "balance", "refund", "subsidy", "error/no")
+	for _, row := range b.Rows {
+		var r settlement.Result
+		var note string
+		switch {
+		case row.Settlement != nil:
+			r = *row.Settlement
+		case row.Closure != nil:
+			r = settlement.Result{Balance: row.Closure.Balance, Refundable: row.Closure.Refundable, Subsidy: row.Closure.Subsidy, Expired: row.Closure.ExpiredSubsidy}
+			note = row.Closure.No
+		}
+		if row.Error != "" {
+			note = row.Error
+		}
+		fmt.Printf("%-6d %-20s %-15s %10.2f %10.2f %10.2f %s\n", row.Row, row.UserID, row.Status, r.Balance, r.Refundable, r.Subsidy+r.Expired, note)
+	}
+
+	action := "销户"
+	if b.DryRun {
+		action = "可销户（试运行）"
+	}
+	fmt.Printf("\n共 %d 人，%s %d 人，已销户跳过 %d 人，失败 %d 人；退还自有资金 %.2f 元，收回补贴 %.2f 元\n",
+		b.Total, action, b.Closed, b.Skipped, b.Failed, b.Refundable, b.Subsidy)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/forecast"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/internal/tenant"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	fromDate   = flag.String("from", "", "first day to score, 2006-01-02")
+	toDate     = flag.String("to", "", "last day to score, 2006-01-02 (default yesterday)")
+	alpha      = flag.Float64("alpha", 0, "override Forecast.Alpha")
+	canteenID  = flag.String("canteen", "", "only use orders of this canteen")
+	jsonOut    = flag.Bool("json", false, "print the result as json")
+)
+
+func main() {
+	flag.Parse()
+
+	if *fromDate == "" {
+		fmt.Fprintln(os.Stderr, "usage: forecastbacktest -from 2024-09-01 [-to 2024-09-30] [-alpha 0.3] [-canteen id] [-json] [-f etc/restaurant-api.yaml]")
+		os.Exit(2)
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	from, err := time.ParseInLocation(time.DateOnly, *fromDate, time.Local)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "开始日期格式错误:", err)
+		os.Exit(2)
+	}
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if *toDate != "" {
+		end, err := time.ParseInLocation(time.DateOnly, *toDate, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "结束日期格式错误:", err)
+			os.Exit(2)
+		}
+		to = end.AddDate(0, 0, 1)
+	}
+
+	cfg := forecast.Config{Alpha: c.Forecast.Alpha, MinSamples: c.Forecast.MinSamples}
+	if *alpha > 0 {
+		cfg.Alpha = *alpha
+	}
+
+	svcCtx := svc.NewServiceContext(c)
+	l := logic.NewRestaurantLogic(svcCtx.DB).
+		WithInventory(c.Inventory.LowStock, svcCtx.Periods).
+		WithForecast(cfg, c.Forecast.HistoryDays)
+
+	ctx := tenant.WithCanteen(context.Background(), *canteenID)
+	result, err := l.BacktestForecast(ctx, from, to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "回测失败:", err)
+		os.Exit(1)
+	}
+
+	if *jsonOut {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	foodIDs := make([]string, 0, len(result.Foods))
+	for id := range result.Foods {
+		foodIDs = append(foodIDs, id)
+	}
+	sort.Strings(foodIDs)
+
+	fmt.Printf("回测区间 %s ~ %s，alpha=%.2f，训练历史 %d 天\n\n",
+		from.Format(time.DateOnly), to.AddDate(0, 0, -1).Format(time.DateOnly), cfg.Alpha, c.Forecast.HistoryDays)
+	fmt.Printf("%-20s %6s %6s %12s %10s %8s %10s\n", "food_id", "count", "missed", "actual(g)", "mae(g)", "wape", "bias(g)")
+	for _, id := range foodIDs {
+		printScore(id, result.Foods[id])
+	}
+	printScore("合计", result.Overall)
+}
+
+func printScore(name string, s forecast.Score) {
+	fmt.Printf("%-20s %6d %6d %12.0f %10.1f %7.1f%% %10.1f\n", name, s.Count, s.Missed, s.Actual, s.MAE, s.WAPE*100, s.Bias)
+}
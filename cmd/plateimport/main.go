@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	csvFile    = flag.String("csv", "", "the plate csv file")
+	dryRun     = flag.Bool("dry-run", false, "validate only, do not insert")
+)
+
+func main() {
+	flag.Parse()
+
+	if *csvFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: plateimport -csv plates.csv [-dry-run] [-f etc/restaurant-api.yaml]")
+		os.Exit(2)
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	file, err := os.Open(*csvFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "打开文件失败:", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	ctx := svc.NewServiceContext(c)
+	result, err := logic.NewRestaurantLogic(ctx.DB).ImportPlates(context.Background(), file, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "导入失败:", err)
+		os.Exit(1)
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "第 %d 行 %s: %s\n", e.Row, e.Field, e.Message)
+	}
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+
+	if len(result.Errors) > 0 {
+		os.Exit(1)
+	}
+}
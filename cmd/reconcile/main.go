@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/reconcile"
+	"github.com/p-program/Fenrir/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	jsonOut    = flag.Bool("json", false, "print the report as json")
+	repair     = flag.Bool("repair", false, "restore wallet balances that differ from the ledger and add adjust transactions for gaps in the transaction log")
+	operator   = flag.String("operator", "", "with -repair, id of the manager who confirms the repair")
+	maxAdjust  = flag.Float64("max-adjust", 100, "with -repair, skip wallets whose difference exceeds this amount")
+	remark     = flag.String("remark", "", "remark of the repair records and adjust transactions")
+)
+
+func main() {
+	flag.Parse()
+	if *repair && *operator == "" {
+		fmt.Fprintln(os.Stderr, "-repair 必须用 -operator 指定确认修复的管理员")
+		os.Exit(2)
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	svcCtx := svc.NewServiceContext(c)
+	l := svcCtx.NewLogic()
+	ctx := context.Background()
+
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "对账失败:", err)
+		os.Exit(1)
+	}
+
+	// 余额、账本和交易流水中只有一项不一致时修复该项：余额不同时改回账本余额，流水不同时补记调整交易。
+	// 三者互不一致、订单问题和孤立交易需要人工核实
+	if *repair {
+		reconcile.Repair(ctx, l, report, reconcile.RepairOptions{OperatorID: *operator, MaxAdjust: *maxAdjust, Remark: *remark}, os.Stderr)
+	}
+
+	if *jsonOut {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printReport(report)
+	}
+
+	if report.Unresolved() > 0 {
+		os.Exit(1)
+	}
+}
+
+func printReport(r *logic.ReconcileReport) {
+	fmt.Printf("对账时间 %s：%d 个钱包、%d 个订单、%d 条交易\n\n",
+		r.At.Format("2006-01-02 15:04:05"), r.Wallets, r.Orders, r.Transactions)
+	if r.OK() {
+		fmt.Println("没有发现问题")
+		return
+	}
+
+	if len(r.Mismatches) > 0 {
+		fmt.Printf("余额与交易流水不一致（%d）\n", len(r.Mismatches))
+		fmt.Printf("%-10s %-20s %12s %12s %12s %8s\n", "wallet_id", "user_id", "balance", "computed", "diff", "txns")
+		for _, m := range r.Mismatches {
+			fmt.Printf("%-10d %-20s %12.2f %12.2f %12.2f %8d %s\n", m.WalletID, m.UserID, m.Balance, m.Computed, m.Diff, m.Transactions, repaired(m))
+		}
+		fmt.Println()
+	}
+
+	if len(r.Ledger) > 0 {
+		fmt.Printf("余额与账本不一致（%d）\n", len(r.Ledger))
+		fmt.Printf("%-10s %-20s %12s %12s %12s\n", "wallet_id", "user_id", "balance", "ledger", "diff")
+		for _, m := range r.Ledger {
+			fmt.Printf("%-10d %-20s %12.2f %12.2f %12.2f %s\n", m.WalletID, m.UserID, m.Balance, m.Computed, m.Diff, repaired(m))
+		}
+		fmt.Println()
+	}
+
+	if len(r.OrderIssues) > 0 {
+		fmt.Printf("订单交易不一致（%d）\n", len(r.OrderIssues))
+		fmt.Printf("%-38s %-20s %-10s %-20s %10s %6s %10s\n", "order_id", "user_id", "status", "kind", "total", "count", "amount")
+		for _, o := range r.OrderIssues {
+			fmt.Printf("%-38s %-20s %-10s %-20s %10.2f %6d %10.2f\n", o.OrderID, o.UserID, o.Status, o.Kind, o.TotalPrice, o.Count, o.Amount)
+		}
+		fmt.Println()
+	}
+
+	if len(r.Orphans) > 0 {
+		fmt.Printf("孤立交易（%d）\n", len(r.Orphans))
+		fmt.Printf("%-10s %-10s %-10s %-38s %10s %s\n", "id", "wallet_id", "type", "order_id", "amount", "reason")
+		for _, o := range r.Orphans {
+			fmt.Printf("%-10d %-10d %-10s %-38s %10.2f %s\n", o.TransactionID, o.WalletID, o.Type, o.OrderID, o.Amount, o.Reason)
+		}
+		fmt.Println()
+	}
+}
+
+func repaired(m logic.WalletMismatch) string {
+	if m.Repaired {
+		return "已修复"
+	}
+	return ""
+}
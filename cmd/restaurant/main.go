@@ -6,10 +6,17 @@ import (
 
 	"github.com/p-program/Fenrir/internal/config"
 	"github.com/p-program/Fenrir/internal/handler"
+	"github.com/p-program/Fenrir/internal/server"
 	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/rpc/restaurant"
 
 	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/service"
 	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 )
 
 var configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
@@ -20,12 +27,55 @@ func main() {
 	var c config.Config
 	conf.MustLoad(*configFile, &c)
 
-	server := rest.MustNewServer(c.RestConf)
-	defer server.Stop()
+	group := service.NewServiceGroup()
+	defer group.Stop()
+
+	restServer := rest.MustNewServer(c.RestConf)
+	group.Add(restServer)
 
 	ctx := svc.NewServiceContext(c)
-	handler.RegisterHandlers(server, ctx)
+	handler.RegisterHandlers(restServer, ctx)
+
+	// gRPC 接口与 REST 接口共用 ServiceContext，没有配置 Rpc 时不启动；
+	// 调用方与 REST 接口一样使用工作人员令牌
+	if c.Rpc.ListenOn != "" {
+		rpcServer := zrpc.MustNewServer(c.Rpc, func(grpcServer *grpc.Server) {
+			restaurant.RegisterRestaurantServer(grpcServer, server.NewRestaurantServer(ctx))
+			if c.Rpc.Mode == service.DevMode || c.Rpc.Mode == service.TestMode {
+				reflection.Register(grpcServer)
+			}
+		})
+		if c.RpcTLS.CertFile != "" {
+			creds, err := credentials.NewServerTLSFromFile(c.RpcTLS.CertFile, c.RpcTLS.KeyFile)
+			if err != nil {
+				panic("failed to load rpc tls certificate: " + err.Error())
+			}
+			rpcServer.AddOptions(grpc.Creds(creds))
+		}
+		rpcAuth := server.NewStaffAuth(c.StaffAuth.AccessSecret, ctx.NewLogic().ActiveWorker)
+		rpcServer.AddUnaryInterceptors(rpcAuth.Unary)
+		rpcServer.AddStreamInterceptors(rpcAuth.Stream)
+		group.Add(rpcServer)
+		fmt.Printf("Starting rpc server at %s...\n", c.Rpc.ListenOn)
+	}
+
+	if err := ctx.Dashboard.Start(); err != nil {
+		panic("failed to start dashboard: " + err.Error())
+	}
+	defer ctx.Dashboard.Stop()
+
+	ctx.Devices.Start()
+	defer ctx.Devices.Stop()
+
+	ctx.Unbinder.Start()
+	defer ctx.Unbinder.Stop()
+
+	ctx.Notifier.Start()
+	defer ctx.Notifier.Stop()
+
+	ctx.Reconcile.Start()
+	defer ctx.Reconcile.Stop()
 
 	fmt.Printf("Starting server at %s:%d...\n", c.Host, c.Port)
-	server.Start()
+	group.Start()
 }
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/internal/reconcile"
+	"github.com/p-program/Fenrir/internal/svc"
+)
+
+// argument 取唯一的位置参数，例如 user info <user_id>
+func (a *app) argument(args []string) (string, error) {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", flag.ErrHelp
+	}
+	return fs.Arg(0), nil
+}
+
+// parse 解析子命令参数，参数错误时 FlagSet 已经输出了错误和用法，返回 flag.ErrHelp 以状态码 2 退出
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+// require 检查必填参数
+func require(fs *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range names {
+		if !set[name] {
+			fmt.Fprintf(fs.Output(), "缺少参数 -%s\n", name)
+			fs.Usage()
+			return flag.ErrHelp
+		}
+	}
+	return nil
+}
+
+func userCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	id := fs.String("id", "", "user id, generated when empty")
+	username := fs.String("username", "", "username")
+	phone := fs.String("phone", "", "phone number")
+	email := fs.String("email", "", "email address")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "username"); err != nil {
+		return err
+	}
+
+	user, err := a.logic.CreateUser(ctx, *id, *username, *phone, *email)
+	if err != nil {
+		return err
+	}
+	return a.print(user)
+}
+
+func userInfo(ctx context.Context, a *app, args []string) error {
+	userID, err := a.argument(args)
+	if err != nil {
+		return err
+	}
+	user, err := a.logic.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return a.print(user)
+}
+
+func workerCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	id := fs.String("id", "", "worker id, generated when empty")
+	name := fs.String("name", "", "worker name")
+	role := fs.String("role", "staff", "role: staff, manager or gc")
+	phone := fs.String("phone", "", "phone number")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "name"); err != nil {
+		return err
+	}
+
+	worker, err := a.logic.CreateWorker(ctx, *id, *name, *role, *phone)
+	if err != nil {
+		return err
+	}
+	return a.print(worker)
+}
+
+func workerList(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags(), args); err != nil {
+		return err
+	}
+	workers, err := a.logic.GetWorkerList(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(workers)
+}
+
+// issuedToken 签发的访问令牌
+type issuedToken struct {
+	UserID    string    `json:"user_id,omitempty"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// userToken 为用户签发访问令牌，用于站内信、通知偏好、实时推送和个人数据导出
+func userToken(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	id := fs.String("id", "", "user id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "id"); err != nil {
+		return err
+	}
+
+	user, err := a.logic.ActiveUser(ctx, *id)
+	if err != nil {
+		return err
+	}
+	expire := a.config.Auth.AccessExpire
+	token, err := auth.NewUserToken(a.config.Auth.AccessSecret, expire, user.ID)
+	if err != nil {
+		return err
+	}
+	return a.print(issuedToken{
+		UserID:    user.ID,
+		Token:     token,
+		ExpiresAt: time.Now().Add(time.Duration(expire) * time.Second),
+	})
+}
+
+// workerToken 为在岗的工作人员签发访问令牌，请求时放在 Authorization: Bearer 请求头中
+func workerToken(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	id := fs.String("id", "", "worker id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "id"); err != nil {
+		return err
+	}
+
+	worker, err := a.logic.ActiveWorker(ctx, *id)
+	if err != nil {
+		return err
+	}
+	expire := a.config.StaffAuth.AccessExpire
+	token, err := auth.NewStaffToken(a.config.StaffAuth.AccessSecret, expire, worker.ID)
+	if err != nil {
+		return err
+	}
+	return a.print(issuedToken{
+		WorkerID:  worker.ID,
+		Token:     token,
+		ExpiresAt: time.Now().Add(time.Duration(expire) * time.Second),
+	})
+}
+
+func foodImport(ctx context.Context, a *app, args []string) error {
+	return importCSV(a, args, func(file *os.File, dryRun bool) (*logic.PlateImportResult, error) {
+		return a.logic.ImportFoods(ctx, file, dryRun)
+	})
+}
+
+func plateImport(ctx context.Context, a *app, args []string) error {
+	return importCSV(a, args, func(file *os.File, dryRun bool) (*logic.PlateImportResult, error) {
+		return a.logic.ImportPlates(ctx, file, dryRun)
+	})
+}
+
+// importCSV 菜品和餐盘导入共用的参数和输出，有校验失败的行时以状态码 1 退出
+func importCSV(a *app, args []string, run func(file *os.File, dryRun bool) (*logic.PlateImportResult, error)) error {
+	fs := a.flags()
+	path := fs.String("csv", "", "the csv file to import")
+	dryRun := fs.Bool("dry-run", false, "validate only, do not import")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "csv"); err != nil {
+		return err
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	result, err := run(file, *dryRun)
+	if err != nil {
+		return err
+	}
+	if err := a.print(result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return errUnresolved
+	}
+	return nil
+}
+
+func walletCharge(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	userID := fs.String("user", "", "user id")
+	amount := fs.Float64("amount", 0, "amount to charge")
+	source := fs.String("source", "payment", "source: payment or subsidy")
+	expires := fs.String("expires", "", "subsidy expiry date (2006-01-02), expires at the start of the next day")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "user", "amount"); err != nil {
+		return err
+	}
+
+	// 与 API 一致：到期日当天仍可使用，次日零点过期
+	var expiresAt *time.Time
+	if *expires != "" {
+		day, err := time.ParseInLocation("2006-01-02", *expires, time.Local)
+		if err != nil {
+			return fmt.Errorf("无效的到期日: %s", *expires)
+		}
+		at := day.AddDate(0, 0, 1)
+		expiresAt = &at
+	}
+
+	wallet, err := a.logic.ChargeWallet(ctx, *userID, *amount, *source, expiresAt)
+	if err != nil {
+		return err
+	}
+	return a.print(wallet)
+}
+
+func orderInfo(ctx context.Context, a *app, args []string) error {
+	orderID, err := a.argument(args)
+	if err != nil {
+		return err
+	}
+	order, err := a.logic.GetOrderInfo(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return a.print(order)
+}
+
+func orderList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	userID := fs.String("user", "", "user id")
+	page := fs.Int("page", 1, "page number")
+	size := fs.Int("size", 20, "page size")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "user"); err != nil {
+		return err
+	}
+
+	orders, total, err := a.logic.GetUserOrders(ctx, *userID, *page, *size)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return a.print(map[string]interface{}{"total": total, "page": *page, "page_size": *size, "orders": orders})
+	}
+	if err := a.print(orders); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "\n共 %d 条，第 %d 页\n", total, *page)
+	return nil
+}
+
+func exceptionList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	status := fs.String("status", "", "filter by status: pending or resolved")
+	limit := fs.Int("limit", 50, "max number of records")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	logs, err := a.logic.GetExceptions(ctx, *status, *limit)
+	if err != nil {
+		return err
+	}
+	return a.print(logs)
+}
+
+func exceptionResolve(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	id := fs.Uint("id", 0, "exception id")
+	workerID := fs.String("worker", "", "id of the worker who resolved it")
+	action := fs.String("action", "", "how it was resolved")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "id", "worker", "action"); err != nil {
+		return err
+	}
+
+	log, err := a.logic.ResolveException(ctx, *id, *workerID, *action)
+	if err != nil {
+		return err
+	}
+	return a.print(log)
+}
+
+// walletReconcile 与 cmd/reconcile 相同：余额、账本和交易流水中只有一项不一致时修复该项，仍有未解决的问题时以状态码 1 退出
+func walletReconcile(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	repair := fs.Bool("repair", false, "restore wallet balances that differ from the ledger and add adjust transactions for gaps in the transaction log")
+	operator := fs.String("operator", "", "with -repair, id of the manager who confirms the repair")
+	maxAdjust := fs.Float64("max-adjust", 100, "with -repair, skip wallets whose difference exceeds this amount")
+	remark := fs.String("remark", "", "remark of the repair records and adjust transactions")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *repair {
+		if err := require(fs, "operator"); err != nil {
+			return err
+		}
+	}
+
+	report, err := a.logic.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *repair {
+		reconcile.Repair(ctx, a.logic, report, reconcile.RepairOptions{OperatorID: *operator, MaxAdjust: *maxAdjust, Remark: *remark}, os.Stderr)
+	}
+
+	if err := a.print(report); err != nil {
+		return err
+	}
+	if report.Unresolved() > 0 {
+		return errUnresolved
+	}
+	return nil
+}
+
+// migrator 只连接数据库，不初始化服务上下文（启动时会检查或执行迁移）
+func (a *app) migrator() (*migrate.Migrator, error) {
+	db, err := svc.OpenDB(a.config)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return migrate.New(db)
+}
+
+func migrateUp(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	to := fs.Int("to", 0, "migrate up to this version, 0 for the latest")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(ctx, *to)
+	return a.printMigrations(ctx, m, applied, err)
+}
+
+func migrateDown(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	to := fs.Int("to", 0, "roll back to this version, 0 drops all tables")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	// 回滚会删除表和数据，必须明确指定目标版本
+	if err := require(fs, "to"); err != nil {
+		return err
+	}
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	reverted, err := m.Down(ctx, *to)
+	return a.printMigrations(ctx, m, reverted, err)
+}
+
+// printMigrations 输出本次执行的迁移和当前版本；迁移中途失败时同样输出已经执行的部分
+func (a *app) printMigrations(ctx context.Context, m *migrate.Migrator, done []migrate.Migration, err error) error {
+	if err != nil && len(done) == 0 {
+		return err
+	}
+	if done == nil {
+		done = []migrate.Migration{}
+	}
+	if printErr := a.print(done); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+	if *output == "table" {
+		version, _, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "\n当前版本 %d，最新版本 %d\n", version, m.Latest())
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags(), args); err != nil {
+		return err
+	}
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.print(statuses); err != nil {
+		return err
+	}
+	// 与 Database.Migrate: check 的启动检查一致，不是最新版本时以状态码 1 退出
+	if err := m.Check(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return errUnresolved
+	}
+	return nil
+}
+
+func migrateForce(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	version := fs.Int("version", 0, "mark this version as the current one without running any sql")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := require(fs, "version"); err != nil {
+		return err
+	}
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err := m.Force(ctx, *version); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "已将数据库版本标记为 %d\n", *version)
+	return nil
+}
+
+func migrateUnlock(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags(), args); err != nil {
+		return err
+	}
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err := m.Unlock(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "已释放迁移锁")
+	return nil
+}
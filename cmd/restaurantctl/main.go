@@ -0,0 +1,161 @@
+// restaurantctl 食堂管理命令行工具，与 API 服务读取同一份配置，直接调用业务逻辑层，
+// 用于初始化数据、日常运维和排查问题
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/internal/tenant"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	output     = flag.String("o", "table", "output format: table or json")
+	canteenID  = flag.String("canteen", "", "run as this canteen, empty for all canteens")
+	verbose    = flag.Bool("v", false, "print sql logs with the configured Database.LogLevel")
+)
+
+// errUnresolved 命令执行完成但结果中有失败的行或未解决的问题，输出结果后以状态码 1 退出
+var errUnresolved = errors.New("unresolved")
+
+// app 子命令的运行环境
+type app struct {
+	config config.Config
+	svcCtx *svc.ServiceContext
+	logic  *logic.RestaurantLogic
+	out    io.Writer
+	name   string // 子命令名称，用于输出用法
+	usage  string
+}
+
+// command 子命令，needSvc 为 false 的命令不初始化服务上下文（migrate 需要在建表前、服务拒绝启动时运行）
+type command struct {
+	usage   string
+	needSvc bool
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"user create":       {"-username name [-id id] [-phone p] [-email e]", true, userCreate},
+	"user info":         {"<user_id>", true, userInfo},
+	"user token":        {"-id user_id", true, userToken},
+	"worker create":     {"-name name [-id id] [-role staff|manager|gc] [-phone p]", true, workerCreate},
+	"worker list":       {"", true, workerList},
+	"worker token":      {"-id worker_id", true, workerToken},
+	"food import":       {"-csv foods.csv [-dry-run]", true, foodImport},
+	"plate import":      {"-csv plates.csv [-dry-run]", true, plateImport},
+	"wallet charge":     {"-user id -amount n [-source payment|subsidy] [-expires 2006-01-02]", true, walletCharge},
+	"order info":        {"<order_id>", true, orderInfo},
+	"order list":        {"-user id [-page 1] [-size 20]", true, orderList},
+	"exception list":    {"[-status pending|resolved] [-limit 50]", true, exceptionList},
+	"exception resolve": {"-id n -worker id -action text", true, exceptionResolve},
+	"reconcile":         {"[-repair -operator <worker_id>] [-max-adjust 100] [-remark text]", true, walletReconcile},
+	"migrate up":        {"[-to version]", false, migrateUp},
+	"migrate down":      {"-to version", false, migrateDown},
+	"migrate status":    {"", false, migrateStatus},
+	"migrate force":     {"-version n", false, migrateForce},
+	"migrate unlock":    {"", false, migrateUnlock},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintln(os.Stderr, "-o 只支持 table 或 json")
+		os.Exit(2)
+	}
+	name, cmd, args, ok := lookup(flag.Args())
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+	if !*verbose {
+		// 默认不输出 SQL 日志，避免混入表格或 JSON 输出；错误通过命令结果输出
+		c.Database.LogLevel = "silent"
+	}
+
+	a := &app{config: c, out: os.Stdout, name: name, usage: cmd.usage}
+	if cmd.needSvc {
+		a.svcCtx = svc.NewServiceContext(c)
+		a.logic = a.svcCtx.NewLogic()
+	}
+	ctx := context.Background()
+	if *canteenID != "" {
+		ctx = tenant.WithCanteen(ctx, *canteenID)
+	}
+
+	if err := cmd.run(ctx, a, args); err != nil {
+		if errors.Is(err, errUnresolved) {
+			os.Exit(1)
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// lookup 按最长匹配查找子命令，返回剩余参数
+func lookup(args []string) (string, command, []string, bool) {
+	for n := 2; n >= 1; n-- {
+		if len(args) < n {
+			continue
+		}
+		name := strings.Join(args[:n], " ")
+		if cmd, ok := commands[name]; ok {
+			return name, cmd, args[n:], true
+		}
+	}
+	return "", command{}, nil, false
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "usage: restaurantctl [-f etc/restaurant-api.yaml] [-o table|json] [-canteen id] [-v] <command> [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-18s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nglobal flags:")
+	flag.PrintDefaults()
+}
+
+// flags 创建子命令的参数集，解析失败时输出子命令的用法
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: restaurantctl %s %s\n", a.name, a.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// print 按 -o 输出结果
+func (a *app) print(v interface{}) error {
+	if *output == "json" {
+		return printJSON(a.out, v)
+	}
+	return printTable(a.out, v)
+}
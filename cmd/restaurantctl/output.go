@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+// printJSON 输出缩进的 JSON
+func printJSON(w io.Writer, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// printTable 以表格输出结果：结构体列表每个元素一行，单个结构体每个字段一行，
+// 结构体中的列表字段在后面单独成表。列名使用 json 标签，与 -o json 的字段名一致
+func printTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeValue(tw, reflect.ValueOf(v))
+	return tw.Flush()
+}
+
+func writeValue(tw *tabwriter.Writer, v reflect.Value) {
+	v = indirect(v)
+	if !v.IsValid() {
+		fmt.Fprintln(tw, "(空)")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		writeRows(tw, v)
+	case reflect.Struct:
+		var lists []field
+		for _, f := range fields(v) {
+			if f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() != reflect.Uint8 {
+				lists = append(lists, f)
+				continue
+			}
+			if !scalar(f.value) {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", f.name, format(f.value))
+		}
+		for _, f := range lists {
+			fmt.Fprintf(tw, "\n%s (%d)\n", f.name, f.value.Len())
+			writeRows(tw, f.value)
+		}
+	default:
+		fmt.Fprintln(tw, format(v))
+	}
+}
+
+// writeRows 列表每个元素一行
+func writeRows(tw *tabwriter.Writer, v reflect.Value) {
+	if v.Len() == 0 {
+		fmt.Fprintln(tw, "(空)")
+		return
+	}
+	var header []string
+	for i := 0; i < v.Len(); i++ {
+		item := indirect(v.Index(i))
+		if item.Kind() != reflect.Struct {
+			fmt.Fprintln(tw, format(item))
+			continue
+		}
+		var names, cells []string
+		for _, f := range fields(item) {
+			if !scalar(f.value) {
+				continue
+			}
+			names = append(names, f.name)
+			cells = append(cells, format(f.value))
+		}
+		if header == nil {
+			header = names
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+}
+
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+// fields 结构体的导出字段，展开匿名嵌入的结构体，跳过 json:"-"
+func fields(v reflect.Value) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && indirect(v.Field(i)).Kind() == reflect.Struct {
+			out = append(out, fields(indirect(v.Field(i)))...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		out = append(out, field{name: name, value: v.Field(i)})
+	}
+	return out
+}
+
+// scalar 可以放进单元格的值：基本类型、时间，以及指向它们的指针
+func scalar(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == timeType || t == deletedAtType
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Interface, reflect.Func, reflect.Chan:
+		return false
+	}
+	return true
+}
+
+func format(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "-"
+		}
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	case deletedAtType:
+		d := v.Interface().(gorm.DeletedAt)
+		if !d.Valid {
+			return "-"
+		}
+		return d.Time.Local().Format("2006-01-02 15:04:05")
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%.2f", v.Float())
+	case reflect.String:
+		if v.String() == "" {
+			return "-"
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,181 @@
+// simulator 食堂午高峰模拟：先直接写入一个独立食堂的演示数据，再按场景并发回放用餐流程调用 API，
+// 输出各步骤的延迟分位数和错误率。与 API 服务读取同一份配置，用于本地压测和演示
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/simulator"
+	"github.com/p-program/Fenrir/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+var (
+	configFile = flag.String("f", "etc/restaurant-api.yaml", "the config file")
+	baseURL    = flag.String("url", "", "api server address (default http://<Host>:<Port> from the config file)")
+	prefix     = flag.String("prefix", "", "id prefix of the seeded data, also the canteen id (default sim-<timestamp in milliseconds>)")
+	seedOnly   = flag.Bool("seed-only", false, "only seed the demo data, do not replay the lunch rush")
+	jsonOut    = flag.Bool("json", false, "print the result as json")
+	maxErrors  = flag.Float64("max-error-rate", 0.01, "exit 1 if the overall error rate exceeds this value")
+
+	users    = flag.Int("users", 200, "number of users")
+	balance  = flag.Float64("balance", 500, "wallet balance of each user")
+	foods    = flag.Int("foods", 8, "number of foods")
+	stations = flag.Int("stations", 4, "number of stations, each with a scale")
+	depots   = flag.Int("depots", 2, "number of plate depots")
+	plates   = flag.Int("plates", 120, "number of plates")
+
+	diners      = flag.Int("diners", 300, "number of diners in the lunch rush")
+	duration    = flag.Duration("duration", time.Minute, "time span of the arrivals")
+	peak        = flag.Float64("peak", 0.3, "position of the arrival peak within the span, 0-1")
+	concurrency = flag.Int("concurrency", 100, "max diners in the canteen at the same time")
+	maxStations = flag.Int("max-stations", 2, "max stations each diner visits")
+	minWeight   = flag.Float64("min-weight", 80, "min weight of each serving in grams")
+	maxWeight   = flag.Float64("max-weight", 300, "max weight of each serving in grams")
+	think       = flag.Duration("think", 500*time.Millisecond, "pause between steps")
+	stationGap  = flag.Duration("station-gap", 0, "min pause between two weighings of a plate (default Anomaly.StationWindow + 1s)")
+	eat         = flag.Duration("eat", 3*time.Second, "time spent eating")
+	seed        = flag.Int64("seed", 1, "random seed of arrivals, stations, weights and prices")
+	timeout     = flag.Duration("timeout", 10*time.Second, "timeout of each request")
+)
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+	c.Database.LogLevel = "silent"
+
+	if *prefix == "" {
+		now := time.Now()
+		*prefix = fmt.Sprintf("sim-%s%03d", now.Format("20060102150405"), now.Nanosecond()/int(time.Millisecond))
+	}
+	if *baseURL == "" {
+		host := c.Host
+		if host == "" || host == "0.0.0.0" {
+			host = "127.0.0.1"
+		}
+		*baseURL = "http://" + net.JoinHostPort(host, strconv.Itoa(c.Port))
+	}
+	if *stationGap == 0 && c.Anomaly.StationWindow > 0 {
+		*stationGap = time.Duration(c.Anomaly.StationWindow+1) * time.Second
+	}
+	scenario := simulator.Scenario{
+		Diners:      *diners,
+		Duration:    *duration,
+		Peak:        *peak,
+		Concurrency: *concurrency,
+		MaxStations: *maxStations,
+		MinWeight:   *minWeight,
+		MaxWeight:   *maxWeight,
+		Think:       *think,
+		StationGap:  *stationGap,
+		Eat:         *eat,
+		Seed:        *seed,
+	}
+	if !*seedOnly {
+		if err := scenario.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "场景参数错误:", err)
+			os.Exit(2)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	svcCtx := svc.NewServiceContext(c)
+	l := logic.NewRestaurantLogic(svcCtx.DB)
+	start := time.Now()
+	fixture, err := simulator.Seed(ctx, l, simulator.SeedConfig{
+		Prefix:   *prefix,
+		Users:    *users,
+		Balance:  *balance,
+		Foods:    *foods,
+		Stations: *stations,
+		Depots:   *depots,
+		Plates:   *plates,
+		// 每人用餐后送回一个餐碗，发放中的数量按全部人数准备
+		Bowls: *diners,
+		Seed:  *seed,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "生成演示数据失败:", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "已生成食堂 %s 的演示数据：%d 个用户、%d 个菜品、%d 个档口、%d 个托管处、%d 个餐盘，用时 %s\n",
+		fixture.CanteenID, len(fixture.Users), len(fixture.Foods), len(fixture.Stations), len(fixture.Depots),
+		len(fixture.Plates), time.Since(start).Round(time.Millisecond))
+
+	if *seedOnly {
+		if *jsonOut {
+			out, _ := json.MarshalIndent(fixture, "", "  ")
+			fmt.Println(string(out))
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "开始回放午高峰：%d 人在 %s 内到达 %s\n", scenario.Diners, scenario.Duration, *baseURL)
+	// 以生成的工作人员身份调用接口，服务端需要使用同一份配置（StaffAuth 密钥）
+	token, err := auth.NewStaffToken(c.StaffAuth.AccessSecret, c.StaffAuth.AccessExpire, fixture.WorkerID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "签发工作人员令牌失败:", err)
+		os.Exit(1)
+	}
+	client := simulator.NewClient(*baseURL, token, *timeout, simulator.NewRecorder())
+	result, err := simulator.Run(ctx, client, fixture, scenario)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "模拟失败:", err)
+		os.Exit(1)
+	}
+
+	if *jsonOut {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printResult(fixture.CanteenID, result)
+	}
+
+	if n := len(result.Steps); n == 0 || result.Steps[n-1].ErrorRate > *maxErrors {
+		os.Exit(1)
+	}
+}
+
+func printResult(canteenID string, r *simulator.Result) {
+	fmt.Printf("食堂 %s：到达 %d 人，完成 %d 人，失败 %d 人，耗时 %.1fs，吞吐 %.2f 人/秒\n",
+		canteenID, r.Diners, r.Completed, r.Failed, r.Elapsed, r.Throughput)
+	fmt.Printf("扣款订单 %d 笔共 %.2f 元，挂起待审核 %d 笔，丢弃读数 %d 次\n\n", r.Orders, r.Revenue, r.Held, r.Rejected)
+
+	fmt.Printf("%-8s %8s %8s %8s %9s %9s %9s %9s %9s %9s\n",
+		"step", "requests", "errors", "rate", "mean_ms", "p50_ms", "p90_ms", "p95_ms", "p99_ms", "max_ms")
+	for _, s := range r.Steps {
+		fmt.Printf("%-8s %8d %8d %7.2f%% %9.1f %9.1f %9.1f %9.1f %9.1f %9.1f\n",
+			s.Step, s.Requests, s.Errors, s.ErrorRate*100, s.Mean, s.P50, s.P90, s.P95, s.P99, s.Max)
+	}
+
+	var printed bool
+	for _, s := range r.Steps {
+		if s.FirstError == "" {
+			continue
+		}
+		if !printed {
+			fmt.Println("\n首个错误")
+			printed = true
+		}
+		fmt.Printf("%-8s %s\n", s.Step, s.FirstError)
+	}
+	if r.ResetFailures > 0 {
+		fmt.Printf("\n%d 次失败后复位餐盘也失败，请检查服务日志\n", r.ResetFailures)
+	}
+}
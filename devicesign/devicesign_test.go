@@ -0,0 +1,72 @@
+package devicesign
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSignRequestRoundTrip(t *testing.T) {
+	body := `{"device_id":"scale-1","plate_id":"P001","weight":150}`
+	r, err := http.NewRequest(http.MethodPost, "http://localhost/api/station/weight?x=1", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SignRequest(r, "scale-1", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求体仍然可读
+	got, _ := io.ReadAll(r.Body)
+	if string(got) != body {
+		t.Fatalf("body = %q, want %q", got, body)
+	}
+	if r.Header.Get(HeaderDeviceID) != "scale-1" {
+		t.Fatalf("device id header = %q", r.Header.Get(HeaderDeviceID))
+	}
+
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	nonce := r.Header.Get(HeaderNonce)
+	sig := r.Header.Get(HeaderSignature)
+	path := "/api/station/weight?x=1"
+
+	if !Verify("secret", sig, http.MethodPost, path, ts, nonce, got) {
+		t.Fatal("valid signature rejected")
+	}
+	if !Verify("secret", strings.ToUpper(sig), http.MethodPost, path, ts, nonce, got) {
+		t.Fatal("upper-case hex signature rejected")
+	}
+
+	tampered := []struct {
+		name   string
+		secret string
+		method string
+		path   string
+		ts     int64
+		nonce  string
+		body   string
+	}{
+		{"secret", "other", http.MethodPost, path, ts, nonce, body},
+		{"method", "secret", http.MethodPut, path, ts, nonce, body},
+		{"path", "secret", http.MethodPost, "/api/device/heartbeat", ts, nonce, body},
+		{"timestamp", "secret", http.MethodPost, path, ts + 1, nonce, body},
+		{"nonce", "secret", http.MethodPost, path, ts, nonce + "0", body},
+		{"body", "secret", http.MethodPost, path, ts, nonce, strings.Replace(body, "150", "15", 1)},
+	}
+	for _, tc := range tampered {
+		if Verify(tc.secret, sig, tc.method, tc.path, tc.ts, tc.nonce, []byte(tc.body)) {
+			t.Errorf("tampered %s accepted", tc.name)
+		}
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	got := StringToSign("post", "/api/device/heartbeat", 1700000000, "abc", nil)
+	want := "POST\n/api/device/heartbeat\n1700000000\nabc\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got != want {
+		t.Fatalf("StringToSign = %q, want %q", got, want)
+	}
+}
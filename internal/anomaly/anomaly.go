@@ -0,0 +1,104 @@
+// Package anomaly 检查档口电子秤上报的读数。
+//
+// 规则分两类：负读数、空秤漂移的读数直接丢弃，不会产生订单；
+// 超大份量、同一餐盘同时出现在两个档口的读数照常生成订单明细，但先挂起等待工作人员审核。
+// 任一阈值设为 0 表示关闭对应规则。
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 规则代码
+const (
+	Negative   = "negative"   // 负读数
+	Jump       = "jump"       // 单次份量超过上限
+	Concurrent = "concurrent" // 同一餐盘短时间内在另一个档口称重
+	Drift      = "drift"      // 空秤（无餐盘）读数漂移
+)
+
+// Rules 检测规则配置
+type Rules struct {
+	MaxPortion     float64       // 单次份量上限（克）
+	StationWindow  time.Duration // 同一餐盘在不同档口称重的最短间隔
+	DriftTolerance float64       // 空秤读数允许的偏差（克）
+}
+
+// DefaultRules 默认规则
+var DefaultRules = Rules{
+	MaxPortion:     1000,
+	StationWindow:  10 * time.Second,
+	DriftTolerance: 5,
+}
+
+// Reading 一次称重读数，PlateID 为空表示空秤读数
+type Reading struct {
+	DeviceID  string
+	StationID string
+	PlateID   string
+	Weight    float64 // 克
+	At        time.Time
+}
+
+// Finding 命中的规则
+type Finding struct {
+	Rule    string
+	Message string
+}
+
+// Reject 是否丢弃读数（不生成订单）
+func (f Finding) Reject() bool {
+	return f.Rule == Negative || f.Rule == Drift
+}
+
+// Check 检查读数，recent 为同一餐盘最近的读数（任意档口）
+func (r Rules) Check(reading Reading, recent []Reading) []Finding {
+	var findings []Finding
+
+	if reading.PlateID == "" {
+		if r.DriftTolerance > 0 && math.Abs(reading.Weight) > r.DriftTolerance {
+			findings = append(findings, Finding{
+				Rule:    Drift,
+				Message: fmt.Sprintf("空秤读数 %.1f 克，超过允许偏差 %.1f 克", reading.Weight, r.DriftTolerance),
+			})
+		}
+		return findings
+	}
+
+	if reading.Weight < 0 {
+		findings = append(findings, Finding{
+			Rule:    Negative,
+			Message: fmt.Sprintf("负读数 %.1f 克", reading.Weight),
+		})
+		return findings
+	}
+
+	if r.MaxPortion > 0 && reading.Weight > r.MaxPortion {
+		findings = append(findings, Finding{
+			Rule:    Jump,
+			Message: fmt.Sprintf("单次份量 %.1f 克，超过上限 %.1f 克", reading.Weight, r.MaxPortion),
+		})
+	}
+
+	if r.StationWindow > 0 {
+		for _, prev := range recent {
+			if prev.StationID == reading.StationID || prev.PlateID != reading.PlateID {
+				continue
+			}
+			gap := reading.At.Sub(prev.At)
+			if gap < 0 {
+				gap = -gap
+			}
+			if gap < r.StationWindow {
+				findings = append(findings, Finding{
+					Rule:    Concurrent,
+					Message: fmt.Sprintf("餐盘 %.1f 秒前在档口 %s 称重", gap.Seconds(), prev.StationID),
+				})
+				break
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,107 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+)
+
+func rules(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Rule)
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		reading Reading
+		recent  []Reading
+		want    []string
+		reject  bool
+	}{
+		{
+			name:    "normal",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 150, At: now},
+		},
+		{
+			name:    "negative",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: -20, At: now},
+			want:    []string{Negative},
+			reject:  true,
+		},
+		{
+			name:    "jump",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 2500, At: now},
+			want:    []string{Jump},
+		},
+		{
+			name:    "concurrent",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 100, At: now},
+			recent:  []Reading{{StationID: "s2", PlateID: "P1", Weight: 80, At: now.Add(-3 * time.Second)}},
+			want:    []string{Concurrent},
+		},
+		{
+			name:    "same station is not concurrent",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 100, At: now},
+			recent:  []Reading{{StationID: "s1", PlateID: "P1", Weight: 80, At: now.Add(-3 * time.Second)}},
+		},
+		{
+			name:    "other station outside window",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 100, At: now},
+			recent:  []Reading{{StationID: "s2", PlateID: "P1", Weight: 80, At: now.Add(-time.Minute)}},
+		},
+		{
+			name:    "jump and concurrent",
+			reading: Reading{StationID: "s1", PlateID: "P1", Weight: 1500, At: now},
+			recent:  []Reading{{StationID: "s2", PlateID: "P1", Weight: 80, At: now.Add(-time.Second)}},
+			want:    []string{Jump, Concurrent},
+		},
+		{
+			name:    "idle within tolerance",
+			reading: Reading{StationID: "s1", Weight: -3, At: now},
+		},
+		{
+			name:    "idle drift",
+			reading: Reading{StationID: "s1", Weight: 12, At: now},
+			want:    []string{Drift},
+			reject:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := DefaultRules.Check(tt.reading, tt.recent)
+			got := rules(findings)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rules = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("rules = %v, want %v", got, tt.want)
+				}
+			}
+			reject := false
+			for _, f := range findings {
+				reject = reject || f.Reject()
+			}
+			if reject != tt.reject {
+				t.Fatalf("reject = %v, want %v", reject, tt.reject)
+			}
+		})
+	}
+}
+
+func TestDisabledRules(t *testing.T) {
+	now := time.Now()
+	off := Rules{}
+	if got := off.Check(Reading{StationID: "s1", PlateID: "P1", Weight: 5000, At: now},
+		[]Reading{{StationID: "s2", PlateID: "P1", At: now}}); len(got) != 0 {
+		t.Fatalf("disabled rules fired: %v", rules(got))
+	}
+	if got := off.Check(Reading{StationID: "s1", Weight: 50, At: now}, nil); len(got) != 0 {
+		t.Fatalf("disabled drift fired: %v", rules(got))
+	}
+}
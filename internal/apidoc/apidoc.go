@@ -0,0 +1,11 @@
+// Package apidoc 由 api/restaurant.api 生成的 OpenAPI 文档，修改 .api 定义后执行 go generate ./internal/apidoc
+package apidoc
+
+import _ "embed"
+
+//go:generate go run ../../cmd/apigen -api ../../api/restaurant.api -o openapi.json
+
+// OpenAPI OpenAPI 3 文档（JSON）
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,28 @@
+package apidoc
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/apispec"
+)
+
+// TestOpenAPIUpToDate openapi.json 与 api/restaurant.api 一致
+func TestOpenAPIUpToDate(t *testing.T) {
+	src, err := os.ReadFile("../../api/restaurant.api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := apispec.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := apispec.OpenAPI(spec).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(OpenAPI, want) {
+		t.Fatal("openapi.json 不是由当前的 api/restaurant.api 生成的，请执行 go generate ./internal/apidoc")
+	}
+}
@@ -0,0 +1,138 @@
+package apispec
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAPI = `syntax = "v1"
+
+info (
+	title: "测试"
+	version: "1.0"
+)
+
+type (
+	BaseResponse {
+		Code int    ` + "`json:\"code\"`" + `
+		Msg  string ` + "`json:\"msg\"`" + `
+	}
+
+	// 查询请求
+	QueryRequest {
+		ID     string ` + "`path:\"id\"`" + `
+		Format string ` + "`form:\"format,optional,default=json,options=json|pdf\"`" + `
+		Page   int    ` + "`form:\"page,range=[1:100]\"`" + `
+	}
+
+	ItemResponse {
+		BaseResponse
+		Data []string ` + "`json:\"data,optional\"`" + ` // 条目
+	}
+)
+
+service test-api {
+	@doc (
+		summary: "查询"
+		produces: "application/json|application/pdf"
+	)
+	@handler Query
+	get /api/item/:id (QueryRequest) returns (ItemResponse)
+}
+
+@server (
+	jwt: Auth
+)
+service test-api {
+	@doc "删除 // 不是注释"
+	@handler Delete
+	post /api/item/delete/:id returns (BaseResponse) // 行尾注释
+}
+`
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(testAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Info["title"] != "测试" || len(spec.Types) != 3 || len(spec.Routes) != 2 {
+		t.Fatalf("解析结果不对: %+v", spec)
+	}
+	if doc := spec.Type("QueryRequest").Doc; doc != "查询请求" {
+		t.Errorf("类型注释 = %q", doc)
+	}
+	if f := spec.Type("ItemResponse").Fields; !f[0].Embedded || f[1].Doc != "条目" || f[1].Tag.Get("json") != "data,optional" {
+		t.Errorf("字段 = %+v", f)
+	}
+	query, del := spec.Routes[0], spec.Routes[1]
+	if query.Method != "get" || query.Path != "/api/item/:id" || query.Request != "QueryRequest" ||
+		query.Response != "ItemResponse" || query.Doc["produces"] != "application/json|application/pdf" {
+		t.Errorf("Query = %+v", query)
+	}
+	if del.Handler != "Delete" || del.Doc["summary"] != "删除 // 不是注释" || del.Server["jwt"] != "Auth" || del.Response != "BaseResponse" {
+		t.Errorf("Delete = %+v", del)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"未定义的类型", "service a {\n@handler A\nget /a returns (Missing)\n}", "未定义的类型 Missing"},
+		{"重复的路由", "service a {\n@handler A\nget /a\n@handler B\nget /a\n}", "重复定义"},
+		{"缺少 handler", "service a {\nget /a\n}", "缺少 @handler"},
+		{"字段类型未定义", "type A {\nB Missing `json:\"b\"`\n}", "未定义的类型 Missing"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.src)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v，应包含 %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	spec, err := Parse([]byte(testAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := OpenAPI(spec)
+
+	query := doc.Paths["/api/item/{id}"]["get"]
+	if query == nil {
+		t.Fatalf("路径参数没有转换为 {id}: %v", doc.Paths)
+	}
+	params := map[string]*Parameter{}
+	for _, p := range query.Parameters {
+		params[p.Name] = p
+	}
+	if p := params["id"]; p == nil || p.In != "path" || !p.Required {
+		t.Errorf("id = %+v", p)
+	}
+	if p := params["format"]; p == nil || p.In != "query" || p.Required || p.Schema.Default != "json" || len(p.Schema.Enum) != 2 {
+		t.Errorf("format = %+v", p)
+	}
+	if p := params["page"]; p == nil || !p.Required || p.Schema.Minimum == nil || *p.Schema.Minimum != 1 || p.Schema.Maximum == nil || *p.Schema.Maximum != 100 {
+		t.Errorf("page = %+v", p)
+	}
+	if query.RequestBody != nil {
+		t.Error("GET 请求只有路径和查询参数时不应有请求体")
+	}
+	if content := query.Responses["200"].Content; content["application/pdf"] == nil || content["application/json"] == nil {
+		t.Errorf("produces 没有生效: %v", content)
+	}
+
+	item := doc.Components.Schemas["ItemResponse"]
+	if item == nil || item.Properties["code"] == nil || item.Properties["data"] == nil {
+		t.Fatalf("嵌入的 BaseResponse 没有展开: %+v", item)
+	}
+	if strings.Join(item.Required, ",") != "code,msg" {
+		t.Errorf("required = %v", item.Required)
+	}
+
+	del := doc.Paths["/api/item/delete/{id}"]["post"]
+	if len(del.Security) != 1 || del.Responses["401"] == nil {
+		t.Errorf("jwt 路由缺少鉴权说明: %+v", del)
+	}
+}
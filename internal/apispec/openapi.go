@@ -0,0 +1,469 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/p-program/Fenrir/devicesign"
+	"github.com/p-program/Fenrir/internal/tenant"
+)
+
+// Document OpenAPI 3 文档，只包含生成时用到的字段
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// jwtSchemes @server 中的 jwt 对应的认证方式，未列出的按用户访问令牌处理
+var jwtSchemes = map[string]string{
+	"Auth":      "bearerAuth",
+	"StaffAuth": "staffAuth",
+}
+
+// jwtDescriptions 各 jwt 认证方式的说明
+var jwtDescriptions = map[string]string{
+	"bearerAuth": "用户访问令牌",
+	"staffAuth":  "工作人员访问令牌",
+}
+
+// tenantMiddlewares 按工作人员所属食堂隔离数据的中间件，这些接口可以带 X-Canteen-ID 请求头
+var tenantMiddlewares = map[string]bool{
+	"StaffAuth":   true,
+	"ManagerAuth": true,
+	"HQAuth":      true,
+}
+
+// middlewareSecurity @server 中的 middleware 对应的认证方式，同一中间件的多个请求头需要同时提供
+var middlewareSecurity = map[string][]string{
+	"DeviceSign": {"deviceId", "deviceTimestamp", "deviceNonce", "deviceSignature"},
+}
+
+var pathParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// OpenAPI 生成 OpenAPI 3 文档
+// 路由的 @doc 支持 summary、description，以及 consumes、produces（多个类型用 | 分隔），
+// 用于请求体不是 JSON（如 CSV 上传）或响应不是 JSON（如图片、PDF）的接口。
+// 标签取路径 /api/ 之后的第一段；工作人员接口可以带 X-Canteen-ID 请求头；错误响应为 400 和纯文本的错误信息
+func OpenAPI(spec *Spec) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       spec.Info["title"],
+			Description: spec.Info["desc"],
+			Version:     spec.Info["version"],
+		},
+		Paths: map[string]map[string]*Operation{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			Parameters: map[string]*Parameter{
+				"CanteenID": {
+					Name:        tenant.Header,
+					In:          "header",
+					Description: "总部工作人员选择食堂；食堂工作人员可以省略，填写时必须是所属食堂",
+					Schema:      &Schema{Type: "string"},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{},
+		},
+	}
+
+	for _, t := range spec.Types {
+		if s := objectSchema(spec, t); s != nil {
+			doc.Components.Schemas[t.Name] = s
+		}
+	}
+
+	seenTags := map[string]bool{}
+	for _, r := range spec.Routes {
+		op := operation(spec, doc, r)
+		path := pathParamPattern.ReplaceAllString(r.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][r.Method] = op
+		for _, tag := range op.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				doc.Tags = append(doc.Tags, Tag{Name: tag})
+			}
+		}
+	}
+	return doc
+}
+
+// Marshal 序列化为缩进的 JSON，不转义 HTML 字符，结尾带换行
+func (d *Document) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func operation(spec *Spec, doc *Document, r *Route) *Operation {
+	op := &Operation{
+		Tags:        []string{tag(r.Path)},
+		Summary:     r.Doc["summary"],
+		Description: r.Doc["description"],
+		OperationID: r.Handler,
+		Responses:   map[string]*Response{},
+	}
+
+	// 参数：路径参数、查询参数、请求头，最后是食堂ID
+	declared := map[string]bool{}
+	var body *Schema
+	if t := spec.Type(r.Request); t != nil {
+		for _, f := range fields(spec, t) {
+			for _, in := range []string{"path", "form", "header"} {
+				if name, opts, ok := tagValue(f.Tag, in); ok {
+					location := map[string]string{"path": "path", "form": "query", "header": "header"}[in]
+					op.Parameters = append(op.Parameters, &Parameter{
+						Name:        name,
+						In:          location,
+						Description: f.Doc,
+						Required:    in == "path" || required(f, opts),
+						Schema:      fieldSchema(f, opts),
+					})
+					declared[name] = true
+				}
+			}
+		}
+		body = doc.Components.Schemas[t.Name]
+	}
+	// .api 中没有声明的路径参数
+	for _, m := range pathParamPattern.FindAllStringSubmatch(r.Path, -1) {
+		if !declared[m[1]] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	middlewares := strings.Split(r.Server["middleware"], ",")
+	for _, middleware := range middlewares {
+		if tenantMiddlewares[strings.TrimSpace(middleware)] {
+			op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/CanteenID"})
+			break
+		}
+	}
+
+	switch {
+	case r.Doc["consumes"] != "":
+		op.RequestBody = &RequestBody{Required: true, Content: binaryContent(r.Doc["consumes"])}
+	case body != nil:
+		op.RequestBody = &RequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]*MediaType{"application/json": {Schema: ref(r.Request)}},
+		}
+	}
+
+	success := &Response{Description: "成功", Content: map[string]*MediaType{}}
+	if r.Response != "" {
+		success.Content["application/json"] = &MediaType{Schema: ref(r.Response)}
+	}
+	if r.Doc["produces"] != "" {
+		for mediaType, content := range binaryContent(r.Doc["produces"]) {
+			if success.Content[mediaType] == nil {
+				success.Content[mediaType] = content
+			}
+		}
+	}
+	if r.Server["sse"] == "true" {
+		success.Description = "Server-Sent Events 事件流"
+		success.Content["text/event-stream"] = &MediaType{Schema: &Schema{Type: "string"}}
+	}
+	if len(success.Content) == 0 {
+		success.Content = nil
+	}
+	op.Responses["200"] = success
+	op.Responses["400"] = &Response{
+		Description: "请求参数错误或业务处理失败",
+		Content:     map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string", Description: "错误信息"}}},
+	}
+
+	if jwt := r.Server["jwt"]; jwt != "" {
+		name := jwtSchemes[jwt]
+		if name == "" {
+			name = "bearerAuth"
+		}
+		doc.Components.SecuritySchemes[name] = &SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  jwtDescriptions[name],
+		}
+		op.Security = append(op.Security, map[string][]string{name: {}})
+		op.Responses["401"] = &Response{Description: "未登录或令牌无效"}
+	}
+	for _, middleware := range middlewares {
+		if tenantMiddlewares[strings.TrimSpace(middleware)] {
+			op.Responses["403"] = &Response{Description: "角色无权访问或不能访问该食堂"}
+		}
+		schemes := middlewareSecurity[strings.TrimSpace(middleware)]
+		if len(schemes) == 0 {
+			continue
+		}
+		requirement := map[string][]string{}
+		for _, name := range schemes {
+			doc.Components.SecuritySchemes[name] = deviceSchemes[name]
+			requirement[name] = []string{}
+		}
+		op.Security = append(op.Security, requirement)
+		op.Responses["401"] = &Response{Description: "签名无效或已过期"}
+	}
+	return op
+}
+
+// deviceSchemes 设备签名的请求头，见 devicesign 包
+var deviceSchemes = map[string]*SecurityScheme{
+	"deviceId":        {Type: "apiKey", In: "header", Name: devicesign.HeaderDeviceID, Description: "设备ID"},
+	"deviceTimestamp": {Type: "apiKey", In: "header", Name: devicesign.HeaderTimestamp, Description: "Unix 时间戳（秒）"},
+	"deviceNonce":     {Type: "apiKey", In: "header", Name: devicesign.HeaderNonce, Description: "随机数，同一设备在有效期内不能重复"},
+	"deviceSignature": {Type: "apiKey", In: "header", Name: devicesign.HeaderSignature, Description: "HMAC-SHA256 签名"},
+}
+
+// objectSchema 结构体中 json 字段组成的对象，没有 json 字段（只有路径、查询参数）时返回 nil
+func objectSchema(spec *Spec, t *Type) *Schema {
+	s := &Schema{Type: "object", Description: t.Doc, Properties: map[string]*Schema{}}
+	for _, f := range fields(spec, t) {
+		name, opts, ok := tagValue(f.Tag, "json")
+		if !ok {
+			continue
+		}
+		s.Properties[name] = fieldSchema(f, opts)
+		if required(f, opts) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	if len(s.Properties) == 0 && len(t.Fields) > 0 {
+		return nil
+	}
+	return s
+}
+
+// fields 展开嵌入的结构体
+func fields(spec *Spec, t *Type) []Field {
+	var out []Field
+	for _, f := range t.Fields {
+		if f.Embedded {
+			if embedded := spec.Type(f.Type); embedded != nil {
+				out = append(out, fields(spec, embedded)...)
+			}
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// fieldSchema 字段的类型和 go-zero 标签中的 default、options、range
+func fieldSchema(f Field, opts map[string]string) *Schema {
+	s := typeSchema(f.Type)
+	if s.Ref != "" {
+		// $ref 不能与其他属性并列
+		return s
+	}
+	s.Description = f.Doc
+	if v, ok := opts["default"]; ok {
+		s.Default = literal(s.Type, v)
+	}
+	if v, ok := opts["options"]; ok {
+		for _, option := range strings.Split(v, "|") {
+			s.Enum = append(s.Enum, literal(s.Type, option))
+		}
+	}
+	if v, ok := opts["range"]; ok && len(v) >= 2 {
+		bounds := strings.SplitN(v[1:len(v)-1], ":", 2)
+		if len(bounds) == 2 {
+			if lo, err := strconv.ParseFloat(bounds[0], 64); err == nil {
+				s.Minimum, s.ExclusiveMinimum = &lo, v[0] == '('
+			}
+			if hi, err := strconv.ParseFloat(bounds[1], 64); err == nil {
+				s.Maximum, s.ExclusiveMaximum = &hi, v[len(v)-1] == ')'
+			}
+		}
+	}
+	return s
+}
+
+func typeSchema(t string) *Schema {
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return &Schema{Type: "array", Items: typeSchema(t[2:])}
+	case strings.HasPrefix(t, "*"):
+		return typeSchema(t[1:])
+	case strings.HasPrefix(t, "map[string]"):
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t[len("map[string]"):])}
+	}
+	switch t {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	case "interface{}", "any":
+		return &Schema{}
+	}
+	return ref(t)
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// binaryContent 用 | 分隔的媒体类型，内容为二进制
+func binaryContent(mediaTypes string) map[string]*MediaType {
+	content := map[string]*MediaType{}
+	for _, mediaType := range strings.Split(mediaTypes, "|") {
+		mediaType = strings.TrimSpace(mediaType)
+		schema := &Schema{Type: "string", Format: "binary"}
+		if strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" {
+			schema.Format = ""
+		}
+		content[mediaType] = &MediaType{Schema: schema}
+	}
+	return content
+}
+
+// tagValue 解析 go-zero 的标签，如 json:"name,optional,default=1,options=a|b,range=[1:10]"
+func tagValue(tag reflect.StructTag, key string) (string, map[string]string, bool) {
+	value, ok := tag.Lookup(key)
+	if !ok {
+		return "", nil, false
+	}
+	parts := strings.Split(value, ",")
+	opts := map[string]string{}
+	for _, part := range parts[1:] {
+		k, v, _ := strings.Cut(part, "=")
+		opts[k] = v
+	}
+	if parts[0] == "" || parts[0] == "-" {
+		return "", nil, false
+	}
+	return parts[0], opts, true
+}
+
+// required 没有 optional、omitempty、default 且不是指针的字段必填
+func required(f Field, opts map[string]string) bool {
+	for _, k := range []string{"optional", "omitempty", "default"} {
+		if _, ok := opts[k]; ok {
+			return false
+		}
+	}
+	return !strings.HasPrefix(f.Type, "*")
+}
+
+// literal 按类型转换标签中的默认值和可选值
+func literal(schemaType, v string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// tag 路径 /api/ 之后的第一段
+func tag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	return segments[0]
+}
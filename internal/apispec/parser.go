@@ -0,0 +1,357 @@
+// Package apispec 解析 go-zero 的 .api 定义并生成 OpenAPI 3 文档
+// 只支持本项目用到的语法：info、type 块、@server、service 块中的 @doc、@handler 和路由，
+// 字段类型为基本类型、切片、map[string]T、指针和其他 type 中定义的结构体
+package apispec
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Spec 一份 .api 定义
+type Spec struct {
+	Info   map[string]string // info 块，title、desc、version
+	Types  []*Type           // 按定义顺序
+	Routes []*Route          // 按定义顺序
+	types  map[string]*Type
+}
+
+// Type 结构体定义
+type Type struct {
+	Name   string
+	Doc    string // 定义前的注释
+	Fields []Field
+}
+
+// Field 结构体字段，Embedded 为 true 时 Type 为嵌入的结构体名称
+type Field struct {
+	Name     string
+	Type     string
+	Tag      reflect.StructTag
+	Doc      string // 行尾注释
+	Embedded bool
+}
+
+// Route 一个接口
+type Route struct {
+	Method   string // 小写，get、post 等
+	Path     string // go-zero 格式，路径参数为 :name
+	Handler  string
+	Request  string            // 请求类型，可为空
+	Response string            // 响应类型，可为空
+	Doc      map[string]string // @doc 的内容，@doc "..." 等价于 summary
+	Server   map[string]string // 所在 service 块的 @server 注解
+}
+
+// Type 按名称查找类型
+func (s *Spec) Type(name string) *Type {
+	return s.types[name]
+}
+
+var (
+	fieldPattern    = regexp.MustCompile("^(\\w+)\\s+([\\w\\[\\]*{}.]+)\\s*(`[^`]*`)?$")
+	embeddedPattern = regexp.MustCompile(`^(\w+)$`)
+	keyValuePattern = regexp.MustCompile(`^(\w+)\s*:\s*(.*)$`)
+	routePattern    = regexp.MustCompile(`^(get|post|put|delete|patch|head)\s+(\S+)(?:\s*\(\s*(\w+)\s*\))?(?:\s*returns\s*\(\s*(\w+)\s*\))?$`)
+	typeNamePattern = regexp.MustCompile(`^(?:type\s+)?(\w+)\s*(?:struct\s*)?\{$`)
+)
+
+// parser 按行解析，.api 文件需要是 goctl api format 的格式（每个字段、注解、路由各占一行）
+type parser struct {
+	spec    *Spec
+	scanner *bufio.Scanner
+	line    int
+	comment []string // 紧挨着的注释行
+}
+
+// Parse 解析 .api 定义，并检查路由引用的类型、字段引用的类型都有定义，方法和路径不重复
+func Parse(src []byte) (*Spec, error) {
+	p := &parser{
+		spec:    &Spec{Info: map[string]string{}, types: map[string]*Type{}},
+		scanner: bufio.NewScanner(bytes.NewReader(src)),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if err := p.spec.validate(); err != nil {
+		return nil, err
+	}
+	return p.spec, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("第 %d 行: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// next 读取下一个非空行，去掉首尾空白；注释行收集到 p.comment，空行清空 p.comment
+func (p *parser) next() (string, bool) {
+	for p.scanner.Scan() {
+		p.line++
+		line := strings.TrimSpace(p.scanner.Text())
+		switch {
+		case line == "":
+			p.comment = nil
+		case strings.HasPrefix(line, "//"):
+			p.comment = append(p.comment, strings.TrimSpace(strings.TrimPrefix(line, "//")))
+		default:
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// takeComment 取出并清空收集的注释
+func (p *parser) takeComment() string {
+	doc := strings.Join(p.comment, "\n")
+	p.comment = nil
+	return doc
+}
+
+func (p *parser) parse() error {
+	server := map[string]string{}
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.scanner.Err()
+		}
+		switch {
+		case strings.HasPrefix(line, "syntax"):
+			p.comment = nil
+		case line == "info (":
+			p.comment = nil
+			if err := p.keyValues(p.spec.Info); err != nil {
+				return err
+			}
+		case line == "type (":
+			p.comment = nil
+			if err := p.typeBlock(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "type "):
+			if err := p.typeDef(line); err != nil {
+				return err
+			}
+		case line == "@server (":
+			p.comment = nil
+			if err := p.keyValues(server); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "service ") && strings.HasSuffix(line, "{"):
+			p.comment = nil
+			if err := p.service(server); err != nil {
+				return err
+			}
+			server = map[string]string{}
+		default:
+			return p.errorf("无法识别: %s", line)
+		}
+	}
+}
+
+// keyValues 读取 key: value 形式的块直到 )，值两端的引号去掉
+func (p *parser) keyValues(into map[string]string) error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.errorf("缺少 )")
+		}
+		if line == ")" {
+			p.comment = nil
+			return nil
+		}
+		m := keyValuePattern.FindStringSubmatch(line)
+		if m == nil {
+			return p.errorf("应为 key: value: %s", line)
+		}
+		v, err := value(m[2])
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		into[m[1]] = v
+	}
+}
+
+func (p *parser) typeBlock() error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.errorf("type 块缺少 )")
+		}
+		if line == ")" {
+			p.comment = nil
+			return nil
+		}
+		if err := p.typeDef(line); err != nil {
+			return err
+		}
+	}
+}
+
+// typeDef 解析一个结构体，line 为 Name { 或 type Name {
+func (p *parser) typeDef(line string) error {
+	m := typeNamePattern.FindStringSubmatch(line)
+	if m == nil {
+		return p.errorf("应为类型定义: %s", line)
+	}
+	t := &Type{Name: m[1], Doc: p.takeComment()}
+	if _, ok := p.spec.types[t.Name]; ok {
+		return p.errorf("类型 %s 重复定义", t.Name)
+	}
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.errorf("类型 %s 缺少 }", t.Name)
+		}
+		p.comment = nil
+		if line == "}" {
+			break
+		}
+		body, doc := splitComment(line)
+		var f Field
+		if m := fieldPattern.FindStringSubmatch(body); m != nil {
+			f = Field{Name: m[1], Type: m[2], Tag: reflect.StructTag(strings.Trim(m[3], "`"))}
+		} else if m := embeddedPattern.FindStringSubmatch(body); m != nil {
+			f = Field{Name: m[1], Type: m[1], Embedded: true}
+		} else {
+			return p.errorf("无法识别的字段: %s", line)
+		}
+		f.Doc = doc
+		t.Fields = append(t.Fields, f)
+	}
+	p.spec.Types = append(p.spec.Types, t)
+	p.spec.types[t.Name] = t
+	return nil
+}
+
+// service 解析 service 块中的路由
+func (p *parser) service(server map[string]string) error {
+	doc := map[string]string{}
+	var handler string
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.errorf("service 块缺少 }")
+		}
+		p.comment = nil
+		switch {
+		case line == "}":
+			return nil
+		case line == "@doc (":
+			if err := p.keyValues(doc); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "@doc "):
+			summary, err := value(strings.TrimPrefix(line, "@doc"))
+			if err != nil {
+				return p.errorf("%v", err)
+			}
+			doc["summary"] = summary
+		case strings.HasPrefix(line, "@handler "):
+			handler = strings.TrimSpace(strings.TrimPrefix(line, "@handler"))
+		default:
+			m := routePattern.FindStringSubmatch(stripComment(line))
+			if m == nil {
+				return p.errorf("无法识别的路由: %s", line)
+			}
+			if handler == "" {
+				return p.errorf("路由 %s %s 缺少 @handler", m[1], m[2])
+			}
+			p.spec.Routes = append(p.spec.Routes, &Route{
+				Method:   m[1],
+				Path:     m[2],
+				Handler:  handler,
+				Request:  m[3],
+				Response: m[4],
+				Doc:      doc,
+				Server:   server,
+			})
+			doc, handler = map[string]string{}, ""
+		}
+	}
+}
+
+// validate 检查引用的类型都有定义，路由不重复
+func (s *Spec) validate() error {
+	for _, t := range s.Types {
+		for _, f := range t.Fields {
+			if name := baseType(f.Type); !builtin(name) && s.types[name] == nil {
+				return fmt.Errorf("类型 %s 的字段 %s 引用了未定义的类型 %s", t.Name, f.Name, name)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	handlers := map[string]bool{}
+	for _, r := range s.Routes {
+		key := r.Method + " " + r.Path
+		if seen[key] {
+			return fmt.Errorf("路由 %s 重复定义", key)
+		}
+		seen[key] = true
+		if handlers[r.Handler] {
+			return fmt.Errorf("handler %s 重复", r.Handler)
+		}
+		handlers[r.Handler] = true
+		for _, name := range []string{r.Request, r.Response} {
+			if name != "" && s.types[name] == nil {
+				return fmt.Errorf("路由 %s 引用了未定义的类型 %s", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// baseType 去掉切片、指针和 map 的值类型外层，返回元素类型名称
+func baseType(t string) string {
+	for {
+		switch {
+		case strings.HasPrefix(t, "[]"):
+			t = t[2:]
+		case strings.HasPrefix(t, "*"):
+			t = t[1:]
+		case strings.HasPrefix(t, "map[string]"):
+			t = t[len("map[string]"):]
+		default:
+			return t
+		}
+	}
+}
+
+func builtin(t string) bool {
+	switch t {
+	case "string", "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "byte", "rune", "interface{}", "any":
+		return true
+	}
+	return false
+}
+
+// splitComment 拆分行尾注释，标签中不会出现 //
+func splitComment(line string) (string, string) {
+	if i := strings.Index(line, "//"); i >= 0 {
+		return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+2:])
+	}
+	return line, ""
+}
+
+func stripComment(line string) string {
+	body, _ := splitComment(line)
+	return body
+}
+
+// value 注解的值，带引号时取引号内的内容（可以包含 //），否则去掉行尾注释
+func value(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, `"`) {
+		return stripComment(s), nil
+	}
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Unquote(quoted)
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoDevice = errors.New("设备未认证")
+
+type deviceKey struct{}
+
+// WithDevice 将已通过签名校验的设备ID写入上下文
+func WithDevice(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, deviceKey{}, deviceID)
+}
+
+// DeviceIDFromContext 从请求上下文中读取已认证的设备ID
+func DeviceIDFromContext(ctx context.Context) (string, error) {
+	deviceID, ok := ctx.Value(deviceKey{}).(string)
+	if !ok || deviceID == "" {
+		return "", ErrNoDevice
+	}
+	return deviceID, nil
+}
@@ -0,0 +1,48 @@
+// Package auth 负责用户和工作人员访问令牌的签发与解析，令牌由 go-zero 的 JWT 中间件校验。
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimUserID 令牌中保存用户ID的声明，go-zero 校验通过后会以同名 key 写入请求上下文
+const ClaimUserID = "user_id"
+
+var ErrNoUser = errors.New("未登录")
+
+// ErrWeakSecret 令牌签名密钥太短，或沿用了示例配置中的占位值
+var ErrWeakSecret = errors.New("访问令牌签名密钥至少 8 位，且不能使用示例配置中的 change-me 占位值")
+
+// CheckSecret 检查令牌签名密钥：go-zero 的 JWT 中间件要求至少 8 位，
+// 示例配置中的 change-me 占位值是公开的，用它签名任何人都能伪造令牌
+func CheckSecret(secret string) error {
+	if len(secret) < 8 || strings.HasPrefix(secret, "change-me") {
+		return ErrWeakSecret
+	}
+	return nil
+}
+
+// NewUserToken 为用户签发访问令牌，expire 单位为秒
+func NewUserToken(secret string, expire int64, userID string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		ClaimUserID: userID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(time.Duration(expire) * time.Second).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// UserIDFromContext 从请求上下文中读取已认证的用户ID
+func UserIDFromContext(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(ClaimUserID).(string)
+	if !ok || userID == "" {
+		return "", ErrNoUser
+	}
+	return userID, nil
+}
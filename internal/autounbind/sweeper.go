@@ -0,0 +1,76 @@
+// Package autounbind 定时解绑长时间未使用的餐盘。
+//
+// 用户绑定餐盘后超过 IdleAfter 既没有下单也没有重新绑定，视为已经离开，
+// Sweeper 解除绑定并发布带 Auto 标记的解绑事件，由通知服务提醒用户。默认不启用，见 Plate.AutoUnbindAfter。
+package autounbind
+
+import (
+	"context"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Sweeper 餐盘自动解绑
+type Sweeper struct {
+	logic     *logic.RestaurantLogic
+	idleAfter time.Duration
+	interval  time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSweeper 创建餐盘自动解绑，idleAfter 为 0 时不启动
+func NewSweeper(l *logic.RestaurantLogic, idleAfter, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		logic:     l,
+		idleAfter: idleAfter,
+		interval:  interval,
+	}
+}
+
+// Start 开始定时检查
+func (s *Sweeper) Start() {
+	if s.idleAfter <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.sweep(ctx, now)
+			}
+		}
+	}()
+}
+
+// Stop 停止检查
+func (s *Sweeper) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *Sweeper) sweep(ctx context.Context, now time.Time) {
+	count, err := s.logic.AutoUnbindIdlePlates(ctx, now.Add(-s.idleAfter))
+	if err != nil {
+		logx.WithContext(ctx).Errorf("餐盘自动解绑失败: %v", err)
+	}
+	if count > 0 {
+		logx.WithContext(ctx).Infof("%d 个餐盘超时未使用，已自动解绑", count)
+	}
+}
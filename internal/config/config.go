@@ -2,14 +2,129 @@ package config
 
 import (
 	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/zrpc"
 )
 
 type Config struct {
 	rest.RestConf
 	Database DatabaseConfig `json:",optional"`
+	// gRPC 接口（rpc/restaurant.proto），与 REST 接口在同一进程中运行，省略时不启动
+	Rpc       zrpc.RpcServerConf `json:",optional"`
+	RpcTLS    RpcTLSConfig       `json:",optional"`
+	PlateQR   PlateQRConfig
+	Auth      AuthConfig
+	StaffAuth AuthConfig // 工作人员访问令牌，与用户令牌使用不同的密钥
+	// 以下各项都有默认值，整段可以省略（不能标记 optional，否则省略时默认值不生效）
+	Wallet    WalletConfig
+	Device    DeviceConfig
+	Anomaly   AnomalyConfig
+	Inventory InventoryConfig
+	Forecast  ForecastConfig
+	Plate     PlateConfig
+	Notify    NotifyConfig
+	Reconcile ReconcileConfig
+	Docs      DocsConfig
 }
 
 type DatabaseConfig struct {
-	Type string `json:",default=sqlite"`
-	DSN  string `json:",default=restaurant.db"`
+	Type     string `json:",default=sqlite"`
+	DSN      string `json:",default=restaurant.db"`
+	LogLevel string `json:",default=info,options=silent|error|warn|info"` // SQL 日志级别
+	// 启动时的迁移方式：up 执行未执行的迁移；check 只检查，数据库结构不是最新版本时拒绝启动，
+	// 多个实例部署时使用 check，发布前单独执行 restaurantctl migrate up
+	Migrate string `json:",default=up,options=up|check"`
+}
+
+// RpcTLSConfig gRPC 接口的 TLS 证书，都为空时不启用 TLS（只应监听本机）
+type RpcTLSConfig struct {
+	CertFile string `json:",optional"`
+	KeyFile  string `json:",optional"`
+}
+
+// PlateQRConfig 餐盘二维码配置
+type PlateQRConfig struct {
+	Secret string // 二维码签名密钥，不能为空，泄露后需要重新打印全部贴纸
+}
+
+// AuthConfig 访问令牌配置
+type AuthConfig struct {
+	AccessSecret string
+	AccessExpire int64 `json:",default=86400"` // 秒
+}
+
+// WalletConfig 钱包配置
+type WalletConfig struct {
+	LowBalanceThreshold float64 `json:",default=10"` // 余额低于该值时提醒用户
+}
+
+// DeviceConfig 终端设备配置
+type DeviceConfig struct {
+	OfflineAfter  int64 `json:",default=120"` // 超过该时间（秒）没有心跳视为离线
+	CheckInterval int64 `json:",default=30"`  // 离线检查间隔（秒）
+	SignatureSkew int64 `json:",default=300"` // 设备请求时间戳允许的偏差（秒）
+}
+
+// AnomalyConfig 称重读数异常检测规则，设为 0 表示关闭对应规则
+type AnomalyConfig struct {
+	MaxPortion     float64 `json:",default=1000"` // 单次份量上限（克），超过时挂起待审核
+	StationWindow  int64   `json:",default=10"`   // 同一餐盘在不同档口称重的最短间隔（秒），过短时挂起待审核
+	DriftTolerance float64 `json:",default=5"`    // 空秤读数允许的偏差（克），超过时记录异常
+}
+
+// InventoryConfig 菜品库存配置
+type InventoryConfig struct {
+	LowStock    float64 `json:",default=1000"`  // 剩余量低于该值（克）时提醒厨房
+	LunchStart  string  `json:",default=10:30"` // 午餐开始时间，之前为早餐
+	DinnerStart string  `json:",default=16:00"` // 晚餐开始时间
+}
+
+// ForecastConfig 备餐量预测配置
+type ForecastConfig struct {
+	Alpha       float64 `json:",default=0.3"` // 指数平滑系数（0-1），越大越偏重最近的用量
+	MinSamples  int     `json:",default=2"`   // 分组至少需要的历史餐次数，不足时退回更粗的分组
+	HistoryDays int     `json:",default=56"`  // 使用最近多少天的订单
+}
+
+// PlateConfig 餐盘配置
+type PlateConfig struct {
+	AutoUnbindAfter int64 `json:",optional"`   // 绑定后超过该时间（分钟）没有下单自动解绑，默认 0 不启用
+	CheckInterval   int64 `json:",default=60"` // 自动解绑检查间隔（秒）
+}
+
+// ReconcileConfig 钱包对账配置
+type ReconcileConfig struct {
+	Interval int64 `json:",default=1440"` // 定时对账间隔（分钟），0 表示关闭
+}
+
+// NotifyConfig 用户通知配置
+type NotifyConfig struct {
+	DefaultChannels string `json:",default=inbox"` // 用户没有设置偏好时的接收渠道（inbox、email、sms），逗号分隔
+	TemplateDir     string `json:",optional"`      // 自定义模板目录，文件名为 <通知类型>.tmpl，没有的使用内置模板
+	MaxAttempts     int    `json:",default=5"`     // 每条通知最多发送次数
+	RetryBase       int64  `json:",default=30"`    // 第一次重试的间隔（秒），之后每次翻倍
+	PollInterval    int64  `json:",default=5"`     // 检查通知事件和待发送通知的间隔（秒）
+	SMTP            SMTPConfig
+	SMS             SMSConfig
+}
+
+// SMTPConfig 邮件服务器配置，Host 为空表示不发送邮件
+type SMTPConfig struct {
+	Host     string `json:",optional"`
+	Port     int    `json:",default=25"`
+	Username string `json:",optional"`
+	Password string `json:",optional"`
+	From     string `json:",optional"`
+}
+
+// SMSConfig 短信网关配置，URL 为空表示不发送短信
+type SMSConfig struct {
+	URL     string `json:",optional"`
+	Token   string `json:",optional"`
+	Timeout int64  `json:",default=10"` // 秒
+}
+
+// DocsConfig 接口文档配置，文档由 api/restaurant.api 生成
+type DocsConfig struct {
+	Enabled   bool   `json:",default=true"`                                      // 是否提供 /api/docs（Swagger UI）和 /api/docs/openapi.json
+	SwaggerUI string `json:",default=https://unpkg.com/swagger-ui-dist@5.17.14"` // Swagger UI 静态资源地址，内网部署时改为自建的镜像
 }
@@ -0,0 +1,474 @@
+// Package dashboard 维护食堂实时看板的统计数据。
+//
+// 每个食堂各有一份看板：第一次有客户端订阅时从数据库加载该食堂的快照，
+// 之后根据事件总线上该食堂的业务事件做增量更新；
+// 总线处理不过来丢弃事件时增量不再准确，改为从数据库重新加载快照。
+// 每次更新都有递增的序号，并在内存中保留最近的增量，客户端断线重连时
+// 带上最后收到的序号即可补齐中间的增量；序号过旧时重新下发完整快照。
+package dashboard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/model"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+const (
+	historySize  = 1024            // 保留的增量条数
+	queueSize    = 64              // 每个客户端的缓冲大小
+	tickInterval = 5 * time.Second // 刷新每分钟订单数、检查跨天的间隔
+)
+
+// Snapshot 看板完整快照
+type Snapshot struct {
+	Seq             uint64    `json:"seq"`
+	BoundPlates     int64     `json:"bound_plates"`
+	AvailablePlates int64     `json:"available_plates"`
+	OrdersPerMinute int       `json:"orders_per_minute"`
+	OrdersToday     int64     `json:"orders_today"`
+	RevenueToday    float64   `json:"revenue_today"`
+	OpenExceptions  int64     `json:"open_exceptions"`
+	GCBacklog       int64     `json:"gc_backlog"`
+	At              time.Time `json:"at"`
+}
+
+// Delta 看板增量，Changes 为各计数的变化量，OrdersPerMinute 是滚动值直接覆盖
+type Delta struct {
+	Seq             uint64             `json:"seq"`
+	Event           string             `json:"event"`
+	Changes         map[string]float64 `json:"changes,omitempty"`
+	Alert           *Alert             `json:"alert,omitempty"`
+	OrdersPerMinute int                `json:"orders_per_minute"`
+	At              time.Time          `json:"at"`
+}
+
+// Alert 需要工作人员处理的提醒，Delta.Event 为提醒类型：
+// food.low_stock、food.sold_out、food.restocked 带菜品，tableware.reorder 带托管处和餐具
+type Alert struct {
+	FoodID        string  `json:"food_id,omitempty"`
+	FoodName      string  `json:"food_name,omitempty"`
+	Remaining     float64 `json:"remaining"` // 菜品剩余量（克）
+	DepotID       string  `json:"depot_id,omitempty"`
+	TablewareID   string  `json:"tableware_id,omitempty"`
+	TablewareName string  `json:"tableware_name,omitempty"`
+	Quantity      int     `json:"quantity,omitempty"` // 托管处在库加在用的数量
+}
+
+// Message 推送给客户端的消息，Snapshot 与 Delta 二选一
+type Message struct {
+	Snapshot *Snapshot
+	Delta    *Delta
+}
+
+// Seq 消息序号
+func (m Message) Seq() uint64 {
+	if m.Snapshot != nil {
+		return m.Snapshot.Seq
+	}
+	return m.Delta.Seq
+}
+
+// Hub 看板数据中心，按食堂维护看板
+type Hub struct {
+	db  *gorm.DB
+	bus *event.Bus
+
+	mu     sync.Mutex
+	boards map[string]*board
+	nextID int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// board 一个食堂的看板，创建后一直保留，序号在重新加载快照时也保持递增
+type board struct {
+	canteenID    string
+	state        Snapshot
+	day          string
+	recentOrders []time.Time
+	history      []Delta
+	subs         map[int]chan Message
+}
+
+// NewHub 创建看板数据中心
+func NewHub(db *gorm.DB, bus *event.Bus) *Hub {
+	return &Hub{
+		db:     db,
+		bus:    bus,
+		boards: make(map[string]*board),
+	}
+}
+
+// Start 加载各食堂的初始快照并开始消费事件
+func (h *Hub) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := h.bus.Subscribe()
+
+	if err := h.loadBoards(ctx); err != nil {
+		cancel()
+		sub.Cancel()
+		return err
+	}
+
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	go func() {
+		defer close(h.done)
+		defer sub.Cancel()
+		h.run(ctx, sub)
+	}()
+	return nil
+}
+
+// loadBoards 加载所有启用食堂的看板
+func (h *Hub) loadBoards(ctx context.Context) error {
+	var canteenIDs []string
+	if err := h.db.WithContext(ctx).Model(&model.Canteen{}).Where("is_active = ?", true).Pluck("id", &canteenIDs).Error; err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, canteenID := range canteenIDs {
+		if _, err := h.board(ctx, canteenID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// board 返回食堂的看板，还没有时从数据库加载，调用方需持有锁
+func (h *Hub) board(ctx context.Context, canteenID string) (*board, error) {
+	if b, ok := h.boards[canteenID]; ok {
+		return b, nil
+	}
+	b := &board{canteenID: canteenID, subs: make(map[int]chan Message)}
+	if err := h.reload(ctx, b, time.Now()); err != nil {
+		return nil, err
+	}
+	h.boards[canteenID] = b
+	return b, nil
+}
+
+// Stop 停止消费事件
+func (h *Hub) Stop() {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	<-h.done
+}
+
+// Subscribe 订阅食堂的看板更新
+// hasLast 为 true 且 lastSeq 之后的增量仍在内存中时，返回需要补发的增量；否则返回当前快照。
+func (h *Hub) Subscribe(ctx context.Context, canteenID string, lastSeq uint64, hasLast bool) ([]Message, <-chan Message, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, err := h.board(ctx, canteenID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var initial []Message
+	if replay, ok := b.replay(lastSeq, hasLast); ok {
+		initial = replay
+	} else {
+		snapshot := b.state
+		initial = []Message{{Snapshot: &snapshot}}
+	}
+
+	id := h.nextID
+	h.nextID++
+	ch := make(chan Message, queueSize)
+	b.subs[id] = ch
+
+	return initial, ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(ch)
+		}
+	}, nil
+}
+
+// replay 计算断线期间需要补发的增量
+func (b *board) replay(lastSeq uint64, hasLast bool) ([]Message, bool) {
+	if !hasLast || lastSeq > b.state.Seq {
+		return nil, false
+	}
+	if lastSeq == b.state.Seq {
+		return nil, true
+	}
+	if len(b.history) == 0 || b.history[0].Seq > lastSeq+1 {
+		return nil, false
+	}
+
+	var messages []Message
+	for i := range b.history {
+		if b.history[i].Seq > lastSeq {
+			delta := b.history[i]
+			messages = append(messages, Message{Delta: &delta})
+		}
+	}
+	return messages, true
+}
+
+func (h *Hub) run(ctx context.Context, sub *event.Subscription) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var dropped uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			h.apply(ctx, e)
+		case now := <-ticker.C:
+			h.tick(ctx, now)
+		}
+
+		if n := sub.Dropped(); n != dropped && h.resync(ctx, sub.Events()) {
+			dropped = n
+		}
+	}
+}
+
+// resync 总线丢过事件后重新加载快照。积压的事件已经包含在新快照里，先全部丢弃；
+// 加载失败时返回 false，下一个事件或定时器到来时重试
+func (h *Hub) resync(ctx context.Context, events <-chan event.Event) bool {
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-events:
+			drained = !ok
+		default:
+			drained = true
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, b := range h.boards {
+		if err := h.resnapshot(ctx, b, time.Now()); err != nil {
+			logx.WithContext(ctx).Errorf("看板丢失事件后重新加载失败: canteen=%s: %v", b.canteenID, err)
+			return false
+		}
+	}
+	return true
+}
+
+// apply 根据业务事件更新所属食堂的统计，还没有看板的食堂在创建看板时从数据库加载
+func (h *Hub) apply(ctx context.Context, e event.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.boards[e.CanteenID]
+	if !ok || h.rollover(ctx, b, e.At) {
+		return
+	}
+
+	changes := map[string]float64{}
+	add := func(key string, v float64) {
+		if v != 0 {
+			changes[key] += v
+		}
+	}
+
+	add("bound_plates", float64(boolInt(e.IsBound)-boolInt(e.WasBound)))
+	add("available_plates", float64(boolInt(isAvailable(e.NewStatus, e.IsBound))-boolInt(isAvailable(e.PrevStatus, e.WasBound))))
+
+	switch e.Type {
+	case event.OrderPaid:
+		add("orders_today", 1)
+		add("revenue_today", e.Amount)
+		b.recentOrders = append(b.recentOrders, e.At)
+	case event.ExceptionOpened:
+		add("open_exceptions", 1)
+	case event.ExceptionResolved:
+		add("open_exceptions", -1)
+	case event.GCStarted:
+		add("gc_backlog", 1)
+	case event.GCCompleted:
+		add("gc_backlog", -1)
+	}
+	alert := alertOf(e)
+
+	perMinute := b.ordersPerMinute(e.At)
+	if len(changes) == 0 && alert == nil && perMinute == b.state.OrdersPerMinute {
+		return
+	}
+
+	b.state.BoundPlates += int64(changes["bound_plates"])
+	b.state.AvailablePlates += int64(changes["available_plates"])
+	b.state.OrdersToday += int64(changes["orders_today"])
+	b.state.RevenueToday += changes["revenue_today"]
+	b.state.OpenExceptions += int64(changes["open_exceptions"])
+	b.state.GCBacklog += int64(changes["gc_backlog"])
+	b.emit(e.Type, changes, alert, perMinute, e.At)
+}
+
+// alertOf 库存和餐具提醒转换为看板提醒，其他事件返回 nil
+func alertOf(e event.Event) *Alert {
+	switch e.Type {
+	case event.FoodLowStock, event.FoodSoldOut, event.FoodRestocked:
+		return &Alert{FoodID: e.FoodID, FoodName: e.FoodName, Remaining: e.Weight}
+	case event.TablewareReorder:
+		return &Alert{DepotID: e.DepotID, TablewareID: e.TablewareID, TablewareName: e.TablewareName, Quantity: e.Quantity}
+	}
+	return nil
+}
+
+// tick 定时刷新每分钟订单数，并处理跨天
+func (h *Hub) tick(ctx context.Context, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, b := range h.boards {
+		if h.rollover(ctx, b, now) {
+			continue
+		}
+		if perMinute := b.ordersPerMinute(now); perMinute != b.state.OrdersPerMinute {
+			b.emit("tick", nil, nil, perMinute, now)
+		}
+	}
+}
+
+// emit 生成增量并推送，调用方需持有锁
+func (b *board) emit(eventType string, changes map[string]float64, alert *Alert, perMinute int, at time.Time) {
+	b.state.Seq++
+	b.state.OrdersPerMinute = perMinute
+	b.state.At = at
+
+	delta := Delta{
+		Seq:             b.state.Seq,
+		Event:           eventType,
+		Changes:         changes,
+		Alert:           alert,
+		OrdersPerMinute: perMinute,
+		At:              at,
+	}
+	b.history = append(b.history, delta)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+	b.broadcast(Message{Delta: &delta})
+}
+
+// rollover 跨天时从数据库重新加载快照，并推送给该食堂的所有客户端
+func (h *Hub) rollover(ctx context.Context, b *board, now time.Time) bool {
+	if now.Format(time.DateOnly) == b.day {
+		return false
+	}
+	if err := h.resnapshot(ctx, b, now); err != nil {
+		logx.WithContext(ctx).Errorf("看板跨天重新加载失败: canteen=%s: %v", b.canteenID, err)
+		return false
+	}
+	return true
+}
+
+// resnapshot 从数据库重新加载快照并推送给该食堂的所有客户端，调用方需持有锁
+func (h *Hub) resnapshot(ctx context.Context, b *board, now time.Time) error {
+	if err := h.reload(ctx, b, now); err != nil {
+		return err
+	}
+	snapshot := b.state
+	b.broadcast(Message{Snapshot: &snapshot})
+	return nil
+}
+
+// broadcast 推送消息，缓冲已满的客户端会被断开，由客户端重连补齐
+func (b *board) broadcast(msg Message) {
+	for id, ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (b *board) ordersPerMinute(now time.Time) int {
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(b.recentOrders) && !b.recentOrders[i].After(cutoff) {
+		i++
+	}
+	b.recentOrders = b.recentOrders[i:]
+	return len(b.recentOrders)
+}
+
+// reload 从数据库加载食堂的快照，调用方需持有锁。
+// 异常记录按发起的工作人员、餐盘或设备所属食堂统计，GC 积压按餐盘所属食堂统计
+func (h *Hub) reload(ctx context.Context, b *board, now time.Time) error {
+	db := h.db.WithContext(ctx)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	state := Snapshot{Seq: b.state.Seq + 1, At: now}
+	plates := db.Model(&model.Plate{}).Select("id").Where("canteen_id = ?", b.canteenID)
+
+	if err := db.Model(&model.Plate{}).Where("canteen_id = ? AND is_bound = ?", b.canteenID, true).Count(&state.BoundPlates).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.Plate{}).Where("canteen_id = ? AND is_bound = ? AND status = ?", b.canteenID, false, "available").
+		Count(&state.AvailablePlates).Error; err != nil {
+		return err
+	}
+
+	var today struct {
+		Count int64
+		Total float64
+	}
+	if err := db.Model(&model.Order{}).Select("COUNT(*) AS count, COALESCE(SUM(total_price), 0) AS total").
+		Where("canteen_id = ? AND status IN ? AND created_at >= ?", b.canteenID, []string{"paid", "completed"}, startOfDay).
+		Scan(&today).Error; err != nil {
+		return err
+	}
+	state.OrdersToday, state.RevenueToday = today.Count, today.Total
+
+	var recent []time.Time
+	if err := db.Model(&model.Order{}).Where("canteen_id = ? AND status IN ? AND created_at > ?", b.canteenID, []string{"paid", "completed"}, now.Add(-time.Minute)).
+		Order("created_at ASC").Pluck("created_at", &recent).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.ExceptionLog{}).Where("status = ?", "pending").
+		Where(db.Where("worker_id IN (?)", db.Model(&model.Worker{}).Select("id").Where("canteen_id = ?", b.canteenID)).
+			Or("plate_id IN (?)", plates).
+			Or("device_id IN (?)", db.Model(&model.Device{}).Select("id").Where("canteen_id = ?", b.canteenID))).
+		Count(&state.OpenExceptions).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.GCProcessLog{}).Where("status IN ? AND plate_id IN (?)", []string{"pending", "processing"}, plates).
+		Count(&state.GCBacklog).Error; err != nil {
+		return err
+	}
+
+	b.state = state
+	b.day = now.Format(time.DateOnly)
+	b.recentOrders = recent
+	b.state.OrdersPerMinute = b.ordersPerMinute(now)
+	// 快照之前的增量已经失效，重连的客户端需要重新拿快照
+	b.history = nil
+	return nil
+}
+
+func isAvailable(status string, bound bool) bool {
+	return status == "available" && !bound
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
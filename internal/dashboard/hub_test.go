@@ -0,0 +1,203 @@
+package dashboard
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestResyncAfterDroppedEvents(t *testing.T) {
+	db := openDB(t)
+	for _, id := range []string{"P1", "P2"} {
+		if err := db.Create(&model.Plate{ID: id, CanteenID: "c1", QRCode: id, RFIDTag: id, IsBound: true, Status: "in_use"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bus := event.NewBus()
+	h := NewHub(db, bus)
+	sub := bus.Subscribe()
+	defer sub.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, messages, unsubscribe, err := h.Subscribe(ctx, "c1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	// 看板还没开始消费时发布超过缓冲的事件，这些事件并没有真正落库
+	for i := 0; i < 300; i++ {
+		bus.Publish(event.Event{Type: event.PlateBound, CanteenID: "c1", IsBound: true, NewStatus: "in_use", PrevStatus: "available"})
+	}
+	if sub.Dropped() == 0 {
+		t.Fatal("应当有事件被丢弃")
+	}
+	go h.run(ctx, sub)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-messages:
+			if msg.Snapshot == nil {
+				continue
+			}
+			if msg.Snapshot.BoundPlates != 2 {
+				t.Fatalf("重新加载后绑定数 = %d，应为 2", msg.Snapshot.BoundPlates)
+			}
+			return
+		case <-deadline:
+			t.Fatal("丢失事件后没有重新下发快照")
+		}
+	}
+}
+
+func TestBoardsPerCanteen(t *testing.T) {
+	db := openDB(t)
+	for _, v := range []interface{}{
+		&model.Canteen{ID: "c1", Name: "一食堂", IsActive: true},
+		&model.Canteen{ID: "c2", Name: "二食堂", IsActive: true},
+		&model.Plate{ID: "P1", CanteenID: "c1", QRCode: "P1", RFIDTag: "P1", IsBound: true, Status: "in_use"},
+		&model.Plate{ID: "P2", CanteenID: "c2", QRCode: "P2", RFIDTag: "P2", Status: "available"},
+		&model.Order{ID: "o1", CanteenID: "c2", UserID: "u1", PlateID: "P2", TotalPrice: 12, Status: "paid"},
+	} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bus := event.NewBus()
+	h := NewHub(db, bus)
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Stop()
+	ctx := context.Background()
+	subscribe := func(canteenID string) (*Snapshot, <-chan Message) {
+		initial, messages, unsubscribe, err := h.Subscribe(ctx, canteenID, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(unsubscribe)
+		return initial[0].Snapshot, messages
+	}
+
+	// 快照只统计本食堂
+	c1, messages1 := subscribe("c1")
+	c2, messages2 := subscribe("c2")
+	if c1.BoundPlates != 1 || c1.AvailablePlates != 0 || c1.OrdersToday != 0 {
+		t.Fatalf("c1 快照 = %+v", c1)
+	}
+	if c2.BoundPlates != 0 || c2.AvailablePlates != 1 || c2.OrdersToday != 1 || c2.RevenueToday != 12 {
+		t.Fatalf("c2 快照 = %+v", c2)
+	}
+
+	// 增量只推送给事件所属食堂
+	bus.Publish(event.Event{Type: event.OrderPaid, CanteenID: "c2", Amount: 8})
+	select {
+	case msg := <-messages2:
+		if msg.Delta == nil || msg.Delta.Changes["orders_today"] != 1 || msg.Delta.Changes["revenue_today"] != 8 {
+			t.Fatalf("c2 增量 = %+v", msg.Delta)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("c2 没有收到增量")
+	}
+	select {
+	case msg := <-messages1:
+		t.Fatalf("c1 收到了其他食堂的增量: %+v", msg.Delta)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStockAlertsReachDashboard(t *testing.T) {
+	db := openDB(t)
+	now := time.Now()
+	for _, v := range []interface{}{
+		&model.Canteen{ID: "c1", Name: "一食堂", IsActive: true},
+		&model.Worker{ID: "w1", CanteenID: "c1", Name: "员工", Role: "staff"},
+		&model.Food{ID: "f1", CanteenID: "c1", Name: "米饭", Price: 10, IsAvailable: true},
+		&model.User{ID: "u1", Username: "u1"}, &model.Wallet{UserID: "u1", Balance: 100},
+		&model.Plate{ID: "p1", CanteenID: "c1", QRCode: "p1", RFIDTag: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+		&model.PlateDepot{ID: "d1", CanteenID: "c1", Name: "一楼"},
+	} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bus := event.NewBus()
+	h := NewHub(db, bus)
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Stop()
+	ctx := tenant.WithCanteen(context.Background(), "c1")
+	_, messages, unsubscribe, err := h.Subscribe(ctx, "c1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	// 菜品售罄、餐具跌破补购线，都由看板推送给本食堂的工作人员
+	l := logic.NewRestaurantLogic(db).WithBus(bus)
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 300, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []logic.OrderFood{{FoodID: "f1", Weight: 300}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateTableware(ctx, "bowl", "汤碗", "bowl", 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ReceiveTableware(ctx, "d1", "bowl", "w1", 10, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ReportTablewareLoss(ctx, "d1", "bowl", "w1", 6, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts := map[string]*Alert{}
+	deadline := time.After(5 * time.Second)
+	for alerts[event.FoodSoldOut] == nil || alerts[event.TablewareReorder] == nil {
+		select {
+		case msg := <-messages:
+			if msg.Delta != nil && msg.Delta.Alert != nil {
+				alerts[msg.Delta.Event] = msg.Delta.Alert
+			}
+		case <-deadline:
+			t.Fatalf("看板只收到提醒 %v", alerts)
+		}
+	}
+	if a := alerts[event.FoodSoldOut]; a == nil || a.FoodID != "f1" || a.FoodName != "米饭" || a.Remaining != 0 {
+		t.Fatalf("售罄提醒 = %+v", a)
+	}
+	if a := alerts[event.TablewareReorder]; a == nil || a.DepotID != "d1" || a.TablewareID != "bowl" || a.Quantity != 4 {
+		t.Fatalf("补购提醒 = %+v", a)
+	}
+}
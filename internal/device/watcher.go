@@ -0,0 +1,79 @@
+// Package device 监控终端设备（电子秤、读卡器）的在线状态。
+//
+// 设备定时调用心跳接口，Watcher 周期性检查超过 OfflineAfter 没有心跳的在线设备，
+// 标记为离线并生成异常记录，由工作人员到现场处理；设备恢复心跳后异常自动关闭。
+// 检查时顺带清理已过期的设备请求 nonce 记录。
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Watcher 设备离线检查
+type Watcher struct {
+	logic        *logic.RestaurantLogic
+	offlineAfter time.Duration
+	interval     time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWatcher 创建设备离线检查
+func NewWatcher(l *logic.RestaurantLogic, offlineAfter, interval time.Duration) *Watcher {
+	return &Watcher{
+		logic:        l,
+		offlineAfter: offlineAfter,
+		interval:     interval,
+	}
+}
+
+// Start 开始定时检查
+func (w *Watcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				w.check(ctx, now)
+			}
+		}
+	}()
+}
+
+// Stop 停止检查
+func (w *Watcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+func (w *Watcher) check(ctx context.Context, now time.Time) {
+	count, err := w.logic.MarkOfflineDevices(ctx, now.Add(-w.offlineAfter))
+	if err != nil {
+		logx.WithContext(ctx).Errorf("设备离线检查失败: %v", err)
+		return
+	}
+	if count > 0 {
+		logx.WithContext(ctx).Infof("%d 台设备离线，已生成异常记录", count)
+	}
+
+	if _, err := w.logic.PruneDeviceNonces(ctx, now); err != nil {
+		logx.WithContext(ctx).Errorf("清理过期 nonce 失败: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+// Package event 提供进程内的业务事件总线，RestaurantLogic 在事务提交后发布事件，
+// 看板、推送等订阅方各自消费。总线只在单个进程内有效，多副本部署时每个副本各自统计。
+// 订阅方处理不过来时事件会被丢弃，依赖完整事件流的订阅方需要检查 Subscription.Dropped。
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 事件类型
+const (
+	PlateBound        = "plate.bound"
+	PlateUnbound      = "plate.unbound"
+	PlateStatus       = "plate.status" // 异常处理、导入等引起的餐盘状态变化
+	PlateGC           = "plate.gc"
+	ItemAdded         = "order.item_added"
+	OrderPaid         = "order.paid"
+	LowBalance        = "wallet.low_balance"
+	ExceptionOpened   = "exception.opened"
+	ExceptionResolved = "exception.resolved"
+	GCStarted         = "gc.started"
+	GCCompleted       = "gc.completed"
+	FoodLowStock      = "food.low_stock"
+	FoodSoldOut       = "food.sold_out"
+	FoodRestocked     = "food.restocked"
+	TablewareReorder  = "tableware.reorder"
+)
+
+// queueSize 每个订阅方的缓冲大小
+const queueSize = 256
+
+// Event 业务事件
+type Event struct {
+	Type      string
+	At        time.Time
+	CanteenID string
+	UserID    string
+	PlateID   string
+	OrderID   string
+	Amount    float64 // 订单或菜品金额
+
+	// 订单相关
+	FoodID   string
+	FoodName string
+	Weight   float64 // 克；库存事件中为剩余量
+	Total    float64 // 本次用餐（绑定餐盘以来）累计金额
+	Balance  float64 // 支付后余额
+
+	// 餐具补购提醒
+	DepotID       string
+	TablewareID   string
+	TablewareName string
+	Quantity      int // 托管处在库加在用的数量
+
+	// 餐盘状态变化前后的快照，用于增量统计
+	PrevStatus string
+	NewStatus  string
+	WasBound   bool
+	IsBound    bool
+	Auto       bool // 超时未使用，系统自动解绑
+}
+
+// Bus 进程内事件总线
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[int]*Subscription
+	next int
+}
+
+// Subscription 一个订阅方，处理不过来时丢弃的事件数记在 Dropped 里，
+// 订阅方据此判断自己维护的状态是否已经不完整
+type Subscription struct {
+	ch      chan Event
+	dropped atomic.Uint64
+	cancel  func()
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]*Subscription)}
+}
+
+// Publish 发布事件，订阅方处理不过来时丢弃并计数，不阻塞业务流程
+func (b *Bus) Publish(events ...Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, e := range events {
+		if e.At.IsZero() {
+			e.At = time.Now()
+		}
+		for _, sub := range b.subs {
+			select {
+			case sub.ch <- e:
+			default:
+				sub.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Subscribe 订阅事件，用完后调用 Cancel 取消订阅
+func (b *Bus) Subscribe() *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	sub := &Subscription{ch: make(chan Event, queueSize)}
+	b.subs[id] = sub
+
+	var once sync.Once
+	sub.cancel = func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			close(sub.ch)
+		})
+	}
+	return sub
+}
+
+// Events 事件通道，取消订阅后关闭
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped 订阅以来因缓冲已满丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Cancel 取消订阅，可以重复调用
+func (s *Subscription) Cancel() {
+	s.cancel()
+}
@@ -0,0 +1,43 @@
+package event
+
+import "testing"
+
+func TestPublishCountsDrops(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe()
+	defer slow.Cancel()
+	fast := bus.Subscribe()
+	defer fast.Cancel()
+
+	for i := 0; i < queueSize+10; i++ {
+		bus.Publish(Event{Type: OrderPaid})
+		if i < queueSize {
+			<-fast.Events()
+		}
+	}
+
+	if got := slow.Dropped(); got != 10 {
+		t.Errorf("慢订阅方丢弃 %d 条，应为 10", got)
+	}
+	if got := fast.Dropped(); got != 0 {
+		t.Errorf("及时消费的订阅方丢弃 %d 条，应为 0", got)
+	}
+	if got := len(slow.Events()); got != queueSize {
+		t.Errorf("缓冲中 %d 条，应为 %d", got, queueSize)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe()
+	sub.Cancel()
+	sub.Cancel()
+
+	bus.Publish(Event{Type: OrderPaid})
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("取消订阅后通道应当关闭")
+	}
+	if sub.Dropped() != 0 {
+		t.Fatal("取消订阅后不应再计数")
+	}
+}
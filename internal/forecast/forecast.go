@@ -0,0 +1,241 @@
+// Package forecast 根据历史订单明细预测每道菜每个餐次需要准备的重量。
+//
+// 模型是按季节分组的指数平滑：同一道菜、同一餐次、同一日期类型（学期、考试周、假期……）、
+// 同一星期几的历史用量构成一个序列，新的观测值按 Alpha 权重更新平滑值。
+// 某个分组的历史不足 MinSamples 时，依次退回到不区分星期几、不区分日期类型的分组。
+//
+// 只有卖出过的餐次才会产生观测值，某天没有供应的菜品不会被当作 0 计入。
+package forecast
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// 预测依据，从细到粗
+const (
+	BasisWeekday = "weekday"  // 同一星期几、同一日期类型
+	BasisDayType = "day_type" // 同一日期类型
+	BasisPeriod  = "period"   // 同一餐次
+	BasisNone    = "none"     // 没有历史
+)
+
+// Observation 一道菜在一个餐次的实际用量
+type Observation struct {
+	FoodID  string
+	Date    time.Time // 只使用年月日
+	Period  string    // breakfast, lunch, dinner
+	DayType string    // 校历日期类型，例如 term、exam、vacation
+	Grams   float64
+}
+
+// Prediction 预测结果
+type Prediction struct {
+	Grams   float64
+	Basis   string
+	Samples int // 所用分组的历史观测数
+}
+
+// Config 模型参数
+type Config struct {
+	Alpha      float64 // 平滑系数，越大越偏重最近的观测
+	MinSamples int     // 分组至少需要的观测数，不足时退回更粗的分组
+}
+
+// DefaultConfig 默认参数
+var DefaultConfig = Config{Alpha: 0.3, MinSamples: 2}
+
+type key struct {
+	basis   string
+	foodID  string
+	period  string
+	dayType string
+	weekday time.Weekday
+}
+
+type series struct {
+	level   float64
+	samples int
+}
+
+// Model 平滑模型，按时间顺序 Update 观测值
+type Model struct {
+	cfg    Config
+	groups map[key]*series
+}
+
+// New 创建模型
+func New(cfg Config) *Model {
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = DefaultConfig.Alpha
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 1
+	}
+	return &Model{cfg: cfg, groups: make(map[key]*series)}
+}
+
+// Fit 按日期排序后依次更新观测值
+func Fit(cfg Config, observations []Observation) *Model {
+	m := New(cfg)
+	for _, o := range Sorted(observations) {
+		m.Update(o)
+	}
+	return m
+}
+
+// Update 加入一个观测值，调用方需保证按日期先后调用
+func (m *Model) Update(o Observation) {
+	for _, k := range keys(o.FoodID, o.Period, o.DayType, o.Date) {
+		s, ok := m.groups[k]
+		if !ok {
+			m.groups[k] = &series{level: o.Grams, samples: 1}
+			continue
+		}
+		s.level += m.cfg.Alpha * (o.Grams - s.level)
+		s.samples++
+	}
+}
+
+// Predict 预测某道菜在某天某个餐次的用量
+func (m *Model) Predict(foodID string, date time.Time, period, dayType string) Prediction {
+	var fallback *series
+	var fallbackBasis string
+	for _, k := range keys(foodID, period, dayType, date) {
+		s, ok := m.groups[k]
+		if !ok {
+			continue
+		}
+		if s.samples >= m.cfg.MinSamples {
+			return Prediction{Grams: s.level, Basis: k.basis, Samples: s.samples}
+		}
+		if fallback == nil {
+			fallback, fallbackBasis = s, k.basis
+		}
+	}
+	// 各分组都不足 MinSamples 时，用最细的那个
+	if fallback != nil {
+		return Prediction{Grams: fallback.level, Basis: fallbackBasis, Samples: fallback.samples}
+	}
+	return Prediction{Basis: BasisNone}
+}
+
+// keys 观测值所属的分组，从细到粗
+func keys(foodID, period, dayType string, date time.Time) []key {
+	return []key{
+		{basis: BasisWeekday, foodID: foodID, period: period, dayType: dayType, weekday: date.Weekday()},
+		{basis: BasisDayType, foodID: foodID, period: period, dayType: dayType},
+		{basis: BasisPeriod, foodID: foodID, period: period},
+	}
+}
+
+// Sorted 按日期、餐次排序的副本
+func Sorted(observations []Observation) []Observation {
+	sorted := make([]Observation, len(observations))
+	copy(sorted, observations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Date.Equal(sorted[j].Date) {
+			return sorted[i].Date.Before(sorted[j].Date)
+		}
+		return PeriodOrder(sorted[i].Period) < PeriodOrder(sorted[j].Period)
+	})
+	return sorted
+}
+
+// PeriodOrder 餐次在一天中的先后：早餐、午餐、晚餐
+func PeriodOrder(period string) int {
+	switch period {
+	case "breakfast":
+		return 0
+	case "lunch":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Score 回测得分
+type Score struct {
+	Count  int     `json:"count"`  // 参与评分的观测数
+	Missed int     `json:"missed"` // 没有历史、无法预测的观测数
+	Actual float64 `json:"actual"` // 实际总用量（克）
+	MAE    float64 `json:"mae"`    // 平均绝对误差（克）
+	WAPE   float64 `json:"wape"`   // 加权绝对百分比误差，总误差 / 总用量
+	Bias   float64 `json:"bias"`   // 平均偏差（克），正数表示预测偏多
+	absErr float64
+	sumErr float64
+}
+
+func (s *Score) add(actual, predicted float64) {
+	s.Count++
+	s.Actual += actual
+	s.absErr += math.Abs(predicted - actual)
+	s.sumErr += predicted - actual
+}
+
+func (s *Score) finish() {
+	if s.Count == 0 {
+		return
+	}
+	s.MAE = s.absErr / float64(s.Count)
+	s.Bias = s.sumErr / float64(s.Count)
+	if s.Actual > 0 {
+		s.WAPE = s.absErr / s.Actual
+	}
+}
+
+// BacktestResult 回测结果
+type BacktestResult struct {
+	Overall Score            `json:"overall"`
+	Foods   map[string]Score `json:"foods"`
+}
+
+// Backtest 逐日前推回测：start 之前的观测只用于训练，之后的每个观测先用当时已有的历史预测再加入模型。
+// 同一天的观测在预测完当天全部餐次后才加入，模拟每天早上做一次预测。
+func Backtest(cfg Config, observations []Observation, start time.Time) BacktestResult {
+	result := BacktestResult{Foods: make(map[string]Score)}
+	m := New(cfg)
+
+	sorted := Sorted(observations)
+	for i := 0; i < len(sorted); {
+		// 同一天的观测
+		j := i
+		for j < len(sorted) && sameDay(sorted[j].Date, sorted[i].Date) {
+			j++
+		}
+		day := sorted[i:j]
+
+		if !sorted[i].Date.Before(start) {
+			for _, o := range day {
+				food := result.Foods[o.FoodID]
+				p := m.Predict(o.FoodID, o.Date, o.Period, o.DayType)
+				if p.Basis == BasisNone {
+					result.Overall.Missed++
+					food.Missed++
+				} else {
+					result.Overall.add(o.Grams, p.Grams)
+					food.add(o.Grams, p.Grams)
+				}
+				result.Foods[o.FoodID] = food
+			}
+		}
+		for _, o := range day {
+			m.Update(o)
+		}
+		i = j
+	}
+
+	result.Overall.finish()
+	for id, s := range result.Foods {
+		s.finish()
+		result.Foods[id] = s
+	}
+	return result
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
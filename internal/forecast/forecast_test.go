@@ -0,0 +1,85 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestPredictFallback(t *testing.T) {
+	// 2024-09-02 是星期一
+	obs := []Observation{
+		{FoodID: "f1", Date: day("2024-09-02"), Period: "lunch", DayType: "term", Grams: 1000},
+		{FoodID: "f1", Date: day("2024-09-09"), Period: "lunch", DayType: "term", Grams: 2000},
+		{FoodID: "f1", Date: day("2024-09-03"), Period: "lunch", DayType: "term", Grams: 4000},
+	}
+	m := Fit(Config{Alpha: 0.5, MinSamples: 2}, obs)
+
+	// 星期一有两个观测：1000 -> 1500
+	p := m.Predict("f1", day("2024-09-16"), "lunch", "term")
+	if p.Basis != BasisWeekday || p.Grams != 1500 || p.Samples != 2 {
+		t.Fatalf("monday = %+v", p)
+	}
+
+	// 星期二只有一个观测，退回到学期所有日期：1000 -> 2500(9-03) -> 2250(9-09)
+	p = m.Predict("f1", day("2024-09-17"), "lunch", "term")
+	if p.Basis != BasisDayType || p.Grams != 2250 || p.Samples != 3 {
+		t.Fatalf("tuesday = %+v", p)
+	}
+
+	// 考试周没有历史，退回到同一餐次
+	p = m.Predict("f1", day("2024-09-17"), "lunch", "exam")
+	if p.Basis != BasisPeriod || p.Samples != 3 {
+		t.Fatalf("exam = %+v", p)
+	}
+
+	if p := m.Predict("f1", day("2024-09-17"), "dinner", "term"); p.Basis != BasisNone {
+		t.Fatalf("dinner = %+v", p)
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	var obs []Observation
+	start := day("2024-09-02")
+	for i := 0; i < 28; i++ {
+		obs = append(obs, Observation{FoodID: "f1", Date: start.AddDate(0, 0, i), Period: "lunch", DayType: "term", Grams: 3000})
+	}
+	obs = append(obs, Observation{FoodID: "f2", Date: start.AddDate(0, 0, 27), Period: "lunch", DayType: "term", Grams: 500})
+
+	result := Backtest(DefaultConfig, obs, start.AddDate(0, 0, 14))
+
+	// 稳定的需求没有误差
+	f1 := result.Foods["f1"]
+	if f1.Count != 14 || f1.MAE != 0 || f1.WAPE != 0 {
+		t.Fatalf("f1 = %+v", f1)
+	}
+	// 没有历史的菜品只计入 missed
+	if f2 := result.Foods["f2"]; f2.Count != 0 || f2.Missed != 1 {
+		t.Fatalf("f2 = %+v", f2)
+	}
+	if result.Overall.Count != 14 || result.Overall.Missed != 1 {
+		t.Fatalf("overall = %+v", result.Overall)
+	}
+}
+
+func TestBacktestScore(t *testing.T) {
+	obs := []Observation{
+		{FoodID: "f1", Date: day("2024-09-02"), Period: "lunch", Grams: 1000},
+		{FoodID: "f1", Date: day("2024-09-03"), Period: "lunch", Grams: 1500},
+		{FoodID: "f1", Date: day("2024-09-04"), Period: "lunch", Grams: 500},
+	}
+	// 9-03 预测 1000（误差 -500），9-04 预测 1000+0.5*500=1250（误差 +750）
+	result := Backtest(Config{Alpha: 0.5, MinSamples: 1}, obs, day("2024-09-03"))
+	s := result.Overall
+	if s.Count != 2 || s.MAE != 625 || s.Bias != 125 || math.Abs(s.WAPE-0.625) > 1e-9 {
+		t.Fatalf("score = %+v", s)
+	}
+}
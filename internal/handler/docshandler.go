@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/p-program/Fenrir/internal/apidoc"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// swaggerPage Swagger UI 页面，静态资源从 Docs.SwaggerUI 加载
+var swaggerPage = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>智慧大学食堂 API</title>
+  <link rel="stylesheet" href="{{.}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.}}/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "/api/docs/openapi.json",
+      dom_id: "#swagger-ui",
+      deepLinking: true
+    });
+  </script>
+</body>
+</html>
+`))
+
+// SwaggerUI 接口文档页面
+func (h *RestaurantHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := swaggerPage.Execute(w, strings.TrimSuffix(h.svcCtx.Config.Docs.SwaggerUI, "/")); err != nil {
+		logx.WithContext(r.Context()).Errorf("渲染 Swagger UI 失败: %v", err)
+	}
+}
+
+// OpenAPIDocument 由 api/restaurant.api 生成的 OpenAPI 3 文档
+func (h *RestaurantHandler) OpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(apidoc.OpenAPI)
+}
@@ -1,13 +1,24 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/p-program/Fenrir/internal/auth"
 	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/plateqr"
+	"github.com/p-program/Fenrir/internal/receipt"
+	"github.com/p-program/Fenrir/internal/settlement"
 	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/model"
 	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/pathvar"
 )
 
 type RestaurantHandler struct {
@@ -20,6 +31,11 @@ func NewRestaurantHandler(svcCtx *svc.ServiceContext) *RestaurantHandler {
 	}
 }
 
+// newLogic 创建业务逻辑实例，并接入事件总线
+func (h *RestaurantHandler) newLogic() *logic.RestaurantLogic {
+	return h.svcCtx.NewLogic()
+}
+
 // HealthCheck 健康检查
 func (h *RestaurantHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	httpx.OkJson(w, map[string]interface{}{
@@ -36,8 +52,20 @@ func (h *RestaurantHandler) WalletCharge(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
-	wallet, err := l.ChargeWallet(r.Context(), req.UserID, req.Amount)
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		day, err := time.ParseInLocation(time.DateOnly, req.ExpiresAt, time.Local)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, fmt.Errorf("日期格式错误: %w", err))
+			return
+		}
+		// 有效期含当天，次日零点到期
+		end := day.AddDate(0, 0, 1)
+		expiresAt = &end
+	}
+
+	l := h.newLogic()
+	wallet, err := l.ChargeWallet(r.Context(), req.UserID, req.Amount, req.Source, expiresAt)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
 		return
@@ -55,13 +83,13 @@ func (h *RestaurantHandler) WalletCharge(w http.ResponseWriter, r *http.Request)
 
 // GetUserInfo 获取用户信息
 func (h *RestaurantHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("user_id")
+	userID := pathvar.Vars(r)["user_id"]
 	if userID == "" {
 		httpx.ErrorCtx(r.Context(), w, fmt.Errorf("用户ID不能为空"))
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	user, err := l.GetUserInfo(r.Context(), userID)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -84,6 +112,39 @@ func (h *RestaurantHandler) GetUserInfo(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// IssueUserToken 工作人员核实学生身份后为其签发用户访问令牌
+func (h *RestaurantHandler) IssueUserToken(w http.ResponseWriter, r *http.Request) {
+	var req logic.UserTokenRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	user, err := l.ActiveUser(r.Context(), req.UserID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	conf := h.svcCtx.Config.Auth
+	token, err := auth.NewUserToken(conf.AccessSecret, conf.AccessExpire, user.ID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"user_id":    user.ID,
+			"token":      token,
+			"expires_at": time.Now().Add(time.Duration(conf.AccessExpire) * time.Second).Format(time.RFC3339),
+		},
+	})
+}
+
 // BindPlate 绑定餐盘
 func (h *RestaurantHandler) BindPlate(w http.ResponseWriter, r *http.Request) {
 	var req logic.BindPlateRequest
@@ -92,8 +153,14 @@ func (h *RestaurantHandler) BindPlate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
-	plate, err := l.BindPlate(r.Context(), req.UserID, req.PlateID)
+	plateID, err := h.svcCtx.ResolvePlateID(req.PlateID, req.QRPayload)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	plate, err := l.BindPlate(r.Context(), req.UserID, plateID)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
 		return
@@ -119,7 +186,7 @@ func (h *RestaurantHandler) UnbindPlate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	if err := l.UnbindPlate(r.Context(), req.UserID, req.PlateID); err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
 		return
@@ -133,13 +200,13 @@ func (h *RestaurantHandler) UnbindPlate(w http.ResponseWriter, r *http.Request)
 
 // GetPlateInfo 获取餐盘信息
 func (h *RestaurantHandler) GetPlateInfo(w http.ResponseWriter, r *http.Request) {
-	plateID := r.PathValue("plate_id")
+	plateID := pathvar.Vars(r)["plate_id"]
 	if plateID == "" {
 		httpx.ErrorCtx(r.Context(), w, fmt.Errorf("餐盘ID不能为空"))
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	plate, err := l.GetPlateInfo(r.Context(), plateID)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -169,7 +236,7 @@ func (h *RestaurantHandler) GetPlateList(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	plates, err := l.GetPlateList(r.Context(), isBound)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -195,6 +262,153 @@ func (h *RestaurantHandler) GetPlateList(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// GetPlateQRCode 获取餐盘二维码图片
+func (h *RestaurantHandler) GetPlateQRCode(w http.ResponseWriter, r *http.Request) {
+	var req logic.PlateQRCodeRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	plate, err := l.GetPlateInfo(r.Context(), req.PlateID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	payload := h.svcCtx.PlateQR.Payload(plate.ID)
+	var (
+		body        []byte
+		contentType string
+	)
+	if req.Format == "svg" {
+		body, err = plateqr.SVG(payload, req.Size)
+		contentType = "image/svg+xml"
+	} else {
+		body, err = plateqr.PNG(payload, req.Size)
+		contentType = "image/png"
+	}
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, fmt.Errorf("生成二维码失败: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// GetPlateLabels 生成餐盘二维码标签页（PDF）
+func (h *RestaurantHandler) GetPlateLabels(w http.ResponseWriter, r *http.Request) {
+	var req logic.PlateLabelsRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	plates, err := l.GetPlatesForLabels(r.Context(), req.PlateIDs, req.StartID, req.EndID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	labels := make([]plateqr.Label, 0, len(plates))
+	for _, plate := range plates {
+		labels = append(labels, plateqr.Label{
+			PlateID: plate.ID,
+			Payload: h.svcCtx.PlateQR.Payload(plate.ID),
+		})
+	}
+
+	body, err := plateqr.LabelSheet(labels)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="plate-labels.pdf"`)
+	w.Write(body)
+}
+
+// GetPlateTimeline 获取餐盘生命周期时间线
+func (h *RestaurantHandler) GetPlateTimeline(w http.ResponseWriter, r *http.Request) {
+	var req logic.PlateTimelineRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	events, err := l.GetPlateTimeline(r.Context(), req.PlateID, req.BeforeID, req.Limit)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var timeline []map[string]interface{}
+	for _, event := range events {
+		timeline = append(timeline, map[string]interface{}{
+			"id":           event.ID,
+			"type":         event.Type,
+			"prev_status":  event.PrevStatus,
+			"new_status":   event.NewStatus,
+			"actor_type":   event.ActorType,
+			"actor_id":     event.ActorID,
+			"user_id":      event.UserID,
+			"order_id":     event.OrderID,
+			"exception_id": event.ExceptionID,
+			"remark":       event.Remark,
+			"created_at":   event.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": timeline,
+	})
+}
+
+// ImportPlates 从 CSV 批量导入餐盘
+// 请求体可以是 text/csv，也可以是 multipart/form-data 的 file 字段；?dry_run=true 时只校验
+func (h *RestaurantHandler) ImportPlates(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	body := io.Reader(r.Body)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, fmt.Errorf("读取上传文件失败: %w", err))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	l := h.newLogic()
+	result, err := l.ImportPlates(r.Context(), body, dryRun)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	code, msg := 0, "导入成功"
+	switch {
+	case len(result.Errors) > 0:
+		code, msg = 1, "校验失败，未导入任何餐盘"
+	case result.DryRun:
+		msg = "校验通过"
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+		"data": result,
+	})
+}
+
 // CreateOrder 创建订单
 func (h *RestaurantHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req logic.OrderRequest
@@ -203,7 +417,7 @@ func (h *RestaurantHandler) CreateOrder(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 
 	// 转换请求数据
 	var orderFoods []logic.OrderFood
@@ -211,6 +425,7 @@ func (h *RestaurantHandler) CreateOrder(w http.ResponseWriter, r *http.Request)
 		orderFoods = append(orderFoods, logic.OrderFood{
 			FoodID: food.FoodID,
 			Weight: food.Weight,
+			Ladles: food.Ladles,
 		})
 	}
 
@@ -261,7 +476,7 @@ func (h *RestaurantHandler) GetUserOrders(w http.ResponseWriter, r *http.Request
 		req.PageSize = 10
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	orders, total, err := l.GetUserOrders(r.Context(), req.UserID, req.Page, req.PageSize)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -301,13 +516,13 @@ func (h *RestaurantHandler) GetUserOrders(w http.ResponseWriter, r *http.Request
 
 // GetOrderInfo 获取订单信息
 func (h *RestaurantHandler) GetOrderInfo(w http.ResponseWriter, r *http.Request) {
-	orderID := r.PathValue("order_id")
+	orderID := pathvar.Vars(r)["order_id"]
 	if orderID == "" {
 		httpx.ErrorCtx(r.Context(), w, fmt.Errorf("订单ID不能为空"))
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	order, err := l.GetOrderInfo(r.Context(), orderID)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -341,13 +556,13 @@ func (h *RestaurantHandler) GetOrderInfo(w http.ResponseWriter, r *http.Request)
 
 // GetPlateDepot 获取餐盘托管处信息
 func (h *RestaurantHandler) GetPlateDepot(w http.ResponseWriter, r *http.Request) {
-	depotID := r.PathValue("depot_id")
+	depotID := pathvar.Vars(r)["depot_id"]
 	if depotID == "" {
 		httpx.ErrorCtx(r.Context(), w, fmt.Errorf("托管处ID不能为空"))
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	depot, err := l.GetPlateDepot(r.Context(), depotID)
 	if err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
@@ -375,7 +590,7 @@ func (h *RestaurantHandler) HandleException(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
+	l := h.newLogic()
 	if err := l.HandleException(r.Context(), req.WorkerID, req.PlateID, req.Exception, req.Action); err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
 		return
@@ -395,8 +610,8 @@ func (h *RestaurantHandler) ProcessGC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	l := logic.NewRestaurantLogic(h.svcCtx.DB)
-	if err := l.ProcessGC(r.Context(), req.PlateID, req.Type); err != nil {
+	l := h.newLogic()
+	if err := l.ProcessGC(r.Context(), req.PlateID, req.Type, req.WorkerID); err != nil {
 		httpx.ErrorCtx(r.Context(), w, err)
 		return
 	}
@@ -406,3 +621,1512 @@ func (h *RestaurantHandler) ProcessGC(w http.ResponseWriter, r *http.Request) {
 		"msg":  "GC处理成功",
 	})
 }
+
+// CreateCanteen 创建食堂
+func (h *RestaurantHandler) CreateCanteen(w http.ResponseWriter, r *http.Request) {
+	var req logic.CreateCanteenRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	canteen, err := l.CreateCanteen(r.Context(), req.ID, req.Name, req.Location)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": map[string]interface{}{
+			"canteen_id": canteen.ID,
+			"name":       canteen.Name,
+			"location":   canteen.Location,
+		},
+	})
+}
+
+// GetCanteenList 获取食堂列表
+func (h *RestaurantHandler) GetCanteenList(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	canteens, err := l.GetCanteenList(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for _, canteen := range canteens {
+		list = append(list, map[string]interface{}{
+			"canteen_id": canteen.ID,
+			"name":       canteen.Name,
+			"location":   canteen.Location,
+			"is_active":  canteen.IsActive,
+		})
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// GetCanteenReport 跨食堂经营报表
+func (h *RestaurantHandler) GetCanteenReport(w http.ResponseWriter, r *http.Request) {
+	var req logic.CanteenReportRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	reports, err := l.GetCanteenReport(r.Context(), from, to)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"from":     req.From,
+			"to":       req.To,
+			"canteens": reports,
+		},
+	})
+}
+
+// GetTrialBalance 试算平衡表
+func (h *RestaurantHandler) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	var req logic.TrialBalanceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var before time.Time
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, time.Local)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, fmt.Errorf("日期格式错误: %w", err))
+			return
+		}
+		before = to.AddDate(0, 0, 1)
+	}
+
+	l := h.newLogic()
+	tb, err := l.GetTrialBalance(r.Context(), before, req.Detail)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"to":       req.To,
+			"accounts": tb.Rows,
+			"debit":    tb.Debit,
+			"credit":   tb.Credit,
+			"balanced": tb.Balanced,
+		},
+	})
+}
+
+// parseDateRange 解析 2006-01-02 格式的日期区间，返回 [from, to+1天)
+func parseDateRange(fromDate, toDate string) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(time.DateOnly, fromDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("开始日期格式错误: %w", err)
+	}
+	to, err := time.ParseInLocation(time.DateOnly, toDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期格式错误: %w", err)
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// CreateStation 创建档口
+func (h *RestaurantHandler) CreateStation(w http.ResponseWriter, r *http.Request) {
+	var req logic.CreateStationRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	station, err := l.CreateStation(r.Context(), req.ID, req.Name, req.FoodID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": map[string]interface{}{
+			"station_id": station.ID,
+			"canteen_id": station.CanteenID,
+			"name":       station.Name,
+			"food_id":    station.FoodID,
+		},
+	})
+}
+
+// GetStationList 获取档口列表
+func (h *RestaurantHandler) GetStationList(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	stations, err := l.GetStationList(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for _, station := range stations {
+		foodName := ""
+		if station.Food != nil {
+			foodName = station.Food.Name
+		}
+		var devices []map[string]interface{}
+		for _, device := range station.Devices {
+			devices = append(devices, map[string]interface{}{
+				"device_id": device.ID,
+				"type":      device.Type,
+			})
+		}
+		list = append(list, map[string]interface{}{
+			"station_id": station.ID,
+			"canteen_id": station.CanteenID,
+			"name":       station.Name,
+			"food_id":    station.FoodID,
+			"food_name":  foodName,
+			"is_active":  station.IsActive,
+			"devices":    devices,
+		})
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// SetStationFood 更换档口菜品
+func (h *RestaurantHandler) SetStationFood(w http.ResponseWriter, r *http.Request) {
+	var req logic.StationFoodRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	station, err := l.SetStationFood(r.Context(), req.StationID, req.FoodID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "菜品已更换",
+		"data": map[string]interface{}{
+			"station_id": station.ID,
+			"food_id":    station.FoodID,
+		},
+	})
+}
+
+// AssignStationDevice 分配档口设备
+func (h *RestaurantHandler) AssignStationDevice(w http.ResponseWriter, r *http.Request) {
+	var req logic.StationDeviceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	device, err := l.AssignDevice(r.Context(), req.DeviceID, req.StationID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "分配成功",
+		"data": map[string]interface{}{
+			"device_id":  device.ID,
+			"station_id": device.StationID,
+			"type":       device.Type,
+		},
+	})
+}
+
+// IngestWeight 电子秤称重上报
+func (h *RestaurantHandler) IngestWeight(w http.ResponseWriter, r *http.Request) {
+	var req logic.WeightIngestRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	deviceID, err := signedDevice(r, req.DeviceID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	result, err := l.IngestWeight(r.Context(), deviceID, req.PlateID, req.Weight)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var anomalies []map[string]interface{}
+	for _, f := range result.Findings {
+		anomalies = append(anomalies, map[string]interface{}{
+			"rule":    f.Rule,
+			"message": f.Message,
+		})
+	}
+	data := map[string]interface{}{
+		"reading_id": result.Reading.ID,
+		"status":     result.Reading.Status,
+		"anomalies":  anomalies,
+	}
+
+	msg := map[string]string{
+		"accepted": "下单成功",
+		"held":     "读数可疑，订单待审核",
+		"rejected": "读数异常，已丢弃",
+		"idle":     "ok",
+	}[result.Reading.Status]
+	if order := result.Order; order != nil {
+		data["order"] = orderInfo(order)
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  msg,
+		"data": data,
+	})
+}
+
+// orderInfo 订单信息
+func orderInfo(order *model.Order) map[string]interface{} {
+	var foods []map[string]interface{}
+	for _, item := range order.OrderItems {
+		food := map[string]interface{}{
+			"food_id":    item.FoodID,
+			"food_name":  item.FoodName,
+			"weight":     item.Weight,
+			"unit":       item.Unit,
+			"price":      item.Price,
+			"station_id": item.StationID,
+			"held":       item.Held,
+		}
+		if item.CauldronID != "" {
+			food["cauldron_id"] = item.CauldronID
+		}
+		foods = append(foods, food)
+	}
+	return map[string]interface{}{
+		"order_id":    order.ID,
+		"user_id":     order.UserID,
+		"plate_id":    order.PlateID,
+		"foods":       foods,
+		"total_price": order.TotalPrice,
+		"status":      order.Status,
+		"created_at":  order.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// GetHeldOrders 获取挂起待审核的订单
+func (h *RestaurantHandler) GetHeldOrders(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	orders, err := l.GetHeldOrders(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for i := range orders {
+		list = append(list, orderInfo(&orders[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// ReviewHeldOrder 审核挂起的订单
+func (h *RestaurantHandler) ReviewHeldOrder(w http.ResponseWriter, r *http.Request) {
+	var req logic.OrderReviewRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	order, err := l.ReviewHeldOrder(r.Context(), req.OrderID, req.WorkerID, req.Approve, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	msg := "审核通过，已扣款"
+	if !req.Approve {
+		msg = "已驳回，订单取消"
+	}
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  msg,
+		"data": orderInfo(order),
+	})
+}
+
+// GetOrderReceipt 获取订单小票：纯文本、ESC/POS 打印指令或 PDF
+func (h *RestaurantHandler) GetOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	var req logic.OrderReceiptRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	rec, err := l.GetOrderReceipt(r.Context(), req.OrderID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	switch req.Format {
+	case "escpos":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%s.bin"`, rec.OrderID))
+		w.Write(receipt.ESCPOS(rec))
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%s.pdf"`, rec.OrderID))
+		w.Write(receipt.PDF(rec))
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(receipt.Text(rec)))
+	}
+}
+
+// CloseAccount 销户结算
+func (h *RestaurantHandler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	var req logic.AccountCloseRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	if !matchTokenWorker(w, r, req.WorkerID) {
+		return
+	}
+
+	l := h.newLogic()
+	closure, err := l.CloseAccount(r.Context(), req.UserID, req.WorkerID, req.Reason)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "销户成功",
+		"data": closure,
+	})
+}
+
+// GetAccountClosure 查询销户记录或下载销户证明
+func (h *RestaurantHandler) GetAccountClosure(w http.ResponseWriter, r *http.Request) {
+	var req logic.AccountClosureRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	closure, err := l.GetAccountClosure(r.Context(), req.UserID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	switch req.Format {
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="closure-%s.pdf"`, closure.No))
+		w.Write(settlement.PDF(logic.ClosureCertificate(closure)))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(settlement.Text(logic.ClosureCertificate(closure))))
+	default:
+		httpx.OkJson(w, map[string]interface{}{
+			"code": 0,
+			"msg":  "success",
+			"data": closure,
+		})
+	}
+}
+
+// ExportPersonalData 工作人员为用户导出个人数据
+func (h *RestaurantHandler) ExportPersonalData(w http.ResponseWriter, r *http.Request) {
+	var req logic.PersonalDataExportRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	h.writePersonalData(w, r, req.UserID, req.Format)
+}
+
+// ExportMyPersonalData 导出当前用户自己的个人数据
+func (h *RestaurantHandler) ExportMyPersonalData(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+	var req logic.MyPersonalDataExportRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	h.writePersonalData(w, r, userID, req.Format)
+}
+
+// writePersonalData 按 format 输出用户个人数据：json 为单个 JSON 文件，zip 按类别拆成多个 JSON 文件
+func (h *RestaurantHandler) writePersonalData(w http.ResponseWriter, r *http.Request, userID, format string) {
+	l := h.newLogic()
+	export, err := l.ExportPersonalData(r.Context(), userID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	name := fmt.Sprintf("personal-data-%s-%s", userID, export.ExportedAt.Format("20060102"))
+	if format == "zip" {
+		var buf bytes.Buffer
+		if err := export.WriteZip(&buf); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+		w.Write(buf.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": export,
+	})
+}
+
+// ErasePersonalData 匿名化用户个人信息
+func (h *RestaurantHandler) ErasePersonalData(w http.ResponseWriter, r *http.Request) {
+	var req logic.PersonalDataEraseRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	if !matchTokenWorker(w, r, req.WorkerID) {
+		return
+	}
+
+	l := h.newLogic()
+	user, err := l.ErasePersonalData(r.Context(), req.UserID, req.WorkerID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "个人信息已匿名化",
+		"data": map[string]interface{}{
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"erased_at": user.ErasedAt,
+		},
+	})
+}
+
+// GetStationStats 档口出餐统计
+func (h *RestaurantHandler) GetStationStats(w http.ResponseWriter, r *http.Request) {
+	var req logic.StationStatsRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stats, err := l.GetStationStats(r.Context(), from, to)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"from":     req.From,
+			"to":       req.To,
+			"stations": stats,
+		},
+	})
+}
+
+// signedDevice 返回通过签名校验的设备ID，请求体中的设备ID必须与之一致
+func signedDevice(r *http.Request, deviceID string) (string, error) {
+	signed, err := auth.DeviceIDFromContext(r.Context())
+	if err != nil {
+		return "", err
+	}
+	if deviceID != "" && deviceID != signed {
+		return "", errors.New("设备ID与签名不一致")
+	}
+	return signed, nil
+}
+
+// deviceInfo 设备信息（不含密钥）
+func deviceInfo(device *model.Device) map[string]interface{} {
+	info := map[string]interface{}{
+		"device_id":  device.ID,
+		"canteen_id": device.CanteenID,
+		"station_id": device.StationID,
+		"type":       device.Type,
+		"firmware":   device.Firmware,
+		"status":     device.Status,
+	}
+	if device.LastSeenAt != nil {
+		info["last_seen_at"] = device.LastSeenAt.Format("2006-01-02 15:04:05")
+	}
+	return info
+}
+
+// RegisterDevice 登记设备，响应中的 secret 只返回这一次
+func (h *RestaurantHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	var req logic.RegisterDeviceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	device, err := l.RegisterDevice(r.Context(), req.DeviceID, req.Type, req.StationID, req.Firmware)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	data := deviceInfo(device)
+	data["secret"] = device.Secret
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "登记成功",
+		"data": data,
+	})
+}
+
+// GetDeviceList 获取设备列表
+func (h *RestaurantHandler) GetDeviceList(w http.ResponseWriter, r *http.Request) {
+	var req logic.DeviceListRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	devices, err := l.GetDeviceList(r.Context(), req.Status)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for i := range devices {
+		list = append(list, deviceInfo(&devices[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// RotateDeviceSecret 轮换设备密钥
+func (h *RestaurantHandler) RotateDeviceSecret(w http.ResponseWriter, r *http.Request) {
+	var req logic.DeviceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	device, err := l.RotateDeviceSecret(r.Context(), req.DeviceID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "密钥已轮换",
+		"data": map[string]interface{}{
+			"device_id": device.ID,
+			"secret":    device.Secret,
+		},
+	})
+}
+
+// RetireDevice 停用设备
+func (h *RestaurantHandler) RetireDevice(w http.ResponseWriter, r *http.Request) {
+	var req logic.DeviceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	if err := l.RetireDevice(r.Context(), req.DeviceID); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "设备已停用",
+	})
+}
+
+// DeviceHeartbeat 设备心跳
+func (h *RestaurantHandler) DeviceHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var req logic.DeviceHeartbeatRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	deviceID, err := signedDevice(r, req.DeviceID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	device, err := l.DeviceHeartbeat(r.Context(), deviceID, req.Firmware)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "ok",
+		"data": deviceInfo(device),
+	})
+}
+
+// AddFoodBatch 厨房出餐，计入当前餐次库存
+func (h *RestaurantHandler) AddFoodBatch(w http.ResponseWriter, r *http.Request) {
+	var req logic.FoodBatchRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.AddFoodBatch(r.Context(), req.FoodID, req.WorkerID, req.Weight, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "出餐已登记",
+		"data": stockInfo(stock),
+	})
+}
+
+// DiscardFood 登记报废菜品
+func (h *RestaurantHandler) DiscardFood(w http.ResponseWriter, r *http.Request) {
+	var req logic.FoodDiscardRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.DiscardFood(r.Context(), req.FoodID, req.WorkerID, req.Weight, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "报废已登记",
+		"data": stockInfo(stock),
+	})
+}
+
+// GetFoodStock 查询菜品库存
+func (h *RestaurantHandler) GetFoodStock(w http.ResponseWriter, r *http.Request) {
+	var req logic.FoodStockRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stocks, err := l.GetFoodStock(r.Context(), req.Date, req.Period)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for i := range stocks {
+		info := stockInfo(&stocks[i])
+		if food := stocks[i].Food; food != nil {
+			info["food_name"] = food.Name
+			info["is_available"] = food.IsAvailable
+		}
+		list = append(list, info)
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// stockInfo 库存信息
+func stockInfo(stock *model.FoodStock) map[string]interface{} {
+	return map[string]interface{}{
+		"food_id":     stock.FoodID,
+		"date":        stock.Date,
+		"period":      stock.Period,
+		"prepared":    stock.Prepared,
+		"remaining":   stock.Remaining,
+		"low_alerted": stock.LowAlerted,
+	}
+}
+
+// SetCalendarDay 登记校历日期类型
+func (h *RestaurantHandler) SetCalendarDay(w http.ResponseWriter, r *http.Request) {
+	var req logic.CalendarDayRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	day, err := l.SetCalendarDay(r.Context(), req.Date, req.Type, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "校历已登记",
+		"data": day,
+	})
+}
+
+// GetCalendar 查询校历
+func (h *RestaurantHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	var req logic.CalendarListRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	days, err := l.GetCalendar(r.Context(), from, to)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": days,
+	})
+}
+
+// GetDemandForecast 备餐量预测报表
+func (h *RestaurantHandler) GetDemandForecast(w http.ResponseWriter, r *http.Request) {
+	var req logic.DemandForecastRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
+	if req.From != "" {
+		var err error
+		if from, err = time.ParseInLocation(time.DateOnly, req.From, time.Local); err != nil {
+			httpx.ErrorCtx(r.Context(), w, fmt.Errorf("开始日期格式错误: %w", err))
+			return
+		}
+	}
+
+	l := h.newLogic()
+	forecasts, err := l.GetDemandForecast(r.Context(), from, req.Days)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"from":      from.Format(time.DateOnly),
+			"days":      req.Days,
+			"forecasts": forecasts,
+		},
+	})
+}
+
+// CreateSoup 创建汤品
+func (h *RestaurantHandler) CreateSoup(w http.ResponseWriter, r *http.Request) {
+	var req logic.CreateSoupRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	soup, err := l.CreateSoup(r.Context(), req.SoupID, req.Name, req.PriceUnit, req.UnitPrice, req.LadleVolume)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": soupInfo(soup),
+	})
+}
+
+// GetSoupList 获取汤品列表
+func (h *RestaurantHandler) GetSoupList(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	soups, err := l.GetSoupList(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for i := range soups {
+		list = append(list, soupInfo(&soups[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// CreateCauldron 创建汤锅
+func (h *RestaurantHandler) CreateCauldron(w http.ResponseWriter, r *http.Request) {
+	var req logic.CreateCauldronRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	cauldron, err := l.CreateCauldron(r.Context(), req.CauldronID, req.StationID, req.DeviceID, req.Capacity)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": cauldronInfo(cauldron),
+	})
+}
+
+// GetCauldronList 获取汤锅列表（含当前汤量）
+func (h *RestaurantHandler) GetCauldronList(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	cauldrons, err := l.GetCauldronList(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	var list []map[string]interface{}
+	for i := range cauldrons {
+		list = append(list, cauldronInfo(&cauldrons[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// SetCauldronDevice 更换汤锅的打汤机
+func (h *RestaurantHandler) SetCauldronDevice(w http.ResponseWriter, r *http.Request) {
+	var req logic.CauldronDeviceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	cauldron, err := l.SetCauldronDevice(r.Context(), req.CauldronID, req.DeviceID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "更换成功",
+		"data": cauldronInfo(cauldron),
+	})
+}
+
+// RefillCauldron 加汤
+func (h *RestaurantHandler) RefillCauldron(w http.ResponseWriter, r *http.Request) {
+	var req logic.CauldronRefillRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	cauldron, err := l.RefillCauldron(r.Context(), req.CauldronID, req.SoupID, req.WorkerID, req.Volume)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "加汤已登记",
+		"data": cauldronInfo(cauldron),
+	})
+}
+
+// EmptyCauldron 清空汤锅
+func (h *RestaurantHandler) EmptyCauldron(w http.ResponseWriter, r *http.Request) {
+	var req logic.CauldronEmptyRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	cauldron, err := l.EmptyCauldron(r.Context(), req.CauldronID, req.WorkerID, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "汤锅已清空",
+		"data": cauldronInfo(cauldron),
+	})
+}
+
+// GetCauldronEvents 获取汤锅事件
+func (h *RestaurantHandler) GetCauldronEvents(w http.ResponseWriter, r *http.Request) {
+	var req logic.CauldronEventsRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	events, err := l.GetCauldronEvents(r.Context(), req.CauldronID, req.Limit)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": events,
+	})
+}
+
+// DispenseSoup 打汤机出汤上报
+func (h *RestaurantHandler) DispenseSoup(w http.ResponseWriter, r *http.Request) {
+	var req logic.SoupDispenseRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	deviceID, err := signedDevice(r, req.DeviceID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	order, cauldron, err := l.DispenseSoup(r.Context(), deviceID, req.PlateID, req.Volume, req.Ladles)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "下单成功",
+		"data": map[string]interface{}{
+			"order":    orderInfo(order),
+			"cauldron": cauldronInfo(cauldron),
+		},
+	})
+}
+
+// soupInfo 汤品信息
+func soupInfo(soup *model.Soup) map[string]interface{} {
+	info := map[string]interface{}{
+		"soup_id":      soup.ID,
+		"price_unit":   soup.PriceUnit,
+		"unit_price":   soup.UnitPrice,
+		"ladle_volume": soup.LadleVolume,
+	}
+	if soup.Food != nil {
+		info["name"] = soup.Food.Name
+		info["is_available"] = soup.Food.IsAvailable
+	}
+	return info
+}
+
+// cauldronInfo 汤锅信息
+func cauldronInfo(cauldron *model.Cauldron) map[string]interface{} {
+	info := map[string]interface{}{
+		"cauldron_id": cauldron.ID,
+		"station_id":  cauldron.StationID,
+		"device_id":   cauldron.DeviceID,
+		"soup_id":     cauldron.SoupID,
+		"capacity":    cauldron.Capacity,
+		"level":       cauldron.Level,
+		"status":      cauldron.Status,
+	}
+	if cauldron.Soup != nil && cauldron.Soup.Food != nil {
+		info["soup_name"] = cauldron.Soup.Food.Name
+	}
+	return info
+}
+
+// CreateTableware 创建餐具种类
+func (h *RestaurantHandler) CreateTableware(w http.ResponseWriter, r *http.Request) {
+	var req logic.CreateTablewareRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	tableware, err := l.CreateTableware(r.Context(), req.TablewareID, req.Name, req.Kind, req.ReorderLevel)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": tablewareInfo(tableware),
+	})
+}
+
+// GetTablewareList 获取餐具种类列表
+func (h *RestaurantHandler) GetTablewareList(w http.ResponseWriter, r *http.Request) {
+	l := h.newLogic()
+	tablewares, err := l.GetTablewareList(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(tablewares))
+	for i := range tablewares {
+		list = append(list, tablewareInfo(&tablewares[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// ReceiveTableware 餐具入库
+func (h *RestaurantHandler) ReceiveTableware(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareMoveRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.ReceiveTableware(r.Context(), req.DepotID, req.TablewareID, req.WorkerID, req.Quantity, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "入库成功",
+		"data": tablewareStockInfo(stock),
+	})
+}
+
+// IssueTableware 发放餐具
+func (h *RestaurantHandler) IssueTableware(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareMoveRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.IssueTableware(r.Context(), req.DepotID, req.TablewareID, req.WorkerID, req.Quantity, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "发放成功",
+		"data": tablewareStockInfo(stock),
+	})
+}
+
+// ReturnTableware 回收餐具
+func (h *RestaurantHandler) ReturnTableware(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareMoveRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.ReturnTableware(r.Context(), req.DepotID, req.TablewareID, req.WorkerID, req.Quantity, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "回收成功",
+		"data": tablewareStockInfo(stock),
+	})
+}
+
+// ReportTablewareLoss 登记餐具丢失
+func (h *RestaurantHandler) ReportTablewareLoss(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareMoveRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stock, err := l.ReportTablewareLoss(r.Context(), req.DepotID, req.TablewareID, req.WorkerID, req.Quantity, req.Remark)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "登记成功",
+		"data": tablewareStockInfo(stock),
+	})
+}
+
+// GetTablewareStock 获取托管处餐具库存
+func (h *RestaurantHandler) GetTablewareStock(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareStockRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	stocks, err := l.GetTablewareStock(r.Context(), req.DepotID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(stocks))
+	for i := range stocks {
+		list = append(list, tablewareStockInfo(&stocks[i]))
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// GetTablewareLosses 餐具丢失统计
+func (h *RestaurantHandler) GetTablewareLosses(w http.ResponseWriter, r *http.Request) {
+	var req logic.TablewareLossRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	losses, err := l.GetTablewareLosses(r.Context(), from, to)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"from":   req.From,
+			"to":     req.To,
+			"losses": losses,
+		},
+	})
+}
+
+// tablewareInfo 餐具种类信息
+func tablewareInfo(tableware *model.Tableware) map[string]interface{} {
+	return map[string]interface{}{
+		"tableware_id":  tableware.ID,
+		"name":          tableware.Name,
+		"kind":          tableware.Kind,
+		"reorder_level": tableware.ReorderLevel,
+	}
+}
+
+// tablewareStockInfo 托管处餐具库存信息，need_reorder 表示在库加在用数量低于补购提醒阈值
+func tablewareStockInfo(stock *model.TablewareStock) map[string]interface{} {
+	info := map[string]interface{}{
+		"depot_id":     stock.DepotID,
+		"tableware_id": stock.TablewareID,
+		"on_hand":      stock.OnHand,
+		"issued":       stock.Issued,
+		"lost":         stock.Lost,
+		"total":        stock.OnHand + stock.Issued,
+		"need_reorder": false,
+	}
+	if stock.Tableware != nil {
+		info["name"] = stock.Tableware.Name
+		info["kind"] = stock.Tableware.Kind
+		info["reorder_level"] = stock.Tableware.ReorderLevel
+		info["need_reorder"] = stock.Tableware.ReorderLevel > 0 && stock.OnHand+stock.Issued < stock.Tableware.ReorderLevel
+	}
+	return info
+}
+
+// GetInbox 获取当前用户的站内信
+func (h *RestaurantHandler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+	var req logic.InboxRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	notifications, unread, err := l.GetInbox(r.Context(), userID, req.Unread, req.Limit)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(notifications))
+	for _, n := range notifications {
+		list = append(list, map[string]interface{}{
+			"id":         n.ID,
+			"kind":       n.Kind,
+			"subject":    n.Subject,
+			"body":       n.Body,
+			"order_id":   n.OrderID,
+			"read":       n.ReadAt != nil,
+			"created_at": n.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"unread": unread,
+			"list":   list,
+		},
+	})
+}
+
+// MarkInboxRead 把当前用户的站内信标记为已读
+func (h *RestaurantHandler) MarkInboxRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+	var req logic.InboxReadRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	count, err := l.MarkInboxRead(r.Context(), userID, req.IDs)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"count": count,
+		},
+	})
+}
+
+// GetNotificationPreferences 获取当前用户的通知偏好
+func (h *RestaurantHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	prefs, err := l.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(logic.NotificationKinds))
+	for _, kind := range logic.NotificationKinds {
+		list = append(list, map[string]interface{}{
+			"kind":     kind,
+			"channels": prefs[kind],
+		})
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// SetNotificationPreference 设置当前用户某种通知的接收渠道
+func (h *RestaurantHandler) SetNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+	var req logic.NotificationPreferenceRequest
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	l := h.newLogic()
+	if err := l.SetNotificationPreference(r.Context(), userID, req.Kind, req.Channels); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJson(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "设置成功",
+	})
+}
+
+// matchTokenWorker 确认请求中的 worker_id 就是工作人员令牌中的工作人员，不一致时返回 403
+func matchTokenWorker(w http.ResponseWriter, r *http.Request, workerID string) bool {
+	if tokenWorker, err := auth.WorkerIDFromContext(r.Context()); err == nil && tokenWorker == workerID {
+		return true
+	}
+	httpx.WriteJsonCtx(r.Context(), w, http.StatusForbidden, map[string]interface{}{
+		"code": http.StatusForbidden,
+		"msg":  "worker_id 与工作人员令牌不一致",
+	})
+	return false
+}
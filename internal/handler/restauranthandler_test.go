@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/model"
+	"github.com/zeromicro/go-zero/rest/router"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestPathParams 详情接口通过 go-zero 的路由读取路径参数
+func TestPathParams(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{
+		&model.User{ID: "u1", Username: "alice"}, &model.Wallet{UserID: "u1", Balance: 12.5},
+		&model.Plate{ID: "p1", QRCode: "p1", Status: "available"},
+		&model.Order{ID: "o1", UserID: "u1", PlateID: "p1", TotalPrice: 8, Status: "paid"},
+		&model.PlateDepot{ID: "d1", Name: "一楼"},
+	} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewRestaurantHandler(&svc.ServiceContext{DB: db})
+	rt := router.NewRouter()
+	routes := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/api/user/info/:user_id", h.GetUserInfo},
+		{"/api/plate/info/:plate_id", h.GetPlateInfo},
+		{"/api/order/info/:order_id", h.GetOrderInfo},
+		{"/api/depot/info/:depot_id", h.GetPlateDepot},
+	}
+	for _, r := range routes {
+		if err := rt.Handle(http.MethodGet, r.path, r.handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		url, field, want string
+	}{
+		{"/api/user/info/u1", "user_id", "u1"},
+		{"/api/plate/info/p1", "plate_id", "p1"},
+		{"/api/order/info/o1", "order_id", "o1"},
+		{"/api/depot/info/d1", "depot_id", "d1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			var resp struct {
+				Code int                    `json:"code"`
+				Data map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if got := resp.Data[tt.field]; got != tt.want {
+				t.Fatalf("%s = %v，应为 %s: %s", tt.field, got, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/p-program/Fenrir/internal/middleware"
 	"github.com/p-program/Fenrir/internal/svc"
 	"github.com/zeromicro/go-zero/rest"
 )
@@ -10,6 +12,13 @@ import (
 func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 	handler := NewRestaurantHandler(serverCtx)
 
+	// 工作人员接口需要工作人员令牌，食堂由令牌对应的工作人员决定：
+	// staff 允许全部在岗工作人员，manager 只允许管理员，两者都必须能确定食堂；hq 只允许总部管理员
+	staffSecret := serverCtx.Config.StaffAuth.AccessSecret
+	staff := middleware.NewStaffAuthMiddleware(handler.newLogic().ActiveWorker, "staff", "manager", "gc")
+	manager := middleware.NewStaffAuthMiddleware(handler.newLogic().ActiveWorker, "manager")
+	hq := middleware.NewHQAuthMiddleware(handler.newLogic().ActiveWorker)
+
 	// 健康检查
 	server.AddRoutes(
 		[]rest.Route{
@@ -23,97 +32,544 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	// 用户相关
 	server.AddRoutes(
-		[]rest.Route{
-			{
-				Method:  http.MethodPost,
-				Path:    "/api/wallet/charge",
-				Handler: handler.WalletCharge,
-			},
-			{
-				Method:  http.MethodGet,
-				Path:    "/api/user/info/:user_id",
-				Handler: handler.GetUserInfo,
-			},
-		},
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/wallet/charge",
+					Handler: handler.WalletCharge,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/user/info/:user_id",
+					Handler: handler.GetUserInfo,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/user/token",
+					Handler: handler.IssueUserToken,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/account/closure/:user_id",
+					Handler: handler.GetAccountClosure,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
 	)
 
 	// 餐盘相关
 	server.AddRoutes(
-		[]rest.Route{
-			{
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/plate/bind",
+					Handler: handler.BindPlate,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/plate/unbind",
+					Handler: handler.UnbindPlate,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/plate/info/:plate_id",
+					Handler: handler.GetPlateInfo,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/plate/list",
+					Handler: handler.GetPlateList,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/plate/qrcode/:plate_id",
+					Handler: handler.GetPlateQRCode,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/plate/labels",
+					Handler: handler.GetPlateLabels,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/plate/timeline/:plate_id",
+					Handler: handler.GetPlateTimeline,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/plate/import",
+					Handler: handler.ImportPlates,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 订单相关
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/order/create",
+					Handler: handler.CreateOrder,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/order/list",
+					Handler: handler.GetUserOrders,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/order/info/:order_id",
+					Handler: handler.GetOrderInfo,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/order/held",
+					Handler: handler.GetHeldOrders,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/order/review",
+					Handler: handler.ReviewHeldOrder,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/order/receipt/:order_id",
+					Handler: handler.GetOrderReceipt,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 餐盘托管处
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/depot/info/:depot_id",
+					Handler: handler.GetPlateDepot,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 餐具
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/tableware/create",
+					Handler: handler.CreateTableware,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/tableware/list",
+					Handler: handler.GetTablewareList,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/tableware/receive",
+					Handler: handler.ReceiveTableware,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/tableware/issue",
+					Handler: handler.IssueTableware,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/tableware/return",
+					Handler: handler.ReturnTableware,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/tableware/loss",
+					Handler: handler.ReportTablewareLoss,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/tableware/stock",
+					Handler: handler.GetTablewareStock,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/report/tableware-loss",
+					Handler: handler.GetTablewareLosses,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 工作人员
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/worker/exception",
+					Handler: handler.HandleException,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 出餐档口
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/station/create",
+					Handler: handler.CreateStation,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/station/list",
+					Handler: handler.GetStationList,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/station/food",
+					Handler: handler.SetStationFood,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/station/device",
+					Handler: handler.AssignStationDevice,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/report/stations",
+					Handler: handler.GetStationStats,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 终端设备
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/device/list",
+					Handler: handler.GetDeviceList,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 终端设备登记、轮换密钥和停用（响应中包含明文设备密钥），个人数据导出、匿名化和销户结算，只允许管理员
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{manager.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/device/register",
+					Handler: handler.RegisterDevice,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/device/rotate",
+					Handler: handler.RotateDeviceSecret,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/device/retire",
+					Handler: handler.RetireDevice,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/user/export/:user_id",
+					Handler: handler.ExportPersonalData,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/user/erase",
+					Handler: handler.ErasePersonalData,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/account/close",
+					Handler: handler.CloseAccount,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 设备上报（需要设备签名）
+	deviceSign := middleware.NewDeviceSignMiddleware(
+		handler.newLogic().DeviceSecret,
+		handler.newLogic().UseDeviceNonce,
+		time.Duration(serverCtx.Config.Device.SignatureSkew)*time.Second,
+	)
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{deviceSign.Handle},
+			rest.Route{
 				Method:  http.MethodPost,
-				Path:    "/api/plate/bind",
-				Handler: handler.BindPlate,
+				Path:    "/api/device/heartbeat",
+				Handler: handler.DeviceHeartbeat,
 			},
-			{
+			rest.Route{
 				Method:  http.MethodPost,
-				Path:    "/api/plate/unbind",
-				Handler: handler.UnbindPlate,
+				Path:    "/api/station/weight",
+				Handler: handler.IngestWeight,
 			},
-			{
-				Method:  http.MethodGet,
-				Path:    "/api/plate/info/:plate_id",
-				Handler: handler.GetPlateInfo,
+			rest.Route{
+				Method:  http.MethodPost,
+				Path:    "/api/cauldron/dispense",
+				Handler: handler.DispenseSoup,
 			},
+		),
+	)
+
+	// 食堂管理与跨食堂报表（总部使用）
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{hq.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/canteen/create",
+					Handler: handler.CreateCanteen,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/canteen/list",
+					Handler: handler.GetCanteenList,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/report/canteens",
+					Handler: handler.GetCanteenReport,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/report/trial-balance",
+					Handler: handler.GetTrialBalance,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 厨房库存
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/kitchen/batch",
+					Handler: handler.AddFoodBatch,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/kitchen/discard",
+					Handler: handler.DiscardFood,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/kitchen/stock",
+					Handler: handler.GetFoodStock,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 汤品与汤锅
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/soup/create",
+					Handler: handler.CreateSoup,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/soup/list",
+					Handler: handler.GetSoupList,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/cauldron/create",
+					Handler: handler.CreateCauldron,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/cauldron/list",
+					Handler: handler.GetCauldronList,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/cauldron/device",
+					Handler: handler.SetCauldronDevice,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/cauldron/refill",
+					Handler: handler.RefillCauldron,
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/cauldron/empty",
+					Handler: handler.EmptyCauldron,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/cauldron/events",
+					Handler: handler.GetCauldronEvents,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 校历与备餐量预测
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/calendar/day",
+					Handler: handler.SetCalendarDay,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/calendar/list",
+					Handler: handler.GetCalendar,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/report/forecast",
+					Handler: handler.GetDemandForecast,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+	)
+
+	// 实时看板（SSE 长连接，不设超时，按食堂统计）
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/dashboard/stream",
+					Handler: handler.DashboardStream,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
+		rest.WithSSE(),
+		rest.WithTimeout(0),
+	)
+
+	// 用户用餐实时推送（SSE 长连接，需要登录）
+	server.AddRoutes(
+		[]rest.Route{
 			{
 				Method:  http.MethodGet,
-				Path:    "/api/plate/list",
-				Handler: handler.GetPlateList,
+				Path:    "/api/user/stream",
+				Handler: handler.UserStream,
 			},
 		},
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
+		rest.WithSSE(),
+		rest.WithTimeout(0),
 	)
 
-	// 订单相关
+	// 用户站内信、通知偏好和个人数据导出（需要登录）
 	server.AddRoutes(
 		[]rest.Route{
 			{
-				Method:  http.MethodPost,
-				Path:    "/api/order/create",
-				Handler: handler.CreateOrder,
+				Method:  http.MethodGet,
+				Path:    "/api/user/notifications",
+				Handler: handler.GetInbox,
 			},
 			{
 				Method:  http.MethodPost,
-				Path:    "/api/order/list",
-				Handler: handler.GetUserOrders,
+				Path:    "/api/user/notifications/read",
+				Handler: handler.MarkInboxRead,
 			},
 			{
 				Method:  http.MethodGet,
-				Path:    "/api/order/info/:order_id",
-				Handler: handler.GetOrderInfo,
+				Path:    "/api/user/notify/preferences",
+				Handler: handler.GetNotificationPreferences,
 			},
-		},
-	)
-
-	// 餐盘托管处
-	server.AddRoutes(
-		[]rest.Route{
 			{
-				Method:  http.MethodGet,
-				Path:    "/api/depot/info/:depot_id",
-				Handler: handler.GetPlateDepot,
+				Method:  http.MethodPost,
+				Path:    "/api/user/notify/preferences",
+				Handler: handler.SetNotificationPreference,
 			},
-		},
-	)
-
-	// 工作人员
-	server.AddRoutes(
-		[]rest.Route{
 			{
-				Method:  http.MethodPost,
-				Path:    "/api/worker/exception",
-				Handler: handler.HandleException,
+				Method:  http.MethodGet,
+				Path:    "/api/user/me/export",
+				Handler: handler.ExportMyPersonalData,
 			},
 		},
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
 	)
 
 	// GC 处理
 	server.AddRoutes(
-		[]rest.Route{
-			{
-				Method:  http.MethodPost,
-				Path:    "/api/gc/process",
-				Handler: handler.ProcessGC,
-			},
-		},
+		rest.WithMiddlewares(
+			[]rest.Middleware{staff.Handle},
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/gc/process",
+					Handler: handler.ProcessGC,
+				},
+			}...,
+		),
+		rest.WithJwt(staffSecret),
 	)
+
+	// 接口文档
+	if serverCtx.Config.Docs.Enabled {
+		server.AddRoutes(
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/docs",
+					Handler: handler.SwaggerUI,
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/docs/openapi.json",
+					Handler: handler.OpenAPIDocument,
+				},
+			},
+		)
+	}
 }
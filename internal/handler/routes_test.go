@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/apispec"
+	"github.com/p-program/Fenrir/internal/config"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/zeromicro/go-zero/rest"
+)
+
+const apiFile = "../../api/restaurant.api"
+
+func loadSpec(t *testing.T) *apispec.Spec {
+	t.Helper()
+	src, err := os.ReadFile(apiFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := apispec.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// TestRoutesMatchSpec routes.go 注册的路由与 api/restaurant.api 一致：方法、路径和处理函数
+func TestRoutesMatchSpec(t *testing.T) {
+	spec := loadSpec(t)
+
+	var c config.Config
+	c.Port = 8888
+	c.Auth.AccessSecret = "test-access-secret"
+	c.StaffAuth.AccessSecret = "test-staff-secret"
+	c.Docs.Enabled = true
+	server := rest.MustNewServer(c.RestConf)
+	defer server.Stop()
+	RegisterHandlers(server, &svc.ServiceContext{Config: c})
+
+	registered := map[string]string{}
+	for _, r := range server.Routes() {
+		// 方法值的函数名为 (*RestaurantHandler).Name-fm，经过中间件包装的路由取不到名称
+		name := runtime.FuncForPC(reflect.ValueOf(r.Handler).Pointer()).Name()
+		if strings.HasSuffix(name, "-fm") {
+			name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+		} else {
+			name = ""
+		}
+		registered[strings.ToLower(r.Method)+" "+r.Path] = name
+	}
+
+	documented := map[string]bool{}
+	for _, r := range spec.Routes {
+		key := r.Method + " " + r.Path
+		documented[key] = true
+		name, ok := registered[key]
+		if !ok {
+			t.Errorf("%s 在 %s 中定义，但 routes.go 没有注册", key, apiFile)
+			continue
+		}
+		if name != "" && name != r.Handler {
+			t.Errorf("%s 在 routes.go 中的处理函数为 %s，%s 中为 %s", key, name, apiFile, r.Handler)
+		}
+	}
+	for _, key := range sortedKeys(registered) {
+		if !documented[key] {
+			t.Errorf("%s 已在 routes.go 注册，但 %s 中没有定义", key, apiFile)
+		}
+	}
+}
+
+// TestRequestsMatchSpec 处理函数解析请求使用的 logic 类型与 .api 中的请求类型参数一致：
+// 参数名、位置（json、path、form、header）以及是否可选
+func TestRequestsMatchSpec(t *testing.T) {
+	spec := loadSpec(t)
+	handlerRequests := requestTypes(t, ".")
+	logicTypes := structTags(t, "../logic")
+
+	for _, r := range spec.Routes {
+		typeName, ok := handlerRequests[r.Handler]
+		if !ok {
+			// 没有使用 httpx.Parse 的处理函数（流式接口、CSV 上传等）自行读取参数，这里不检查
+			continue
+		}
+		key := r.Method + " " + r.Path
+		if r.Request == "" {
+			t.Errorf("%s 的处理函数解析 logic.%s，但 %s 中没有声明请求类型", key, typeName, apiFile)
+			continue
+		}
+		tags, ok := logicTypes[typeName]
+		if !ok {
+			t.Errorf("%s: 找不到 logic.%s", key, typeName)
+			continue
+		}
+		got := params(tags)
+		want := params(specTags(spec, r.Request))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s 的请求参数不一致\nlogic.%s: %v\n%s: %v", key, typeName, got, r.Request, want)
+		}
+	}
+}
+
+// requestTypes 处理函数中 var req logic.X 声明的请求类型
+func requestTypes(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	result := map[string]string{}
+	for _, file := range parseDir(t, dir) {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				spec, ok := n.(*ast.ValueSpec)
+				if !ok || len(spec.Names) != 1 || spec.Names[0].Name != "req" {
+					return true
+				}
+				if sel, ok := spec.Type.(*ast.SelectorExpr); ok {
+					if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "logic" {
+						result[fn.Name.Name] = sel.Sel.Name
+					}
+				}
+				return false
+			})
+		}
+	}
+	return result
+}
+
+// structTags 目录中各结构体字段的标签
+func structTags(t *testing.T, dir string) map[string][]reflect.StructTag {
+	t.Helper()
+	result := map[string][]reflect.StructTag{}
+	for _, file := range parseDir(t, dir) {
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			var tags []reflect.StructTag
+			for _, f := range st.Fields.List {
+				if f.Tag == nil {
+					continue
+				}
+				tag, err := strconv.Unquote(f.Tag.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tags = append(tags, reflect.StructTag(tag))
+			}
+			result[spec.Name.Name] = tags
+			return false
+		})
+	}
+	return result
+}
+
+func parseDir(t *testing.T, dir string) []*ast.File {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []*ast.File
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// specTags .api 类型的字段标签，嵌入的类型展开
+func specTags(spec *apispec.Spec, name string) []reflect.StructTag {
+	var tags []reflect.StructTag
+	for _, f := range spec.Type(name).Fields {
+		if f.Embedded {
+			tags = append(tags, specTags(spec, f.Type)...)
+			continue
+		}
+		tags = append(tags, f.Tag)
+	}
+	return tags
+}
+
+// params 把字段标签整理为 "位置:名称" 列表，可选参数带 ? 后缀
+func params(tags []reflect.StructTag) []string {
+	var result []string
+	for _, tag := range tags {
+		for _, key := range []string{"json", "path", "form", "header"} {
+			value, ok := tag.Lookup(key)
+			if !ok || value == "-" {
+				continue
+			}
+			parts := strings.Split(value, ",")
+			param := key + ":" + parts[0]
+			for _, option := range parts[1:] {
+				if option == "optional" || strings.HasPrefix(option, "default=") {
+					param += "?"
+					break
+				}
+			}
+			result = append(result, param)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
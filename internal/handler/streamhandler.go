@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/dashboard"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// sseKeepAlive SSE 心跳间隔，避免代理断开空闲连接
+const sseKeepAlive = 15 * time.Second
+
+// writeSSE 写入一条 SSE 消息并立即刷新
+func writeSSE(w http.ResponseWriter, id uint64, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, eventType, body); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// lastEventID 读取断线重连时客户端带上的最后一条消息序号
+// 浏览器 EventSource 重连时会自动带上 Last-Event-ID 请求头，首次连接可用 ?last_event_id= 指定
+func lastEventID(r *http.Request) (uint64, bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	return id, err == nil
+}
+
+// DashboardStream 实时看板（SSE），只推送调用方所属食堂的看板
+// 首次连接推送 snapshot，之后推送 delta；重连时补发断线期间的 delta，无法补发时重新推送 snapshot
+func (h *RestaurantHandler) DashboardStream(w http.ResponseWriter, r *http.Request) {
+	lastSeq, hasLast := lastEventID(r)
+	initial, messages, cancel, err := h.svcCtx.Dashboard.Subscribe(r.Context(), tenant.CanteenID(r.Context()), lastSeq, hasLast)
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+	defer cancel()
+
+	write := func(msg dashboard.Message) error {
+		if msg.Snapshot != nil {
+			return writeSSE(w, msg.Seq(), "snapshot", msg.Snapshot)
+		}
+		return writeSSE(w, msg.Seq(), "delta", msg.Delta)
+	}
+
+	for _, msg := range initial {
+		if err := write(msg); err != nil {
+			return
+		}
+	}
+	if len(initial) == 0 {
+		// 客户端已是最新状态，刷新响应头让连接尽快建立
+		http.NewResponseController(w).Flush()
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			http.NewResponseController(w).Flush()
+		case msg, ok := <-messages:
+			if !ok {
+				// 推送跟不上被断开，客户端重连后补齐
+				return
+			}
+			if err := write(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// userStreamEvents 推送给用户的事件及其 SSE 事件名
+var userStreamEvents = map[string]string{
+	event.PlateBound:   "plate_bound",
+	event.ItemAdded:    "item_added",
+	event.OrderPaid:    "order_paid",
+	event.PlateUnbound: "plate_unbound",
+	event.PlateGC:      "plate_unbound", // 餐盘被回收时自动解绑
+	event.LowBalance:   "low_balance",
+}
+
+// UserStream 用户个人的用餐实时推送（SSE，需要登录）
+func (h *RestaurantHandler) UserStream(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	sub := h.svcCtx.Bus.Subscribe()
+	defer sub.Cancel()
+	http.NewResponseController(w).Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	var seq uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			http.NewResponseController(w).Flush()
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			name, wanted := userStreamEvents[e.Type]
+			if !wanted || e.UserID != userID {
+				continue
+			}
+			// GC 只有在回收已绑定的餐盘时才算解绑
+			if e.Type == event.PlateGC && (!e.WasBound || e.IsBound) {
+				continue
+			}
+			seq++
+			if err := writeSSE(w, seq, name, userStreamPayload(e)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// userStreamPayload 转换为推送给用户的数据
+func userStreamPayload(e event.Event) map[string]interface{} {
+	data := map[string]interface{}{
+		"at": e.At.Format("2006-01-02 15:04:05"),
+	}
+	switch e.Type {
+	case event.PlateBound, event.PlateUnbound, event.PlateGC:
+		data["plate_id"] = e.PlateID
+	case event.ItemAdded:
+		data["plate_id"] = e.PlateID
+		data["order_id"] = e.OrderID
+		data["name"] = e.FoodName
+		data["weight"] = e.Weight
+		data["price"] = e.Amount
+		data["total"] = e.Total
+	case event.OrderPaid:
+		data["plate_id"] = e.PlateID
+		data["order_id"] = e.OrderID
+		data["total_price"] = e.Amount
+		data["total"] = e.Total
+		data["balance"] = e.Balance
+	case event.LowBalance:
+		data["balance"] = e.Balance
+	}
+	return data
+}
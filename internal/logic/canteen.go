@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// inCanteen 按调用方所属食堂过滤；未指定食堂时不过滤（总部视角）
+// 只用于带 canteen_id 列的表：菜品、餐盘、托管处、工作人员、订单
+func inCanteen(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return sameCanteen(tenant.CanteenID(ctx))
+}
+
+// sameCanteen 限定在指定食堂内，canteenID 为空时不过滤
+func sameCanteen(canteenID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if canteenID == "" {
+			return db
+		}
+		return db.Where("canteen_id = ?", canteenID)
+	}
+}
+
+// CreateCanteen 创建食堂
+func (l *RestaurantLogic) CreateCanteen(ctx context.Context, id, name, location string) (*model.Canteen, error) {
+	if name == "" {
+		return nil, errors.New("食堂名称不能为空")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	canteen := model.Canteen{
+		ID:       id,
+		Name:     name,
+		Location: location,
+		IsActive: true,
+	}
+	if err := l.db.WithContext(ctx).Create(&canteen).Error; err != nil {
+		return nil, fmt.Errorf("创建食堂失败: %w", err)
+	}
+	return &canteen, nil
+}
+
+// GetCanteenList 获取食堂列表
+func (l *RestaurantLogic) GetCanteenList(ctx context.Context) ([]model.Canteen, error) {
+	var canteens []model.Canteen
+	if err := l.db.WithContext(ctx).Order("id ASC").Find(&canteens).Error; err != nil {
+		return nil, fmt.Errorf("查询食堂列表失败: %w", err)
+	}
+	return canteens, nil
+}
+
+// CanteenReport 单个食堂在统计区间内的经营数据
+type CanteenReport struct {
+	CanteenID   string  `json:"canteen_id"`
+	Name        string  `json:"name"`
+	Orders      int64   `json:"orders"`
+	Revenue     float64 `json:"revenue"`
+	AvgOrder    float64 `json:"avg_order"`
+	Diners      int64   `json:"diners"`       // 就餐人数（去重）
+	WeightGrams float64 `json:"weight_grams"` // 售出菜品总重量
+	Plates      int64   `json:"plates"`
+	Workers     int64   `json:"workers"` // 在岗工作人员
+}
+
+// GetCanteenReport 跨食堂经营报表（总部使用，不受调用方食堂限制）
+func (l *RestaurantLogic) GetCanteenReport(ctx context.Context, from, to time.Time) ([]CanteenReport, error) {
+	if !to.After(from) {
+		return nil, errors.New("统计结束时间必须晚于开始时间")
+	}
+	db := l.db.WithContext(ctx)
+	paid := []string{"paid", "completed"}
+
+	var canteens []model.Canteen
+	if err := db.Order("id ASC").Find(&canteens).Error; err != nil {
+		return nil, fmt.Errorf("查询食堂列表失败: %w", err)
+	}
+
+	var orders []struct {
+		CanteenID string
+		Orders    int64
+		Revenue   float64
+		Diners    int64
+	}
+	if err := db.Model(&model.Order{}).
+		Select("canteen_id, COUNT(*) AS orders, COALESCE(SUM(total_price), 0) AS revenue, COUNT(DISTINCT user_id) AS diners").
+		Where("status IN ? AND created_at >= ? AND created_at < ?", paid, from, to).
+		Group("canteen_id").Scan(&orders).Error; err != nil {
+		return nil, fmt.Errorf("统计订单失败: %w", err)
+	}
+
+	var weights []struct {
+		CanteenID string
+		Weight    float64
+	}
+	if err := db.Table("order_items").
+		Select("orders.canteen_id AS canteen_id, COALESCE(SUM(order_items.weight), 0) AS weight").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status IN ? AND orders.created_at >= ? AND orders.created_at < ? AND orders.deleted_at IS NULL", paid, from, to).
+		Group("orders.canteen_id").Scan(&weights).Error; err != nil {
+		return nil, fmt.Errorf("统计菜品重量失败: %w", err)
+	}
+
+	type counter struct {
+		CanteenID string
+		Count     int64
+	}
+	var plates, workers []counter
+	if err := db.Model(&model.Plate{}).Select("canteen_id, COUNT(*) AS count").Group("canteen_id").Scan(&plates).Error; err != nil {
+		return nil, fmt.Errorf("统计餐盘失败: %w", err)
+	}
+	if err := db.Model(&model.Worker{}).Select("canteen_id, COUNT(*) AS count").Where("is_active = ?", true).Group("canteen_id").Scan(&workers).Error; err != nil {
+		return nil, fmt.Errorf("统计工作人员失败: %w", err)
+	}
+
+	reports := map[string]*CanteenReport{}
+	var ids []string
+	row := func(id string) *CanteenReport {
+		if r, ok := reports[id]; ok {
+			return r
+		}
+		r := &CanteenReport{CanteenID: id, Name: "未分配食堂"}
+		reports[id] = r
+		ids = append(ids, id)
+		return r
+	}
+
+	for _, c := range canteens {
+		row(c.ID).Name = c.Name
+	}
+	for _, o := range orders {
+		r := row(o.CanteenID)
+		r.Orders, r.Revenue, r.Diners = o.Orders, o.Revenue, o.Diners
+		if o.Orders > 0 {
+			r.AvgOrder = o.Revenue / float64(o.Orders)
+		}
+	}
+	for _, w := range weights {
+		row(w.CanteenID).WeightGrams = w.Weight
+	}
+	for _, p := range plates {
+		row(p.CanteenID).Plates = p.Count
+	}
+	for _, w := range workers {
+		row(w.CanteenID).Workers = w.Count
+	}
+
+	result := make([]CanteenReport, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, *reports[id])
+	}
+	return result, nil
+}
@@ -0,0 +1,411 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/settlement"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// 钱包状态，启用状态字段前创建的钱包为空，视同 active
+const (
+	WalletActive = "active" // 正常
+	WalletFrozen = "frozen" // 销户处理中，不能充值和下单
+	WalletClosed = "closed" // 已销户，余额已结清
+)
+
+var (
+	// ErrWalletClosed 钱包已冻结或已销户，不能充值、绑定餐盘和下单
+	ErrWalletClosed = errors.New("钱包已冻结或已销户")
+	// ErrAccountClosed 账户已经销户，返回已有的销户记录
+	ErrAccountClosed = errors.New("账户已销户")
+)
+
+func walletClosing(status string) bool {
+	return status == WalletFrozen || status == WalletClosed
+}
+
+// CloseAccount 销户：冻结钱包、解绑餐盘，按交易流水计算余额中的自有资金和补贴，
+// 退还自有资金、收回剩余补贴（含已过期的补贴），记录一条 settlement 结算交易和销户记录，最后删除用户。
+// 必须指定办理销户的工作人员，系统批量销户见 CloseAccounts。已删除的用户（例如已毕业的学生）同样可以销户。
+// 钱包余额与交易流水不一致时不结算，钱包保持冻结，对账处理后可以重新销户
+func (l *RestaurantLogic) CloseAccount(ctx context.Context, userID, workerID, reason string) (*model.AccountClosure, error) {
+	if workerID == "" {
+		return nil, errors.New("worker_id 不能为空")
+	}
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ? AND id <> ?", workerID, systemWorkerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	return l.closeAccount(ctx, userID, "worker", workerID, reason)
+}
+
+// closeAccount 执行销户，operatorType 为 "worker" 或 "system"（批量销户，operatorID 为空）
+func (l *RestaurantLogic) closeAccount(ctx context.Context, userID, operatorType, operatorID, reason string) (*model.AccountClosure, error) {
+	var user model.User
+	if err := l.db.WithContext(ctx).Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %w", err)
+	}
+	if closure, err := l.GetAccountClosure(ctx, userID); err == nil {
+		return closure, ErrAccountClosed
+	}
+
+	// 冻结钱包，此后的充值、下单和扣款都会失败
+	var wallet model.Wallet
+	if err := l.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Limit(1).Find(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	if wallet.ID != 0 {
+		result := l.db.WithContext(ctx).Unscoped().Model(&model.Wallet{}).
+			Where("id = ? AND (status IS NULL OR status IN ?)", wallet.ID, []string{WalletActive, WalletFrozen}).
+			Update("status", WalletFrozen)
+		if result.Error != nil {
+			return nil, fmt.Errorf("冻结钱包失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrWalletClosed
+		}
+	}
+
+	if err := l.unbindUserPlates(ctx, userID, operatorType, operatorID); err != nil {
+		return nil, err
+	}
+
+	// 冻结后重新读取余额和流水
+	if wallet.ID != 0 {
+		if err := l.db.WithContext(ctx).Unscoped().Where("id = ?", wallet.ID).First(&wallet).Error; err != nil {
+			return nil, fmt.Errorf("查询钱包失败: %w", err)
+		}
+	}
+	now := time.Now()
+	settled, err := l.settleWallet(ctx, &wallet, now)
+	if err != nil {
+		return nil, err
+	}
+
+	closure := model.AccountClosure{
+		No:             now.Format("20060102") + "-" + strings.ToUpper(uuid.New().String()[:8]),
+		UserID:         user.ID,
+		Username:       user.Username,
+		WalletID:       wallet.ID,
+		Balance:        settled.Balance,
+		Refundable:     settled.Refundable,
+		Subsidy:        settled.Subsidy,
+		ExpiredSubsidy: settled.Expired,
+		OperatorType:   operatorType,
+		OperatorID:     operatorID,
+		Reason:         reason,
+	}
+	remark := fmt.Sprintf("销户结算：退还自有资金 %.2f 元，收回补贴 %.2f 元", settled.Refundable, settled.Subsidy+settled.Expired)
+
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if wallet.ID != 0 {
+			// 记账：自有资金经支付清算退还给用户，补贴退回补贴资金池
+			lines := []model.JournalLine{{AccountID: walletAccount(userID), Debit: settled.Balance}}
+			if settled.Refundable > 0 {
+				lines = append(lines, model.JournalLine{AccountID: AccountClearing, Credit: settled.Refundable})
+			}
+			if subsidy := roundCents(settled.Subsidy + settled.Expired); subsidy > 0 {
+				lines = append(lines, model.JournalLine{AccountID: AccountSubsidy, Credit: subsidy})
+			}
+			// 带上余额条件，读取流水之后钱包有变动时重新结算
+			transaction, err := postWallet(tx, &wallet, walletPosting{
+				Entry:     model.JournalEntry{Kind: "settlement", Remark: remark},
+				Lines:     lines,
+				Guard:     "status = ? AND balance = ?",
+				GuardArgs: []interface{}{WalletFrozen, wallet.Balance},
+			})
+			if errors.Is(err, errWalletGuard) {
+				return ErrWalletChanged
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&model.Wallet{}).Where("id = ?", wallet.ID).
+				Update("status", WalletClosed).Error; err != nil {
+				return fmt.Errorf("结清钱包失败: %w", err)
+			}
+			closure.TransactionID = transaction.ID
+			if settled.Refundable > 0 {
+				if err := queueNotificationEvents(tx, model.NotificationEvent{
+					UserID: userID,
+					Kind:   NotifyRefundIssued,
+					Amount: settled.Refundable,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Create(&closure).Error; err != nil {
+			return fmt.Errorf("记录销户失败: %w", err)
+		}
+		if err := tx.Where("id = ?", userID).Delete(&model.User{}).Error; err != nil {
+			return fmt.Errorf("删除用户失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &closure, nil
+}
+
+// settleWallet 按交易流水计算 at 时刻钱包余额的构成，余额与流水合计不一致时返回错误。wallet.ID 为 0 表示没有钱包
+func (l *RestaurantLogic) settleWallet(ctx context.Context, wallet *model.Wallet, at time.Time) (settlement.Result, error) {
+	var transactions []model.Transaction
+	if wallet.ID != 0 {
+		if err := l.db.WithContext(ctx).Where("wallet_id = ?", wallet.ID).Order("id").Find(&transactions).Error; err != nil {
+			return settlement.Result{}, fmt.Errorf("查询交易记录失败: %w", err)
+		}
+	}
+	entries := make([]settlement.Entry, 0, len(transactions))
+	for _, t := range transactions {
+		entries = append(entries, settlement.Entry{
+			At:        t.CreatedAt,
+			Type:      t.Type,
+			Amount:    t.Amount,
+			OrderID:   t.OrderID,
+			ExpiresAt: t.ExpiresAt,
+		})
+	}
+	settled := settlement.Settle(entries, at)
+	if cents(wallet.Balance) != cents(settled.Balance) {
+		return settled, fmt.Errorf("钱包余额 %.2f 与交易流水合计 %.2f 不一致，请先对账", wallet.Balance, settled.Balance)
+	}
+	if wallet.Balance < 0 {
+		return settled, fmt.Errorf("钱包余额为负: %.2f，请先对账", wallet.Balance)
+	}
+	return settled, nil
+}
+
+// unbindUserPlates 解绑用户在所有食堂绑定的餐盘，餐盘状态不变，由工作人员回收
+func (l *RestaurantLogic) unbindUserPlates(ctx context.Context, userID, operatorType, operatorID string) error {
+	var plates []model.Plate
+	if err := l.db.WithContext(ctx).Where("is_bound = ? AND bound_user_id = ?", true, userID).Find(&plates).Error; err != nil {
+		return fmt.Errorf("查询已绑定餐盘失败: %w", err)
+	}
+
+	for _, plate := range plates {
+		unbound := false
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&model.Plate{}).
+				Where("id = ? AND is_bound = ? AND bound_user_id = ?", plate.ID, true, userID).
+				Updates(map[string]interface{}{"is_bound": false, "bound_user_id": "", "bound_at": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+			unbound = true
+			return recordPlateEvent(tx, model.PlateEvent{
+				PlateID:    plate.ID,
+				Type:       "unbind",
+				PrevStatus: plate.Status,
+				NewStatus:  plate.Status,
+				ActorType:  operatorType,
+				ActorID:    operatorID,
+				UserID:     userID,
+				Remark:     "销户解绑",
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("解绑餐盘失败: %w", err)
+		}
+		if !unbound {
+			continue
+		}
+
+		l.bus.Publish(event.Event{
+			CanteenID:  plate.CanteenID,
+			Type:       event.PlateUnbound,
+			UserID:     userID,
+			PlateID:    plate.ID,
+			PrevStatus: plate.Status,
+			NewStatus:  plate.Status,
+			WasBound:   true,
+		})
+	}
+	return nil
+}
+
+// GetAccountClosure 查询用户的销户记录
+func (l *RestaurantLogic) GetAccountClosure(ctx context.Context, userID string) (*model.AccountClosure, error) {
+	var closure model.AccountClosure
+	if err := l.db.WithContext(ctx).Where("user_id = ?", userID).First(&closure).Error; err != nil {
+		return nil, fmt.Errorf("销户记录不存在: %w", err)
+	}
+	return &closure, nil
+}
+
+// ClosureCertificate 根据销户记录生成销户证明
+func ClosureCertificate(c *model.AccountClosure) *settlement.Certificate {
+	return &settlement.Certificate{
+		No:       c.No,
+		UserID:   c.UserID,
+		Username: c.Username,
+		ClosedAt: c.CreatedAt,
+		Reason:   c.Reason,
+		Result: settlement.Result{
+			Balance:    c.Balance,
+			Refundable: c.Refundable,
+			Subsidy:    c.Subsidy,
+			Expired:    c.ExpiredSubsidy,
+		},
+	}
+}
+
+// 批量销户中每个用户的处理结果
+const (
+	ClosureClosed        = "closed"         // 已销户
+	ClosureAlreadyClosed = "already_closed" // 之前已销户，跳过
+	ClosurePreview       = "preview"        // 试运行，只计算结算金额
+	ClosureFailed        = "failed"
+)
+
+// AccountClosureRow 批量销户中一个用户的结果
+type AccountClosureRow struct {
+	Row        int                   `json:"row"` // CSV 行号（表头为第 1 行）
+	UserID     string                `json:"user_id"`
+	Status     string                `json:"status"`
+	Settlement *settlement.Result    `json:"settlement,omitempty"`
+	Closure    *model.AccountClosure `json:"closure,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// AccountClosureBatch 批量销户结果，金额为本次销户（或试运行）的合计
+type AccountClosureBatch struct {
+	DryRun     bool                `json:"dry_run"`
+	Total      int                 `json:"total"`
+	Closed     int                 `json:"closed"`
+	Skipped    int                 `json:"skipped"`
+	Failed     int                 `json:"failed"`
+	Refundable float64             `json:"refundable"`
+	Subsidy    float64             `json:"subsidy"` // 收回的补贴，含已过期的补贴
+	Rows       []AccountClosureRow `json:"rows"`
+}
+
+// CloseAccounts 按毕业名单批量销户。名单为带表头的 CSV，必须有 user_id 列，可以有 reason 列，
+// 没有 reason 时使用 defaultReason。每个用户单独销户，一个用户失败不影响其他用户；
+// 已销户的用户跳过，可以重复执行。dryRun 时只计算结算金额，不冻结钱包、不写入。
+// 销户记录的操作人为 system，只供 cmd/closeaccounts 使用，不对外提供接口
+func (l *RestaurantLogic) CloseAccounts(ctx context.Context, r io.Reader, defaultReason string, dryRun bool) (*AccountClosureBatch, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV 内容为空")
+		}
+		return nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["user_id"]; !ok {
+		return nil, errors.New("CSV 缺少 user_id 列")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	batch := &AccountClosureBatch{DryRun: dryRun, Rows: make([]AccountClosureRow, 0)}
+	var refundable, subsidy int64
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		batch.Total++
+		row := AccountClosureRow{Row: line}
+		if err == nil {
+			row.UserID = field(record, "user_id")
+			if row.UserID == "" {
+				err = errors.New("user_id 不能为空")
+			}
+		}
+		if err != nil {
+			row.Status, row.Error = ClosureFailed, err.Error()
+			batch.Failed++
+			batch.Rows = append(batch.Rows, row)
+			continue
+		}
+
+		reason := field(record, "reason")
+		if reason == "" {
+			reason = defaultReason
+		}
+
+		var settled settlement.Result
+		if dryRun {
+			settled, err = l.previewClosure(ctx, row.UserID)
+			if err == nil {
+				row.Settlement = &settled
+			}
+		} else {
+			row.Closure, err = l.closeAccount(ctx, row.UserID, "system", "", reason)
+			if row.Closure != nil {
+				settled = settlement.Result{
+					Balance:    row.Closure.Balance,
+					Refundable: row.Closure.Refundable,
+					Subsidy:    row.Closure.Subsidy,
+					Expired:    row.Closure.ExpiredSubsidy,
+				}
+			}
+		}
+
+		switch {
+		case errors.Is(err, ErrAccountClosed):
+			row.Status = ClosureAlreadyClosed
+			batch.Skipped++
+		case err != nil:
+			row.Status, row.Error = ClosureFailed, err.Error()
+			batch.Failed++
+		default:
+			row.Status = ClosureClosed
+			if dryRun {
+				row.Status = ClosurePreview
+			}
+			batch.Closed++
+			refundable += cents(settled.Refundable)
+			subsidy += cents(settled.Subsidy) + cents(settled.Expired)
+		}
+		batch.Rows = append(batch.Rows, row)
+	}
+
+	batch.Refundable = float64(refundable) / 100
+	batch.Subsidy = float64(subsidy) / 100
+	return batch, nil
+}
+
+// previewClosure 计算现在销户的结算金额，不修改数据
+func (l *RestaurantLogic) previewClosure(ctx context.Context, userID string) (settlement.Result, error) {
+	var user model.User
+	if err := l.db.WithContext(ctx).Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+		return settlement.Result{}, fmt.Errorf("用户不存在: %w", err)
+	}
+	if _, err := l.GetAccountClosure(ctx, userID); err == nil {
+		return settlement.Result{}, ErrAccountClosed
+	}
+	var wallet model.Wallet
+	if err := l.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Limit(1).Find(&wallet).Error; err != nil {
+		return settlement.Result{}, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	return l.settleWallet(ctx, &wallet, time.Now())
+}
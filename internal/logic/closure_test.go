@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+func TestCloseAccountOperator(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.Worker{ID: systemWorkerID, Name: "系统", Role: "staff"},
+		&model.User{ID: "u1", Username: "u1"}, &model.Wallet{UserID: "u1"},
+		&model.User{ID: "u2", Username: "u2"}, &model.Wallet{UserID: "u2"},
+	)
+
+	// 接口调用必须指定工作人员，不能冒用系统身份
+	for _, workerID := range []string{"", systemWorkerID, "nobody"} {
+		if _, err := l.CloseAccount(ctx, "u1", workerID, "毕业"); err == nil {
+			t.Errorf("worker_id %q 应被拒绝", workerID)
+		}
+	}
+
+	closure, err := l.CloseAccount(ctx, "u1", "m1", "毕业")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closure.OperatorType != "worker" || closure.OperatorID != "m1" {
+		t.Errorf("操作人 = %s/%s，应为 worker/m1", closure.OperatorType, closure.OperatorID)
+	}
+
+	// 批量销户以 system 身份操作
+	batch, err := l.CloseAccounts(ctx, strings.NewReader("user_id\nu2\n"), "毕业", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Failed != 0 || len(batch.Rows) != 1 || batch.Rows[0].Closure == nil {
+		t.Fatalf("批量销户结果 = %+v", batch)
+	}
+	if got := batch.Rows[0].Closure; got.OperatorType != "system" || got.OperatorID != "" {
+		t.Errorf("操作人 = %s/%s，应为 system", got.OperatorType, got.OperatorID)
+	}
+}
+
+func TestCloseAccountQueuesRefundNotification(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.User{ID: "u1", Username: "u1"}, &model.User{ID: "u2", Username: "u2"},
+	)
+	if _, err := l.ChargeWallet(ctx, "u1", 30, ChargeSourcePayment, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ChargeWallet(ctx, "u1", 5, ChargeSourceSubsidy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ChargeWallet(ctx, "u2", 5, ChargeSourceSubsidy, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := l.CloseAccount(ctx, userID, "m1", "毕业"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只有退还了自有资金的销户才通知，补贴收回不通知
+	var events []model.NotificationEvent
+	db.Where("kind = ?", NotifyRefundIssued).Find(&events)
+	if len(events) != 1 || events[0].UserID != "u1" || events[0].Amount != 30 {
+		t.Fatalf("退款通知事件 = %+v", events)
+	}
+	// 用户已删除，仍是通知的接收人
+	if user, err := l.NotificationRecipient(ctx, "u1"); err != nil || user.Username != "u1" {
+		t.Fatalf("接收人 = %+v, %v", user, err)
+	}
+}
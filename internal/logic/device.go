@@ -0,0 +1,308 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// systemWorkerID 系统自动生成的异常记录使用的工作人员ID
+const systemWorkerID = "system"
+
+// ErrNonceUsed 设备请求的 nonce 在有效期内已经用过，通常是重放的请求
+var ErrNonceUsed = errors.New("nonce 已使用")
+
+// RegisterDevice 登记终端设备并生成设备密钥
+// 返回的设备中包含明文密钥，只在登记时返回这一次
+func (l *RestaurantLogic) RegisterDevice(ctx context.Context, deviceID, deviceType, stationID, firmware string) (*model.Device, error) {
+	if deviceID == "" {
+		return nil, errors.New("设备ID不能为空")
+	}
+	if deviceType != "scale" && deviceType != "reader" && deviceType != "dispenser" {
+		return nil, errors.New("设备类型必须是 scale、reader 或 dispenser")
+	}
+
+	canteenID := tenant.CanteenID(ctx)
+	if stationID != "" {
+		var station model.Station
+		if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", stationID).First(&station).Error; err != nil {
+			return nil, fmt.Errorf("档口不存在: %w", err)
+		}
+		canteenID = station.CanteenID
+	}
+
+	secret, err := newDeviceSecret()
+	if err != nil {
+		return nil, err
+	}
+	device := model.Device{
+		ID:        deviceID,
+		CanteenID: canteenID,
+		StationID: stationID,
+		Type:      deviceType,
+		Firmware:  firmware,
+		Secret:    secret,
+		Status:    "offline",
+	}
+	if err := l.db.WithContext(ctx).Create(&device).Error; err != nil {
+		return nil, fmt.Errorf("登记设备失败: %w", err)
+	}
+	return &device, nil
+}
+
+// GetDeviceList 获取设备列表，status 为空时返回全部
+func (l *RestaurantLogic) GetDeviceList(ctx context.Context, status string) ([]model.Device, error) {
+	var devices []model.Device
+	query := l.db.WithContext(ctx).Scopes(inCanteen(ctx))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("id ASC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("查询设备列表失败: %w", err)
+	}
+	return devices, nil
+}
+
+// RotateDeviceSecret 轮换设备密钥，旧密钥立即失效
+func (l *RestaurantLogic) RotateDeviceSecret(ctx context.Context, deviceID string) (*model.Device, error) {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newDeviceSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.db.WithContext(ctx).Model(device).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("轮换设备密钥失败: %w", err)
+	}
+	device.Secret = secret
+	return device, nil
+}
+
+// RetireDevice 停用设备：清空密钥、解除档口分配，并关闭该设备未处理的异常
+func (l *RestaurantLogic) RetireDevice(ctx context.Context, deviceID string) error {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	var resolved int64
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(device).Updates(map[string]interface{}{
+			"status":     "retired",
+			"secret":     "",
+			"station_id": "",
+		}).Error; err != nil {
+			return fmt.Errorf("停用设备失败: %w", err)
+		}
+		resolved, err = resolveDeviceExceptions(tx, device.ID, "设备已停用")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	l.publishResolved(device, resolved)
+	return nil
+}
+
+// DeviceHeartbeat 设备心跳，离线的设备恢复在线时自动关闭离线异常
+func (l *RestaurantLogic) DeviceHeartbeat(ctx context.Context, deviceID, firmware string) (*model.Device, error) {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       "online",
+		"last_seen_at": now,
+	}
+	if firmware != "" {
+		updates["firmware"] = firmware
+	}
+
+	wasOffline := device.Status == "offline"
+	var resolved int64
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(device).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新设备心跳失败: %w", err)
+		}
+		if !wasOffline {
+			return nil
+		}
+		resolved, err = resolveDeviceExceptions(tx, device.ID, "设备恢复在线")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	device.Status, device.LastSeenAt = "online", &now
+	if firmware != "" {
+		device.Firmware = firmware
+	}
+	l.publishResolved(device, resolved)
+	return device, nil
+}
+
+// MarkOfflineDevices 把 cutoff 之后没有心跳的在线设备标记为离线，并为每台设备生成一条异常记录
+// 返回本次标记离线的设备数
+func (l *RestaurantLogic) MarkOfflineDevices(ctx context.Context, cutoff time.Time) (int, error) {
+	var devices []model.Device
+	if err := l.db.WithContext(ctx).Where("status = ? AND (last_seen_at IS NULL OR last_seen_at < ?)", "online", cutoff).
+		Find(&devices).Error; err != nil {
+		return 0, fmt.Errorf("查询在线设备失败: %w", err)
+	}
+
+	var events []event.Event
+	for _, device := range devices {
+		marked := false
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 带上状态条件，避免覆盖查询之后刚到达的心跳
+			result := tx.Model(&model.Device{}).
+				Where("id = ? AND status = ? AND (last_seen_at IS NULL OR last_seen_at < ?)", device.ID, "online", cutoff).
+				Update("status", "offline")
+			if result.Error != nil {
+				return fmt.Errorf("标记设备离线失败: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+
+			if err := ensureSystemWorker(tx); err != nil {
+				return err
+			}
+
+			lastSeen := "从未上报"
+			if device.LastSeenAt != nil {
+				lastSeen = device.LastSeenAt.Format("2006-01-02 15:04:05")
+			}
+			if err := tx.Create(&model.ExceptionLog{
+				WorkerID:  systemWorkerID,
+				DeviceID:  device.ID,
+				Exception: fmt.Sprintf("设备离线: %s（%s），最后心跳 %s", device.ID, device.Type, lastSeen),
+				Action:    "请检查设备电源和网络",
+				Status:    "pending",
+			}).Error; err != nil {
+				return fmt.Errorf("记录设备异常失败: %w", err)
+			}
+			marked = true
+			return nil
+		})
+		if err != nil {
+			return len(events), err
+		}
+		if marked {
+			events = append(events, event.Event{Type: event.ExceptionOpened, CanteenID: device.CanteenID})
+		}
+	}
+
+	l.bus.Publish(events...)
+	return len(events), nil
+}
+
+// DeviceSecret 查询设备密钥和所属食堂，用于校验设备请求签名
+func (l *RestaurantLogic) DeviceSecret(ctx context.Context, deviceID string) (string, string, error) {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return "", "", err
+	}
+	return device.Secret, device.CanteenID, nil
+}
+
+// UseDeviceNonce 记录设备请求用过的 nonce，在 expiresAt 之前再次使用时返回 ErrNonceUsed。
+// 以设备ID和 nonce 为主键写入数据库，并发的重复请求只有一个能写入
+func (l *RestaurantLogic) UseDeviceNonce(ctx context.Context, deviceID, nonce string, expiresAt time.Time) error {
+	record := model.DeviceNonce{DeviceID: deviceID, Nonce: nonce, ExpiresAt: expiresAt}
+	result := l.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return fmt.Errorf("记录 nonce 失败: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	// 已过期但还没有清理的记录可以重新使用
+	result = l.db.WithContext(ctx).Model(&model.DeviceNonce{}).
+		Where("device_id = ? AND nonce = ? AND expires_at < ?", deviceID, nonce, time.Now()).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return fmt.Errorf("记录 nonce 失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNonceUsed
+	}
+	return nil
+}
+
+// PruneDeviceNonces 删除 now 之前过期的 nonce 记录，返回删除的数量
+func (l *RestaurantLogic) PruneDeviceNonces(ctx context.Context, now time.Time) (int64, error) {
+	result := l.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&model.DeviceNonce{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理过期 nonce 失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// activeDevice 查询未停用的设备
+func (l *RestaurantLogic) activeDevice(ctx context.Context, deviceID string) (*model.Device, error) {
+	var device model.Device
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", deviceID).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("设备不存在: %w", err)
+	}
+	if device.Status == "retired" {
+		return nil, fmt.Errorf("设备已停用: %s", deviceID)
+	}
+	return &device, nil
+}
+
+// publishResolved 发布设备异常关闭事件
+func (l *RestaurantLogic) publishResolved(device *model.Device, count int64) {
+	for i := int64(0); i < count; i++ {
+		l.bus.Publish(event.Event{Type: event.ExceptionResolved, CanteenID: device.CanteenID})
+	}
+}
+
+// resolveDeviceExceptions 关闭设备未处理的异常记录，返回关闭的条数
+func resolveDeviceExceptions(tx *gorm.DB, deviceID, action string) (int64, error) {
+	result := tx.Model(&model.ExceptionLog{}).
+		Where("device_id = ? AND status = ?", deviceID, "pending").
+		Updates(map[string]interface{}{"status": "resolved", "action": action})
+	if result.Error != nil {
+		return 0, fmt.Errorf("关闭设备异常失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ensureSystemWorker 确保系统工作人员存在（异常记录的 worker_id 有外键约束），不计入在岗人数
+func ensureSystemWorker(tx *gorm.DB) error {
+	worker := model.Worker{ID: systemWorkerID}
+	if err := tx.Where(&worker).Attrs(model.Worker{Name: "系统", Role: "system"}).FirstOrCreate(&worker).Error; err != nil {
+		return fmt.Errorf("创建系统工作人员失败: %w", err)
+	}
+	if worker.IsActive {
+		return tx.Model(&worker).Update("is_active", false).Error
+	}
+	return nil
+}
+
+// newDeviceSecret 生成随机设备密钥
+func newDeviceSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成设备密钥失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,62 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+func TestUseDeviceNonce(t *testing.T) {
+	l, db := newTestLogic(t)
+	// 另一个副本：共享同一个数据库
+	other := NewRestaurantLogic(db)
+	ctx := context.Background()
+	expires := time.Now().Add(10 * time.Minute)
+
+	if err := l.UseDeviceNonce(ctx, "scale-1", "n1", expires); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.UseDeviceNonce(ctx, "scale-1", "n1", expires); !errors.Is(err, ErrNonceUsed) {
+		t.Fatalf("另一个副本重放 = %v, want ErrNonceUsed", err)
+	}
+	// nonce 按设备区分
+	if err := other.UseDeviceNonce(ctx, "scale-2", "n1", expires); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发的重复请求只有一个成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.UseDeviceNonce(ctx, "scale-1", "n2", expires); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrNonceUsed) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Fatalf("并发重复请求成功 %d 次，应为 1", accepted)
+	}
+
+	// 过期的记录可以重新使用，并会被清理
+	mustCreate(t, db, &model.DeviceNonce{DeviceID: "scale-1", Nonce: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+	if err := l.UseDeviceNonce(ctx, "scale-1", "old", expires); err != nil {
+		t.Fatalf("过期的 nonce = %v", err)
+	}
+	mustCreate(t, db, &model.DeviceNonce{DeviceID: "scale-1", Nonce: "stale", ExpiresAt: time.Now().Add(-time.Minute)})
+	if n, err := l.PruneDeviceNonces(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("清理 = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+)
+
+// FoodImportResult 菜品导入结果，与餐盘导入的格式相同
+type FoodImportResult = PlateImportResult
+
+// foodImportRow CSV 中的一行
+type foodImportRow struct {
+	line int
+	food model.Food
+}
+
+// ImportFoods 从 CSV 批量导入菜品，菜品归属于调用方所在的食堂
+// CSV 必须带表头，支持的列：id, name, price, category, description, is_available，其中 name 和 price（每100克单价）必填。
+// 任意一行校验失败则整批不导入；dryRun 时只校验不写入。
+func (l *RestaurantLogic) ImportFoods(ctx context.Context, r io.Reader, dryRun bool) (*FoodImportResult, error) {
+	rows, result, err := parseFoodCSV(r, tenant.CanteenID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = dryRun
+
+	// 文件内和数据库中的 ID 不能重复
+	seen := make(map[string]int, len(rows))
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.food.ID)
+	}
+	var existing []string
+	if len(ids) > 0 {
+		if err := l.db.WithContext(ctx).Model(&model.Food{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+			return nil, fmt.Errorf("查询已有菜品失败: %w", err)
+		}
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+	for _, row := range rows {
+		switch {
+		case exists[row.food.ID]:
+			result.Errors = append(result.Errors, PlateImportError{Row: row.line, Field: "id", Message: fmt.Sprintf("菜品已存在: %s", row.food.ID)})
+		case seen[row.food.ID] > 0:
+			result.Errors = append(result.Errors, PlateImportError{Row: row.line, Field: "id", Message: fmt.Sprintf("与第 %d 行重复", seen[row.food.ID])})
+		default:
+			seen[row.food.ID] = row.line
+		}
+	}
+	result.Valid = result.Total - countRows(result.Errors)
+
+	if dryRun || len(result.Errors) > 0 || len(rows) == 0 {
+		return result, nil
+	}
+
+	foods := make([]model.Food, 0, len(rows))
+	for _, row := range rows {
+		foods = append(foods, row.food)
+	}
+	// is_available=false 需要显式写入，否则会被当作零值使用默认值 true
+	if err := l.db.WithContext(ctx).Select("*").CreateInBatches(&foods, plateImportBatchSize).Error; err != nil {
+		return nil, fmt.Errorf("导入菜品失败: %w", err)
+	}
+	result.Imported = len(foods)
+	return result, nil
+}
+
+// parseFoodCSV 解析 CSV，格式错误的行直接记入结果
+func parseFoodCSV(r io.Reader, canteenID string) ([]foodImportRow, *FoodImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("CSV 内容为空")
+		}
+		return nil, nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("CSV 缺少 %s 列", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	result := &FoodImportResult{}
+	var rows []foodImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		result.Total++
+		if err != nil {
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Message: err.Error()})
+			continue
+		}
+
+		food := model.Food{
+			ID:          field(record, "id"),
+			CanteenID:   canteenID,
+			Name:        field(record, "name"),
+			Category:    field(record, "category"),
+			Description: field(record, "description"),
+			IsAvailable: true,
+		}
+		if food.ID == "" {
+			food.ID = uuid.New().String()
+		}
+		if food.Name == "" {
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Field: "name", Message: "菜品名称不能为空"})
+			continue
+		}
+		price, err := strconv.ParseFloat(field(record, "price"), 64)
+		if err != nil || price <= 0 {
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Field: "price", Message: "单价必须是大于0的数字"})
+			continue
+		}
+		food.Price = price
+		if v := field(record, "is_available"); v != "" {
+			available, err := strconv.ParseBool(v)
+			if err != nil {
+				result.Errors = append(result.Errors, PlateImportError{Row: line, Field: "is_available", Message: fmt.Sprintf("无效的布尔值: %s", v)})
+				continue
+			}
+			food.IsAvailable = available
+		}
+
+		rows = append(rows, foodImportRow{line: line, food: food})
+	}
+
+	return rows, result, nil
+}
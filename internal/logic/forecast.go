@@ -0,0 +1,229 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/forecast"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm/clause"
+)
+
+// 默认的预测配置
+const (
+	defaultHistoryDays = 56
+	defaultDayType     = "term"
+	maxForecastDays    = 14
+)
+
+// DemandForecast 某道菜某个餐次的预测备餐量
+type DemandForecast struct {
+	Date     string  `json:"date"`
+	Period   string  `json:"period"`
+	DayType  string  `json:"day_type"`
+	FoodID   string  `json:"food_id"`
+	FoodName string  `json:"food_name"`
+	Grams    float64 `json:"grams"`
+	Basis    string  `json:"basis"`   // weekday, day_type, period
+	Samples  int     `json:"samples"` // 所用分组的历史餐次数
+}
+
+// WithForecast 设置预测模型参数和使用的历史天数
+func (l *RestaurantLogic) WithForecast(cfg forecast.Config, historyDays int) *RestaurantLogic {
+	l.forecast = cfg
+	if historyDays > 0 {
+		l.historyDays = historyDays
+	}
+	return l
+}
+
+// SetCalendarDay 登记校历日期类型，覆盖已有登记
+func (l *RestaurantLogic) SetCalendarDay(ctx context.Context, date, dayType, remark string) (*model.CalendarDay, error) {
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return nil, fmt.Errorf("日期格式错误: %w", err)
+	}
+
+	day := model.CalendarDay{Date: date, Type: dayType, Remark: remark}
+	if err := l.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "remark", "updated_at"}),
+	}).Create(&day).Error; err != nil {
+		return nil, fmt.Errorf("登记校历失败: %w", err)
+	}
+	return &day, nil
+}
+
+// GetCalendar 获取 [from, to) 内登记过的校历日期
+func (l *RestaurantLogic) GetCalendar(ctx context.Context, from, to time.Time) ([]model.CalendarDay, error) {
+	var days []model.CalendarDay
+	if err := l.db.WithContext(ctx).
+		Where("date >= ? AND date < ?", from.Format(time.DateOnly), to.Format(time.DateOnly)).
+		Order("date ASC").Find(&days).Error; err != nil {
+		return nil, fmt.Errorf("查询校历失败: %w", err)
+	}
+	return days, nil
+}
+
+// GetDemandForecast 预测从 from 开始 days 天内每道菜每个餐次的备餐量（克）
+// 使用 from 之前 historyDays 天的订单训练，只预测这段时间内卖出过的菜品和餐次。
+func (l *RestaurantLogic) GetDemandForecast(ctx context.Context, from time.Time, days int) ([]DemandForecast, error) {
+	if days <= 0 || days > maxForecastDays {
+		return nil, fmt.Errorf("预测天数必须在 1-%d 之间", maxForecastDays)
+	}
+	to := from.AddDate(0, 0, days)
+
+	history, err := l.demandHistory(ctx, from.AddDate(0, 0, -l.historyDays), from)
+	if err != nil {
+		return nil, err
+	}
+	dayTypes, err := l.dayTypes(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	fitted := forecast.Fit(l.forecast, history)
+
+	// 历史中出现过的菜品和餐次
+	type target struct{ foodID, period string }
+	seen := make(map[target]bool)
+	var targets []target
+	for _, o := range history {
+		t := target{o.FoodID, o.Period}
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].period != targets[j].period {
+			return forecast.PeriodOrder(targets[i].period) < forecast.PeriodOrder(targets[j].period)
+		}
+		return targets[i].foodID < targets[j].foodID
+	})
+
+	foodIDs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		foodIDs = append(foodIDs, t.foodID)
+	}
+	names, err := l.foodNames(ctx, foodIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []DemandForecast
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		dayType := dayTypeOf(dayTypes, date)
+		for _, t := range targets {
+			p := fitted.Predict(t.foodID, date, t.period, dayType)
+			forecasts = append(forecasts, DemandForecast{
+				Date:     date.Format(time.DateOnly),
+				Period:   t.period,
+				DayType:  dayType,
+				FoodID:   t.foodID,
+				FoodName: names[t.foodID],
+				Grams:    p.Grams,
+				Basis:    p.Basis,
+				Samples:  p.Samples,
+			})
+		}
+	}
+	return forecasts, nil
+}
+
+// BacktestForecast 在 [from, to) 的历史订单上回测预测准确度，from 之前 historyDays 天的订单只用于训练
+func (l *RestaurantLogic) BacktestForecast(ctx context.Context, from, to time.Time) (*forecast.BacktestResult, error) {
+	if !to.After(from) {
+		return nil, errors.New("回测结束时间必须晚于开始时间")
+	}
+	history, err := l.demandHistory(ctx, from.AddDate(0, 0, -l.historyDays), to)
+	if err != nil {
+		return nil, err
+	}
+	result := forecast.Backtest(l.forecast, history, from)
+	return &result, nil
+}
+
+// demandHistory 汇总 [from, to) 内已支付订单的用量，按菜品、日期、餐次各一个观测值
+func (l *RestaurantLogic) demandHistory(ctx context.Context, from, to time.Time) ([]forecast.Observation, error) {
+	var rows []struct {
+		FoodID    string
+		Weight    float64
+		CreatedAt time.Time
+	}
+	query := l.db.WithContext(ctx).Table("order_items").
+		Select("order_items.food_id AS food_id, order_items.weight AS weight, orders.created_at AS created_at").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status IN ? AND orders.created_at >= ? AND orders.created_at < ? AND orders.deleted_at IS NULL",
+			[]string{"paid", "completed"}, from, to)
+	if canteenID := tenant.CanteenID(ctx); canteenID != "" {
+		query = query.Where("orders.canteen_id = ?", canteenID)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询历史订单失败: %w", err)
+	}
+
+	dayTypes, err := l.dayTypes(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct{ foodID, date, period string }
+	totals := make(map[group]float64)
+	for _, row := range rows {
+		date, period := l.periods.Period(row.CreatedAt.Local())
+		totals[group{row.FoodID, date, period}] += row.Weight
+	}
+
+	observations := make([]forecast.Observation, 0, len(totals))
+	for g, grams := range totals {
+		date, _ := time.ParseInLocation(time.DateOnly, g.date, time.Local)
+		observations = append(observations, forecast.Observation{
+			FoodID:  g.foodID,
+			Date:    date,
+			Period:  g.period,
+			DayType: dayTypeOf(dayTypes, date),
+			Grams:   grams,
+		})
+	}
+	return observations, nil
+}
+
+// dayTypes 查询 [from, to) 内登记过的校历日期类型
+func (l *RestaurantLogic) dayTypes(ctx context.Context, from, to time.Time) (map[string]string, error) {
+	days, err := l.GetCalendar(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(days))
+	for _, day := range days {
+		types[day.Date] = day.Type
+	}
+	return types, nil
+}
+
+// dayTypeOf 没有登记的日期按学期中处理
+func dayTypeOf(types map[string]string, date time.Time) string {
+	if t, ok := types[date.Format(time.DateOnly)]; ok {
+		return t
+	}
+	return defaultDayType
+}
+
+// foodNames 菜品名称
+func (l *RestaurantLogic) foodNames(ctx context.Context, foodIDs []string) (map[string]string, error) {
+	names := make(map[string]string, len(foodIDs))
+	if len(foodIDs) == 0 {
+		return names, nil
+	}
+	var foods []model.Food
+	if err := l.db.WithContext(ctx).Where("id IN ?", foodIDs).Find(&foods).Error; err != nil {
+		return nil, fmt.Errorf("查询菜品失败: %w", err)
+	}
+	for _, food := range foods {
+		names[food.ID] = food.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,311 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// 默认的库存配置
+const (
+	defaultLowStock    = 1000.0
+	defaultLunchStart  = 10*time.Hour + 30*time.Minute
+	defaultDinnerStart = 16 * time.Hour
+)
+
+// MealPeriods 餐次划分，LunchStart 之前为早餐，DinnerStart 之后为晚餐
+type MealPeriods struct {
+	LunchStart  time.Duration // 距零点的时长
+	DinnerStart time.Duration
+}
+
+// ParseMealPeriods 解析 15:04 格式的午餐、晚餐开始时间
+func ParseMealPeriods(lunchStart, dinnerStart string) (MealPeriods, error) {
+	lunch, err := time.Parse("15:04", lunchStart)
+	if err != nil {
+		return MealPeriods{}, fmt.Errorf("午餐开始时间格式错误: %w", err)
+	}
+	dinner, err := time.Parse("15:04", dinnerStart)
+	if err != nil {
+		return MealPeriods{}, fmt.Errorf("晚餐开始时间格式错误: %w", err)
+	}
+	periods := MealPeriods{
+		LunchStart:  time.Duration(lunch.Hour())*time.Hour + time.Duration(lunch.Minute())*time.Minute,
+		DinnerStart: time.Duration(dinner.Hour())*time.Hour + time.Duration(dinner.Minute())*time.Minute,
+	}
+	if periods.DinnerStart <= periods.LunchStart {
+		return MealPeriods{}, errors.New("晚餐开始时间必须晚于午餐开始时间")
+	}
+	return periods, nil
+}
+
+// Period 返回时间所在的日期和餐次
+func (p MealPeriods) Period(t time.Time) (string, string) {
+	sinceMidnight := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+	switch {
+	case sinceMidnight < p.LunchStart:
+		return t.Format(time.DateOnly), "breakfast"
+	case sinceMidnight < p.DinnerStart:
+		return t.Format(time.DateOnly), "lunch"
+	default:
+		return t.Format(time.DateOnly), "dinner"
+	}
+}
+
+// WithInventory 设置低库存提醒阈值（克）和餐次划分
+func (l *RestaurantLogic) WithInventory(lowStock float64, periods MealPeriods) *RestaurantLogic {
+	l.lowStock = lowStock
+	l.periods = periods
+	return l
+}
+
+// AddFoodBatch 厨房出一批菜，计入当前餐次的库存；售罄的菜品重新上架
+func (l *RestaurantLogic) AddFoodBatch(ctx context.Context, foodID, workerID string, weight float64, remark string) (*model.FoodStock, error) {
+	if weight <= 0 {
+		return nil, errors.New("备餐量必须大于0")
+	}
+	food, err := l.kitchenFood(ctx, foodID, workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stock *model.FoodStock
+	var events []event.Event
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stock, events, err = l.addBatch(tx, food, weight, workerID, remark)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.bus.Publish(events...)
+	return stock, nil
+}
+
+// addBatch 把一批菜计入当前餐次的库存
+func (l *RestaurantLogic) addBatch(tx *gorm.DB, food *model.Food, weight float64, workerID, remark string) (*model.FoodStock, []event.Event, error) {
+	var stock model.FoodStock
+	date, period := l.periods.Period(time.Now())
+	if err := tx.Where(model.FoodStock{FoodID: food.ID, Date: date, Period: period}).
+		Attrs(model.FoodStock{CanteenID: food.CanteenID}).FirstOrCreate(&stock).Error; err != nil {
+		return nil, nil, fmt.Errorf("创建库存记录失败: %w", err)
+	}
+	if err := tx.Model(&stock).Update("prepared", gorm.Expr("prepared + ?", weight)).Error; err != nil {
+		return nil, nil, fmt.Errorf("更新备餐量失败: %w", err)
+	}
+	events, err := l.adjustStock(tx, food, &stock, model.StockMovement{
+		Type:     "batch",
+		Weight:   weight,
+		WorkerID: workerID,
+		Remark:   remark,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &stock, events, nil
+}
+
+// DiscardFood 记录当前餐次报废的菜品
+func (l *RestaurantLogic) DiscardFood(ctx context.Context, foodID, workerID string, weight float64, remark string) (*model.FoodStock, error) {
+	if weight <= 0 {
+		return nil, errors.New("报废量必须大于0")
+	}
+	food, err := l.kitchenFood(ctx, foodID, workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stock model.FoodStock
+	var events []event.Event
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		date, period := l.periods.Period(time.Now())
+		if err := tx.Where("food_id = ? AND date = ? AND period = ?", food.ID, date, period).First(&stock).Error; err != nil {
+			return fmt.Errorf("本餐次没有该菜品的库存: %w", err)
+		}
+		if weight > stock.Remaining {
+			return fmt.Errorf("报废量超过剩余量，剩余: %.0f 克", stock.Remaining)
+		}
+		events, err = l.adjustStock(tx, food, &stock, model.StockMovement{
+			Type:     "discard",
+			Weight:   -weight,
+			WorkerID: workerID,
+			Remark:   remark,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.bus.Publish(events...)
+	return &stock, nil
+}
+
+// GetFoodStock 获取某一餐次的菜品库存，date、period 为空时取当前餐次
+func (l *RestaurantLogic) GetFoodStock(ctx context.Context, date, period string) ([]model.FoodStock, error) {
+	nowDate, nowPeriod := l.periods.Period(time.Now())
+	if date == "" {
+		date = nowDate
+	}
+	if period == "" {
+		period = nowPeriod
+	}
+
+	var stocks []model.FoodStock
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("Food").
+		Where("date = ? AND period = ?", date, period).Order("food_id ASC").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("查询库存失败: %w", err)
+	}
+	return stocks, nil
+}
+
+// consumeStock 按订单明细扣减当前餐次的库存，没有登记库存的菜品不扣减
+// 称重已经发生，库存不足时只扣到 0，不拒绝订单
+func (l *RestaurantLogic) consumeStock(tx *gorm.DB, order *model.Order, items []model.OrderItem) ([]event.Event, error) {
+	date, period := l.periods.Period(time.Now())
+
+	var events []event.Event
+	for _, item := range items {
+		var stock model.FoodStock
+		err := tx.Where("food_id = ? AND date = ? AND period = ?", item.FoodID, date, period).First(&stock).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询库存失败: %w", err)
+		}
+
+		var food model.Food
+		if err := tx.Where("id = ?", item.FoodID).First(&food).Error; err != nil {
+			return nil, fmt.Errorf("食物不存在: %s, %w", item.FoodID, err)
+		}
+		adjusted, err := l.adjustStock(tx, &food, &stock, model.StockMovement{
+			Type:    "sale",
+			Weight:  -item.Weight,
+			OrderID: order.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, adjusted...)
+	}
+	return events, nil
+}
+
+// restoreStock 订单取消时把扣减的库存加回原餐次
+func (l *RestaurantLogic) restoreStock(tx *gorm.DB, order *model.Order) ([]event.Event, error) {
+	var sales []model.StockMovement
+	if err := tx.Where("order_id = ? AND type = ?", order.ID, "sale").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("查询库存流水失败: %w", err)
+	}
+
+	var events []event.Event
+	for _, sale := range sales {
+		var stock model.FoodStock
+		if err := tx.Where("id = ?", sale.StockID).First(&stock).Error; err != nil {
+			return nil, fmt.Errorf("查询库存失败: %w", err)
+		}
+		var food model.Food
+		if err := tx.Where("id = ?", sale.FoodID).First(&food).Error; err != nil {
+			return nil, fmt.Errorf("食物不存在: %s, %w", sale.FoodID, err)
+		}
+		adjusted, err := l.adjustStock(tx, &food, &stock, model.StockMovement{
+			Type:    "restore",
+			Weight:  -sale.Weight,
+			OrderID: order.ID,
+			Remark:  "订单取消",
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, adjusted...)
+	}
+	return events, nil
+}
+
+// adjustStock 调整剩余量并记录流水，然后处理售罄、低库存和重新上架
+func (l *RestaurantLogic) adjustStock(tx *gorm.DB, food *model.Food, stock *model.FoodStock, movement model.StockMovement) ([]event.Event, error) {
+	// 在数据库中计算，避免并发扣减互相覆盖；更新后这一行由本事务锁定，读到的是本事务写入的值
+	if err := tx.Model(stock).Update("remaining", gorm.Expr("remaining + ?", movement.Weight)).Error; err != nil {
+		return nil, fmt.Errorf("更新库存失败: %w", err)
+	}
+	if err := tx.Where("id = ?", stock.ID).First(stock).Error; err != nil {
+		return nil, fmt.Errorf("查询库存失败: %w", err)
+	}
+	// 剩余量不低于 0，流水只记实际扣减的量，订单取消时按流水加回不会多加
+	if stock.Remaining < 0 {
+		movement.Weight = roundCents(movement.Weight - stock.Remaining)
+		if err := tx.Model(stock).Update("remaining", 0).Error; err != nil {
+			return nil, fmt.Errorf("更新库存失败: %w", err)
+		}
+		stock.Remaining = 0
+	}
+	movement.StockID = stock.ID
+	movement.FoodID = food.ID
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, fmt.Errorf("记录库存流水失败: %w", err)
+	}
+
+	newEvent := func(eventType string) event.Event {
+		return event.Event{
+			Type:      eventType,
+			CanteenID: food.CanteenID,
+			FoodID:    food.ID,
+			FoodName:  food.Name,
+			Weight:    stock.Remaining,
+		}
+	}
+
+	var events []event.Event
+	switch {
+	case stock.Remaining <= 0:
+		if food.IsAvailable {
+			if err := tx.Model(food).Update("is_available", false).Error; err != nil {
+				return nil, fmt.Errorf("更新菜品状态失败: %w", err)
+			}
+			events = append(events, newEvent(event.FoodSoldOut))
+		}
+	case !food.IsAvailable && movement.Weight > 0:
+		if err := tx.Model(food).Update("is_available", true).Error; err != nil {
+			return nil, fmt.Errorf("更新菜品状态失败: %w", err)
+		}
+		events = append(events, newEvent(event.FoodRestocked))
+	}
+
+	// 低库存提醒每次跌破阈值只发一次，补货回到阈值以上后重新计算
+	low := stock.Remaining > 0 && stock.Remaining < l.lowStock
+	switch {
+	case low && !stock.LowAlerted:
+		if err := tx.Model(stock).Update("low_alerted", true).Error; err != nil {
+			return nil, fmt.Errorf("更新库存失败: %w", err)
+		}
+		events = append(events, newEvent(event.FoodLowStock))
+	case stock.Remaining >= l.lowStock && stock.LowAlerted:
+		if err := tx.Model(stock).Update("low_alerted", false).Error; err != nil {
+			return nil, fmt.Errorf("更新库存失败: %w", err)
+		}
+	}
+	return events, nil
+}
+
+// kitchenFood 检查工作人员和菜品属于同一食堂
+func (l *RestaurantLogic) kitchenFood(ctx context.Context, foodID, workerID string) (*model.Food, error) {
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	var food model.Food
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", foodID).First(&food).Error; err != nil {
+		return nil, fmt.Errorf("食物不存在: %s, %w", foodID, err)
+	}
+	if food.Category == soupCategory {
+		return nil, fmt.Errorf("汤品的库存随汤锅加汤、清空变化: %s", food.Name)
+	}
+	return &food, nil
+}
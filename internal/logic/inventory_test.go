@@ -0,0 +1,257 @@
+package logic
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// newStockFixture 员工 w1、菜品 f1（每100克10元），用户 u1 余额 1000 并绑定餐盘 p1，返回订阅了库存事件的业务逻辑
+func newStockFixture(t *testing.T) (*RestaurantLogic, *gorm.DB, *event.Subscription) {
+	t.Helper()
+	l, db := newTestLogic(t)
+	bus := event.NewBus()
+	sub := bus.Subscribe()
+	t.Cleanup(sub.Cancel)
+	l.WithBus(bus)
+	now := time.Now()
+	mustCreate(t, db,
+		&model.Worker{ID: "w1", Name: "员工", Role: "staff"},
+		&model.Food{ID: "f1", Name: "米饭", Price: 10, IsAvailable: true},
+		&model.User{ID: "u1", Username: "u1"}, &model.Wallet{UserID: "u1", Balance: 1000},
+		&model.Plate{ID: "p1", QRCode: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+	)
+	return l, db, sub
+}
+
+// stockEvents 取出已经发布的库存事件类型
+func stockEvents(sub *event.Subscription) []string {
+	var types []string
+	for {
+		select {
+		case e := <-sub.Events():
+			if e.FoodID != "" {
+				types = append(types, e.Type)
+			}
+		default:
+			return types
+		}
+	}
+}
+
+// currentStock 当前餐次 f1 的库存和上架状态
+func currentStock(t *testing.T, db *gorm.DB) (model.FoodStock, bool) {
+	t.Helper()
+	var stock model.FoodStock
+	if err := db.Where("food_id = ?", "f1").First(&stock).Error; err != nil {
+		t.Fatal(err)
+	}
+	var food model.Food
+	if err := db.Where("id = ?", "f1").First(&food).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stock, food.IsAvailable
+}
+
+func TestStockSellOutAndRestock(t *testing.T) {
+	l, db, sub := newStockFixture(t)
+	ctx := context.Background()
+	order := func(weight float64) error {
+		_, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: weight}})
+		return err
+	}
+
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 300, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := order(200); err != nil {
+		t.Fatal(err)
+	}
+	// 称重已经发生，超出剩余量时只扣到 0 并下架
+	if err := order(150); err != nil {
+		t.Fatal(err)
+	}
+	stock, available := currentStock(t, db)
+	if stock.Remaining != 0 || available {
+		t.Fatalf("剩余 %.0f 克，上架 %v，应当售罄", stock.Remaining, available)
+	}
+	if err := order(100); err == nil {
+		t.Fatal("售罄的菜品不能下单")
+	}
+	want := []string{event.FoodLowStock, event.FoodSoldOut}
+	if got := stockEvents(sub); !slices.Equal(got, want) {
+		t.Fatalf("库存事件 = %v，应为 %v", got, want)
+	}
+
+	// 补货后重新上架，回到阈值以上时重置低库存提醒
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 2000, ""); err != nil {
+		t.Fatal(err)
+	}
+	stock, available = currentStock(t, db)
+	if stock.Remaining != 2000 || stock.Prepared != 2300 || !available || stock.LowAlerted {
+		t.Fatalf("补货后库存 = %+v，上架 %v", stock, available)
+	}
+	if got := stockEvents(sub); !slices.Equal(got, []string{event.FoodRestocked}) {
+		t.Fatalf("补货事件 = %v", got)
+	}
+	if err := order(100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHeldOrderStock(t *testing.T) {
+	l, db, sub := newStockFixture(t)
+	ctx := context.Background()
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 500, ""); err != nil {
+		t.Fatal(err)
+	}
+	var plate model.Plate
+	if err := db.Where("id = ?", "p1").First(&plate).Error; err != nil {
+		t.Fatal(err)
+	}
+	hold := func(weight float64) *model.Order {
+		items, total, err := l.buildOrderItems(ctx, &plate, []OrderFood{{FoodID: "f1", Weight: weight}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		order, err := l.placeOrder(ctx, &plate, items, total, "device", "d1", true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return order
+	}
+
+	// 挂起的订单先扣库存，超出剩余量时只扣到 0 并下架
+	held := hold(600)
+	if stock, available := currentStock(t, db); stock.Remaining != 0 || available {
+		t.Fatalf("挂起后剩余 %.0f 克，上架 %v", stock.Remaining, available)
+	}
+	stockEvents(sub)
+
+	var sale model.StockMovement
+	if err := db.Where("order_id = ? AND type = ?", held.ID, "sale").First(&sale).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sale.Weight != -500 {
+		t.Fatalf("sale 流水 %.0f 克，应为实际扣减的 -500", sale.Weight)
+	}
+
+	// 驳回后按实际扣减的量加回原餐次，重新上架，不扣款
+	if _, err := l.ReviewHeldOrder(ctx, held.ID, "w1", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if stock, available := currentStock(t, db); stock.Remaining != 500 || !available {
+		t.Fatalf("驳回后剩余 %.0f 克，上架 %v", stock.Remaining, available)
+	}
+	if got := stockEvents(sub); !slices.Equal(got, []string{event.FoodRestocked}) {
+		t.Fatalf("驳回后的库存事件 = %v", got)
+	}
+	var restores int64
+	db.Model(&model.StockMovement{}).Where("order_id = ? AND type = ? AND weight = ?", held.ID, "restore", 500).Count(&restores)
+	if restores != 1 {
+		t.Fatalf("restore 流水 %d 条，应为 1", restores)
+	}
+	// 重复审核不会再加一次库存
+	if _, err := l.ReviewHeldOrder(ctx, held.ID, "w1", false, ""); err == nil {
+		t.Fatal("已审核的订单不能再审核")
+	}
+
+	// 审核通过时库存不变，正常扣款
+	approved := hold(100)
+	if _, err := l.ReviewHeldOrder(ctx, approved.ID, "w1", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if stock, _ := currentStock(t, db); stock.Remaining != 400 {
+		t.Fatalf("审核通过后剩余 %.0f 克，应为 400", stock.Remaining)
+	}
+	var wallet model.Wallet
+	db.Where("user_id = ?", "u1").First(&wallet)
+	if wallet.Balance != 990 {
+		t.Fatalf("余额 %.2f，应为 990", wallet.Balance)
+	}
+}
+
+func TestConcurrentStockDecrements(t *testing.T) {
+	l, db, sub := newStockFixture(t)
+	ctx := context.Background()
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 1000, ""); err != nil {
+		t.Fatal(err)
+	}
+	stockEvents(sub)
+
+	// 20 份各 50 克同时下单，刚好卖完
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: 50}})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stock, available := currentStock(t, db)
+	if stock.Remaining != 0 || available {
+		t.Fatalf("剩余 %.0f 克，上架 %v，应当售罄", stock.Remaining, available)
+	}
+	var sales int64
+	db.Model(&model.StockMovement{}).Where("type = ?", "sale").Count(&sales)
+	if sales != 20 {
+		t.Fatalf("sale 流水 %d 条，应为 20", sales)
+	}
+	// 低库存和售罄提醒各只发一次
+	if got, want := stockEvents(sub), []string{event.FoodLowStock, event.FoodSoldOut}; !slices.Equal(got, want) {
+		t.Fatalf("库存事件 = %v，应为 %v", got, want)
+	}
+}
+
+func TestIngestWeightAfterStockSoldOut(t *testing.T) {
+	l, db, _ := newStockFixture(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Station{ID: "s1", Name: "米饭档口", FoodID: "f1", IsActive: true},
+		&model.Device{ID: "d1", StationID: "s1", Type: "scale", Status: "online"},
+	)
+	if _, err := l.AddFoodBatch(ctx, "f1", "w1", 300, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次称重把库存扣完，菜品下架
+	if _, err := l.IngestWeight(ctx, "d1", "p1", 300); err != nil {
+		t.Fatal(err)
+	}
+	if stock, available := currentStock(t, db); stock.Remaining != 0 || available {
+		t.Fatalf("剩余 %.0f 克，上架 %v，应当售罄", stock.Remaining, available)
+	}
+
+	// 菜已经打到餐盘上，下一次称重照常计费
+	result, err := l.IngestWeight(ctx, "d1", "p1", 200)
+	if err != nil {
+		t.Fatalf("库存扣完后称重没有计费: %v", err)
+	}
+	if order := result.Order; order == nil || order.Status != "paid" || order.TotalPrice != 20 {
+		t.Fatalf("订单 = %+v，应为 paid 20.00", order)
+	}
+	if stock, _ := currentStock(t, db); stock.Remaining != 0 {
+		t.Fatalf("剩余 %.0f 克，应为 0", stock.Remaining)
+	}
+
+	// 直接下单仍然检查上架状态
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: 100}}); err == nil {
+		t.Fatal("已下架的菜品不能直接下单")
+	}
+}
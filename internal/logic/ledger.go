@@ -0,0 +1,347 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 科目种类
+const (
+	AccountWallet   = "wallet"   // 用户钱包，学校欠用户的钱
+	AccountRevenue  = "revenue"  // 食堂营收
+	AccountSubsidy  = "subsidy"  // 补贴资金池，发放给用户的补贴
+	AccountRefund   = "refund"   // 退款，冲减营收
+	AccountClearing = "clearing" // 支付清算，充值收到、尚未结算的款项
+	AccountOpening  = "opening"  // 期初余额，启用账本前已有的钱包余额
+)
+
+// 充值来源
+const (
+	ChargeSourcePayment = "payment" // 用户支付，借记支付清算
+	ChargeSourceSubsidy = "subsidy" // 学校补贴，借记补贴资金池
+)
+
+// 科目类型，借方余额类（资产、费用）余额为借方减贷方，其他为贷方减借方
+var accountTypes = map[string]string{
+	AccountWallet:   "liability",
+	AccountRevenue:  "income",
+	AccountSubsidy:  "expense",
+	AccountRefund:   "expense",
+	AccountClearing: "asset",
+	AccountOpening:  "equity",
+}
+
+var accountNames = map[string]string{
+	AccountWallet:   "用户钱包",
+	AccountRevenue:  "食堂营收",
+	AccountSubsidy:  "补贴资金池",
+	AccountRefund:   "退款",
+	AccountClearing: "支付清算",
+	AccountOpening:  "期初余额",
+}
+
+// 试算平衡表中科目类型的顺序
+var accountTypeOrder = map[string]int{"asset": 0, "liability": 1, "equity": 2, "income": 3, "expense": 4}
+
+func walletAccount(userID string) string {
+	return AccountWallet + ":" + userID
+}
+
+// revenueAccount 食堂营收科目，未归属食堂的订单记到 revenue
+func revenueAccount(canteenID string) string {
+	if canteenID == "" {
+		return AccountRevenue
+	}
+	return AccountRevenue + ":" + canteenID
+}
+
+// transfer 从 from 科目转到 to 科目：借记 from，贷记 to
+func transfer(from, to string, amount float64) []model.JournalLine {
+	if amount < 0 {
+		from, to, amount = to, from, -amount
+	}
+	return []model.JournalLine{
+		{AccountID: from, Debit: amount},
+		{AccountID: to, Credit: amount},
+	}
+}
+
+// postEntry 写入一条记账凭证，借贷不平衡时返回错误，金额为 0 时不记账；科目不存在时自动创建
+func postEntry(tx *gorm.DB, entry model.JournalEntry, lines []model.JournalLine) error {
+	var debits, credits int64
+	for _, line := range lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return errors.New("记账金额不能为负")
+		}
+		debits += cents(line.Debit)
+		credits += cents(line.Credit)
+	}
+	if debits != credits {
+		return fmt.Errorf("记账凭证借贷不平衡: 借方 %.2f, 贷方 %.2f", float64(debits)/100, float64(credits)/100)
+	}
+	if debits == 0 {
+		return nil
+	}
+
+	for _, line := range lines {
+		if _, err := ensureAccount(tx, line.AccountID); err != nil {
+			return err
+		}
+	}
+	entry.Lines = lines
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("记账失败: %w", err)
+	}
+	return nil
+}
+
+// errWalletGuard 钱包不满足变动余额的条件（状态或余额），由调用方换成具体的错误
+var errWalletGuard = errors.New("钱包不满足变动条件")
+
+// walletPosting 一次钱包余额变动，凭证中该钱包科目的发生额（贷方减借方）就是余额的变动额
+type walletPosting struct {
+	Entry     model.JournalEntry
+	Lines     []model.JournalLine
+	ExpiresAt *time.Time    // 补贴的到期时间
+	Guard     string        // 变动余额的附加条件，不满足时返回 errWalletGuard
+	GuardArgs []interface{} // Guard 的参数
+}
+
+// postWallet 变动钱包余额的唯一入口：在同一事务中按凭证更新余额、写入凭证并记录一条同类型的交易，
+// 余额、账本和交易流水一起变动。wallet 更新为变动后的钱包
+func postWallet(tx *gorm.DB, wallet *model.Wallet, p walletPosting) (*model.Transaction, error) {
+	account := walletAccount(wallet.UserID)
+	var delta int64
+	for _, line := range p.Lines {
+		switch {
+		case line.AccountID == account:
+			delta += cents(line.Credit) - cents(line.Debit)
+		case strings.HasPrefix(line.AccountID, AccountWallet+":"):
+			return nil, fmt.Errorf("凭证涉及其他钱包: %s", line.AccountID)
+		}
+	}
+	amount := float64(delta) / 100
+
+	query := tx.Unscoped().Model(&model.Wallet{}).Where("id = ?", wallet.ID)
+	if p.Guard != "" {
+		query = query.Where(p.Guard, p.GuardArgs...)
+	}
+	result := query.Update("balance", gorm.Expr("balance + ?", amount))
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新钱包失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errWalletGuard
+	}
+	if err := tx.Unscoped().Where("id = ?", wallet.ID).First(wallet).Error; err != nil {
+		return nil, fmt.Errorf("查询钱包失败: %w", err)
+	}
+
+	if err := postEntry(tx, p.Entry, p.Lines); err != nil {
+		return nil, err
+	}
+	transaction := model.Transaction{
+		WalletID:  wallet.ID,
+		Type:      p.Entry.Kind,
+		Amount:    amount,
+		Balance:   wallet.Balance,
+		OrderID:   p.Entry.OrderID,
+		ExpiresAt: p.ExpiresAt,
+		Remark:    p.Entry.Remark,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, fmt.Errorf("记录交易失败: %w", err)
+	}
+	return &transaction, nil
+}
+
+// ensureAccount 科目不存在时创建，返回是否新建
+func ensureAccount(tx *gorm.DB, id string) (bool, error) {
+	kind, owner, _ := strings.Cut(id, ":")
+	accountType, ok := accountTypes[kind]
+	if !ok {
+		return false, fmt.Errorf("未知的科目: %s", id)
+	}
+	name := accountNames[kind]
+	if owner != "" {
+		name += " " + owner
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.LedgerAccount{
+		ID:      id,
+		Type:    accountType,
+		Kind:    kind,
+		OwnerID: owner,
+		Name:    name,
+	})
+	if result.Error != nil {
+		return false, fmt.Errorf("创建科目失败: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// OpenLedger 为还没有账本科目的钱包建立科目，并把当前余额记为期初余额。
+// 启用账本前已有的钱包由此转入账本，服务启动时调用，可以重复执行
+func (l *RestaurantLogic) OpenLedger(ctx context.Context) (int, error) {
+	var wallets []model.Wallet
+	if err := l.db.WithContext(ctx).Unscoped().Select("id", "user_id", "balance").Find(&wallets).Error; err != nil {
+		return 0, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	var opened []string
+	if err := l.db.WithContext(ctx).Model(&model.LedgerAccount{}).Where("kind = ?", AccountWallet).
+		Pluck("owner_id", &opened).Error; err != nil {
+		return 0, fmt.Errorf("查询科目失败: %w", err)
+	}
+	exists := make(map[string]bool, len(opened))
+	for _, userID := range opened {
+		exists[userID] = true
+	}
+
+	count := 0
+	for _, wallet := range wallets {
+		if exists[wallet.UserID] {
+			continue
+		}
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			created, err := ensureAccount(tx, walletAccount(wallet.UserID))
+			if err != nil || !created {
+				// 其他实例已经建立了科目
+				return err
+			}
+			return postEntry(tx, model.JournalEntry{Kind: "opening", Remark: "期初余额"},
+				transfer(AccountOpening, walletAccount(wallet.UserID), wallet.Balance))
+		})
+		if err != nil {
+			return count, fmt.Errorf("钱包 %s 转入账本失败: %w", wallet.UserID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// TrialBalanceRow 试算平衡表的一行
+type TrialBalanceRow struct {
+	AccountID string  `json:"account_id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Kind      string  `json:"kind"`
+	Accounts  int     `json:"accounts"` // 合并显示的科目数
+	Debit     float64 `json:"debit"`
+	Credit    float64 `json:"credit"`
+	Balance   float64 `json:"balance"` // 按科目余额方向计算
+}
+
+// TrialBalance 试算平衡表
+type TrialBalance struct {
+	Before   time.Time         `json:"before"`
+	Rows     []TrialBalanceRow `json:"rows"`
+	Debit    float64           `json:"debit"`
+	Credit   float64           `json:"credit"`
+	Balanced bool              `json:"balanced"`
+}
+
+// GetTrialBalance 统计 before 之前所有凭证的科目借贷发生额和余额，before 为零值时统计全部。
+// detail 为 false 时所有用户钱包合并为一行
+func (l *RestaurantLogic) GetTrialBalance(ctx context.Context, before time.Time, detail bool) (*TrialBalance, error) {
+	if before.IsZero() {
+		before = time.Now()
+	}
+
+	var sums []struct {
+		AccountID string
+		Debit     float64
+		Credit    float64
+	}
+	if err := l.db.WithContext(ctx).Table("journal_lines").
+		Select("journal_lines.account_id, COALESCE(SUM(journal_lines.debit), 0) AS debit, COALESCE(SUM(journal_lines.credit), 0) AS credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_entries.created_at < ?", before).
+		Group("journal_lines.account_id").Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("汇总凭证失败: %w", err)
+	}
+
+	var accounts []model.LedgerAccount
+	if err := l.db.WithContext(ctx).Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("查询科目失败: %w", err)
+	}
+	byID := make(map[string]model.LedgerAccount, len(accounts))
+	for _, a := range accounts {
+		byID[a.ID] = a
+	}
+
+	rows := make(map[string]*TrialBalanceRow)
+	var debits, credits int64
+	for _, s := range sums {
+		account, ok := byID[s.AccountID]
+		if !ok {
+			kind, owner, _ := strings.Cut(s.AccountID, ":")
+			account = model.LedgerAccount{ID: s.AccountID, Kind: kind, OwnerID: owner, Type: accountTypes[kind], Name: s.AccountID}
+		}
+		key, name := account.ID, account.Name
+		if account.Kind == AccountWallet && !detail {
+			key, name = AccountWallet, accountNames[AccountWallet]
+		}
+		row := rows[key]
+		if row == nil {
+			row = &TrialBalanceRow{AccountID: key, Name: name, Type: account.Type, Kind: account.Kind}
+			rows[key] = row
+		}
+		row.Accounts++
+		row.Debit += s.Debit
+		row.Credit += s.Credit
+		debits += cents(s.Debit)
+		credits += cents(s.Credit)
+	}
+
+	tb := &TrialBalance{
+		Before:   before,
+		Rows:     make([]TrialBalanceRow, 0, len(rows)),
+		Debit:    float64(debits) / 100,
+		Credit:   float64(credits) / 100,
+		Balanced: debits == credits,
+	}
+	for _, row := range rows {
+		row.Debit = roundCents(row.Debit)
+		row.Credit = roundCents(row.Credit)
+		if row.Type == "asset" || row.Type == "expense" {
+			row.Balance = roundCents(row.Debit - row.Credit)
+		} else {
+			row.Balance = roundCents(row.Credit - row.Debit)
+		}
+		tb.Rows = append(tb.Rows, *row)
+	}
+	sort.Slice(tb.Rows, func(i, j int) bool {
+		a, b := tb.Rows[i], tb.Rows[j]
+		if accountTypeOrder[a.Type] != accountTypeOrder[b.Type] {
+			return accountTypeOrder[a.Type] < accountTypeOrder[b.Type]
+		}
+		return a.AccountID < b.AccountID
+	})
+	return tb, nil
+}
+
+// walletLedgerBalances 按账本计算每个用户钱包的余额
+func (l *RestaurantLogic) walletLedgerBalances(ctx context.Context) (map[string]float64, error) {
+	var sums []struct {
+		AccountID string
+		Balance   float64
+	}
+	if err := l.db.WithContext(ctx).Model(&model.JournalLine{}).
+		Select("account_id, COALESCE(SUM(credit), 0) - COALESCE(SUM(debit), 0) AS balance").
+		Where("account_id LIKE ?", AccountWallet+":%").
+		Group("account_id").Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("汇总钱包科目失败: %w", err)
+	}
+	balances := make(map[string]float64, len(sums))
+	for _, s := range sums {
+		balances[strings.TrimPrefix(s.AccountID, AccountWallet+":")] = roundCents(s.Balance)
+	}
+	return balances, nil
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// assertLedger 检查账本不变量：每条凭证借贷平衡，每个钱包的余额等于账本中钱包科目的余额，也等于交易流水合计
+func assertLedger(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	var entries []model.JournalEntry
+	if err := db.Preload("Lines").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		var debits, credits int64
+		for _, line := range e.Lines {
+			debits += cents(line.Debit)
+			credits += cents(line.Credit)
+		}
+		if debits != credits || debits == 0 {
+			t.Errorf("凭证 %d（%s）借方 %d 分，贷方 %d 分", e.ID, e.Kind, debits, credits)
+		}
+	}
+
+	var wallets []model.Wallet
+	if err := db.Unscoped().Find(&wallets).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range wallets {
+		var ledger, total float64
+		db.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(w.UserID)).
+			Select("COALESCE(SUM(credit), 0) - COALESCE(SUM(debit), 0)").Scan(&ledger)
+		db.Model(&model.Transaction{}).Where("wallet_id = ?", w.ID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&total)
+		if cents(w.Balance) != cents(ledger) || cents(w.Balance) != cents(total) {
+			t.Errorf("钱包 %s 余额 %.2f，账本 %.2f，流水 %.2f", w.UserID, w.Balance, ledger, total)
+		}
+	}
+}
+
+func TestLedgerInvariants(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	now := time.Now()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.User{ID: "u1", Username: "u1"}, &model.User{ID: "u2", Username: "u2"},
+		&model.Food{ID: "f1", Name: "米饭", Price: 10, IsAvailable: true},
+		&model.Plate{ID: "p1", QRCode: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+	)
+
+	// 充值和补贴
+	if _, err := l.ChargeWallet(ctx, "u1", 30, ChargeSourcePayment, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ChargeWallet(ctx, "u1", 12.5, ChargeSourceSubsidy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ChargeWallet(ctx, "u2", 20, ChargeSourcePayment, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertLedger(t, db)
+
+	// 下单扣款；余额不足的订单不留下凭证和交易
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: 123}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: 1000}}); err == nil {
+		t.Fatal("余额不足时应当失败")
+	}
+	assertLedger(t, db)
+
+	// 销户结算
+	if _, err := l.CloseAccount(ctx, "u1", "m1", "毕业"); err != nil {
+		t.Fatal(err)
+	}
+	assertLedger(t, db)
+
+	// 余额丢失更新后按账本修复
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u2").Update("balance", 15)
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Ledger) != 1 {
+		t.Fatalf("账本不一致 = %+v", report.Ledger)
+	}
+	if _, err := l.RepairWalletMismatch(ctx, report.Ledger[0], "m1", ""); err != nil {
+		t.Fatal(err)
+	}
+	assertLedger(t, db)
+
+	// 试算平衡
+	tb, err := l.GetTrialBalance(ctx, time.Now().Add(time.Minute), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tb.Balanced {
+		t.Fatalf("试算不平衡: %+v", tb)
+	}
+}
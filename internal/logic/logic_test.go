@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/migrate"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestLogic 在临时 SQLite 数据库上执行全部迁移，返回业务逻辑和数据库
+func newTestLogic(t *testing.T) (*RestaurantLogic, *gorm.DB) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return NewRestaurantLogic(db), db
+}
+
+// mustCreate 写入测试数据
+func mustCreate(t *testing.T, db *gorm.DB, values ...interface{}) {
+	t.Helper()
+	for _, v := range values {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 通知类型
+const (
+	NotifyOrderPaid        = "order_paid"
+	NotifyRefundIssued     = "refund_issued"
+	NotifyLowBalance       = "low_balance"
+	NotifyPlateAutoUnbound = "plate_auto_unbound"
+)
+
+// 通知渠道
+const (
+	ChannelInbox = "inbox"
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// NotificationKinds 所有通知类型
+var NotificationKinds = []string{NotifyOrderPaid, NotifyRefundIssued, NotifyLowBalance, NotifyPlateAutoUnbound}
+
+// NotificationChannels 所有通知渠道
+var NotificationChannels = []string{ChannelInbox, ChannelEmail, ChannelSMS}
+
+// WithNotifyDefaults 设置用户没有设置偏好时使用的通知渠道
+func (l *RestaurantLogic) WithNotifyDefaults(channels []string) *RestaurantLogic {
+	l.notifyDefaults = channels
+	return l
+}
+
+// ParseChannels 解析逗号分隔的通知渠道，去掉重复和空白
+func ParseChannels(value string) ([]string, error) {
+	channels := []string{}
+	for _, channel := range strings.Split(value, ",") {
+		channel = strings.TrimSpace(channel)
+		if channel == "" || slices.Contains(channels, channel) {
+			continue
+		}
+		if !slices.Contains(NotificationChannels, channel) {
+			return nil, fmt.Errorf("未知的通知渠道: %s", channel)
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// GetNotificationPreferences 获取用户每种通知的接收渠道，没有设置的使用默认渠道
+func (l *RestaurantLogic) GetNotificationPreferences(ctx context.Context, userID string) (map[string][]string, error) {
+	var prefs []model.NotificationPreference
+	if err := l.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+
+	result := make(map[string][]string, len(NotificationKinds))
+	for _, kind := range NotificationKinds {
+		result[kind] = l.notifyDefaults
+	}
+	for _, pref := range prefs {
+		channels, err := ParseChannels(pref.Channels)
+		if err != nil {
+			continue
+		}
+		result[pref.Kind] = channels
+	}
+	return result, nil
+}
+
+// SetNotificationPreference 设置用户某种通知的接收渠道，channels 为空表示不接收
+func (l *RestaurantLogic) SetNotificationPreference(ctx context.Context, userID, kind string, channels []string) error {
+	if !slices.Contains(NotificationKinds, kind) {
+		return fmt.Errorf("未知的通知类型: %s", kind)
+	}
+	parsed, err := ParseChannels(strings.Join(channels, ","))
+	if err != nil {
+		return err
+	}
+	if err := l.db.WithContext(ctx).Where("id = ?", userID).First(&model.User{}).Error; err != nil {
+		return fmt.Errorf("用户不存在: %w", err)
+	}
+
+	pref := model.NotificationPreference{UserID: userID, Kind: kind, Channels: strings.Join(parsed, ",")}
+	if err := l.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channels", "updated_at"}),
+	}).Create(&pref).Error; err != nil {
+		return fmt.Errorf("保存通知偏好失败: %w", err)
+	}
+	return nil
+}
+
+// NotificationRecipient 查询通知的接收人。销户的用户已被删除，仍要收到销户退款的通知；个人信息已匿名化的用户不再接收通知
+func (l *RestaurantLogic) NotificationRecipient(ctx context.Context, userID string) (*model.User, error) {
+	var user model.User
+	if err := l.db.WithContext(ctx).Unscoped().Where("id = ? AND erased_at IS NULL", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return &user, nil
+}
+
+// queueNotificationEvents 在业务事务中写入待生成通知的事件，事务回滚时一起撤销
+func queueNotificationEvents(tx *gorm.DB, events ...model.NotificationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return fmt.Errorf("写入通知事件失败: %w", err)
+	}
+	return nil
+}
+
+// ClaimNotificationEvents 取出未处理的通知事件，并在 lease 内不再被领取，
+// 处理过程中进程退出或处理失败时，lease 过后由其他副本或重启后的进程重新处理
+func (l *RestaurantLogic) ClaimNotificationEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.NotificationEvent, error) {
+	var due []model.NotificationEvent
+	if err := l.db.WithContext(ctx).Where("processed_at IS NULL AND claimed_until <= ?", now).
+		Order("id ASC").Limit(limit).Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("查询通知事件失败: %w", err)
+	}
+
+	claimed := due[:0]
+	for _, e := range due {
+		// 带上原来的领取时间作为条件，同一个事件只会被一个副本取走
+		result := l.db.WithContext(ctx).Model(&model.NotificationEvent{}).
+			Where("id = ? AND processed_at IS NULL AND claimed_until = ?", e.ID, e.ClaimedUntil).
+			Update("claimed_until", now.Add(lease))
+		if result.Error != nil {
+			return nil, fmt.Errorf("领取通知事件失败: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, e)
+		}
+	}
+	return claimed, nil
+}
+
+// CompleteNotificationEvent 把事件生成的通知写入发件箱并把事件标记为已处理，两者在同一个事务中；
+// 事件已经处理过时不重复写入
+func (l *RestaurantLogic) CompleteNotificationEvent(ctx context.Context, id uint, notifications []model.Notification) error {
+	return l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.NotificationEvent{}).Where("id = ? AND processed_at IS NULL", id).Update("processed_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("更新通知事件失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 || len(notifications) == 0 {
+			return nil
+		}
+
+		// 站内信写入即送达
+		now := time.Now()
+		for i := range notifications {
+			n := &notifications[i]
+			n.NextAttemptAt = now
+			n.Status = "pending"
+			if n.Channel == ChannelInbox {
+				n.Status = "sent"
+				n.SentAt = &now
+			}
+		}
+		if err := tx.Create(&notifications).Error; err != nil {
+			return fmt.Errorf("写入通知失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClaimDueNotifications 取出到期待发送的通知，并把下次发送时间推迟 lease，
+// 发送过程中进程退出时，lease 过后由其他副本或重启后的进程重新发送
+func (l *RestaurantLogic) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Notification, error) {
+	var due []model.Notification
+	if err := l.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("next_attempt_at ASC, id ASC").Limit(limit).Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("查询待发送通知失败: %w", err)
+	}
+
+	claimed := due[:0]
+	for _, n := range due {
+		// 带上原来的发送时间作为条件，同一条通知只会被一个副本取走
+		result := l.db.WithContext(ctx).Model(&model.Notification{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", n.ID, "pending", n.NextAttemptAt).
+			Update("next_attempt_at", now.Add(lease))
+		if result.Error != nil {
+			return nil, fmt.Errorf("领取通知失败: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, n)
+		}
+	}
+	return claimed, nil
+}
+
+// RecordNotificationAttempt 记录一次发送结果。发送失败时 retryAt 为下次重试时间，为零值表示不再重试
+func (l *RestaurantLogic) RecordNotificationAttempt(ctx context.Context, id uint, sendErr error, retryAt time.Time) error {
+	updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		updates["status"] = "sent"
+		updates["sent_at"] = &now
+		updates["last_error"] = ""
+	case retryAt.IsZero():
+		updates["status"] = "failed"
+		updates["last_error"] = truncate(sendErr.Error(), 255)
+	default:
+		updates["next_attempt_at"] = retryAt
+		updates["last_error"] = truncate(sendErr.Error(), 255)
+	}
+	if err := l.db.WithContext(ctx).Model(&model.Notification{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新通知状态失败: %w", err)
+	}
+	return nil
+}
+
+// GetInbox 获取用户的站内信，按时间倒序
+func (l *RestaurantLogic) GetInbox(ctx context.Context, userID string, unreadOnly bool, limit int) ([]model.Notification, int64, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	inbox := func() *gorm.DB {
+		return l.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND channel = ?", userID, ChannelInbox)
+	}
+
+	var unread int64
+	if err := inbox().Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询未读站内信失败: %w", err)
+	}
+	query := inbox()
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []model.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询站内信失败: %w", err)
+	}
+	return notifications, unread, nil
+}
+
+// MarkInboxRead 把用户的站内信标记为已读，ids 为空表示全部，返回标记的数量
+func (l *RestaurantLogic) MarkInboxRead(ctx context.Context, userID string, ids []uint) (int64, error) {
+	query := l.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND channel = ? AND read_at IS NULL", userID, ChannelInbox)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("标记已读失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+func TestOrderQueuesNotificationEvents(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	now := time.Now()
+	mustCreate(t, db,
+		&model.User{ID: "u1", Username: "u1"}, &model.Wallet{UserID: "u1", Balance: 25},
+		&model.Food{ID: "f1", Name: "米饭", Price: 10, IsAvailable: true},
+		&model.Plate{ID: "p1", QRCode: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+	)
+	kinds := func() []string {
+		var events []model.NotificationEvent
+		if err := db.Order("id").Find(&events).Error; err != nil {
+			t.Fatal(err)
+		}
+		var kinds []string
+		for _, e := range events {
+			kinds = append(kinds, e.Kind)
+		}
+		return kinds
+	}
+	order := func(weight float64) error {
+		_, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: weight}})
+		return err
+	}
+
+	// 余额 25 -> 15 -> 5（跌破阈值）-> 4（已低于阈值，不重复提醒）
+	for _, weight := range []float64{100, 100, 10} {
+		if err := order(weight); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{NotifyOrderPaid, NotifyOrderPaid, NotifyLowBalance, NotifyOrderPaid}
+	if got := kinds(); !slices.Equal(got, want) {
+		t.Fatalf("通知事件 = %v，应为 %v", got, want)
+	}
+
+	// 扣款失败时事务回滚，不留下通知事件
+	if err := order(1000); err == nil {
+		t.Fatal("余额不足时应当失败")
+	}
+	if got := kinds(); len(got) != len(want) {
+		t.Fatalf("扣款失败后通知事件 = %v", got)
+	}
+}
+
+func TestClaimAndCompleteNotificationEvents(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db, &model.NotificationEvent{UserID: "u1", Kind: NotifyPlateAutoUnbound, PlateID: "p1"})
+
+	now := time.Now()
+	claimed, err := l.ClaimNotificationEvents(ctx, now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("领取 = %v, %v", claimed, err)
+	}
+	// lease 内不会被其他副本重复领取，过期后重新领取
+	if again, _ := l.ClaimNotificationEvents(ctx, now, time.Minute, 10); len(again) != 0 {
+		t.Fatalf("lease 内重复领取 %d 个事件", len(again))
+	}
+	if again, _ := l.ClaimNotificationEvents(ctx, now.Add(2*time.Minute), time.Minute, 10); len(again) != 1 {
+		t.Fatalf("lease 过期后领取 %d 个事件，应为 1", len(again))
+	}
+
+	inbox := []model.Notification{{UserID: "u1", Kind: NotifyPlateAutoUnbound, Channel: ChannelInbox, Subject: "s", Body: "b"}}
+	for i := 0; i < 2; i++ {
+		if err := l.CompleteNotificationEvent(ctx, claimed[0].ID, inbox); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int64
+	db.Model(&model.Notification{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("重复处理事件写入了 %d 条通知", count)
+	}
+	if again, _ := l.ClaimNotificationEvents(ctx, now.Add(time.Hour), time.Minute, 10); len(again) != 0 {
+		t.Fatalf("已处理的事件又被领取")
+	}
+}
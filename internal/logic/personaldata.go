@@ -0,0 +1,159 @@
+package logic
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// ErrUserErased 用户的个人信息已经匿名化
+var ErrUserErased = errors.New("用户个人信息已匿名化")
+
+// PersonalDataExport 用户个人数据导出，包括已删除的记录
+type PersonalDataExport struct {
+	ExportedAt              time.Time                      `json:"exported_at"`
+	Profile                 model.User                     `json:"profile"`
+	Wallet                  *model.Wallet                  `json:"wallet,omitempty"`
+	Transactions            []model.Transaction            `json:"transactions"`
+	Orders                  []model.Order                  `json:"orders"`
+	PlateEvents             []model.PlateEvent             `json:"plate_events"`
+	Notifications           []model.Notification           `json:"notifications"`
+	NotificationPreferences []model.NotificationPreference `json:"notification_preferences"`
+	Closure                 *model.AccountClosure          `json:"closure,omitempty"`
+}
+
+// ExportPersonalData 导出用户的个人资料、钱包、交易记录、订单（含明细）、餐盘使用记录、通知和销户记录，
+// 用于答复学生的个人数据查询。已删除的用户同样可以导出
+func (l *RestaurantLogic) ExportPersonalData(ctx context.Context, userID string) (*PersonalDataExport, error) {
+	// 先 Unscoped 再 WithContext，得到可以复用的新会话
+	db := l.db.Unscoped().WithContext(ctx)
+	export := &PersonalDataExport{
+		ExportedAt:              time.Now(),
+		Transactions:            make([]model.Transaction, 0),
+		Orders:                  make([]model.Order, 0),
+		PlateEvents:             make([]model.PlateEvent, 0),
+		Notifications:           make([]model.Notification, 0),
+		NotificationPreferences: make([]model.NotificationPreference, 0),
+	}
+	if err := db.Where("id = ?", userID).First(&export.Profile).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %w", err)
+	}
+
+	var wallet model.Wallet
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	if wallet.ID != 0 {
+		export.Wallet = &wallet
+		if err := db.Where("wallet_id = ?", wallet.ID).Order("id").Find(&export.Transactions).Error; err != nil {
+			return nil, fmt.Errorf("查询交易记录失败: %w", err)
+		}
+	}
+	if err := db.Preload("OrderItems").Where("user_id = ?", userID).Order("created_at").Find(&export.Orders).Error; err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&export.PlateEvents).Error; err != nil {
+		return nil, fmt.Errorf("查询餐盘记录失败: %w", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&export.Notifications).Error; err != nil {
+		return nil, fmt.Errorf("查询通知失败: %w", err)
+	}
+	if err := db.Where("user_id = ?", userID).Find(&export.NotificationPreferences).Error; err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+	if closure, err := l.GetAccountClosure(ctx, userID); err == nil {
+		export.Closure = closure
+	}
+	return export, nil
+}
+
+// WriteZip 把导出内容按类别写成 ZIP 中的多个 JSON 文件
+func (e *PersonalDataExport) WriteZip(w io.Writer) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", e.Profile},
+		{"wallet.json", e.Wallet},
+		{"transactions.json", e.Transactions},
+		{"orders.json", e.Orders},
+		{"plate_events.json", e.PlateEvents},
+		{"notifications.json", e.Notifications},
+		{"notification_preferences.json", e.NotificationPreferences},
+		{"closure.json", e.Closure},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", file.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// ErasePersonalData 匿名化用户的个人信息：用户名替换为随机的匿名名称，清空手机号和邮箱，
+// 销户记录中的用户名同步替换，删除通知（含收件地址和带用户名的正文）和通知偏好。
+// 钱包、交易记录、订单、记账凭证和餐盘记录只通过用户ID关联，保持不变，账务仍然可以核对
+func (l *RestaurantLogic) ErasePersonalData(ctx context.Context, userID, workerID string) (*model.User, error) {
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+
+	var user model.User
+	if err := l.db.WithContext(ctx).Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %w", err)
+	}
+	if user.ErasedAt != nil {
+		return &user, ErrUserErased
+	}
+
+	now := time.Now()
+	user.Username = "erased-" + uuid.New().String()
+	user.Phone = ""
+	user.Email = ""
+	user.ErasedAt = &now
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&model.User{}).Where("id = ? AND erased_at IS NULL", userID).
+			Updates(map[string]interface{}{"username": user.Username, "phone": "", "email": "", "erased_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("匿名化用户失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserErased
+		}
+		if err := tx.Model(&model.AccountClosure{}).Where("user_id = ?", userID).
+			Update("username", user.Username).Error; err != nil {
+			return fmt.Errorf("匿名化销户记录失败: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Notification{}).Error; err != nil {
+			return fmt.Errorf("删除通知失败: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.NotificationPreference{}).Error; err != nil {
+			return fmt.Errorf("删除通知偏好失败: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.NotificationEvent{}).Error; err != nil {
+			return fmt.Errorf("删除待生成的通知失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
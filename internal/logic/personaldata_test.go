@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// personalCounts 用户在账务表中的记录数和金额合计，匿名化前后应当一致
+type personalCounts struct {
+	Transactions, JournalLines, Orders int64
+	Amount, Credit, Debit              float64
+}
+
+func countPersonal(t *testing.T, db *gorm.DB, userID string) personalCounts {
+	t.Helper()
+	var c personalCounts
+	wallet := db.Unscoped().Model(&model.Wallet{}).Select("id").Where("user_id = ?", userID)
+	db.Model(&model.Transaction{}).Where("wallet_id IN (?)", wallet).Count(&c.Transactions)
+	db.Model(&model.Transaction{}).Where("wallet_id IN (?)", wallet).Select("COALESCE(SUM(amount), 0)").Scan(&c.Amount)
+	db.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(userID)).Count(&c.JournalLines)
+	db.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(userID)).
+		Select("COALESCE(SUM(credit), 0)").Scan(&c.Credit)
+	db.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(userID)).
+		Select("COALESCE(SUM(debit), 0)").Scan(&c.Debit)
+	db.Unscoped().Model(&model.Order{}).Where("user_id = ?", userID).Count(&c.Orders)
+	return c
+}
+
+func TestErasePersonalData(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	now := time.Now()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.User{ID: "u1", Username: "alice", Phone: "13800000000", Email: "alice@example.com"},
+		&model.User{ID: "u2", Username: "bob", Email: "bob@example.com"},
+		&model.Food{ID: "f1", Name: "米饭", Price: 10, IsAvailable: true},
+		&model.Plate{ID: "p1", QRCode: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+	)
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := l.ChargeWallet(ctx, userID, 30, ChargeSourcePayment, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.SetNotificationPreference(ctx, userID, NotifyOrderPaid, []string{"inbox", "email"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "f1", Weight: 100}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CloseAccount(ctx, "u1", "m1", "毕业"); err != nil {
+		t.Fatal(err)
+	}
+	mustCreate(t, db,
+		&model.Notification{UserID: "u1", Kind: NotifyOrderPaid, Channel: "email", Address: "alice@example.com", Subject: "扣款", Body: "alice 您好"},
+		&model.Notification{UserID: "u2", Kind: NotifyOrderPaid, Channel: "email", Address: "bob@example.com", Subject: "扣款", Body: "bob 您好"},
+	)
+
+	// 匿名化之前导出包含全部个人数据，已销户的用户同样可以导出
+	export, err := l.ExportPersonalData(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Profile.Email != "alice@example.com" || export.Wallet == nil || len(export.Transactions) != 3 ||
+		len(export.Orders) != 1 || len(export.Orders[0].OrderItems) != 1 || len(export.Notifications) != 1 ||
+		len(export.NotificationPreferences) != 1 || export.Closure == nil || export.Closure.Username != "alice" {
+		t.Fatalf("导出 = %+v", export)
+	}
+
+	before := countPersonal(t, db, "u1")
+	user, err := l.ErasePersonalData(ctx, "u1", "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(user.Username, "erased-") || user.ErasedAt == nil {
+		t.Fatalf("匿名化后的用户 = %+v", user)
+	}
+
+	var stored model.User
+	db.Unscoped().Where("id = ?", "u1").First(&stored)
+	if stored.Username != user.Username || stored.Phone != "" || stored.Email != "" || stored.ErasedAt == nil {
+		t.Fatalf("数据库中的用户 = %+v", stored)
+	}
+	var closure model.AccountClosure
+	db.Where("user_id = ?", "u1").First(&closure)
+	if closure.Username != user.Username {
+		t.Fatalf("销户记录用户名 = %s，应为 %s", closure.Username, user.Username)
+	}
+	for _, m := range []interface{}{&model.Notification{}, &model.NotificationPreference{}, &model.NotificationEvent{}} {
+		var n int64
+		db.Model(m).Where("user_id = ?", "u1").Count(&n)
+		if n != 0 {
+			t.Fatalf("%T 还剩 %d 条", m, n)
+		}
+	}
+
+	// 账务记录不变，对账仍然通过
+	if after := countPersonal(t, db, "u1"); after != before {
+		t.Fatalf("匿名化前 %+v，之后 %+v", before, after)
+	}
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("匿名化后对账不通过: %+v", report)
+	}
+	assertLedger(t, db)
+
+	// 其他用户不受影响
+	var bob model.User
+	db.Where("id = ?", "u2").First(&bob)
+	var bobNotices int64
+	db.Model(&model.Notification{}).Where("user_id = ?", "u2").Count(&bobNotices)
+	if bob.Username != "bob" || bob.Email != "bob@example.com" || bobNotices != 1 {
+		t.Fatalf("u2 = %+v，通知 %d 条", bob, bobNotices)
+	}
+
+	// 导出只剩匿名信息，不能重复匿名化
+	export, err = l.ExportPersonalData(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Profile.Username != user.Username || export.Profile.Email != "" || len(export.Transactions) != 3 ||
+		len(export.Notifications) != 0 || len(export.NotificationPreferences) != 0 || export.Closure.Username != user.Username {
+		t.Fatalf("匿名化后导出 = %+v", export)
+	}
+	if _, err := l.ErasePersonalData(ctx, "u1", "m1"); !errors.Is(err, ErrUserErased) {
+		t.Fatalf("重复匿名化 err = %v，应为 ErrUserErased", err)
+	}
+}
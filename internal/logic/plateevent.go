@@ -0,0 +1,39 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// recordPlateEvent 追加一条餐盘事件，调用方应与餐盘的修改放在同一个事务里
+func recordPlateEvent(tx *gorm.DB, event model.PlateEvent) error {
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("记录餐盘事件失败: %w", err)
+	}
+	return nil
+}
+
+// GetPlateTimeline 获取餐盘生命周期时间线，最新的在前；beforeID 大于 0 时只返回更早的事件，用于翻页
+func (l *RestaurantLogic) GetPlateTimeline(ctx context.Context, plateID string, beforeID uint, limit int) ([]model.PlateEvent, error) {
+	var plate model.Plate
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Select("id").Where("id = ?", plateID).First(&plate).Error; err != nil {
+		return nil, fmt.Errorf("餐盘不存在: %w", err)
+	}
+
+	var events []model.PlateEvent
+	// 事件只追加，ID 与时间先后一致
+	query := l.db.WithContext(ctx).Where("plate_id = ?", plateID).Order("id DESC")
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("查询餐盘事件失败: %w", err)
+	}
+	return events, nil
+}
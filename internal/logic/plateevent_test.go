@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+func TestGetPlateTimeline(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db, &model.Plate{ID: "P1", QRCode: "P1"})
+	for _, typ := range []string{"created", "bind", "order", "unbind", "gc"} {
+		mustCreate(t, db, &model.PlateEvent{PlateID: "P1", Type: typ, ActorType: "system"})
+	}
+
+	// 最新的在前
+	page, err := l.GetPlateTimeline(ctx, "P1", 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Type != "gc" || page[1].Type != "unbind" {
+		t.Fatalf("第一页 = %+v", page)
+	}
+
+	// 从上一页最后一条继续
+	page, err = l.GetPlateTimeline(ctx, "P1", page[1].ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range page {
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, ",") != "order,bind,created" {
+		t.Fatalf("第二页 = %v", types)
+	}
+
+	if _, err := l.GetPlateTimeline(ctx, "P404", 0, 10); err == nil {
+		t.Fatal("餐盘不存在时应当报错")
+	}
+}
+
+func TestHandleExceptionRemark(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Plate{ID: "P1", QRCode: "P1"},
+		&model.Worker{ID: "W1", Name: "张三", Role: "staff"},
+	)
+
+	if err := l.HandleException(ctx, "W1", "P1", " ", "送修"); err == nil {
+		t.Fatal("异常描述为空时应当报错")
+	}
+	if err := l.HandleException(ctx, "W1", "P1", "破损", strings.Repeat("修", 256)); err == nil {
+		t.Fatal("处理措施超过 255 个字符时应当报错")
+	}
+
+	exception := strings.Repeat("盘", 300)
+	if err := l.HandleException(ctx, "W1", "P1", exception, "送修"); err != nil {
+		t.Fatal(err)
+	}
+	var event model.PlateEvent
+	if err := db.Where("plate_id = ? AND type = ?", "P1", "exception").First(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(event.Remark); n != 255 {
+		t.Errorf("备注长度 = %d，应截断为 255", n)
+	}
+	var log model.ExceptionLog
+	if err := db.First(&log, event.ExceptionID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if log.Exception != exception {
+		t.Error("异常记录应保存完整描述")
+	}
+}
@@ -0,0 +1,244 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// plateImportBatchSize 批量插入的批次大小
+const plateImportBatchSize = 100
+
+// importablePlateStatus 导入时允许的初始状态
+var importablePlateStatus = map[string]bool{
+	"available":   true,
+	"cleaning":    true,
+	"maintenance": true,
+}
+
+// PlateImportError 导入失败的行
+type PlateImportError struct {
+	Row     int    `json:"row"` // CSV 行号（表头为第 1 行）
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// PlateImportResult 餐盘导入结果
+type PlateImportResult struct {
+	DryRun   bool               `json:"dry_run"`
+	Total    int                `json:"total"`
+	Valid    int                `json:"valid"`
+	Imported int                `json:"imported"`
+	Errors   []PlateImportError `json:"errors,omitempty"`
+}
+
+// plateImportRow CSV 中的一行
+type plateImportRow struct {
+	line  int
+	plate model.Plate
+}
+
+// ImportPlates 从 CSV 批量导入餐盘，餐盘归属于调用方所在的食堂
+// CSV 必须带表头，支持的列：id, rfid_tag, qr_code, depot_id, status，其中 rfid_tag 必填。
+// 任意一行校验失败则整批不导入；dryRun 时只校验不写入。
+func (l *RestaurantLogic) ImportPlates(ctx context.Context, r io.Reader, dryRun bool) (*PlateImportResult, error) {
+	rows, result, err := parsePlateCSV(r, tenant.CanteenID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = dryRun
+
+	if err := l.validatePlateRows(ctx, rows, result); err != nil {
+		return nil, err
+	}
+	result.Valid = result.Total - countRows(result.Errors)
+
+	if dryRun || len(result.Errors) > 0 || len(rows) == 0 {
+		return result, nil
+	}
+
+	plates := make([]model.Plate, 0, len(rows))
+	for _, row := range rows {
+		plates = append(plates, row.plate)
+	}
+
+	events := make([]model.PlateEvent, 0, len(plates))
+	for _, plate := range plates {
+		events = append(events, model.PlateEvent{
+			PlateID:   plate.ID,
+			Type:      "created",
+			NewStatus: plate.Status,
+			ActorType: "system",
+			Remark:    "CSV 批量导入",
+		})
+	}
+
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&plates, plateImportBatchSize).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&events, plateImportBatchSize).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("导入餐盘失败: %w", err)
+	}
+
+	for _, plate := range plates {
+		l.bus.Publish(event.Event{Type: event.PlateStatus, CanteenID: plate.CanteenID, PlateID: plate.ID, NewStatus: plate.Status})
+	}
+
+	result.Imported = len(plates)
+	return result, nil
+}
+
+// parsePlateCSV 解析 CSV，格式错误的行直接记入结果
+func parsePlateCSV(r io.Reader, canteenID string) ([]plateImportRow, *PlateImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("CSV 内容为空")
+		}
+		return nil, nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["rfid_tag"]; !ok {
+		return nil, nil, errors.New("CSV 缺少 rfid_tag 列")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	result := &PlateImportResult{}
+	var rows []plateImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		result.Total++
+		if err != nil {
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Message: err.Error()})
+			continue
+		}
+
+		plate := model.Plate{
+			ID:        field(record, "id"),
+			CanteenID: canteenID,
+			RFIDTag:   field(record, "rfid_tag"),
+			QRCode:    field(record, "qr_code"),
+			DepotID:   field(record, "depot_id"),
+			Status:    field(record, "status"),
+		}
+		if plate.ID == "" {
+			plate.ID = uuid.New().String()
+		}
+		if plate.QRCode == "" {
+			plate.QRCode = plate.ID
+		}
+		if plate.Status == "" {
+			plate.Status = "available"
+		}
+
+		switch {
+		case plate.RFIDTag == "":
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Field: "rfid_tag", Message: "RFID 标签不能为空"})
+			continue
+		case !importablePlateStatus[plate.Status]:
+			result.Errors = append(result.Errors, PlateImportError{Row: line, Field: "status", Message: fmt.Sprintf("不支持的初始状态: %s", plate.Status)})
+			continue
+		}
+
+		rows = append(rows, plateImportRow{line: line, plate: plate})
+	}
+
+	return rows, result, nil
+}
+
+// validatePlateRows 校验文件内以及与数据库已有数据的唯一性、托管处是否存在
+func (l *RestaurantLogic) validatePlateRows(ctx context.Context, rows []plateImportRow, result *PlateImportResult) error {
+	var ids, rfids, qrcodes, depots []string
+	for _, row := range rows {
+		ids = append(ids, row.plate.ID)
+		rfids = append(rfids, row.plate.RFIDTag)
+		qrcodes = append(qrcodes, row.plate.QRCode)
+		if row.plate.DepotID != "" {
+			depots = append(depots, row.plate.DepotID)
+		}
+	}
+
+	var existing []model.Plate
+	if len(rows) > 0 {
+		if err := l.db.WithContext(ctx).Unscoped().Select("id", "rf_id_tag", "qr_code").
+			Where("id IN ? OR rf_id_tag IN ? OR qr_code IN ?", ids, rfids, qrcodes).
+			Find(&existing).Error; err != nil {
+			return fmt.Errorf("查询已有餐盘失败: %w", err)
+		}
+	}
+	existingDepots := map[string]bool{}
+	if len(depots) > 0 {
+		var found []string
+		if err := l.db.WithContext(ctx).Model(&model.PlateDepot{}).Scopes(inCanteen(ctx)).Where("id IN ?", depots).Pluck("id", &found).Error; err != nil {
+			return fmt.Errorf("查询托管处失败: %w", err)
+		}
+		for _, id := range found {
+			existingDepots[id] = true
+		}
+	}
+
+	seen := map[string]map[string]bool{"id": {}, "rfid_tag": {}, "qr_code": {}}
+	for _, p := range existing {
+		seen["id"][p.ID] = true
+		seen["rfid_tag"][p.RFIDTag] = true
+		seen["qr_code"][p.QRCode] = true
+	}
+	inFile := map[string]map[string]int{"id": {}, "rfid_tag": {}, "qr_code": {}}
+
+	for _, row := range rows {
+		values := map[string]string{"id": row.plate.ID, "rfid_tag": row.plate.RFIDTag, "qr_code": row.plate.QRCode}
+		for _, name := range []string{"id", "rfid_tag", "qr_code"} {
+			v := values[name]
+			if seen[name][v] {
+				result.Errors = append(result.Errors, PlateImportError{Row: row.line, Field: name, Message: fmt.Sprintf("%s 已存在: %s", name, v)})
+			} else if first, ok := inFile[name][v]; ok {
+				result.Errors = append(result.Errors, PlateImportError{Row: row.line, Field: name, Message: fmt.Sprintf("%s 与第 %d 行重复: %s", name, first, v)})
+			} else {
+				inFile[name][v] = row.line
+			}
+		}
+		if row.plate.DepotID != "" && !existingDepots[row.plate.DepotID] {
+			result.Errors = append(result.Errors, PlateImportError{Row: row.line, Field: "depot_id", Message: fmt.Sprintf("托管处不存在: %s", row.plate.DepotID)})
+		}
+	}
+
+	return nil
+}
+
+// countRows 统计出错的行数（同一行可能有多个错误）
+func countRows(errs []PlateImportError) int {
+	rows := map[int]bool{}
+	for _, e := range errs {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
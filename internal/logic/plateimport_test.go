@@ -0,0 +1,143 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+)
+
+// importErrors 把导入错误整理成 "行号:字段"，便于比较
+func importErrors(result *PlateImportResult) []string {
+	var got []string
+	for _, e := range result.Errors {
+		got = append(got, fmt.Sprintf("%d:%s", e.Row, e.Field))
+	}
+	return got
+}
+
+func TestImportPlatesValidation(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := tenant.WithCanteen(context.Background(), "c1")
+	mustCreate(t, db,
+		&model.PlateDepot{ID: "d1", CanteenID: "c1", Name: "一楼"},
+		&model.PlateDepot{ID: "d2", CanteenID: "c2", Name: "别的食堂"},
+		&model.Plate{ID: "old", QRCode: "qr-old", RFIDTag: "rfid-old", Status: "available"},
+	)
+	// 已删除的餐盘仍然占着唯一索引
+	deleted := &model.Plate{ID: "gone", QRCode: "qr-gone", RFIDTag: "rfid-gone", Status: "available"}
+	mustCreate(t, db, deleted)
+	db.Delete(deleted)
+
+	csv := strings.Join([]string{
+		"id,rfid_tag,qr_code,depot_id,status",
+		"p1,rfid-1,qr-1,d1,",
+		"p2,,qr-2,,",                    // 第 3 行：缺 RFID
+		"p3,rfid-3,qr-3,,in_use",        // 第 4 行：不支持的初始状态
+		"p4,rfid-old,qr-4,,",            // 第 5 行：RFID 与已有餐盘重复
+		"p5,rfid-5,qr-gone,,",           // 第 6 行：二维码与已删除的餐盘重复
+		"p6,rfid-1,qr-6,,",              // 第 7 行：RFID 与第 2 行重复
+		"p7,rfid-7,qr-7,d2,",            // 第 8 行：托管处属于别的食堂
+		"p8,rfid-8,qr-8,d1,maintenance", // 第 9 行：合法
+	}, "\n")
+
+	for _, dryRun := range []bool{true, false} {
+		result, err := l.ImportPlates(ctx, strings.NewReader(csv), dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"3:rfid_tag", "4:status", "5:rfid_tag", "6:qr_code", "7:rfid_tag", "8:depot_id"}
+		if got := importErrors(result); !slices.Equal(got, want) {
+			t.Fatalf("dryRun=%v 错误 = %v，应为 %v", dryRun, got, want)
+		}
+		if result.DryRun != dryRun || result.Total != 8 || result.Valid != 2 || result.Imported != 0 {
+			t.Fatalf("dryRun=%v 结果 = %+v", dryRun, result)
+		}
+	}
+
+	// 有错误时整批不导入，合法的行也不写入
+	var count int64
+	db.Model(&model.Plate{}).Where("id IN ?", []string{"p1", "p8"}).Count(&count)
+	if count != 0 {
+		t.Fatalf("校验失败时写入了 %d 个餐盘", count)
+	}
+}
+
+func TestImportPlatesDryRunAndBatches(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := tenant.WithCanteen(context.Background(), "c1")
+
+	// 超过一个批次，id 和二维码留空时自动生成
+	lines := []string{"rfid_tag"}
+	for i := 0; i < plateImportBatchSize+20; i++ {
+		lines = append(lines, fmt.Sprintf("rfid-%03d", i))
+	}
+	csv := strings.Join(lines, "\n")
+	total := len(lines) - 1
+
+	result, err := l.ImportPlates(ctx, strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || result.Valid != total || result.Imported != 0 || len(result.Errors) != 0 {
+		t.Fatalf("预检结果 = %+v", result)
+	}
+	var count int64
+	db.Model(&model.Plate{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("预检写入了 %d 个餐盘", count)
+	}
+
+	// 第二批的事件写入失败时，第一批的餐盘也一起回滚
+	if err := db.Exec(fmt.Sprintf(`CREATE TRIGGER fail_import BEFORE INSERT ON plate_events
+		WHEN (SELECT COUNT(*) FROM plate_events) >= %d
+		BEGIN SELECT RAISE(ABORT, 'boom'); END`, plateImportBatchSize)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ImportPlates(ctx, strings.NewReader(csv), false); err == nil {
+		t.Fatal("事件写入失败时导入应当失败")
+	}
+	db.Model(&model.Plate{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("导入失败后残留 %d 个餐盘", count)
+	}
+	if err := db.Exec("DROP TRIGGER fail_import").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = l.ImportPlates(ctx, strings.NewReader(csv), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != total {
+		t.Fatalf("导入 %d 个餐盘，应为 %d", result.Imported, total)
+	}
+	var plates []model.Plate
+	db.Find(&plates)
+	if len(plates) != total {
+		t.Fatalf("餐盘 %d 个，应为 %d", len(plates), total)
+	}
+	for _, p := range plates {
+		if p.CanteenID != "c1" || p.QRCode != p.ID || p.Status != "available" {
+			t.Fatalf("餐盘 = %+v", p)
+		}
+	}
+	var events int64
+	db.Model(&model.PlateEvent{}).Where("type = ?", "created").Count(&events)
+	if events != int64(total) {
+		t.Fatalf("created 事件 %d 条，应为 %d", events, total)
+	}
+
+	// 再导入一次全部与已有餐盘重复
+	result, err = l.ImportPlates(ctx, strings.NewReader(csv), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid != 0 || result.Imported != 0 || len(result.Errors) != total {
+		t.Fatalf("重复导入结果 Valid=%d Imported=%d Errors=%d", result.Valid, result.Imported, len(result.Errors))
+	}
+}
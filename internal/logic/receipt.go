@@ -0,0 +1,100 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/p-program/Fenrir/internal/receipt"
+	"github.com/p-program/Fenrir/model"
+)
+
+// GetOrderReceipt 生成订单小票内容，只有已支付、已完成或已退款的订单可以打印
+func (l *RestaurantLogic) GetOrderReceipt(ctx context.Context, orderID string) (*receipt.Receipt, error) {
+	order, err := l.GetOrderInfo(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	switch order.Status {
+	case "paid", "completed", "refunded":
+	default:
+		return nil, fmt.Errorf("订单未支付，不能打印小票，当前状态: %s", order.Status)
+	}
+
+	r := &receipt.Receipt{
+		OrderID:   order.ID,
+		PlateID:   order.PlateID,
+		CreatedAt: order.CreatedAt,
+		Total:     order.TotalPrice,
+	}
+	if order.User != nil {
+		r.Username = order.User.Username
+	}
+	if order.CanteenID != "" {
+		var canteen model.Canteen
+		if err := l.db.WithContext(ctx).Where("id = ?", order.CanteenID).First(&canteen).Error; err == nil {
+			r.Title = canteen.Name
+		}
+	}
+
+	// 按勺计价的汤品单价是每勺的价格
+	var soupFoods []string
+	for _, item := range order.OrderItems {
+		if item.Unit == "ml" {
+			soupFoods = append(soupFoods, item.FoodID)
+		}
+	}
+	ladleFoods := make(map[string]bool)
+	if len(soupFoods) > 0 {
+		var soups []model.Soup
+		if err := l.db.WithContext(ctx).Where("food_id IN ? AND price_unit = ?", soupFoods, "ladle").Find(&soups).Error; err != nil {
+			return nil, fmt.Errorf("查询汤品失败: %w", err)
+		}
+		for _, soup := range soups {
+			ladleFoods[soup.FoodID] = true
+		}
+	}
+
+	for _, item := range order.OrderItems {
+		unit := item.Unit
+		if unit == "" {
+			unit = "g"
+		}
+		priceUnit := "100" + unit
+		if ladleFoods[item.FoodID] {
+			priceUnit = "勺"
+		}
+		r.Items = append(r.Items, receipt.Item{
+			Name:      item.FoodName,
+			Weight:    item.Weight,
+			Unit:      unit,
+			UnitPrice: item.UnitPrice,
+			PriceUnit: priceUnit,
+			Price:     item.Price,
+		})
+		r.Subtotal += item.Price
+	}
+	r.Subtotal = math.Round(r.Subtotal*100) / 100
+	if discount := math.Round((r.Subtotal-r.Total)*100) / 100; discount > 0 {
+		r.Discount = discount
+	}
+
+	var transactions []model.Transaction
+	if err := l.db.WithContext(ctx).Where("order_id = ?", order.ID).Order("id").Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	for _, t := range transactions {
+		switch t.Type {
+		case "consume":
+			balance := t.Balance
+			r.Balance = &balance
+		case "refund":
+			refundedAt := t.CreatedAt
+			r.RefundedAt = &refundedAt
+		}
+	}
+	if order.Status == "refunded" && r.RefundedAt == nil {
+		r.RefundedAt = &order.UpdatedAt
+	}
+	return r, nil
+}
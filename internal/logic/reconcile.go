@@ -0,0 +1,400 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// 对账发现的订单问题
+const (
+	IssueMissingConsume    = "missing_consume"    // 已支付的订单没有扣款记录
+	IssueDuplicateConsume  = "duplicate_consume"  // 同一订单扣款多次
+	IssueAmountMismatch    = "amount_mismatch"    // 扣款金额与订单金额不一致
+	IssueUnexpectedConsume = "unexpected_consume" // 未支付或已取消的订单有扣款记录
+)
+
+// ErrWalletChanged 钱包在对账之后有新的变动，需要重新对账
+var ErrWalletChanged = errors.New("钱包在对账之后有变动，请重新对账")
+
+// ErrLedgerDisputed 交易流水与账本也不一致，不能按账本修复余额
+var ErrLedgerDisputed = errors.New("交易流水与账本不一致，需要人工核实")
+
+// WalletMismatch 余额与交易流水合计不一致的钱包
+type WalletMismatch struct {
+	WalletID     uint    `json:"wallet_id"`
+	UserID       string  `json:"user_id"`
+	Balance      float64 `json:"balance"`  // 钱包当前余额
+	Computed     float64 `json:"computed"` // 交易流水合计
+	Diff         float64 `json:"diff"`     // Balance - Computed
+	Transactions int64   `json:"transactions"`
+	Repaired     bool    `json:"repaired,omitempty"`
+}
+
+// OrderIssue 订单与扣款记录不一致
+type OrderIssue struct {
+	OrderID    string  `json:"order_id"`
+	UserID     string  `json:"user_id"`
+	Status     string  `json:"status"`
+	Kind       string  `json:"kind"`
+	TotalPrice float64 `json:"total_price"`
+	Count      int64   `json:"count"`  // 对应类型的交易条数
+	Amount     float64 `json:"amount"` // 对应类型的交易金额合计
+}
+
+// OrphanTransaction 找不到钱包或订单的交易
+type OrphanTransaction struct {
+	TransactionID uint    `json:"transaction_id"`
+	WalletID      uint    `json:"wallet_id"`
+	Type          string  `json:"type"`
+	OrderID       string  `json:"order_id"`
+	Amount        float64 `json:"amount"`
+	Reason        string  `json:"reason"`
+}
+
+// ReconcileReport 对账结果
+type ReconcileReport struct {
+	At           time.Time           `json:"at"`
+	Wallets      int                 `json:"wallets"`
+	Orders       int                 `json:"orders"`
+	Transactions int64               `json:"transactions"`
+	Mismatches   []WalletMismatch    `json:"mismatches"`
+	Ledger       []WalletMismatch    `json:"ledger"` // 余额与账本不一致的钱包，Computed 为账本余额
+	OrderIssues  []OrderIssue        `json:"order_issues"`
+	Orphans      []OrphanTransaction `json:"orphans"`
+}
+
+// OK 没有发现任何问题
+func (r *ReconcileReport) OK() bool {
+	return len(r.Mismatches) == 0 && len(r.Ledger) == 0 && len(r.OrderIssues) == 0 && len(r.Orphans) == 0
+}
+
+// MarkRepaired 标记钱包的余额、账本和交易流水已经一致，报告中该钱包的两类不一致都已解决
+func (r *ReconcileReport) MarkRepaired(walletID uint) {
+	for _, list := range [][]WalletMismatch{r.Mismatches, r.Ledger} {
+		for i := range list {
+			if list[i].WalletID == walletID {
+				list[i].Repaired = true
+			}
+		}
+	}
+}
+
+// Unresolved 未解决的问题数，已修复的钱包不计入
+func (r *ReconcileReport) Unresolved() int {
+	n := len(r.OrderIssues) + len(r.Orphans)
+	for _, list := range [][]WalletMismatch{r.Mismatches, r.Ledger} {
+		for _, m := range list {
+			if !m.Repaired {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Reconcile 核对钱包与交易流水：用交易流水重新计算每个钱包的余额，并与账本中的钱包科目比较，检查每个已支付的订单
+// 恰好有一条金额一致的扣款记录，并找出没有钱包或订单的交易。
+// 已删除的钱包和订单同样参与核对。只读，不修改数据
+func (l *RestaurantLogic) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	db := l.db.WithContext(ctx)
+	report := &ReconcileReport{
+		At:          time.Now(),
+		Mismatches:  make([]WalletMismatch, 0),
+		Ledger:      make([]WalletMismatch, 0),
+		OrderIssues: make([]OrderIssue, 0),
+		Orphans:     make([]OrphanTransaction, 0),
+	}
+
+	// 钱包余额
+	var wallets []model.Wallet
+	if err := db.Unscoped().Select("id", "user_id", "balance").Order("id").Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	var sums []struct {
+		WalletID uint
+		Total    float64
+		Count    int64
+	}
+	if err := db.Model(&model.Transaction{}).Select("wallet_id, COALESCE(SUM(amount), 0) AS total, COUNT(*) AS count").
+		Group("wallet_id").Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("汇总交易失败: %w", err)
+	}
+	type walletSum struct {
+		total float64
+		count int64
+	}
+	byWallet := make(map[uint]walletSum, len(sums))
+	for _, s := range sums {
+		byWallet[s.WalletID] = walletSum{s.Total, s.Count}
+		report.Transactions += s.Count
+	}
+	ledger, err := l.walletLedgerBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Wallets = len(wallets)
+	for _, w := range wallets {
+		if diff := cents(w.Balance) - cents(ledger[w.UserID]); diff != 0 {
+			report.Ledger = append(report.Ledger, WalletMismatch{
+				WalletID: w.ID,
+				UserID:   w.UserID,
+				Balance:  w.Balance,
+				Computed: ledger[w.UserID],
+				Diff:     float64(diff) / 100,
+			})
+		}
+
+		s := byWallet[w.ID]
+		if diff := cents(w.Balance) - cents(s.total); diff != 0 {
+			report.Mismatches = append(report.Mismatches, WalletMismatch{
+				WalletID:     w.ID,
+				UserID:       w.UserID,
+				Balance:      w.Balance,
+				Computed:     roundCents(s.total),
+				Diff:         float64(diff) / 100,
+				Transactions: s.count,
+			})
+		}
+	}
+
+	// 订单的扣款
+	var orders []model.Order
+	if err := db.Unscoped().Select("id", "user_id", "status", "total_price").Order("created_at").Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	var orderSums []struct {
+		OrderID string
+		Count   int64
+		Total   float64
+	}
+	if err := db.Model(&model.Transaction{}).Select("order_id, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS total").
+		Where("order_id <> '' AND type = ?", "consume").
+		Group("order_id").Scan(&orderSums).Error; err != nil {
+		return nil, fmt.Errorf("汇总订单交易失败: %w", err)
+	}
+	type orderSum struct {
+		count int64
+		total float64
+	}
+	consumes := make(map[string]orderSum, len(orderSums))
+	for _, s := range orderSums {
+		consumes[s.OrderID] = orderSum{s.Count, s.Total}
+	}
+
+	report.Orders = len(orders)
+	for _, o := range orders {
+		issue := func(kind string, s orderSum) {
+			report.OrderIssues = append(report.OrderIssues, OrderIssue{
+				OrderID:    o.ID,
+				UserID:     o.UserID,
+				Status:     o.Status,
+				Kind:       kind,
+				TotalPrice: o.TotalPrice,
+				Count:      s.count,
+				Amount:     roundCents(s.total),
+			})
+		}
+
+		consume := consumes[o.ID]
+		switch o.Status {
+		case "paid", "completed":
+			switch {
+			case consume.count == 0:
+				issue(IssueMissingConsume, consume)
+			case consume.count > 1:
+				issue(IssueDuplicateConsume, consume)
+			case cents(consume.total) != -cents(o.TotalPrice):
+				issue(IssueAmountMismatch, consume)
+			}
+		default:
+			if consume.count > 0 {
+				issue(IssueUnexpectedConsume, consume)
+			}
+		}
+	}
+
+	// 孤立交易
+	orphan := func(reason string, query *gorm.DB) error {
+		var transactions []model.Transaction
+		if err := query.Order("id").Find(&transactions).Error; err != nil {
+			return fmt.Errorf("查询孤立交易失败: %w", err)
+		}
+		for _, t := range transactions {
+			report.Orphans = append(report.Orphans, OrphanTransaction{
+				TransactionID: t.ID,
+				WalletID:      t.WalletID,
+				Type:          t.Type,
+				OrderID:       t.OrderID,
+				Amount:        t.Amount,
+				Reason:        reason,
+			})
+		}
+		return nil
+	}
+	walletIDs := l.db.Unscoped().Model(&model.Wallet{}).Select("id")
+	orderIDs := l.db.Unscoped().Model(&model.Order{}).Select("id")
+	if err := orphan("钱包不存在", db.Where("wallet_id NOT IN (?)", walletIDs)); err != nil {
+		return nil, err
+	}
+	if err := orphan("没有关联订单", db.Where("type IN ? AND (order_id IS NULL OR order_id = '')", []string{"consume", "refund"})); err != nil {
+		return nil, err
+	}
+	if err := orphan("订单不存在", db.Where("order_id <> '' AND order_id NOT IN (?)", orderIDs)); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// RepairWalletMismatch 按账本修复余额与账本不一致的钱包（m 取自对账报告的 Ledger）：账本和交易流水是两份独立的记录，
+// 两者一致而钱包余额不同，说明余额缓存丢失了更新，把余额改回账本余额并写入一条修复记录。
+// 修复不产生资金变动，账本不变。必须由管理员确认，交易流水与账本也不一致时返回 ErrLedgerDisputed，需要人工核实。
+// 写入前锁住钱包并重新核对，钱包在对账之后有变动时返回 ErrWalletChanged，不做修改
+func (l *RestaurantLogic) RepairWalletMismatch(ctx context.Context, m WalletMismatch, operatorID, remark string) (*model.WalletRepair, error) {
+	if operatorID == "" {
+		return nil, errors.New("修复钱包必须由管理员确认")
+	}
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Where("id = ? AND role = ?", operatorID, "manager").First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("管理员不存在: %w", err)
+	}
+	if remark == "" {
+		remark = "按账本修复余额"
+	}
+
+	repair := model.WalletRepair{
+		WalletID:      m.WalletID,
+		UserID:        m.UserID,
+		BalanceBefore: m.Balance,
+		BalanceAfter:  m.Computed,
+		OperatorID:    operatorID,
+		Remark:        remark,
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 余额不变时更新 updated_at，同时在事务结束前锁住这一行
+		result := tx.Unscoped().Model(&model.Wallet{}).Where("id = ? AND user_id = ? AND balance = ?", m.WalletID, m.UserID, m.Balance).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("锁定钱包失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWalletChanged
+		}
+
+		var ledger, total float64
+		if err := tx.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(m.UserID)).
+			Select("COALESCE(SUM(credit), 0) - COALESCE(SUM(debit), 0)").Scan(&ledger).Error; err != nil {
+			return fmt.Errorf("汇总钱包科目失败: %w", err)
+		}
+		if cents(ledger) == cents(m.Balance) || cents(ledger) != cents(m.Computed) {
+			return ErrWalletChanged
+		}
+		if err := tx.Model(&model.Transaction{}).Where("wallet_id = ?", m.WalletID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&total).Error; err != nil {
+			return fmt.Errorf("汇总交易失败: %w", err)
+		}
+		if cents(total) != cents(ledger) {
+			return ErrLedgerDisputed
+		}
+
+		if err := tx.Unscoped().Model(&model.Wallet{}).Where("id = ?", m.WalletID).
+			Update("balance", roundCents(ledger)).Error; err != nil {
+			return fmt.Errorf("修复钱包余额失败: %w", err)
+		}
+		if err := tx.Create(&repair).Error; err != nil {
+			return fmt.Errorf("记录钱包修复失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &repair, nil
+}
+
+// RepairTransactionMismatch 为余额与交易流水不一致的钱包（m 取自对账报告的 Mismatches）补记一条 adjust 调整交易：
+// 余额与账本一致而流水合计不同，说明交易流水缺了或改了记录，补记差额使流水合计等于余额，并写入一条修复记录。
+// 余额和账本都不变，所以不经过 postWallet，也不写凭证。必须由管理员确认，余额与账本也不一致时返回 ErrLedgerDisputed；
+// 写入前锁住钱包并重新核对，钱包在对账之后有变动时返回 ErrWalletChanged，不做修改
+func (l *RestaurantLogic) RepairTransactionMismatch(ctx context.Context, m WalletMismatch, operatorID, remark string) (*model.WalletRepair, error) {
+	if operatorID == "" {
+		return nil, errors.New("修复钱包必须由管理员确认")
+	}
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Where("id = ? AND role = ?", operatorID, "manager").First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("管理员不存在: %w", err)
+	}
+	if remark == "" {
+		remark = "补记交易流水"
+	}
+
+	repair := model.WalletRepair{
+		WalletID:      m.WalletID,
+		UserID:        m.UserID,
+		BalanceBefore: m.Balance,
+		BalanceAfter:  m.Balance,
+		OperatorID:    operatorID,
+		Remark:        remark,
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 余额不变时更新 updated_at，同时在事务结束前锁住这一行
+		result := tx.Unscoped().Model(&model.Wallet{}).Where("id = ? AND user_id = ? AND balance = ?", m.WalletID, m.UserID, m.Balance).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("锁定钱包失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWalletChanged
+		}
+
+		var ledger, total float64
+		if err := tx.Model(&model.Transaction{}).Where("wallet_id = ?", m.WalletID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&total).Error; err != nil {
+			return fmt.Errorf("汇总交易失败: %w", err)
+		}
+		if cents(total) == cents(m.Balance) || cents(total) != cents(m.Computed) {
+			return ErrWalletChanged
+		}
+		if err := tx.Model(&model.JournalLine{}).Where("account_id = ?", walletAccount(m.UserID)).
+			Select("COALESCE(SUM(credit), 0) - COALESCE(SUM(debit), 0)").Scan(&ledger).Error; err != nil {
+			return fmt.Errorf("汇总钱包科目失败: %w", err)
+		}
+		if cents(ledger) != cents(m.Balance) {
+			return ErrLedgerDisputed
+		}
+
+		if err := tx.Create(&model.Transaction{
+			WalletID: m.WalletID,
+			Type:     "adjust",
+			Amount:   float64(cents(m.Balance)-cents(total)) / 100,
+			Balance:  m.Balance,
+			Remark:   remark,
+		}).Error; err != nil {
+			return fmt.Errorf("记录调整交易失败: %w", err)
+		}
+		if err := tx.Create(&repair).Error; err != nil {
+			return fmt.Errorf("记录钱包修复失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &repair, nil
+}
+
+// cents 金额换算为分，避免浮点误差
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func roundCents(v float64) float64 {
+	return float64(cents(v)) / 100
+}
@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+func TestRepairWalletMismatchFromLedger(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.Worker{ID: "s1", Name: "员工", Role: "staff"},
+		&model.User{ID: "u1", Username: "u1"}, &model.User{ID: "u2", Username: "u2"},
+	)
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := l.ChargeWallet(ctx, userID, 50, ChargeSourcePayment, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// u1 的余额丢失了一次更新；u2 的交易流水也被改过，账本与流水不一致
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u1").Update("balance", 30)
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u2").Update("balance", 40)
+	db.Model(&model.Transaction{}).Where("amount = ?", 50).Where("wallet_id = (?)",
+		db.Model(&model.Wallet{}).Select("id").Where("user_id = ?", "u2")).Update("amount", 40)
+
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Ledger) != 2 {
+		t.Fatalf("账本不一致 = %+v", report.Ledger)
+	}
+	u1, u2 := report.Ledger[0], report.Ledger[1]
+	if u1.UserID != "u1" || u1.Computed != 50 {
+		t.Fatalf("u1 = %+v", u1)
+	}
+
+	// 必须由管理员确认
+	for _, operatorID := range []string{"", "s1", "nobody"} {
+		if _, err := l.RepairWalletMismatch(ctx, u1, operatorID, ""); err == nil {
+			t.Errorf("operator %q 应被拒绝", operatorID)
+		}
+	}
+	if _, err := l.RepairWalletMismatch(ctx, u2, "m1", ""); !errors.Is(err, ErrLedgerDisputed) {
+		t.Fatalf("流水与账本不一致时 err = %v，应为 ErrLedgerDisputed", err)
+	}
+
+	repair, err := l.RepairWalletMismatch(ctx, u1, "m1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repair.BalanceBefore != 30 || repair.BalanceAfter != 50 || repair.OperatorID != "m1" {
+		t.Fatalf("修复记录 = %+v", repair)
+	}
+	if _, err := l.RepairWalletMismatch(ctx, u1, "m1", ""); !errors.Is(err, ErrWalletChanged) {
+		t.Fatalf("重复修复 err = %v，应为 ErrWalletChanged", err)
+	}
+
+	after, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range append(after.Ledger, after.Mismatches...) {
+		if m.UserID == "u1" {
+			t.Fatalf("修复后 u1 仍不一致: %+v", m)
+		}
+	}
+	var count int64
+	db.Model(&model.Transaction{}).Where("type = ?", "adjust").Count(&count)
+	if count != 0 {
+		t.Fatalf("修复写入了 %d 条调整交易", count)
+	}
+}
+
+func TestRepairTransactionMismatch(t *testing.T) {
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.Worker{ID: "s1", Name: "员工", Role: "staff"},
+		&model.User{ID: "u1", Username: "u1"}, &model.User{ID: "u2", Username: "u2"},
+	)
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := l.ChargeWallet(ctx, userID, 50, ChargeSourcePayment, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.ChargeWallet(ctx, userID, 20, ChargeSourcePayment, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// u1 的一条交易流水丢了，余额与账本一致；u2 丢了流水，余额也被改过，三者互不一致
+	walletOf := func(userID string) *gorm.DB {
+		return db.Model(&model.Wallet{}).Select("id").Where("user_id = ?", userID)
+	}
+	db.Where("amount = ? AND wallet_id IN (?)", 20, walletOf("u1")).Delete(&model.Transaction{})
+	db.Where("amount = ? AND wallet_id IN (?)", 20, walletOf("u2")).Delete(&model.Transaction{})
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u2").Update("balance", 60)
+
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Mismatches) != 2 {
+		t.Fatalf("余额与流水不一致 = %+v", report.Mismatches)
+	}
+	u1, u2 := report.Mismatches[0], report.Mismatches[1]
+	if u1.UserID != "u1" || u1.Balance != 70 || u1.Computed != 50 {
+		t.Fatalf("u1 = %+v", u1)
+	}
+
+	if _, err := l.RepairTransactionMismatch(ctx, u1, "s1", ""); err == nil {
+		t.Fatal("员工不能确认修复")
+	}
+	if _, err := l.RepairTransactionMismatch(ctx, u2, "m1", ""); !errors.Is(err, ErrLedgerDisputed) {
+		t.Fatalf("余额与账本不一致时 err = %v，应为 ErrLedgerDisputed", err)
+	}
+
+	repair, err := l.RepairTransactionMismatch(ctx, u1, "m1", "补记丢失的充值")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repair.BalanceBefore != 70 || repair.BalanceAfter != 70 || repair.OperatorID != "m1" {
+		t.Fatalf("修复记录 = %+v", repair)
+	}
+	var adjust model.Transaction
+	if err := db.Where("type = ?", "adjust").First(&adjust).Error; err != nil {
+		t.Fatal(err)
+	}
+	if adjust.Amount != 20 || adjust.Balance != 70 || adjust.Remark != "补记丢失的充值" {
+		t.Fatalf("调整交易 = %+v", adjust)
+	}
+	if _, err := l.RepairTransactionMismatch(ctx, u1, "m1", ""); !errors.Is(err, ErrWalletChanged) {
+		t.Fatalf("重复修复 err = %v，应为 ErrWalletChanged", err)
+	}
+
+	// 余额和账本都没有变，u1 恢复一致
+	after, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range append(after.Ledger, after.Mismatches...) {
+		if m.UserID == "u1" {
+			t.Fatalf("修复后 u1 仍不一致: %+v", m)
+		}
+	}
+	var entries int64
+	db.Model(&model.JournalEntry{}).Count(&entries)
+	if entries != 4 {
+		t.Fatalf("凭证 %d 条，修复不应写凭证", entries)
+	}
+}
@@ -4,62 +4,115 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/anomaly"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/forecast"
+	"github.com/p-program/Fenrir/internal/tenant"
 	"github.com/p-program/Fenrir/model"
 	"gorm.io/gorm"
 )
 
+// defaultLowBalanceThreshold 默认的低余额提醒阈值
+const defaultLowBalanceThreshold = 10.0
+
+// maxLabelPlates 单次最多生成的标签数（A4 每页 21 个，约 24 页），防止一个请求渲染整个餐盘库
+const maxLabelPlates = 500
+
 // RestaurantLogic 餐厅业务逻辑
 type RestaurantLogic struct {
-	db *gorm.DB
+	db          *gorm.DB
+	bus         *event.Bus
+	lowBalance  float64
+	anomaly     anomaly.Rules
+	lowStock    float64
+	periods     MealPeriods
+	forecast    forecast.Config
+	historyDays int
+
+	notifyDefaults []string
 }
 
 // NewRestaurantLogic 创建餐厅业务逻辑实例
 func NewRestaurantLogic(db *gorm.DB) *RestaurantLogic {
-	return &RestaurantLogic{db: db}
+	return &RestaurantLogic{
+		db:          db,
+		lowBalance:  defaultLowBalanceThreshold,
+		anomaly:     anomaly.DefaultRules,
+		lowStock:    defaultLowStock,
+		periods:     MealPeriods{LunchStart: defaultLunchStart, DinnerStart: defaultDinnerStart},
+		forecast:    forecast.DefaultConfig,
+		historyDays: defaultHistoryDays,
+
+		notifyDefaults: []string{ChannelInbox},
+	}
+}
+
+// WithAnomalyRules 设置称重读数异常检测规则
+func (l *RestaurantLogic) WithAnomalyRules(rules anomaly.Rules) *RestaurantLogic {
+	l.anomaly = rules
+	return l
 }
 
-// ChargeWallet 钱包充值
-func (l *RestaurantLogic) ChargeWallet(ctx context.Context, userID string, amount float64) (*model.Wallet, error) {
+// WithLowBalanceThreshold 设置低余额提醒阈值
+func (l *RestaurantLogic) WithLowBalanceThreshold(threshold float64) *RestaurantLogic {
+	l.lowBalance = threshold
+	return l
+}
+
+// WithBus 设置事件总线，修改数据的操作在事务提交后发布事件
+func (l *RestaurantLogic) WithBus(bus *event.Bus) *RestaurantLogic {
+	l.bus = bus
+	return l
+}
+
+// ChargeWallet 钱包充值，source 为 payment（用户支付）或 subsidy（学校补贴），补贴可以设置到期时间 expiresAt
+func (l *RestaurantLogic) ChargeWallet(ctx context.Context, userID string, amount float64, source string, expiresAt *time.Time) (*model.Wallet, error) {
 	if amount <= 0 {
 		return nil, errors.New("充值金额必须大于0")
 	}
+	if expiresAt != nil && source != ChargeSourceSubsidy {
+		return nil, errors.New("只有补贴可以设置有效期")
+	}
+
+	entry := model.JournalEntry{Kind: "charge", Remark: "钱包充值"}
+	from := AccountClearing
+	switch source {
+	case "", ChargeSourcePayment:
+	case ChargeSourceSubsidy:
+		entry = model.JournalEntry{Kind: "subsidy", Remark: "补贴发放"}
+		from = AccountSubsidy
+	default:
+		return nil, fmt.Errorf("未知的充值来源: %s", source)
+	}
 
 	var wallet model.Wallet
-	err := l.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 创建新钱包
-			wallet = model.Wallet{
-				UserID:  userID,
-				Balance: amount,
-			}
-			if err := l.db.WithContext(ctx).Create(&wallet).Error; err != nil {
-				return nil, fmt.Errorf("创建钱包失败: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("查询钱包失败: %w", err)
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 钱包不存在时创建
+		if err := tx.Where(model.Wallet{UserID: userID}).FirstOrCreate(&wallet).Error; err != nil {
+			return fmt.Errorf("查询钱包失败: %w", err)
 		}
-	} else {
-		// 更新余额
-		wallet.Balance += amount
-		if err := l.db.WithContext(ctx).Save(&wallet).Error; err != nil {
-			return nil, fmt.Errorf("更新钱包失败: %w", err)
+		if walletClosing(wallet.Status) {
+			return ErrWalletClosed
 		}
-	}
-
-	// 记录交易
-	transaction := model.Transaction{
-		WalletID: wallet.ID,
-		Type:     "charge",
-		Amount:   amount,
-		Balance:  wallet.Balance,
-		Remark:   "钱包充值",
-	}
-	if err := l.db.WithContext(ctx).Create(&transaction).Error; err != nil {
-		return nil, fmt.Errorf("记录交易失败: %w", err)
+		_, err := postWallet(tx, &wallet, walletPosting{
+			Entry:     entry,
+			Lines:     transfer(from, walletAccount(userID), amount),
+			ExpiresAt: expiresAt,
+			Guard:     "status IS NULL OR status = ?",
+			GuardArgs: []interface{}{WalletActive},
+		})
+		if errors.Is(err, errWalletGuard) {
+			return ErrWalletClosed
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &wallet, nil
@@ -75,6 +128,42 @@ func (l *RestaurantLogic) GetUserInfo(ctx context.Context, userID string) (*mode
 	return &user, nil
 }
 
+// ActiveUser 查询可以登录的用户，用于签发用户令牌：已删除、已销户或个人信息已匿名化的用户不能登录
+func (l *RestaurantLogic) ActiveUser(ctx context.Context, userID string) (*model.User, error) {
+	var user model.User
+	if err := l.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %w", err)
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+	if _, err := l.GetAccountClosure(ctx, userID); err == nil {
+		return nil, ErrAccountClosed
+	}
+	return &user, nil
+}
+
+// CreateUser 创建用户，id 为空时自动生成
+func (l *RestaurantLogic) CreateUser(ctx context.Context, id, username, phone, email string) (*model.User, error) {
+	if username == "" {
+		return nil, errors.New("用户名不能为空")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	user := model.User{
+		ID:       id,
+		Username: username,
+		Phone:    phone,
+		Email:    email,
+	}
+	if err := l.db.WithContext(ctx).Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+	return &user, nil
+}
+
 // BindPlate 绑定餐盘
 func (l *RestaurantLogic) BindPlate(ctx context.Context, userID string, plateID string) (*model.Plate, error) {
 	// 检查用户是否存在
@@ -82,10 +171,17 @@ func (l *RestaurantLogic) BindPlate(ctx context.Context, userID string, plateID
 	if err := l.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
 		return nil, fmt.Errorf("用户不存在: %w", err)
 	}
+	var wallet model.Wallet
+	if err := l.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("查询钱包失败: %w", err)
+	}
+	if walletClosing(wallet.Status) {
+		return nil, ErrWalletClosed
+	}
 
 	// 检查餐盘是否存在
 	var plate model.Plate
-	if err := l.db.WithContext(ctx).Where("id = ?", plateID).First(&plate).Error; err != nil {
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", plateID).First(&plate).Error; err != nil {
 		return nil, fmt.Errorf("餐盘不存在: %w", err)
 	}
 
@@ -94,52 +190,127 @@ func (l *RestaurantLogic) BindPlate(ctx context.Context, userID string, plateID
 		return nil, errors.New("餐盘已被其他用户绑定")
 	}
 
-	// 如果用户已有绑定的餐盘，先解绑
-	var existingPlate model.Plate
-	if err := l.db.WithContext(ctx).Where("bound_user_id = ? AND is_bound = ?", userID, true).First(&existingPlate).Error; err == nil {
-		existingPlate.IsBound = false
-		existingPlate.BoundUserID = ""
-		existingPlate.BoundAt = nil
-		l.db.WithContext(ctx).Save(&existingPlate)
-	}
-
 	// 绑定餐盘
 	now := time.Now()
+	prevStatus, wasBound := plate.Status, plate.IsBound
+	var events []event.Event
 	plate.IsBound = true
 	plate.BoundUserID = userID
 	plate.BoundAt = &now
 	plate.Status = "in_use"
 
-	if err := l.db.WithContext(ctx).Save(&plate).Error; err != nil {
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 如果用户已有绑定的餐盘，先解绑
+		var existingPlate model.Plate
+		if err := tx.Where("bound_user_id = ? AND is_bound = ? AND id <> ?", userID, true, plateID).First(&existingPlate).Error; err == nil {
+			existingPlate.IsBound = false
+			existingPlate.BoundUserID = ""
+			existingPlate.BoundAt = nil
+			if err := tx.Save(&existingPlate).Error; err != nil {
+				return err
+			}
+			if err := recordPlateEvent(tx, model.PlateEvent{
+				PlateID:    existingPlate.ID,
+				Type:       "unbind",
+				PrevStatus: existingPlate.Status,
+				NewStatus:  existingPlate.Status,
+				ActorType:  "user",
+				ActorID:    userID,
+				UserID:     userID,
+				Remark:     "绑定新餐盘时自动解绑",
+			}); err != nil {
+				return err
+			}
+			events = append(events, event.Event{
+				CanteenID:  existingPlate.CanteenID,
+				Type:       event.PlateUnbound,
+				UserID:     userID,
+				PlateID:    existingPlate.ID,
+				PrevStatus: existingPlate.Status,
+				NewStatus:  existingPlate.Status,
+				WasBound:   true,
+			})
+		}
+
+		if err := tx.Save(&plate).Error; err != nil {
+			return err
+		}
+		return recordPlateEvent(tx, model.PlateEvent{
+			PlateID:    plate.ID,
+			Type:       "bind",
+			PrevStatus: prevStatus,
+			NewStatus:  plate.Status,
+			ActorType:  "user",
+			ActorID:    userID,
+			UserID:     userID,
+		})
+	})
+	if err != nil {
 		return nil, fmt.Errorf("绑定餐盘失败: %w", err)
 	}
 
+	l.bus.Publish(append(events, event.Event{
+		CanteenID:  plate.CanteenID,
+		Type:       event.PlateBound,
+		UserID:     userID,
+		PlateID:    plate.ID,
+		PrevStatus: prevStatus,
+		NewStatus:  plate.Status,
+		WasBound:   wasBound,
+		IsBound:    true,
+	})...)
+
 	return &plate, nil
 }
 
 // UnbindPlate 解绑餐盘
 func (l *RestaurantLogic) UnbindPlate(ctx context.Context, userID string, plateID string) error {
 	var plate model.Plate
-	if err := l.db.WithContext(ctx).Where("id = ? AND bound_user_id = ?", plateID, userID).First(&plate).Error; err != nil {
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ? AND bound_user_id = ?", plateID, userID).First(&plate).Error; err != nil {
 		return fmt.Errorf("餐盘不存在或未绑定: %w", err)
 	}
 
+	prevStatus, wasBound := plate.Status, plate.IsBound
 	plate.IsBound = false
 	plate.BoundUserID = ""
 	plate.BoundAt = nil
 	plate.Status = "available"
 
-	if err := l.db.WithContext(ctx).Save(&plate).Error; err != nil {
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&plate).Error; err != nil {
+			return err
+		}
+		return recordPlateEvent(tx, model.PlateEvent{
+			PlateID:    plate.ID,
+			Type:       "unbind",
+			PrevStatus: prevStatus,
+			NewStatus:  plate.Status,
+			ActorType:  "user",
+			ActorID:    userID,
+			UserID:     userID,
+		})
+	})
+	if err != nil {
 		return fmt.Errorf("解绑餐盘失败: %w", err)
 	}
 
+	l.bus.Publish(event.Event{
+		CanteenID:  plate.CanteenID,
+		Type:       event.PlateUnbound,
+		UserID:     userID,
+		PlateID:    plate.ID,
+		PrevStatus: prevStatus,
+		NewStatus:  plate.Status,
+		WasBound:   wasBound,
+	})
+
 	return nil
 }
 
 // GetPlateInfo 获取餐盘信息
 func (l *RestaurantLogic) GetPlateInfo(ctx context.Context, plateID string) (*model.Plate, error) {
 	var plate model.Plate
-	err := l.db.WithContext(ctx).Preload("BoundUser").Where("id = ?", plateID).First(&plate).Error
+	err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("BoundUser").Where("id = ?", plateID).First(&plate).Error
 	if err != nil {
 		return nil, fmt.Errorf("查询餐盘失败: %w", err)
 	}
@@ -149,7 +320,7 @@ func (l *RestaurantLogic) GetPlateInfo(ctx context.Context, plateID string) (*mo
 // GetPlateList 获取餐盘列表
 func (l *RestaurantLogic) GetPlateList(ctx context.Context, isBound *bool) ([]model.Plate, error) {
 	var plates []model.Plate
-	query := l.db.WithContext(ctx).Preload("BoundUser")
+	query := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("BoundUser")
 
 	if isBound != nil {
 		query = query.Where("is_bound = ?", *isBound)
@@ -161,109 +332,315 @@ func (l *RestaurantLogic) GetPlateList(ctx context.Context, isBound *bool) ([]mo
 	return plates, nil
 }
 
+// GetPlatesForLabels 获取需要打印标签的餐盘，支持ID列表或ID区间（闭区间，按字典序）
+func (l *RestaurantLogic) GetPlatesForLabels(ctx context.Context, plateIDs []string, startID, endID string) ([]model.Plate, error) {
+	var plates []model.Plate
+	query := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Order("id ASC")
+
+	switch {
+	case len(plateIDs) > maxLabelPlates:
+		return nil, fmt.Errorf("单次最多生成 %d 个标签", maxLabelPlates)
+	case len(plateIDs) > 0:
+		query = query.Where("id IN ?", plateIDs)
+	case startID != "" && endID != "":
+		query = query.Where("id BETWEEN ? AND ?", startID, endID)
+	default:
+		return nil, errors.New("请指定餐盘ID列表或ID区间")
+	}
+
+	// 多查一条用来判断区间是否超出上限
+	if err := query.Limit(maxLabelPlates + 1).Find(&plates).Error; err != nil {
+		return nil, fmt.Errorf("查询餐盘列表失败: %w", err)
+	}
+	if len(plates) > maxLabelPlates {
+		return nil, fmt.Errorf("单次最多生成 %d 个标签，请缩小ID区间", maxLabelPlates)
+	}
+	if len(plates) == 0 {
+		return nil, errors.New("没有符合条件的餐盘")
+	}
+	return plates, nil
+}
+
 // CreateOrder 创建订单
 func (l *RestaurantLogic) CreateOrder(ctx context.Context, userID string, plateID string, foods []OrderFood) (*model.Order, error) {
 	// 检查用户和餐盘绑定关系
 	var plate model.Plate
-	if err := l.db.WithContext(ctx).Where("id = ? AND bound_user_id = ? AND is_bound = ?", plateID, userID, true).First(&plate).Error; err != nil {
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ? AND bound_user_id = ? AND is_bound = ?", plateID, userID, true).First(&plate).Error; err != nil {
 		return nil, fmt.Errorf("餐盘未绑定或绑定关系不正确: %w", err)
 	}
 
-	// 检查用户钱包
-	var wallet model.Wallet
-	if err := l.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return nil, fmt.Errorf("用户钱包不存在: %w", err)
+	items, totalPrice, err := l.buildOrderItems(ctx, &plate, foods)
+	if err != nil {
+		return nil, err
 	}
+	return l.placeOrder(ctx, &plate, items, totalPrice, "user", userID, false, nil)
+}
 
-	// 计算订单总价
+// buildOrderItems 按菜品单价和重量计算订单明细，菜品必须与餐盘属于同一食堂
+func (l *RestaurantLogic) buildOrderItems(ctx context.Context, plate *model.Plate, foods []OrderFood) ([]model.OrderItem, float64, error) {
 	var totalPrice float64
 	var orderItems []model.OrderItem
 
 	for _, foodReq := range foods {
 		var food model.Food
-		if err := l.db.WithContext(ctx).Where("id = ?", foodReq.FoodID).First(&food).Error; err != nil {
-			return nil, fmt.Errorf("食物不存在: %s, %w", foodReq.FoodID, err)
+		if err := l.db.WithContext(ctx).Scopes(sameCanteen(plate.CanteenID)).Where("id = ?", foodReq.FoodID).First(&food).Error; err != nil {
+			return nil, 0, fmt.Errorf("食物不存在: %s, %w", foodReq.FoodID, err)
 		}
 
-		if !food.IsAvailable {
-			return nil, fmt.Errorf("食物不可用: %s", food.Name)
+		// 档口称重和打汤机上报时菜已经打出，照常计费，不因为库存刚好扣完、菜品下架而漏单
+		if !food.IsAvailable && foodReq.StationID == "" && foodReq.CauldronID == "" {
+			return nil, 0, fmt.Errorf("食物不可用: %s", food.Name)
 		}
 
-		weight := foodReq.Weight
-		if weight <= 0 {
-			weight = 100 // 默认100克
+		item := model.OrderItem{
+			FoodID:     food.ID,
+			FoodName:   food.Name,
+			Unit:       "g",
+			StationID:  foodReq.StationID,
+			CauldronID: foodReq.CauldronID,
+		}
+		if food.Category == soupCategory {
+			var soup model.Soup
+			if err := l.db.WithContext(ctx).Where("id = ?", food.ID).First(&soup).Error; err != nil {
+				return nil, 0, fmt.Errorf("汤品不存在: %s, %w", food.Name, err)
+			}
+			item.Unit = "ml"
+			item.UnitPrice = soup.UnitPrice
+			item.Weight, item.Price = soupCharge(&soup, foodReq.Weight, foodReq.Ladles)
+			// 直接下单（不经打汤机或档口称重）的汤从正在供应这种汤的汤锅里打，下单时扣减汤量
+			if item.CauldronID == "" && item.StationID == "" {
+				var cauldron model.Cauldron
+				if err := l.db.WithContext(ctx).Scopes(sameCanteen(plate.CanteenID)).
+					Where("soup_id = ? AND status = ?", food.ID, "serving").Order("level DESC").
+					First(&cauldron).Error; err != nil {
+					return nil, 0, fmt.Errorf("没有汤锅正在供应: %s, %w", food.Name, err)
+				}
+				item.CauldronID = cauldron.ID
+				item.StationID = cauldron.StationID
+			}
+		} else {
+			item.Weight = foodReq.Weight
+			if item.Weight <= 0 {
+				item.Weight = 100 // 默认100克
+			}
+			item.UnitPrice = food.Price
+			item.Price = (food.Price / 100.0) * item.Weight
 		}
 
-		itemPrice := (food.Price / 100.0) * weight
-		totalPrice += itemPrice
-
-		orderItems = append(orderItems, model.OrderItem{
-			FoodID:    food.ID,
-			FoodName:  food.Name,
-			Weight:    weight,
-			UnitPrice: food.Price,
-			Price:     itemPrice,
-		})
-	}
-
-	// 检查余额
-	if wallet.Balance < totalPrice {
-		return nil, fmt.Errorf("余额不足，当前余额: %.2f, 需要: %.2f", wallet.Balance, totalPrice)
+		totalPrice += item.Price
+		orderItems = append(orderItems, item)
 	}
+	return orderItems, totalPrice, nil
+}
 
-	// 创建订单
-	orderID := uuid.New().String()
+// placeOrder 为餐盘的持有人创建订单并扣款，同时扣减库存和汤锅的汤量
+// hold 为 true 时订单挂起（status=held）等待审核，暂不扣款；
+// extra 在同一事务中执行，用于写入与订单关联的记录。actorType/actorID 记录到餐盘事件中。
+func (l *RestaurantLogic) placeOrder(ctx context.Context, plate *model.Plate, items []model.OrderItem, totalPrice float64,
+	actorType, actorID string, hold bool, extra func(tx *gorm.DB, order *model.Order) error) (*model.Order, error) {
 	order := model.Order{
-		ID:         orderID,
-		UserID:     userID,
-		PlateID:    plateID,
+		ID:         uuid.New().String(),
+		CanteenID:  plate.CanteenID,
+		UserID:     plate.BoundUserID,
+		PlateID:    plate.ID,
 		TotalPrice: totalPrice,
 		Status:     "pending",
 	}
+	remark := ""
+	if hold {
+		order.Status = "held"
+		remark = "读数可疑，订单待审核"
+	}
 
-	if err := l.db.WithContext(ctx).Create(&order).Error; err != nil {
-		return nil, fmt.Errorf("创建订单失败: %w", err)
+	var balance float64
+	var stockEvents []event.Event
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return fmt.Errorf("创建订单失败: %w", err)
+		}
+
+		// 创建订单明细
+		for i := range items {
+			items[i].OrderID = order.ID
+			items[i].Held = hold
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return fmt.Errorf("创建订单明细失败: %w", err)
+		}
+
+		// 挂起的订单也扣减库存：菜已经打到餐盘里，驳回时再加回
+		var err error
+		if stockEvents, err = l.consumeStock(tx, &order, items); err != nil {
+			return err
+		}
+		deviceID := ""
+		if actorType == "device" {
+			deviceID = actorID
+		}
+		if err := drawCauldrons(tx, &order, items, deviceID); err != nil {
+			return err
+		}
+
+		if !hold {
+			if balance, err = l.chargeOrder(tx, &order); err != nil {
+				return err
+			}
+		}
+
+		if err := recordPlateEvent(tx, model.PlateEvent{
+			PlateID:    plate.ID,
+			Type:       "order",
+			PrevStatus: plate.Status,
+			NewStatus:  plate.Status,
+			ActorType:  actorType,
+			ActorID:    actorID,
+			UserID:     plate.BoundUserID,
+			OrderID:    order.ID,
+			Remark:     remark,
+		}); err != nil {
+			return err
+		}
+
+		if extra != nil {
+			return extra(tx, &order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 创建订单明细
-	for i := range orderItems {
-		orderItems[i].OrderID = orderID
+	if !hold {
+		l.publishOrder(ctx, plate, &order, items, balance)
 	}
-	if err := l.db.WithContext(ctx).Create(&orderItems).Error; err != nil {
-		return nil, fmt.Errorf("创建订单明细失败: %w", err)
+	l.bus.Publish(stockEvents...)
+
+	// 加载关联数据
+	if err := l.db.WithContext(ctx).Preload("OrderItems").Preload("User").Preload("Plate").Where("id = ?", order.ID).First(&order).Error; err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	return &order, nil
+}
+
+// chargeOrder 从用户钱包扣除订单金额、记录交易并把订单标记为已支付，返回扣款后的余额。
+// 支付通知和低余额提醒在同一个事务中写入
+func (l *RestaurantLogic) chargeOrder(tx *gorm.DB, order *model.Order) (float64, error) {
+	// 检查用户钱包
+	var wallet model.Wallet
+	if err := tx.Where("user_id = ?", order.UserID).First(&wallet).Error; err != nil {
+		return 0, fmt.Errorf("用户钱包不存在: %w", err)
 	}
 
-	// 扣款
-	wallet.Balance -= totalPrice
-	if err := l.db.WithContext(ctx).Save(&wallet).Error; err != nil {
-		return nil, fmt.Errorf("扣款失败: %w", err)
+	if walletClosing(wallet.Status) {
+		return 0, ErrWalletClosed
 	}
 
-	// 记录交易
-	transaction := model.Transaction{
-		WalletID: wallet.ID,
-		Type:     "consume",
-		Amount:   -totalPrice,
-		Balance:  wallet.Balance,
-		OrderID:  orderID,
-		Remark:   "订单消费",
+	// 检查余额
+	if wallet.Balance < order.TotalPrice {
+		return 0, fmt.Errorf("余额不足，当前余额: %.2f, 需要: %.2f", wallet.Balance, order.TotalPrice)
+	}
+
+	// 扣款并记账：用户钱包转入食堂营收。带上余额和状态条件，避免并发扣款透支或在销户冻结后扣款
+	_, err := postWallet(tx, &wallet, walletPosting{
+		Entry:     model.JournalEntry{Kind: "consume", OrderID: order.ID, Remark: "订单消费"},
+		Lines:     transfer(walletAccount(order.UserID), revenueAccount(order.CanteenID), order.TotalPrice),
+		Guard:     "balance >= ? AND (status IS NULL OR status = ?)",
+		GuardArgs: []interface{}{order.TotalPrice, WalletActive},
+	})
+	if errors.Is(err, errWalletGuard) {
+		if err := tx.Where("id = ?", wallet.ID).First(&wallet).Error; err != nil {
+			return 0, fmt.Errorf("查询钱包失败: %w", err)
+		}
+		if walletClosing(wallet.Status) {
+			return 0, ErrWalletClosed
+		}
+		return 0, fmt.Errorf("余额不足，当前余额: %.2f, 需要: %.2f", wallet.Balance, order.TotalPrice)
 	}
-	if err := l.db.WithContext(ctx).Create(&transaction).Error; err != nil {
-		return nil, fmt.Errorf("记录交易失败: %w", err)
+	if err != nil {
+		return 0, err
 	}
 
 	// 更新订单状态
 	order.Status = "paid"
-	if err := l.db.WithContext(ctx).Save(&order).Error; err != nil {
-		return nil, fmt.Errorf("更新订单状态失败: %w", err)
+	if err := tx.Model(order).Update("status", order.Status).Error; err != nil {
+		return 0, fmt.Errorf("更新订单状态失败: %w", err)
+	}
+
+	notifications := []model.NotificationEvent{{
+		UserID:  order.UserID,
+		Kind:    NotifyOrderPaid,
+		OrderID: order.ID,
+		PlateID: order.PlateID,
+		Amount:  order.TotalPrice,
+		Balance: wallet.Balance,
+	}}
+	// 只在余额跌破阈值的那一次提醒
+	if wallet.Balance < l.lowBalance && wallet.Balance+order.TotalPrice >= l.lowBalance {
+		notifications = append(notifications, model.NotificationEvent{
+			UserID:  order.UserID,
+			Kind:    NotifyLowBalance,
+			OrderID: order.ID,
+			Amount:  order.TotalPrice,
+			Balance: wallet.Balance,
+		})
 	}
-
-	// 加载关联数据
-	if err := l.db.WithContext(ctx).Preload("OrderItems").Preload("User").Preload("Plate").Where("id = ?", orderID).First(&order).Error; err != nil {
-		return nil, fmt.Errorf("查询订单失败: %w", err)
+	if err := queueNotificationEvents(tx, notifications...); err != nil {
+		return 0, err
 	}
+	return wallet.Balance, nil
+}
 
-	return &order, nil
+// publishOrder 发布订单相关事件：逐个菜品（带本次用餐累计金额）、支付完成以及低余额提醒
+func (l *RestaurantLogic) publishOrder(ctx context.Context, plate *model.Plate, order *model.Order, items []model.OrderItem, balance float64) {
+	if l.bus == nil {
+		return
+	}
+
+	// 本次绑定餐盘以来已支付的金额
+	var sessionTotal float64
+	query := l.db.WithContext(ctx).Model(&model.Order{}).
+		Where("plate_id = ? AND user_id = ? AND status = ? AND id <> ?", order.PlateID, order.UserID, "paid", order.ID)
+	if plate.BoundAt != nil {
+		query = query.Where("created_at >= ?", *plate.BoundAt)
+	}
+	query.Select("COALESCE(SUM(total_price), 0)").Scan(&sessionTotal)
+
+	events := make([]event.Event, 0, len(items)+2)
+	for _, item := range items {
+		sessionTotal += item.Price
+		events = append(events, event.Event{
+			CanteenID: order.CanteenID,
+			Type:      event.ItemAdded,
+			UserID:    order.UserID,
+			PlateID:   order.PlateID,
+			OrderID:   order.ID,
+			FoodName:  item.FoodName,
+			Weight:    item.Weight,
+			Amount:    item.Price,
+			Total:     sessionTotal,
+		})
+	}
+	events = append(events, event.Event{
+		CanteenID: order.CanteenID,
+		Type:      event.OrderPaid,
+		UserID:    order.UserID,
+		PlateID:   order.PlateID,
+		OrderID:   order.ID,
+		Amount:    order.TotalPrice,
+		Total:     sessionTotal,
+		Balance:   balance,
+	})
+	if balance < l.lowBalance {
+		events = append(events, event.Event{
+			CanteenID: order.CanteenID,
+			Type:      event.LowBalance,
+			UserID:    order.UserID,
+			Amount:    order.TotalPrice, // 本次扣款，用于判断是否刚跌破阈值
+			Balance:   balance,
+		})
+	}
+	l.bus.Publish(events...)
 }
 
 // GetUserOrders 获取用户订单列表
@@ -273,7 +650,7 @@ func (l *RestaurantLogic) GetUserOrders(ctx context.Context, userID string, page
 
 	offset := (page - 1) * pageSize
 
-	query := l.db.WithContext(ctx).Where("user_id = ?", userID)
+	query := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("user_id = ?", userID)
 
 	// 获取总数
 	if err := query.Model(&model.Order{}).Count(&total).Error; err != nil {
@@ -295,7 +672,7 @@ func (l *RestaurantLogic) GetUserOrders(ctx context.Context, userID string, page
 // GetOrderInfo 获取订单信息
 func (l *RestaurantLogic) GetOrderInfo(ctx context.Context, orderID string) (*model.Order, error) {
 	var order model.Order
-	err := l.db.WithContext(ctx).Preload("OrderItems").Preload("User").Preload("Plate").
+	err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("OrderItems").Preload("User").Preload("Plate").
 		Where("id = ?", orderID).First(&order).Error
 	if err != nil {
 		return nil, fmt.Errorf("查询订单失败: %w", err)
@@ -303,10 +680,108 @@ func (l *RestaurantLogic) GetOrderInfo(ctx context.Context, orderID string) (*mo
 	return &order, nil
 }
 
+// AutoUnbindIdlePlates 解绑 idleSince 之后既没有绑定也没有下单的餐盘，返回解绑的数量。
+// 与绑定新餐盘时的自动解绑一样只解除绑定，餐盘状态不变，由工作人员回收。
+func (l *RestaurantLogic) AutoUnbindIdlePlates(ctx context.Context, idleSince time.Time) (int, error) {
+	var plates []model.Plate
+	if err := l.db.WithContext(ctx).Where("is_bound = ? AND bound_at < ?", true, idleSince).Find(&plates).Error; err != nil {
+		return 0, fmt.Errorf("查询已绑定餐盘失败: %w", err)
+	}
+
+	count := 0
+	for _, plate := range plates {
+		var recent int64
+		if err := l.db.WithContext(ctx).Model(&model.Order{}).
+			Where("plate_id = ? AND user_id = ? AND created_at >= ?", plate.ID, plate.BoundUserID, idleSince).
+			Count(&recent).Error; err != nil {
+			return count, fmt.Errorf("查询餐盘订单失败: %w", err)
+		}
+		if recent > 0 {
+			continue
+		}
+
+		unbound := false
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 带上绑定条件，用户刚好在此时解绑或重新绑定时跳过
+			result := tx.Model(&model.Plate{}).
+				Where("id = ? AND is_bound = ? AND bound_user_id = ? AND bound_at < ?", plate.ID, true, plate.BoundUserID, idleSince).
+				Updates(map[string]interface{}{"is_bound": false, "bound_user_id": "", "bound_at": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+			unbound = true
+			if err := recordPlateEvent(tx, model.PlateEvent{
+				PlateID:    plate.ID,
+				Type:       "unbind",
+				PrevStatus: plate.Status,
+				NewStatus:  plate.Status,
+				ActorType:  "system",
+				UserID:     plate.BoundUserID,
+				Remark:     "超时未使用自动解绑",
+			}); err != nil {
+				return err
+			}
+			return queueNotificationEvents(tx, model.NotificationEvent{
+				UserID:  plate.BoundUserID,
+				Kind:    NotifyPlateAutoUnbound,
+				PlateID: plate.ID,
+			})
+		})
+		if err != nil {
+			return count, fmt.Errorf("自动解绑餐盘失败: %w", err)
+		}
+		if !unbound {
+			continue
+		}
+
+		count++
+		l.bus.Publish(event.Event{
+			CanteenID:  plate.CanteenID,
+			Type:       event.PlateUnbound,
+			UserID:     plate.BoundUserID,
+			PlateID:    plate.ID,
+			PrevStatus: plate.Status,
+			NewStatus:  plate.Status,
+			WasBound:   true,
+			Auto:       true,
+		})
+	}
+	return count, nil
+}
+
+// CreatePlateDepot 创建餐盘托管处，归属于调用方所在的食堂，id 为空时自动生成
+func (l *RestaurantLogic) CreatePlateDepot(ctx context.Context, id, name, location string, capacity int) (*model.PlateDepot, error) {
+	if name == "" {
+		return nil, errors.New("托管处名称不能为空")
+	}
+	if capacity <= 0 {
+		return nil, errors.New("容量必须大于0")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	depot := model.PlateDepot{
+		ID:        id,
+		CanteenID: tenant.CanteenID(ctx),
+		Name:      name,
+		Location:  location,
+		Capacity:  capacity,
+		Available: capacity,
+	}
+	if err := l.db.WithContext(ctx).Create(&depot).Error; err != nil {
+		return nil, fmt.Errorf("创建托管处失败: %w", err)
+	}
+	return &depot, nil
+}
+
 // GetPlateDepot 获取餐盘托管处信息
 func (l *RestaurantLogic) GetPlateDepot(ctx context.Context, depotID string) (*model.PlateDepot, error) {
 	var depot model.PlateDepot
-	err := l.db.WithContext(ctx).Where("id = ?", depotID).First(&depot).Error
+	err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", depotID).First(&depot).Error
 	if err != nil {
 		return nil, fmt.Errorf("查询餐盘托管处失败: %w", err)
 	}
@@ -315,9 +790,17 @@ func (l *RestaurantLogic) GetPlateDepot(ctx context.Context, depotID string) (*m
 
 // HandleException 处理异常
 func (l *RestaurantLogic) HandleException(ctx context.Context, workerID string, plateID string, exception string, action string) error {
+	if strings.TrimSpace(exception) == "" {
+		return errors.New("异常描述不能为空")
+	}
+	// action 列最长 255 个字符
+	if utf8.RuneCountInString(action) > 255 {
+		return errors.New("处理措施不能超过 255 个字符")
+	}
+
 	// 检查工作人员是否存在
 	var worker model.Worker
-	if err := l.db.WithContext(ctx).Where("id = ?", workerID).First(&worker).Error; err != nil {
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
 		return fmt.Errorf("工作人员不存在: %w", err)
 	}
 
@@ -330,27 +813,61 @@ func (l *RestaurantLogic) HandleException(ctx context.Context, workerID string,
 		Status:    "pending",
 	}
 
-	if err := l.db.WithContext(ctx).Create(&exceptionLog).Error; err != nil {
-		return fmt.Errorf("记录异常失败: %w", err)
-	}
+	events := []event.Event{{Type: event.ExceptionOpened, CanteenID: worker.CanteenID, PlateID: plateID}}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&exceptionLog).Error; err != nil {
+			return fmt.Errorf("记录异常失败: %w", err)
+		}
 
-	// 如果有餐盘ID，更新餐盘状态
-	if plateID != "" {
+		// 如果有餐盘ID，更新餐盘状态
+		if plateID == "" {
+			return nil
+		}
 		var plate model.Plate
-		if err := l.db.WithContext(ctx).Where("id = ?", plateID).First(&plate).Error; err == nil {
-			plate.Status = "maintenance"
-			l.db.WithContext(ctx).Save(&plate)
+		if err := tx.Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", plateID).First(&plate).Error; err != nil {
+			return nil
 		}
+		prevStatus := plate.Status
+		plate.Status = "maintenance"
+		if err := tx.Save(&plate).Error; err != nil {
+			return fmt.Errorf("更新餐盘状态失败: %w", err)
+		}
+		events = append(events, event.Event{
+			CanteenID:  plate.CanteenID,
+			Type:       event.PlateStatus,
+			UserID:     plate.BoundUserID,
+			PlateID:    plate.ID,
+			PrevStatus: prevStatus,
+			NewStatus:  plate.Status,
+			WasBound:   plate.IsBound,
+			IsBound:    plate.IsBound,
+		})
+		return recordPlateEvent(tx, model.PlateEvent{
+			PlateID:     plate.ID,
+			Type:        "exception",
+			PrevStatus:  prevStatus,
+			NewStatus:   plate.Status,
+			ActorType:   "worker",
+			ActorID:     workerID,
+			UserID:      plate.BoundUserID,
+			ExceptionID: exceptionLog.ID,
+			Remark:      truncate(exception, 255), // 完整描述保存在异常记录里
+		})
+	})
+	if err != nil {
+		return err
 	}
 
+	l.bus.Publish(events...)
 	return nil
 }
 
 // ProcessGC 处理GC
-func (l *RestaurantLogic) ProcessGC(ctx context.Context, plateID string, gcType string) error {
+// workerID 为空时视为系统自动处理
+func (l *RestaurantLogic) ProcessGC(ctx context.Context, plateID string, gcType string, workerID string) error {
 	// 检查餐盘是否存在
 	var plate model.Plate
-	if err := l.db.WithContext(ctx).Where("id = ?", plateID).First(&plate).Error; err != nil {
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", plateID).First(&plate).Error; err != nil {
 		return fmt.Errorf("餐盘不存在: %w", err)
 	}
 
@@ -364,24 +881,64 @@ func (l *RestaurantLogic) ProcessGC(ctx context.Context, plateID string, gcType
 	if err := l.db.WithContext(ctx).Create(&gcLog).Error; err != nil {
 		return fmt.Errorf("创建GC处理记录失败: %w", err)
 	}
+	l.bus.Publish(event.Event{Type: event.GCStarted, CanteenID: plate.CanteenID, PlateID: plateID})
+
+	plateEvent := model.PlateEvent{
+		PlateID:    plate.ID,
+		Type:       "gc",
+		PrevStatus: plate.Status,
+		ActorType:  "system",
+		ActorID:    workerID,
+		UserID:     plate.BoundUserID,
+		Remark:     gcType,
+	}
+	if workerID != "" {
+		plateEvent.ActorType = "worker"
+	}
+	busEvent := event.Event{
+		CanteenID:  plate.CanteenID,
+		Type:       event.PlateGC,
+		UserID:     plate.BoundUserID,
+		PlateID:    plate.ID,
+		PrevStatus: plate.Status,
+		WasBound:   plate.IsBound,
+	}
 
 	// 根据类型处理
-	if gcType == "plate" {
-		// 餐盘处理：清理、重置状态
-		plate.Weight = 0
-		plate.Status = "available"
-		plate.IsBound = false
-		plate.BoundUserID = ""
-		plate.BoundAt = nil
-		if err := l.db.WithContext(ctx).Save(&plate).Error; err != nil {
-			return fmt.Errorf("处理餐盘失败: %w", err)
-		}
-	} else if gcType == "food_waste" {
-		// 厨余垃圾处理：重置重量
-		plate.Weight = 0
-		if err := l.db.WithContext(ctx).Save(&plate).Error; err != nil {
-			return fmt.Errorf("处理厨余垃圾失败: %w", err)
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if gcType == "plate" {
+			// 餐盘处理：清理、重置状态
+			plate.Weight = 0
+			plate.Status = "available"
+			plate.IsBound = false
+			plate.BoundUserID = ""
+			plate.BoundAt = nil
+			if err := tx.Save(&plate).Error; err != nil {
+				return fmt.Errorf("处理餐盘失败: %w", err)
+			}
+			// 回收仍绑定着的餐盘时同时解绑，通知用户
+			if busEvent.WasBound && busEvent.UserID != "" {
+				if err := queueNotificationEvents(tx, model.NotificationEvent{
+					UserID:  busEvent.UserID,
+					Kind:    NotifyPlateAutoUnbound,
+					PlateID: plate.ID,
+				}); err != nil {
+					return err
+				}
+			}
+		} else if gcType == "food_waste" {
+			// 厨余垃圾处理：重置重量
+			plate.Weight = 0
+			if err := tx.Save(&plate).Error; err != nil {
+				return fmt.Errorf("处理厨余垃圾失败: %w", err)
+			}
 		}
+
+		plateEvent.NewStatus = plate.Status
+		return recordPlateEvent(tx, plateEvent)
+	})
+	if err != nil {
+		return err
 	}
 
 	// 更新GC处理状态
@@ -390,11 +947,17 @@ func (l *RestaurantLogic) ProcessGC(ctx context.Context, plateID string, gcType
 		return fmt.Errorf("更新GC处理状态失败: %w", err)
 	}
 
+	busEvent.NewStatus, busEvent.IsBound = plate.Status, plate.IsBound
+	l.bus.Publish(busEvent, event.Event{Type: event.GCCompleted, CanteenID: plate.CanteenID, PlateID: plateID})
+
 	return nil
 }
 
 // OrderFood 订单食物
 type OrderFood struct {
-	FoodID string
-	Weight float64
+	FoodID     string
+	Weight     float64 // 汤品为容量（毫升）
+	Ladles     int     // 汤品按勺数出汤时填写
+	StationID  string  // 档口称重时填写
+	CauldronID string  // 打汤机出汤时填写
 }
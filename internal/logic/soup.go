@@ -0,0 +1,436 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// soupCategory 汤品对应的 Food 记录的分类
+const soupCategory = "soup"
+
+// CreateSoup 创建汤品，同时创建对应的 Food 记录
+// priceUnit 为 ml 时 unitPrice 为每100毫升单价，为 ladle 时为每勺单价，需要填写每勺容量
+func (l *RestaurantLogic) CreateSoup(ctx context.Context, id, name, priceUnit string, unitPrice, ladleVolume float64) (*model.Soup, error) {
+	if name == "" {
+		return nil, errors.New("汤品名称不能为空")
+	}
+	if unitPrice < 0 {
+		return nil, errors.New("单价不能为负")
+	}
+	// Food.Price 统一保存每100毫升的单价
+	pricePer100 := unitPrice
+	switch priceUnit {
+	case "ml":
+	case "ladle":
+		if ladleVolume <= 0 {
+			return nil, errors.New("按勺计价时每勺容量必须大于0")
+		}
+		pricePer100 = unitPrice / ladleVolume * 100
+	default:
+		return nil, errors.New("计价单位必须是 ml 或 ladle")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	canteenID := tenant.CanteenID(ctx)
+	soup := model.Soup{
+		ID:          id,
+		CanteenID:   canteenID,
+		FoodID:      id,
+		PriceUnit:   priceUnit,
+		UnitPrice:   unitPrice,
+		LadleVolume: ladleVolume,
+		Food: &model.Food{
+			ID:          id,
+			CanteenID:   canteenID,
+			Name:        name,
+			Price:       pricePer100,
+			Category:    soupCategory,
+			IsAvailable: true,
+		},
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(soup.Food).Error; err != nil {
+			return fmt.Errorf("创建汤品失败: %w", err)
+		}
+		if err := tx.Omit("Food").Create(&soup).Error; err != nil {
+			return fmt.Errorf("创建汤品失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &soup, nil
+}
+
+// GetSoupList 获取汤品列表
+func (l *RestaurantLogic) GetSoupList(ctx context.Context) ([]model.Soup, error) {
+	var soups []model.Soup
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("Food").Order("id ASC").Find(&soups).Error; err != nil {
+		return nil, fmt.Errorf("查询汤品列表失败: %w", err)
+	}
+	return soups, nil
+}
+
+// soupCharge 计算出汤的容量和价格
+// 按勺计价时报告的容量四舍五入到整勺，至少一勺；ladles 大于 0 时按勺数计算容量
+func soupCharge(soup *model.Soup, volume float64, ladles int) (float64, float64) {
+	if ladles > 0 && soup.LadleVolume > 0 {
+		volume = float64(ladles) * soup.LadleVolume
+	}
+	if volume <= 0 {
+		volume = 100 // 默认100毫升
+		if soup.LadleVolume > 0 {
+			volume = soup.LadleVolume
+		}
+	}
+
+	if soup.PriceUnit == "ladle" && soup.LadleVolume > 0 {
+		count := math.Max(1, math.Round(volume/soup.LadleVolume))
+		return volume, count * soup.UnitPrice
+	}
+	return volume, soup.UnitPrice / 100 * volume
+}
+
+// CreateCauldron 在档口放置汤锅，deviceID 为打汤机，可以为空
+func (l *RestaurantLogic) CreateCauldron(ctx context.Context, id, stationID, deviceID string, capacity float64) (*model.Cauldron, error) {
+	if capacity <= 0 {
+		return nil, errors.New("汤锅容量必须大于0")
+	}
+
+	var station model.Station
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", stationID).First(&station).Error; err != nil {
+		return nil, fmt.Errorf("档口不存在: %w", err)
+	}
+	if deviceID != "" {
+		if err := l.checkDispenser(ctx, deviceID, station.CanteenID, ""); err != nil {
+			return nil, err
+		}
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	cauldron := model.Cauldron{
+		ID:        id,
+		CanteenID: station.CanteenID,
+		StationID: station.ID,
+		DeviceID:  deviceID,
+		Capacity:  capacity,
+		Status:    "empty",
+	}
+	if err := l.db.WithContext(ctx).Create(&cauldron).Error; err != nil {
+		return nil, fmt.Errorf("创建汤锅失败: %w", err)
+	}
+	return &cauldron, nil
+}
+
+// GetCauldronList 获取汤锅列表
+func (l *RestaurantLogic) GetCauldronList(ctx context.Context) ([]model.Cauldron, error) {
+	var cauldrons []model.Cauldron
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("Soup.Food").Order("id ASC").Find(&cauldrons).Error; err != nil {
+		return nil, fmt.Errorf("查询汤锅列表失败: %w", err)
+	}
+	return cauldrons, nil
+}
+
+// SetCauldronDevice 更换汤锅的打汤机，deviceID 为空表示解除
+func (l *RestaurantLogic) SetCauldronDevice(ctx context.Context, cauldronID, deviceID string) (*model.Cauldron, error) {
+	var cauldron model.Cauldron
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", cauldronID).First(&cauldron).Error; err != nil {
+		return nil, fmt.Errorf("汤锅不存在: %w", err)
+	}
+	if deviceID != "" {
+		if err := l.checkDispenser(ctx, deviceID, cauldron.CanteenID, cauldron.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.db.WithContext(ctx).Model(&cauldron).Update("device_id", deviceID).Error; err != nil {
+		return nil, fmt.Errorf("更换打汤机失败: %w", err)
+	}
+	cauldron.DeviceID = deviceID
+	return &cauldron, nil
+}
+
+// checkDispenser 检查打汤机可用，并且没有装在另一个汤锅上
+func (l *RestaurantLogic) checkDispenser(ctx context.Context, deviceID, canteenID, cauldronID string) error {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if device.Type != "dispenser" {
+		return errors.New("只有打汤机可以装到汤锅上")
+	}
+	if canteenID != "" && device.CanteenID != "" && device.CanteenID != canteenID {
+		return errors.New("打汤机不属于该食堂")
+	}
+
+	var count int64
+	if err := l.db.WithContext(ctx).Model(&model.Cauldron{}).
+		Where("device_id = ? AND id <> ?", deviceID, cauldronID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询汤锅失败: %w", err)
+	}
+	if count > 0 {
+		return errors.New("打汤机已装在其他汤锅上")
+	}
+	return nil
+}
+
+// RefillCauldron 向汤锅加汤，加汤量计入该汤品当前餐次的库存
+// 锅里还有另一种汤时需要先清空
+func (l *RestaurantLogic) RefillCauldron(ctx context.Context, cauldronID, soupID, workerID string, volume float64) (*model.Cauldron, error) {
+	if volume <= 0 {
+		return nil, errors.New("加汤量必须大于0")
+	}
+
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	var cauldron model.Cauldron
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", cauldronID).First(&cauldron).Error; err != nil {
+		return nil, fmt.Errorf("汤锅不存在: %w", err)
+	}
+	if soupID == "" {
+		soupID = cauldron.SoupID
+	}
+	if cauldron.Level > 0 && soupID != cauldron.SoupID {
+		return nil, errors.New("汤锅里还有其他汤，请先清空")
+	}
+	var food model.Food
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(cauldron.CanteenID)).
+		Where("id = ? AND category = ?", soupID, soupCategory).First(&food).Error; err != nil {
+		return nil, fmt.Errorf("汤品不存在: %w", err)
+	}
+
+	var events []event.Event
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 带上汤量条件，避免并发加汤超过容量
+		result := tx.Model(&model.Cauldron{}).Where("id = ? AND level + ? <= capacity", cauldron.ID, volume).
+			Updates(map[string]interface{}{
+				"soup_id": soupID,
+				"level":   gorm.Expr("level + ?", volume),
+				"status":  "serving",
+			})
+		if result.Error != nil {
+			return fmt.Errorf("加汤失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("超过汤锅容量，当前 %.0f 毫升，容量 %.0f 毫升", cauldron.Level, cauldron.Capacity)
+		}
+		if err := tx.Where("id = ?", cauldron.ID).First(&cauldron).Error; err != nil {
+			return fmt.Errorf("查询汤锅失败: %w", err)
+		}
+		if err := tx.Create(&model.CauldronEvent{
+			CauldronID: cauldron.ID,
+			SoupID:     soupID,
+			Type:       "refill",
+			Volume:     volume,
+			Level:      cauldron.Level,
+			WorkerID:   workerID,
+		}).Error; err != nil {
+			return fmt.Errorf("记录汤锅事件失败: %w", err)
+		}
+
+		var err error
+		_, events, err = l.addBatch(tx, &food, volume, workerID, "汤锅 "+cauldron.ID+" 加汤")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.bus.Publish(events...)
+	return &cauldron, nil
+}
+
+// EmptyCauldron 清空汤锅，锅里剩下的汤从库存中报废
+func (l *RestaurantLogic) EmptyCauldron(ctx context.Context, cauldronID, workerID, remark string) (*model.Cauldron, error) {
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	var cauldron model.Cauldron
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", cauldronID).First(&cauldron).Error; err != nil {
+		return nil, fmt.Errorf("汤锅不存在: %w", err)
+	}
+	if cauldron.Status == "empty" {
+		return nil, errors.New("汤锅已经是空的")
+	}
+
+	var events []event.Event
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", cauldron.ID).First(&cauldron).Error; err != nil {
+			return fmt.Errorf("查询汤锅失败: %w", err)
+		}
+		leftover := cauldron.Level
+		if err := tx.Model(&cauldron).Updates(map[string]interface{}{"level": 0, "status": "empty"}).Error; err != nil {
+			return fmt.Errorf("清空汤锅失败: %w", err)
+		}
+		cauldron.Level, cauldron.Status = 0, "empty"
+		if err := tx.Create(&model.CauldronEvent{
+			CauldronID: cauldron.ID,
+			SoupID:     cauldron.SoupID,
+			Type:       "empty",
+			Volume:     -leftover,
+			WorkerID:   workerID,
+			Remark:     remark,
+		}).Error; err != nil {
+			return fmt.Errorf("记录汤锅事件失败: %w", err)
+		}
+		if leftover <= 0 {
+			return nil
+		}
+
+		// 剩下的汤按报废处理；当前餐次没有库存记录时（例如上一餐剩下的）不调整
+		var stock model.FoodStock
+		date, period := l.periods.Period(time.Now())
+		err := tx.Where("food_id = ? AND date = ? AND period = ?", cauldron.SoupID, date, period).First(&stock).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询库存失败: %w", err)
+		}
+		var food model.Food
+		if err := tx.Where("id = ?", cauldron.SoupID).First(&food).Error; err != nil {
+			return fmt.Errorf("汤品不存在: %w", err)
+		}
+		if remark == "" {
+			remark = "汤锅 " + cauldron.ID + " 清空"
+		}
+		events, err = l.adjustStock(tx, &food, &stock, model.StockMovement{
+			Type:     "discard",
+			Weight:   -math.Min(leftover, stock.Remaining),
+			WorkerID: workerID,
+			Remark:   remark,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.bus.Publish(events...)
+	return &cauldron, nil
+}
+
+// DispenseSoup 打汤机上报一次出汤，按汤锅里的汤给餐盘的持有人下单
+// volume 为出汤量（毫升），按勺出汤的打汤机也可以只上报 ladles
+func (l *RestaurantLogic) DispenseSoup(ctx context.Context, deviceID, plateID string, volume float64, ladles int) (*model.Order, *model.Cauldron, error) {
+	if volume <= 0 && ladles <= 0 {
+		return nil, nil, errors.New("出汤量必须大于0")
+	}
+
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if device.Type != "dispenser" {
+		return nil, nil, errors.New("只有打汤机可以上报出汤")
+	}
+	var cauldron model.Cauldron
+	if err := l.db.WithContext(ctx).Where("device_id = ?", device.ID).First(&cauldron).Error; err != nil {
+		return nil, nil, fmt.Errorf("打汤机没有装在汤锅上: %w", err)
+	}
+	if cauldron.Status != "serving" || cauldron.SoupID == "" {
+		return nil, nil, errors.New("汤锅是空的")
+	}
+
+	var plate model.Plate
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(cauldron.CanteenID)).Where("id = ?", plateID).First(&plate).Error; err != nil {
+		return nil, nil, fmt.Errorf("餐盘不存在: %w", err)
+	}
+	if !plate.IsBound {
+		return nil, nil, errors.New("餐盘未绑定用户")
+	}
+
+	// 订单按汤锅所在食堂处理
+	ctx = tenant.WithCanteen(ctx, cauldron.CanteenID)
+	items, totalPrice, err := l.buildOrderItems(ctx, &plate, []OrderFood{{
+		FoodID:     cauldron.SoupID,
+		Weight:     volume,
+		Ladles:     ladles,
+		StationID:  cauldron.StationID,
+		CauldronID: cauldron.ID,
+	}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order, err := l.placeOrder(ctx, &plate, items, totalPrice, "device", device.ID, false, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := l.db.WithContext(ctx).Where("id = ?", cauldron.ID).First(&cauldron).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询汤锅失败: %w", err)
+	}
+	return order, &cauldron, nil
+}
+
+// drawCauldrons 按订单明细扣减汤锅的汤量并记录出汤事件，汤量扣完后汤锅改为空
+func drawCauldrons(tx *gorm.DB, order *model.Order, items []model.OrderItem, deviceID string) error {
+	for _, item := range items {
+		if item.CauldronID == "" {
+			continue
+		}
+		// 出汤已经发生，汤量只扣到 0
+		if err := tx.Model(&model.Cauldron{}).Where("id = ?", item.CauldronID).Updates(map[string]interface{}{
+			"level": gorm.Expr("CASE WHEN level > ? THEN level - ? ELSE 0 END", item.Weight, item.Weight),
+		}).Error; err != nil {
+			return fmt.Errorf("更新汤量失败: %w", err)
+		}
+		var cauldron model.Cauldron
+		if err := tx.Where("id = ?", item.CauldronID).First(&cauldron).Error; err != nil {
+			return fmt.Errorf("查询汤锅失败: %w", err)
+		}
+		if cauldron.Level <= 0 && cauldron.Status != "empty" {
+			if err := tx.Model(&cauldron).Update("status", "empty").Error; err != nil {
+				return fmt.Errorf("更新汤锅状态失败: %w", err)
+			}
+		}
+		if err := tx.Create(&model.CauldronEvent{
+			CauldronID: cauldron.ID,
+			SoupID:     cauldron.SoupID,
+			Type:       "dispense",
+			Volume:     -item.Weight,
+			Level:      cauldron.Level,
+			DeviceID:   deviceID,
+			PlateID:    order.PlateID,
+			OrderID:    order.ID,
+		}).Error; err != nil {
+			return fmt.Errorf("记录汤锅事件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCauldronEvents 获取汤锅最近的事件
+func (l *RestaurantLogic) GetCauldronEvents(ctx context.Context, cauldronID string, limit int) ([]model.CauldronEvent, error) {
+	var cauldron model.Cauldron
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", cauldronID).First(&cauldron).Error; err != nil {
+		return nil, fmt.Errorf("汤锅不存在: %w", err)
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var events []model.CauldronEvent
+	if err := l.db.WithContext(ctx).Where("cauldron_id = ?", cauldron.ID).
+		Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("查询汤锅事件失败: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,100 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+// newSoupFixture 档口 s1 上装有打汤机 d1 的汤锅 c1，锅里有上一餐剩下的 1000 毫升番茄汤，本餐次又加了 200 毫升
+func newSoupFixture(t *testing.T) (*RestaurantLogic, func() model.Cauldron) {
+	t.Helper()
+	l, db := newTestLogic(t)
+	ctx := context.Background()
+	now := time.Now()
+	mustCreate(t, db,
+		&model.Worker{ID: "w1", Name: "员工", Role: "staff"},
+		&model.Station{ID: "s1", Name: "汤档口", IsActive: true},
+		&model.Device{ID: "d1", StationID: "s1", Type: "dispenser", Status: "online"},
+		&model.User{ID: "u1", Username: "u1"}, &model.Wallet{UserID: "u1", Balance: 100},
+		&model.Plate{ID: "p1", QRCode: "p1", IsBound: true, BoundUserID: "u1", BoundAt: &now, Status: "in_use"},
+	)
+	if _, err := l.CreateSoup(ctx, "tomato", "番茄汤", "ml", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	mustCreate(t, db, &model.Cauldron{ID: "c1", StationID: "s1", DeviceID: "d1", SoupID: "tomato", Capacity: 5000, Level: 1000, Status: "serving"})
+	if _, err := l.RefillCauldron(ctx, "c1", "tomato", "w1", 200); err != nil {
+		t.Fatal(err)
+	}
+	return l, func() model.Cauldron {
+		var cauldron model.Cauldron
+		if err := db.Where("id = ?", "c1").First(&cauldron).Error; err != nil {
+			t.Fatal(err)
+		}
+		return cauldron
+	}
+}
+
+func TestDispenseSoupAfterStockSoldOut(t *testing.T) {
+	l, cauldron := newSoupFixture(t)
+	ctx := context.Background()
+
+	// 第一次出汤把本餐次的库存扣完，汤品下架，但锅里还有汤
+	if _, _, err := l.DispenseSoup(ctx, "d1", "p1", 250, 0); err != nil {
+		t.Fatal(err)
+	}
+	foods, err := l.GetSoupList(ctx)
+	if err != nil || len(foods) != 1 || foods[0].Food.IsAvailable {
+		t.Fatalf("库存扣完后汤品应当下架: %+v, %v", foods, err)
+	}
+
+	// 汤已经打出，下一次出汤照常计费
+	order, got, err := l.DispenseSoup(ctx, "d1", "p1", 250, 0)
+	if err != nil {
+		t.Fatalf("库存扣完后出汤没有计费: %v", err)
+	}
+	if order.Status != "paid" || order.TotalPrice != 5 {
+		t.Fatalf("订单 = %s %.2f，应为 paid 5.00", order.Status, order.TotalPrice)
+	}
+	if got.Level != 700 || cauldron().Level != 700 {
+		t.Fatalf("汤量 = %.0f，应为 700", got.Level)
+	}
+
+	// 直接下单仍然检查上架状态
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "tomato", Weight: 100}}); err == nil {
+		t.Fatal("已下架的汤品不能直接下单")
+	}
+}
+
+func TestCreateOrderDrawsSoupFromCauldron(t *testing.T) {
+	l, cauldron := newSoupFixture(t)
+	ctx := context.Background()
+
+	order, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "tomato", Weight: 150}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := order.OrderItems[0]; item.CauldronID != "c1" || item.StationID != "s1" {
+		t.Fatalf("订单明细 = %+v，应当从汤锅 c1 打汤", item)
+	}
+	if level := cauldron().Level; level != 1050 {
+		t.Fatalf("汤量 = %.0f，应为 1050", level)
+	}
+	events, err := l.GetCauldronEvents(ctx, "c1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[0]; e.Type != "dispense" || e.OrderID != order.ID || e.Volume != -150 || e.Level != 1050 {
+		t.Fatalf("出汤事件 = %+v", e)
+	}
+
+	// 汤锅清空后不能再直接点这种汤
+	if _, err := l.EmptyCauldron(ctx, "c1", "w1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateOrder(ctx, "u1", "p1", []OrderFood{{FoodID: "tomato", Weight: 100}}); err == nil {
+		t.Fatal("没有汤锅供应时不能点汤")
+	}
+}
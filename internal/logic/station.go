@@ -0,0 +1,171 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+)
+
+// CreateStation 创建出餐档口，归属调用方所在食堂
+func (l *RestaurantLogic) CreateStation(ctx context.Context, id, name, foodID string) (*model.Station, error) {
+	if name == "" {
+		return nil, errors.New("档口名称不能为空")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	station := model.Station{
+		ID:        id,
+		CanteenID: tenant.CanteenID(ctx),
+		Name:      name,
+		IsActive:  true,
+	}
+	if foodID != "" {
+		if _, err := l.stationFood(ctx, station.CanteenID, foodID); err != nil {
+			return nil, err
+		}
+		station.FoodID = foodID
+	}
+
+	if err := l.db.WithContext(ctx).Create(&station).Error; err != nil {
+		return nil, fmt.Errorf("创建档口失败: %w", err)
+	}
+	return &station, nil
+}
+
+// GetStationList 获取档口列表（包含当前菜品和设备）
+func (l *RestaurantLogic) GetStationList(ctx context.Context) ([]model.Station, error) {
+	var stations []model.Station
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("Food").Preload("Devices").
+		Order("id ASC").Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("查询档口列表失败: %w", err)
+	}
+	return stations, nil
+}
+
+// SetStationFood 更换档口供应的菜品，foodID 为空表示暂停供应
+// 称重时实时读取档口当前菜品，更换后下一次称重即按新菜品计价
+func (l *RestaurantLogic) SetStationFood(ctx context.Context, stationID, foodID string) (*model.Station, error) {
+	var station model.Station
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", stationID).First(&station).Error; err != nil {
+		return nil, fmt.Errorf("档口不存在: %w", err)
+	}
+	if foodID != "" {
+		if _, err := l.stationFood(ctx, station.CanteenID, foodID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.db.WithContext(ctx).Model(&station).Update("food_id", foodID).Error; err != nil {
+		return nil, fmt.Errorf("更换菜品失败: %w", err)
+	}
+	station.FoodID = foodID
+	return &station, nil
+}
+
+// AssignDevice 把已登记的电子秤或读卡器分配到档口
+func (l *RestaurantLogic) AssignDevice(ctx context.Context, deviceID, stationID string) (*model.Device, error) {
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var station model.Station
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(device.CanteenID)).Where("id = ?", stationID).First(&station).Error; err != nil {
+		return nil, fmt.Errorf("档口不存在: %w", err)
+	}
+
+	if err := l.db.WithContext(ctx).Model(device).Updates(map[string]interface{}{
+		"canteen_id": station.CanteenID,
+		"station_id": station.ID,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("分配设备失败: %w", err)
+	}
+	device.CanteenID, device.StationID = station.CanteenID, station.ID
+	return device, nil
+}
+
+// stationFood 检查菜品属于档口所在食堂
+func (l *RestaurantLogic) stationFood(ctx context.Context, canteenID, foodID string) (*model.Food, error) {
+	var food model.Food
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(canteenID)).Where("id = ?", foodID).First(&food).Error; err != nil {
+		return nil, fmt.Errorf("食物不存在: %s, %w", foodID, err)
+	}
+	if food.Category == soupCategory {
+		return nil, fmt.Errorf("汤品由汤锅出汤，不能在称重档口供应: %s", food.Name)
+	}
+	return &food, nil
+}
+
+// StationStats 档口在统计区间内的出餐数据
+type StationStats struct {
+	StationID   string  `json:"station_id"`
+	Name        string  `json:"name"`
+	FoodID      string  `json:"food_id,omitempty"` // 当前菜品
+	Servings    int64   `json:"servings"`          // 出餐份数
+	Plates      int64   `json:"plates"`            // 服务的餐盘数（去重）
+	WeightGrams float64 `json:"weight_grams"`
+	Revenue     float64 `json:"revenue"`
+	PerHour     float64 `json:"per_hour"` // 平均每小时出餐份数
+}
+
+// GetStationStats 档口出餐统计
+func (l *RestaurantLogic) GetStationStats(ctx context.Context, from, to time.Time) ([]StationStats, error) {
+	if !to.After(from) {
+		return nil, errors.New("统计结束时间必须晚于开始时间")
+	}
+	db := l.db.WithContext(ctx)
+
+	var stations []model.Station
+	if err := db.Scopes(inCanteen(ctx)).Order("id ASC").Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("查询档口列表失败: %w", err)
+	}
+
+	var rows []struct {
+		StationID string
+		Servings  int64
+		Plates    int64
+		Weight    float64
+		Revenue   float64
+	}
+	query := db.Table("order_items").
+		Select("order_items.station_id AS station_id, COUNT(*) AS servings, COUNT(DISTINCT orders.plate_id) AS plates, "+
+			"COALESCE(SUM(order_items.weight), 0) AS weight, COALESCE(SUM(order_items.price), 0) AS revenue").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.station_id <> '' AND orders.status IN ? AND orders.created_at >= ? AND orders.created_at < ? AND orders.deleted_at IS NULL",
+			[]string{"paid", "completed"}, from, to)
+	if canteenID := tenant.CanteenID(ctx); canteenID != "" {
+		query = query.Where("orders.canteen_id = ?", canteenID)
+	}
+	if err := query.Group("order_items.station_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计档口出餐失败: %w", err)
+	}
+
+	hours := to.Sub(from).Hours()
+	stats := make([]StationStats, 0, len(stations))
+	index := make(map[string]int, len(stations))
+	for _, station := range stations {
+		index[station.ID] = len(stats)
+		stats = append(stats, StationStats{StationID: station.ID, Name: station.Name, FoodID: station.FoodID})
+	}
+	for _, row := range rows {
+		i, ok := index[row.StationID]
+		if !ok {
+			// 已删除的档口仍计入统计
+			i = len(stats)
+			stats = append(stats, StationStats{StationID: row.StationID})
+		}
+		stats[i].Servings = row.Servings
+		stats[i].Plates = row.Plates
+		stats[i].WeightGrams = row.Weight
+		stats[i].Revenue = row.Revenue
+		stats[i].PerHour = float64(row.Servings) / hours
+	}
+	return stats, nil
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/model"
+)
+
+func TestStationFoodSwitchAndStats(t *testing.T) {
+	l, db, _ := newStockFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+	mustCreate(t, db,
+		&model.Food{ID: "f2", Name: "红烧肉", Price: 20, IsAvailable: true},
+		&model.Food{ID: "soup", Name: "番茄汤", Category: soupCategory, Price: 2, IsAvailable: true},
+		&model.User{ID: "u2", Username: "u2"}, &model.Wallet{UserID: "u2", Balance: 1000},
+		&model.Plate{ID: "p2", QRCode: "p2", RFIDTag: "p2", IsBound: true, BoundUserID: "u2", BoundAt: &now, Status: "in_use"},
+		&model.Device{ID: "d1", Type: "scale", Status: "online"},
+	)
+	if _, err := l.CreateStation(ctx, "s1", "主档口", "f1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateStation(ctx, "s2", "空档口", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AssignDevice(ctx, "d1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	weigh := func(plateID string) *model.Order {
+		t.Helper()
+		result, err := l.IngestWeight(ctx, "d1", plateID, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Order
+	}
+
+	if order := weigh("p1"); order.TotalPrice != 10 || order.OrderItems[0].FoodID != "f1" {
+		t.Fatalf("订单 = %+v，应为 f1 10.00", order)
+	}
+
+	// 汤品不能在称重档口供应，更换失败时档口菜品不变
+	if _, err := l.SetStationFood(ctx, "s1", "soup"); err == nil {
+		t.Fatal("汤品不能在称重档口供应")
+	}
+
+	// 更换菜品后下一次称重立即按新菜品计价
+	if _, err := l.SetStationFood(ctx, "s1", "f2"); err != nil {
+		t.Fatal(err)
+	}
+	if order := weigh("p2"); order.TotalPrice != 20 || order.OrderItems[0].FoodID != "f2" {
+		t.Fatalf("订单 = %+v，应为 f2 20.00", order)
+	}
+
+	// 暂停供应后称重不再下单
+	if _, err := l.SetStationFood(ctx, "s1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.IngestWeight(ctx, "d1", "p1", 100); err == nil {
+		t.Fatal("档口暂停供应时称重不能下单")
+	}
+
+	stats, err := l.GetStationStats(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("统计 = %+v", stats)
+	}
+	if s := stats[0]; s.StationID != "s1" || s.FoodID != "" || s.Servings != 2 || s.Plates != 2 ||
+		s.WeightGrams != 200 || s.Revenue != 30 || s.PerHour != 1 {
+		t.Fatalf("s1 统计 = %+v", s)
+	}
+	if s := stats[1]; s.StationID != "s2" || s.Servings != 0 || s.Revenue != 0 {
+		t.Fatalf("s2 统计 = %+v", s)
+	}
+	if _, err := l.GetStationStats(ctx, now, now); err == nil {
+		t.Fatal("统计区间为空时应当报错")
+	}
+}
@@ -0,0 +1,227 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// TablewareLoss 一段时间内某个托管处某种餐具的丢失数量
+type TablewareLoss struct {
+	DepotID       string `json:"depot_id"`
+	TablewareID   string `json:"tableware_id"`
+	TablewareName string `json:"tableware_name"`
+	Quantity      int    `json:"quantity"`
+	Reports       int    `json:"reports"` // 登记次数
+}
+
+// CreateTableware 创建餐具种类，reorderLevel 为每个托管处的补购提醒阈值
+func (l *RestaurantLogic) CreateTableware(ctx context.Context, id, name, kind string, reorderLevel int) (*model.Tableware, error) {
+	if name == "" {
+		return nil, errors.New("餐具名称不能为空")
+	}
+	if reorderLevel < 0 {
+		return nil, errors.New("补购提醒阈值不能为负")
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	tableware := model.Tableware{
+		ID:           id,
+		CanteenID:    tenant.CanteenID(ctx),
+		Name:         name,
+		Kind:         kind,
+		ReorderLevel: reorderLevel,
+	}
+	if err := l.db.WithContext(ctx).Create(&tableware).Error; err != nil {
+		return nil, fmt.Errorf("创建餐具失败: %w", err)
+	}
+	return &tableware, nil
+}
+
+// GetTablewareList 获取餐具种类列表
+func (l *RestaurantLogic) GetTablewareList(ctx context.Context) ([]model.Tableware, error) {
+	var tablewares []model.Tableware
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Order("id ASC").Find(&tablewares).Error; err != nil {
+		return nil, fmt.Errorf("查询餐具列表失败: %w", err)
+	}
+	return tablewares, nil
+}
+
+// ReceiveTableware 托管处入库新购或调入的餐具
+func (l *RestaurantLogic) ReceiveTableware(ctx context.Context, depotID, tablewareID, workerID string, quantity int, remark string) (*model.TablewareStock, error) {
+	return l.moveTableware(ctx, depotID, tablewareID, workerID, "receive", quantity, remark)
+}
+
+// IssueTableware 从托管处发放餐具到档口、餐区
+func (l *RestaurantLogic) IssueTableware(ctx context.Context, depotID, tablewareID, workerID string, quantity int, remark string) (*model.TablewareStock, error) {
+	return l.moveTableware(ctx, depotID, tablewareID, workerID, "issue", quantity, remark)
+}
+
+// ReturnTableware 回收餐具到托管处
+func (l *RestaurantLogic) ReturnTableware(ctx context.Context, depotID, tablewareID, workerID string, quantity int, remark string) (*model.TablewareStock, error) {
+	return l.moveTableware(ctx, depotID, tablewareID, workerID, "return", quantity, remark)
+}
+
+// ReportTablewareLoss 登记丢失、损坏的餐具，先从使用中的数量扣减，不够时再扣在库数量
+func (l *RestaurantLogic) ReportTablewareLoss(ctx context.Context, depotID, tablewareID, workerID string, quantity int, remark string) (*model.TablewareStock, error) {
+	return l.moveTableware(ctx, depotID, tablewareID, workerID, "loss", quantity, remark)
+}
+
+// moveTableware 按变动类型调整托管处的餐具库存并记录流水，然后处理补购提醒
+func (l *RestaurantLogic) moveTableware(ctx context.Context, depotID, tablewareID, workerID, movementType string, quantity int, remark string) (*model.TablewareStock, error) {
+	if quantity <= 0 {
+		return nil, errors.New("数量必须大于0")
+	}
+
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	var depot model.PlateDepot
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", depotID).First(&depot).Error; err != nil {
+		return nil, fmt.Errorf("托管处不存在: %w", err)
+	}
+	var tableware model.Tableware
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Where("id = ?", tablewareID).First(&tableware).Error; err != nil {
+		return nil, fmt.Errorf("餐具不存在: %w", err)
+	}
+
+	var stock model.TablewareStock
+	var events []event.Event
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(model.TablewareStock{DepotID: depot.ID, TablewareID: tableware.ID}).
+			Attrs(model.TablewareStock{CanteenID: depot.CanteenID}).FirstOrCreate(&stock).Error; err != nil {
+			return fmt.Errorf("创建餐具库存失败: %w", err)
+		}
+
+		var onHand, issued, lost int
+		switch movementType {
+		case "receive":
+			onHand = quantity
+		case "issue":
+			if stock.OnHand < quantity {
+				return fmt.Errorf("托管处在库数量不足，在库: %d", stock.OnHand)
+			}
+			onHand, issued = -quantity, quantity
+		case "return":
+			if stock.Issued < quantity {
+				return fmt.Errorf("回收数量超过发放中的数量，发放中: %d", stock.Issued)
+			}
+			onHand, issued = quantity, -quantity
+		case "loss":
+			if stock.OnHand+stock.Issued < quantity {
+				return fmt.Errorf("丢失数量超过现有数量，在库: %d，发放中: %d", stock.OnHand, stock.Issued)
+			}
+			issued = -min(quantity, stock.Issued)
+			onHand = -(quantity + issued)
+			lost = quantity
+		default:
+			return fmt.Errorf("未知的餐具变动类型: %s", movementType)
+		}
+
+		// 在数据库中计算并校验不为负，避免并发操作互相覆盖
+		result := tx.Model(&stock).
+			Where("on_hand + ? >= 0 AND issued + ? >= 0", onHand, issued).
+			Updates(map[string]interface{}{
+				"on_hand": gorm.Expr("on_hand + ?", onHand),
+				"issued":  gorm.Expr("issued + ?", issued),
+				"lost":    gorm.Expr("lost + ?", lost),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("更新餐具库存失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("餐具库存已变化，请重试")
+		}
+		if err := tx.Create(&model.TablewareMovement{
+			StockID:     stock.ID,
+			DepotID:     depot.ID,
+			TablewareID: tableware.ID,
+			Type:        movementType,
+			Quantity:    quantity,
+			WorkerID:    worker.ID,
+			Remark:      remark,
+		}).Error; err != nil {
+			return fmt.Errorf("记录餐具流水失败: %w", err)
+		}
+		if err := tx.Where("id = ?", stock.ID).First(&stock).Error; err != nil {
+			return fmt.Errorf("查询餐具库存失败: %w", err)
+		}
+
+		// 补购提醒每次跌破阈值只发一次，入库回到阈值以上后重新计算
+		total := stock.OnHand + stock.Issued
+		switch {
+		case tableware.ReorderLevel > 0 && total < tableware.ReorderLevel && !stock.ReorderAlerted:
+			if err := tx.Model(&stock).Update("reorder_alerted", true).Error; err != nil {
+				return fmt.Errorf("更新餐具库存失败: %w", err)
+			}
+			events = append(events, event.Event{
+				Type:          event.TablewareReorder,
+				CanteenID:     stock.CanteenID,
+				DepotID:       depot.ID,
+				TablewareID:   tableware.ID,
+				TablewareName: tableware.Name,
+				Quantity:      total,
+			})
+		case total >= tableware.ReorderLevel && stock.ReorderAlerted:
+			if err := tx.Model(&stock).Update("reorder_alerted", false).Error; err != nil {
+				return fmt.Errorf("更新餐具库存失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.bus.Publish(events...)
+	stock.Tableware = &tableware
+	return &stock, nil
+}
+
+// GetTablewareStock 获取餐具库存，depotID 为空时返回所有托管处
+func (l *RestaurantLogic) GetTablewareStock(ctx context.Context, depotID string) ([]model.TablewareStock, error) {
+	query := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("Tableware")
+	if depotID != "" {
+		query = query.Where("depot_id = ?", depotID)
+	}
+
+	var stocks []model.TablewareStock
+	if err := query.Order("depot_id ASC, tableware_id ASC").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("查询餐具库存失败: %w", err)
+	}
+	return stocks, nil
+}
+
+// GetTablewareLosses 统计 [from, to) 内登记的餐具丢失，按托管处和餐具汇总
+func (l *RestaurantLogic) GetTablewareLosses(ctx context.Context, from, to time.Time) ([]TablewareLoss, error) {
+	if !to.After(from) {
+		return nil, errors.New("结束时间必须晚于开始时间")
+	}
+
+	query := l.db.WithContext(ctx).Table("tableware_movements").
+		Select("tableware_movements.depot_id AS depot_id, tableware_movements.tableware_id AS tableware_id, "+
+			"tablewares.name AS tableware_name, SUM(tableware_movements.quantity) AS quantity, COUNT(*) AS reports").
+		Joins("JOIN tablewares ON tablewares.id = tableware_movements.tableware_id").
+		Where("tableware_movements.type = ? AND tableware_movements.created_at >= ? AND tableware_movements.created_at < ?",
+			"loss", from, to)
+	if canteenID := tenant.CanteenID(ctx); canteenID != "" {
+		query = query.Where("tablewares.canteen_id = ?", canteenID)
+	}
+
+	losses := make([]TablewareLoss, 0)
+	if err := query.Group("tableware_movements.depot_id, tableware_movements.tableware_id, tablewares.name").
+		Order("quantity DESC, depot_id ASC, tableware_id ASC").Scan(&losses).Error; err != nil {
+		return nil, fmt.Errorf("统计餐具丢失失败: %w", err)
+	}
+	return losses, nil
+}
@@ -0,0 +1,114 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/model"
+)
+
+// reorderEvents 取出已经发布的补购提醒，返回提醒时的数量
+func reorderEvents(sub *event.Subscription) []int {
+	var quantities []int
+	for {
+		select {
+		case e := <-sub.Events():
+			if e.Type == event.TablewareReorder {
+				quantities = append(quantities, e.Quantity)
+			}
+		default:
+			return quantities
+		}
+	}
+}
+
+func TestTablewareMovementsAndReorder(t *testing.T) {
+	l, db := newTestLogic(t)
+	bus := event.NewBus()
+	sub := bus.Subscribe()
+	t.Cleanup(sub.Cancel)
+	l.WithBus(bus)
+	ctx := context.Background()
+	mustCreate(t, db,
+		&model.Worker{ID: "w1", Name: "员工", Role: "staff"},
+		&model.PlateDepot{ID: "d1", Name: "一楼"},
+		&model.PlateDepot{ID: "d2", Name: "二楼"},
+	)
+	if _, err := l.CreateTableware(ctx, "bowl", "汤碗", "bowl", 50); err != nil {
+		t.Fatal(err)
+	}
+	move := func(f func(context.Context, string, string, string, int, string) (*model.TablewareStock, error), depotID string, quantity int) *model.TablewareStock {
+		t.Helper()
+		stock, err := f(ctx, depotID, "bowl", "w1", quantity, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stock
+	}
+
+	move(l.ReceiveTableware, "d1", 100)
+	if stock := move(l.IssueTableware, "d1", 80); stock.OnHand != 20 || stock.Issued != 80 {
+		t.Fatalf("发放后库存 = %+v", stock)
+	}
+	if _, err := l.IssueTableware(ctx, "d1", "bowl", "w1", 30, ""); err == nil {
+		t.Fatal("在库数量不足时不能发放")
+	}
+	if _, err := l.ReturnTableware(ctx, "d1", "bowl", "w1", 81, ""); err == nil {
+		t.Fatal("回收数量不能超过发放中的数量")
+	}
+	if stock := move(l.ReturnTableware, "d1", 30); stock.OnHand != 50 || stock.Issued != 50 {
+		t.Fatalf("回收后库存 = %+v", stock)
+	}
+
+	// 丢失先扣使用中的数量，不够时再扣在库数量
+	if stock := move(l.ReportTablewareLoss, "d1", 30); stock.OnHand != 50 || stock.Issued != 20 || stock.Lost != 30 {
+		t.Fatalf("丢失后库存 = %+v", stock)
+	}
+	if stock := move(l.ReportTablewareLoss, "d1", 25); stock.OnHand != 45 || stock.Issued != 0 || stock.Lost != 55 || !stock.ReorderAlerted {
+		t.Fatalf("丢失后库存 = %+v", stock)
+	}
+	if _, err := l.ReportTablewareLoss(ctx, "d1", "bowl", "w1", 46, ""); err == nil {
+		t.Fatal("丢失数量不能超过现有数量")
+	}
+
+	// 跌破阈值只提醒一次，继续减少不再提醒
+	if got := reorderEvents(sub); len(got) != 1 || got[0] != 45 {
+		t.Fatalf("补购提醒 = %v，应为一次 45", got)
+	}
+	move(l.ReportTablewareLoss, "d1", 5)
+	move(l.IssueTableware, "d1", 10)
+	if got := reorderEvents(sub); len(got) != 0 {
+		t.Fatalf("重复的补购提醒 = %v", got)
+	}
+
+	// 入库回到阈值以上后重置，再次跌破时重新提醒
+	if stock := move(l.ReceiveTableware, "d1", 20); stock.ReorderAlerted {
+		t.Fatalf("补货后仍标记已提醒: %+v", stock)
+	}
+	move(l.ReportTablewareLoss, "d1", 21)
+	if got := reorderEvents(sub); len(got) != 1 || got[0] != 39 {
+		t.Fatalf("补购提醒 = %v，应为一次 39", got)
+	}
+
+	// 每个托管处单独计数
+	move(l.ReceiveTableware, "d2", 60)
+	if got := reorderEvents(sub); len(got) != 0 {
+		t.Fatalf("d2 不应提醒: %v", got)
+	}
+
+	now := time.Now()
+	losses, err := l.GetTablewareLosses(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(losses) != 1 || losses[0].DepotID != "d1" || losses[0].Quantity != 81 || losses[0].Reports != 4 || losses[0].TablewareName != "汤碗" {
+		t.Fatalf("丢失统计 = %+v", losses)
+	}
+	var movements int64
+	db.Model(&model.TablewareMovement{}).Count(&movements)
+	if movements != 10 {
+		t.Fatalf("餐具流水 %d 条，应为 10", movements)
+	}
+}
@@ -2,14 +2,18 @@ package logic
 
 // WalletChargeRequest 钱包充值请求
 type WalletChargeRequest struct {
-	UserID string  `json:"user_id"`
-	Amount float64 `json:"amount"`
+	UserID    string  `json:"user_id"`
+	Amount    float64 `json:"amount"`
+	Source    string  `json:"source,optional,default=payment,options=payment|subsidy"` // 用户支付或学校补贴
+	ExpiresAt string  `json:"expires_at,optional"`                                     // 补贴有效期至（含当天），格式 2006-01-02，为空表示长期有效
 }
 
 // BindPlateRequest 绑定餐盘请求
+// PlateID 与 QRPayload 至少提供一个；扫码绑定时以签名校验后的餐盘ID为准
 type BindPlateRequest struct {
-	UserID  string `json:"user_id"`
-	PlateID string `json:"plate_id"`
+	UserID    string `json:"user_id"`
+	PlateID   string `json:"plate_id,optional"`
+	QRPayload string `json:"qr_payload,optional"`
 }
 
 // UnbindPlateRequest 解绑餐盘请求
@@ -18,10 +22,25 @@ type UnbindPlateRequest struct {
 	PlateID string `json:"plate_id"`
 }
 
+// PlateQRCodeRequest 餐盘二维码请求
+type PlateQRCodeRequest struct {
+	PlateID string `path:"plate_id"`
+	Format  string `form:"format,optional,default=png,options=png|svg"`
+	Size    int    `form:"size,optional,default=256,range=[64:2048]"`
+}
+
+// PlateLabelsRequest 餐盘标签页请求
+type PlateLabelsRequest struct {
+	PlateIDs []string `json:"plate_ids,optional"`
+	StartID  string   `json:"start_id,optional"`
+	EndID    string   `json:"end_id,optional"`
+}
+
 // OrderFoodRequest 订单食物请求
 type OrderFoodRequest struct {
 	FoodID string  `json:"food_id"`
-	Weight float64 `json:"weight,optional"`
+	Weight float64 `json:"weight,optional"` // 汤品为容量（毫升）
+	Ladles int     `json:"ladles,optional"` // 汤品按勺
 }
 
 // OrderRequest 点餐请求
@@ -31,6 +50,12 @@ type OrderRequest struct {
 	Foods   []OrderFoodRequest `json:"foods"`
 }
 
+// OrderReceiptRequest 订单小票请求
+type OrderReceiptRequest struct {
+	OrderID string `path:"order_id"`
+	Format  string `form:"format,optional,default=text,options=text|escpos|pdf"`
+}
+
 // UserOrderListRequest 获取用户订单列表请求
 type UserOrderListRequest struct {
 	UserID   string `json:"user_id"`
@@ -48,6 +73,270 @@ type WorkerExceptionRequest struct {
 
 // GCProcessRequest GC处理请求
 type GCProcessRequest struct {
-	PlateID string `json:"plate_id"`
-	Type    string `json:"type"` // "plate" or "food_waste"
+	PlateID  string `json:"plate_id"`
+	Type     string `json:"type"`               // "plate" or "food_waste"
+	WorkerID string `json:"worker_id,optional"` // 为空表示系统自动处理
+}
+
+// PlateTimelineRequest 餐盘时间线请求，BeforeID 为上一页最后一条事件的ID
+type PlateTimelineRequest struct {
+	PlateID  string `path:"plate_id"`
+	Limit    int    `form:"limit,optional,default=100,range=[1:500]"`
+	BeforeID uint   `form:"before_id,optional"`
+}
+
+// CreateCanteenRequest 创建食堂请求
+type CreateCanteenRequest struct {
+	ID       string `json:"id,optional"`
+	Name     string `json:"name"`
+	Location string `json:"location,optional"`
+}
+
+// UserTokenRequest 为用户签发访问令牌
+type UserTokenRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// AccountCloseRequest 销户请求
+type AccountCloseRequest struct {
+	UserID   string `json:"user_id"`
+	WorkerID string `json:"worker_id"`
+	Reason   string `json:"reason,optional"`
+}
+
+// AccountClosureRequest 查询销户记录，format=text 或 pdf 时返回销户证明
+type AccountClosureRequest struct {
+	UserID string `path:"user_id"`
+	Format string `form:"format,optional,default=json,options=json|text|pdf"`
+}
+
+// PersonalDataExportRequest 导出用户个人数据，format=zip 时按类别拆成多个 JSON 文件
+type PersonalDataExportRequest struct {
+	UserID string `path:"user_id"`
+	Format string `form:"format,optional,default=json,options=json|zip"`
+}
+
+// MyPersonalDataExportRequest 用户导出自己的个人数据，用户ID取自用户令牌
+type MyPersonalDataExportRequest struct {
+	Format string `form:"format,optional,default=json,options=json|zip"`
+}
+
+// PersonalDataEraseRequest 匿名化用户个人信息请求
+type PersonalDataEraseRequest struct {
+	UserID   string `json:"user_id"`
+	WorkerID string `json:"worker_id"`
+}
+
+// TrialBalanceRequest 试算平衡表请求，to 为空时统计到当前，detail 为 true 时逐个列出用户钱包
+type TrialBalanceRequest struct {
+	To     string `form:"to,optional"`
+	Detail bool   `form:"detail,optional"`
+}
+
+// CanteenReportRequest 跨食堂报表请求，日期格式 2006-01-02，区间左闭右闭
+type CanteenReportRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// CreateStationRequest 创建档口请求
+type CreateStationRequest struct {
+	ID     string `json:"id,optional"`
+	Name   string `json:"name"`
+	FoodID string `json:"food_id,optional"`
+}
+
+// StationFoodRequest 更换档口菜品请求，food_id 为空表示暂停供应
+type StationFoodRequest struct {
+	StationID string `json:"station_id"`
+	FoodID    string `json:"food_id,optional"`
+}
+
+// StationDeviceRequest 分配档口设备请求，设备需先登记
+type StationDeviceRequest struct {
+	DeviceID  string `json:"device_id"`
+	StationID string `json:"station_id"`
+}
+
+// WeightIngestRequest 电子秤称重上报请求
+type WeightIngestRequest struct {
+	DeviceID string  `json:"device_id,optional"` // 为空时使用签名中的设备ID
+	PlateID  string  `json:"plate_id,optional"`  // 为空表示空秤读数
+	Weight   float64 `json:"weight"`             // 净重（克）
+}
+
+// StationStatsRequest 档口统计请求，日期格式 2006-01-02，区间左闭右闭
+type StationStatsRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// RegisterDeviceRequest 登记设备请求
+type RegisterDeviceRequest struct {
+	DeviceID  string `json:"device_id"`
+	Type      string `json:"type,options=scale|reader|dispenser"`
+	StationID string `json:"station_id,optional"`
+	Firmware  string `json:"firmware,optional"`
+}
+
+// DeviceRequest 按设备ID操作的请求（轮换密钥、停用）
+type DeviceRequest struct {
+	DeviceID string `json:"device_id"`
+}
+
+// DeviceListRequest 设备列表请求
+type DeviceListRequest struct {
+	Status string `form:"status,optional,options=|online|offline|retired"`
+}
+
+// DeviceHeartbeatRequest 设备心跳请求
+type DeviceHeartbeatRequest struct {
+	DeviceID string `json:"device_id,optional"` // 为空时使用签名中的设备ID
+	Firmware string `json:"firmware,optional"`
+}
+
+// OrderReviewRequest 审核挂起订单请求
+type OrderReviewRequest struct {
+	OrderID  string `json:"order_id"`
+	WorkerID string `json:"worker_id"`
+	Approve  bool   `json:"approve"`
+	Remark   string `json:"remark,optional"`
+}
+
+// FoodBatchRequest 厨房出餐（补货）请求
+type FoodBatchRequest struct {
+	FoodID   string  `json:"food_id"`
+	WorkerID string  `json:"worker_id"`
+	Weight   float64 `json:"weight"` // 克
+	Remark   string  `json:"remark,optional"`
+}
+
+// FoodDiscardRequest 报废菜品请求
+type FoodDiscardRequest struct {
+	FoodID   string  `json:"food_id"`
+	WorkerID string  `json:"worker_id"`
+	Weight   float64 `json:"weight"` // 克
+	Remark   string  `json:"remark,optional"`
+}
+
+// FoodStockRequest 菜品库存查询请求，为空时取当前餐次
+type FoodStockRequest struct {
+	Date   string `form:"date,optional"` // 2006-01-02
+	Period string `form:"period,optional,options=|breakfast|lunch|dinner"`
+}
+
+// CalendarDayRequest 登记校历请求
+type CalendarDayRequest struct {
+	Date   string `json:"date"` // 2006-01-02
+	Type   string `json:"type,options=term|exam|vacation|holiday"`
+	Remark string `json:"remark,optional"`
+}
+
+// CalendarListRequest 校历查询请求，日期格式 2006-01-02，区间左闭右闭
+type CalendarListRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// DemandForecastRequest 备餐量预测请求
+type DemandForecastRequest struct {
+	From string `form:"from,optional"` // 为空时从明天开始
+	Days int    `form:"days,default=1,range=[1:14]"`
+}
+
+// CreateSoupRequest 创建汤品请求
+type CreateSoupRequest struct {
+	SoupID      string  `json:"soup_id,optional"`
+	Name        string  `json:"name"`
+	PriceUnit   string  `json:"price_unit,options=ml|ladle"`
+	UnitPrice   float64 `json:"unit_price"`            // 每100毫升或每勺
+	LadleVolume float64 `json:"ladle_volume,optional"` // 每勺容量（毫升）
+}
+
+// CreateCauldronRequest 创建汤锅请求
+type CreateCauldronRequest struct {
+	CauldronID string  `json:"cauldron_id,optional"`
+	StationID  string  `json:"station_id"`
+	DeviceID   string  `json:"device_id,optional"`
+	Capacity   float64 `json:"capacity"` // 毫升
+}
+
+// CauldronDeviceRequest 更换打汤机请求，device_id 为空表示解除
+type CauldronDeviceRequest struct {
+	CauldronID string `json:"cauldron_id"`
+	DeviceID   string `json:"device_id,optional"`
+}
+
+// CauldronRefillRequest 加汤请求，soup_id 为空时沿用锅里原来的汤
+type CauldronRefillRequest struct {
+	CauldronID string  `json:"cauldron_id"`
+	SoupID     string  `json:"soup_id,optional"`
+	WorkerID   string  `json:"worker_id"`
+	Volume     float64 `json:"volume"` // 毫升
+}
+
+// CauldronEmptyRequest 清空汤锅请求
+type CauldronEmptyRequest struct {
+	CauldronID string `json:"cauldron_id"`
+	WorkerID   string `json:"worker_id"`
+	Remark     string `json:"remark,optional"`
+}
+
+// CauldronEventsRequest 汤锅事件查询请求
+type CauldronEventsRequest struct {
+	CauldronID string `form:"cauldron_id"`
+	Limit      int    `form:"limit,default=100,range=[1:1000]"`
+}
+
+// SoupDispenseRequest 打汤机出汤上报请求
+type SoupDispenseRequest struct {
+	DeviceID string  `json:"device_id,optional"` // 为空时使用签名中的设备ID
+	PlateID  string  `json:"plate_id"`
+	Volume   float64 `json:"volume,optional"` // 毫升
+	Ladles   int     `json:"ladles,optional"`
+}
+
+// CreateTablewareRequest 创建餐具种类请求
+type CreateTablewareRequest struct {
+	TablewareID  string `json:"tableware_id,optional"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind,options=bowl|plate|chopsticks|spoon|cup|other"`
+	ReorderLevel int    `json:"reorder_level,optional"` // 每个托管处的补购提醒阈值，0 表示不提醒
+}
+
+// TablewareMoveRequest 餐具入库、发放、回收、丢失登记请求
+type TablewareMoveRequest struct {
+	DepotID     string `json:"depot_id"`
+	TablewareID string `json:"tableware_id"`
+	WorkerID    string `json:"worker_id"`
+	Quantity    int    `json:"quantity"`
+	Remark      string `json:"remark,optional"`
+}
+
+// TablewareStockRequest 餐具库存查询请求，depot_id 为空时返回所有托管处
+type TablewareStockRequest struct {
+	DepotID string `form:"depot_id,optional"`
+}
+
+// TablewareLossRequest 餐具丢失统计请求，日期格式 2006-01-02，区间左闭右闭
+type TablewareLossRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// InboxRequest 站内信查询请求
+type InboxRequest struct {
+	Unread bool `form:"unread,optional"` // 只看未读
+	Limit  int  `form:"limit,default=50,range=[1:200]"`
+}
+
+// InboxReadRequest 站内信标记已读请求，ids 为空表示全部
+type InboxReadRequest struct {
+	IDs []uint `json:"ids,optional"`
+}
+
+// NotificationPreferenceRequest 设置通知偏好请求，channels 为空表示不接收
+type NotificationPreferenceRequest struct {
+	Kind     string   `json:"kind,options=order_paid|refund_issued|low_balance|plate_auto_unbound"`
+	Channels []string `json:"channels,optional"`
 }
@@ -0,0 +1,338 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/anomaly"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/gorm"
+)
+
+// WeightIngestResult 称重上报结果
+type WeightIngestResult struct {
+	Reading  *model.WeightReading
+	Order    *model.Order // 读数被丢弃或空秤读数时为空
+	Findings []anomaly.Finding
+}
+
+// IngestWeight 档口电子秤上报称重结果，按档口当前菜品给餐盘的持有人下单
+// weight 为本次打菜的净重（克），由电子秤去皮后上报；plateID 为空表示空秤读数，用于检测漂移。
+// 读数先经过异常检测：负读数和空秤漂移直接丢弃，可疑读数生成的订单挂起待审核，两种情况都会自动生成异常记录。
+func (l *RestaurantLogic) IngestWeight(ctx context.Context, deviceID, plateID string, weight float64) (*WeightIngestResult, error) {
+	if plateID != "" && weight == 0 {
+		return nil, errors.New("称重结果不能为0")
+	}
+
+	device, err := l.activeDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Type != "scale" {
+		return nil, errors.New("只有电子秤可以上报重量")
+	}
+	if device.StationID == "" {
+		return nil, errors.New("设备未分配到档口")
+	}
+
+	var station model.Station
+	if err := l.db.WithContext(ctx).Where("id = ?", device.StationID).First(&station).Error; err != nil {
+		return nil, fmt.Errorf("档口不存在: %w", err)
+	}
+
+	var plate model.Plate
+	if plateID != "" {
+		if err := l.db.WithContext(ctx).Scopes(sameCanteen(station.CanteenID)).Where("id = ?", plateID).First(&plate).Error; err != nil {
+			return nil, fmt.Errorf("餐盘不存在: %w", err)
+		}
+	}
+
+	now := time.Now()
+	recent, err := l.recentReadings(ctx, plateID, now)
+	if err != nil {
+		return nil, err
+	}
+	findings := l.anomaly.Check(anomaly.Reading{
+		DeviceID:  device.ID,
+		StationID: station.ID,
+		PlateID:   plateID,
+		Weight:    weight,
+		At:        now,
+	}, recent)
+
+	reading := model.WeightReading{
+		DeviceID:  device.ID,
+		StationID: station.ID,
+		PlateID:   plateID,
+		Weight:    weight,
+		Anomalies: anomalyRules(findings),
+		CreatedAt: now,
+	}
+	result := &WeightIngestResult{Reading: &reading, Findings: findings}
+
+	// 空秤读数和被丢弃的读数只做记录
+	if plateID == "" || rejected(findings) {
+		reading.Status = "idle"
+		if plateID != "" {
+			reading.Status = "rejected"
+		}
+		opened := false
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&reading).Error; err != nil {
+				return fmt.Errorf("记录称重读数失败: %w", err)
+			}
+			if len(findings) == 0 {
+				return nil
+			}
+			opened = true
+			return createReadingException(tx, &reading, &station, findings, "", "读数已丢弃，请检查电子秤")
+		})
+		if err != nil {
+			return nil, err
+		}
+		if opened {
+			l.bus.Publish(event.Event{Type: event.ExceptionOpened, CanteenID: station.CanteenID, PlateID: plateID})
+		}
+		return result, nil
+	}
+
+	if !station.IsActive || station.FoodID == "" {
+		return nil, fmt.Errorf("档口当前没有供应菜品: %s", station.Name)
+	}
+	if !plate.IsBound {
+		return nil, errors.New("餐盘未绑定用户")
+	}
+
+	// 订单按设备所在食堂处理
+	ctx = tenant.WithCanteen(ctx, station.CanteenID)
+	items, totalPrice, err := l.buildOrderItems(ctx, &plate, []OrderFood{{
+		FoodID:    station.FoodID,
+		Weight:    weight,
+		StationID: station.ID,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	hold := len(findings) > 0
+	reading.Status = "accepted"
+	if hold {
+		reading.Status = "held"
+	}
+	order, err := l.placeOrder(ctx, &plate, items, totalPrice, "device", device.ID, hold, func(tx *gorm.DB, order *model.Order) error {
+		reading.OrderID = order.ID
+		if err := tx.Create(&reading).Error; err != nil {
+			return fmt.Errorf("记录称重读数失败: %w", err)
+		}
+		if err := tx.Model(&model.OrderItem{}).Where("order_id = ?", order.ID).Update("reading_id", reading.ID).Error; err != nil {
+			return fmt.Errorf("关联称重读数失败: %w", err)
+		}
+		if !hold {
+			return nil
+		}
+		return createReadingException(tx, &reading, &station, findings, order.ID, "订单已挂起，请审核")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hold {
+		l.bus.Publish(event.Event{Type: event.ExceptionOpened, CanteenID: station.CanteenID, PlateID: plateID})
+	}
+
+	result.Order = order
+	return result, nil
+}
+
+// recentReadings 查询餐盘在检测窗口内未被丢弃的读数
+func (l *RestaurantLogic) recentReadings(ctx context.Context, plateID string, now time.Time) ([]anomaly.Reading, error) {
+	if plateID == "" || l.anomaly.StationWindow <= 0 {
+		return nil, nil
+	}
+
+	var readings []model.WeightReading
+	if err := l.db.WithContext(ctx).
+		Where("plate_id = ? AND status <> ? AND created_at > ?", plateID, "rejected", now.Add(-l.anomaly.StationWindow)).
+		Find(&readings).Error; err != nil {
+		return nil, fmt.Errorf("查询称重读数失败: %w", err)
+	}
+
+	recent := make([]anomaly.Reading, 0, len(readings))
+	for _, r := range readings {
+		recent = append(recent, anomaly.Reading{
+			DeviceID:  r.DeviceID,
+			StationID: r.StationID,
+			PlateID:   r.PlateID,
+			Weight:    r.Weight,
+			At:        r.CreatedAt,
+		})
+	}
+	return recent, nil
+}
+
+// GetHeldOrders 获取挂起待审核的订单
+func (l *RestaurantLogic) GetHeldOrders(ctx context.Context) ([]model.Order, error) {
+	var orders []model.Order
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Preload("OrderItems").
+		Where("status = ?", "held").Order("created_at ASC").Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("查询待审核订单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// ReviewHeldOrder 工作人员审核挂起的订单：通过时正常扣款，驳回时取消订单
+// 订单关联的异常记录随审核结果关闭
+func (l *RestaurantLogic) ReviewHeldOrder(ctx context.Context, orderID, workerID string, approve bool, remark string) (*model.Order, error) {
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+
+	var order model.Order
+	if err := l.db.WithContext(ctx).Scopes(sameCanteen(worker.CanteenID)).Preload("OrderItems").
+		Where("id = ?", orderID).First(&order).Error; err != nil {
+		return nil, fmt.Errorf("订单不存在: %w", err)
+	}
+	if order.Status != "held" {
+		return nil, fmt.Errorf("订单不需要审核，当前状态: %s", order.Status)
+	}
+
+	var plate model.Plate
+	if err := l.db.WithContext(ctx).Where("id = ?", order.PlateID).First(&plate).Error; err != nil {
+		return nil, fmt.Errorf("餐盘不存在: %w", err)
+	}
+
+	action, readingStatus := "审核通过", "accepted"
+	if !approve {
+		action, readingStatus = "审核驳回", "rejected"
+	}
+	if remark != "" {
+		action += "：" + remark
+	}
+
+	var balance float64
+	var resolved int64
+	var stockEvents []event.Event
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 带上状态条件，避免重复审核
+		result := tx.Model(&model.Order{}).Where("id = ? AND status = ?", order.ID, "held").Update("status", "pending")
+		if result.Error != nil {
+			return fmt.Errorf("更新订单状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("订单已被审核")
+		}
+
+		if approve {
+			var err error
+			if balance, err = l.chargeOrder(tx, &order); err != nil {
+				return err
+			}
+		} else {
+			order.Status = "cancelled"
+			if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+				return fmt.Errorf("取消订单失败: %w", err)
+			}
+			var err error
+			if stockEvents, err = l.restoreStock(tx, &order); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&model.OrderItem{}).Where("order_id = ?", order.ID).Update("held", false).Error; err != nil {
+			return fmt.Errorf("更新订单明细失败: %w", err)
+		}
+		if err := tx.Model(&model.WeightReading{}).Where("order_id = ?", order.ID).Update("status", readingStatus).Error; err != nil {
+			return fmt.Errorf("更新称重读数失败: %w", err)
+		}
+
+		exceptions := tx.Model(&model.ExceptionLog{}).Where("order_id = ? AND status = ?", order.ID, "pending").
+			Updates(map[string]interface{}{"status": "resolved", "action": action})
+		if exceptions.Error != nil {
+			return fmt.Errorf("关闭异常记录失败: %w", exceptions.Error)
+		}
+		resolved = exceptions.RowsAffected
+
+		return recordPlateEvent(tx, model.PlateEvent{
+			PlateID:    plate.ID,
+			Type:       "order",
+			PrevStatus: plate.Status,
+			NewStatus:  plate.Status,
+			ActorType:  "worker",
+			ActorID:    workerID,
+			UserID:     order.UserID,
+			OrderID:    order.ID,
+			Remark:     action,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range order.OrderItems {
+		order.OrderItems[i].Held = false
+	}
+	if approve {
+		l.publishOrder(ctx, &plate, &order, order.OrderItems, balance)
+	}
+	for i := int64(0); i < resolved; i++ {
+		l.bus.Publish(event.Event{Type: event.ExceptionResolved, CanteenID: order.CanteenID, PlateID: order.PlateID})
+	}
+	l.bus.Publish(stockEvents...)
+	return &order, nil
+}
+
+// createReadingException 为异常读数生成异常记录
+func createReadingException(tx *gorm.DB, reading *model.WeightReading, station *model.Station, findings []anomaly.Finding, orderID, action string) error {
+	if err := ensureSystemWorker(tx); err != nil {
+		return err
+	}
+
+	messages := make([]string, 0, len(findings))
+	for _, f := range findings {
+		messages = append(messages, f.Message)
+	}
+	target := "空秤"
+	if reading.PlateID != "" {
+		target = "餐盘 " + reading.PlateID
+	}
+
+	exceptionLog := model.ExceptionLog{
+		WorkerID:  systemWorkerID,
+		PlateID:   reading.PlateID,
+		DeviceID:  reading.DeviceID,
+		OrderID:   orderID,
+		ReadingID: reading.ID,
+		Exception: fmt.Sprintf("称重读数异常（档口 %s，设备 %s，%s，读数 %.1f 克）：%s",
+			station.Name, reading.DeviceID, target, reading.Weight, strings.Join(messages, "；")),
+		Action: action,
+		Status: "pending",
+	}
+	if err := tx.Create(&exceptionLog).Error; err != nil {
+		return fmt.Errorf("记录读数异常失败: %w", err)
+	}
+	return nil
+}
+
+// anomalyRules 命中的规则代码，逗号分隔
+func anomalyRules(findings []anomaly.Finding) string {
+	rules := make([]string, 0, len(findings))
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+	return strings.Join(rules, ",")
+}
+
+// rejected 是否有需要丢弃读数的规则
+func rejected(findings []anomaly.Finding) bool {
+	for _, f := range findings {
+		if f.Reject() {
+			return true
+		}
+	}
+	return false
+}
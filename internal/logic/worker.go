@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+)
+
+// workerRoles 可以创建的工作人员角色，system 由系统内部使用
+var workerRoles = map[string]bool{
+	"staff":   true,
+	"manager": true,
+	"gc":      true,
+}
+
+// CreateWorker 创建工作人员，归属于调用方所在的食堂，id 为空时自动生成
+func (l *RestaurantLogic) CreateWorker(ctx context.Context, id, name, role, phone string) (*model.Worker, error) {
+	if name == "" {
+		return nil, errors.New("工作人员姓名不能为空")
+	}
+	if role == "" {
+		role = "staff"
+	}
+	if !workerRoles[role] {
+		return nil, fmt.Errorf("未知的角色: %s", role)
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	worker := model.Worker{
+		ID:        id,
+		CanteenID: tenant.CanteenID(ctx),
+		Name:      name,
+		Role:      role,
+		Phone:     phone,
+		IsActive:  true,
+	}
+	if err := l.db.WithContext(ctx).Create(&worker).Error; err != nil {
+		return nil, fmt.Errorf("创建工作人员失败: %w", err)
+	}
+	return &worker, nil
+}
+
+// ActiveWorker 查询在岗的工作人员，用于校验工作人员令牌，不按食堂过滤（令牌决定食堂）
+func (l *RestaurantLogic) ActiveWorker(ctx context.Context, workerID string) (*model.Worker, error) {
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+	if !worker.IsActive || !workerRoles[worker.Role] {
+		return nil, fmt.Errorf("工作人员已停用: %s", workerID)
+	}
+	return &worker, nil
+}
+
+// GetWorkerList 工作人员列表，不含系统工作人员
+func (l *RestaurantLogic) GetWorkerList(ctx context.Context) ([]model.Worker, error) {
+	var workers []model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id <> ?", systemWorkerID).
+		Order("created_at ASC").Find(&workers).Error; err != nil {
+		return nil, fmt.Errorf("查询工作人员失败: %w", err)
+	}
+	return workers, nil
+}
+
+// GetExceptions 异常记录列表，status 为空时不限状态，按时间倒序
+func (l *RestaurantLogic) GetExceptions(ctx context.Context, status string, limit int) ([]model.ExceptionLog, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := l.db.WithContext(ctx).Model(&model.ExceptionLog{})
+	if canteenID := tenant.CanteenID(ctx); canteenID != "" {
+		query = query.Where("worker_id IN (?)", l.db.Model(&model.Worker{}).Select("id").Where("canteen_id = ? OR id = ?", canteenID, systemWorkerID))
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var logs []model.ExceptionLog
+	if err := query.Order("id DESC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询异常记录失败: %w", err)
+	}
+	return logs, nil
+}
+
+// ResolveException 工作人员关闭一条待处理的异常记录，action 为处理结果，追加到原处理措施之后。
+// 挂起订单的异常需要通过订单审核关闭
+func (l *RestaurantLogic) ResolveException(ctx context.Context, id uint, workerID, action string) (*model.ExceptionLog, error) {
+	if strings.TrimSpace(action) == "" {
+		return nil, errors.New("处理结果不能为空")
+	}
+	var worker model.Worker
+	if err := l.db.WithContext(ctx).Scopes(inCanteen(ctx)).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, fmt.Errorf("工作人员不存在: %w", err)
+	}
+
+	var log model.ExceptionLog
+	if err := l.db.WithContext(ctx).Where("id = ?", id).First(&log).Error; err != nil {
+		return nil, fmt.Errorf("异常记录不存在: %w", err)
+	}
+	if log.OrderID != "" {
+		return nil, errors.New("挂起订单的异常请通过订单审核处理")
+	}
+	if log.Status != "pending" {
+		return nil, fmt.Errorf("异常已处理，当前状态: %s", log.Status)
+	}
+
+	action = log.Action + "；" + worker.Name + "：" + action
+	if runes := []rune(action); len(runes) > 255 {
+		// action 列最长 255 个字符
+		action = string(runes[:255])
+	}
+	result := l.db.WithContext(ctx).Model(&model.ExceptionLog{}).Where("id = ? AND status = ?", id, "pending").
+		Updates(map[string]interface{}{"status": "resolved", "action": action})
+	if result.Error != nil {
+		return nil, fmt.Errorf("关闭异常失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("异常已处理")
+	}
+	log.Status = "resolved"
+	log.Action = action
+
+	l.bus.Publish(event.Event{Type: event.ExceptionResolved, CanteenID: worker.CanteenID})
+	return &log, nil
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/p-program/Fenrir/devicesign"
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// maxDeviceBody 设备请求体大小上限
+const maxDeviceBody = 1 << 20
+
+// maxNonceLen nonce 的长度上限，与 device_nonces 表的列宽一致
+const maxNonceLen = 64
+
+// DeviceSecretFunc 根据设备ID查询设备密钥和所属食堂，设备不存在或已停用时返回错误
+type DeviceSecretFunc func(ctx context.Context, deviceID string) (secret, canteenID string, err error)
+
+// NonceFunc 记录设备用过的 nonce，在 expiresAt 之前重复使用时返回 logic.ErrNonceUsed。
+// 记录需要在多副本之间共享，否则重放到另一个副本的请求无法识别
+type NonceFunc func(ctx context.Context, deviceID, nonce string, expiresAt time.Time) error
+
+// DeviceSignMiddleware 校验设备请求签名（见 devicesign 包），拒绝时间戳超出偏差窗口或 nonce 重复的请求。
+// 签名通过后以设备所属食堂作为调用方食堂，不读取 X-Canteen-ID 请求头
+type DeviceSignMiddleware struct {
+	secret DeviceSecretFunc
+	nonce  NonceFunc
+	skew   time.Duration
+}
+
+func NewDeviceSignMiddleware(secret DeviceSecretFunc, nonce NonceFunc, skew time.Duration) *DeviceSignMiddleware {
+	return &DeviceSignMiddleware{
+		secret: secret,
+		nonce:  nonce,
+		skew:   skew,
+	}
+}
+
+func (m *DeviceSignMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID := r.Header.Get(devicesign.HeaderDeviceID)
+		nonce := r.Header.Get(devicesign.HeaderNonce)
+		signature := r.Header.Get(devicesign.HeaderSignature)
+		timestamp, err := strconv.ParseInt(r.Header.Get(devicesign.HeaderTimestamp), 10, 64)
+		if deviceID == "" || nonce == "" || signature == "" || err != nil {
+			unauthorized(w, r, "缺少设备签名")
+			return
+		}
+		if len(nonce) > maxNonceLen {
+			unauthorized(w, r, "nonce 过长")
+			return
+		}
+
+		now := time.Now()
+		if diff := now.Sub(time.Unix(timestamp, 0)); diff > m.skew || diff < -m.skew {
+			unauthorized(w, r, "请求时间戳超出允许范围")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDeviceBody))
+		if err != nil {
+			unauthorized(w, r, "读取请求体失败")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		secret, canteenID, err := m.secret(r.Context(), deviceID)
+		if err == nil && canteenID == "" {
+			err = errors.New("设备未归属食堂")
+		}
+		if err != nil || secret == "" {
+			logx.WithContext(r.Context()).Infof("设备签名校验失败: %s, %v", deviceID, err)
+			unauthorized(w, r, "设备签名无效")
+			return
+		}
+		if !devicesign.Verify(secret, signature, r.Method, r.URL.RequestURI(), timestamp, nonce, body) {
+			unauthorized(w, r, "设备签名无效")
+			return
+		}
+		// 签名通过后再记录 nonce，避免伪造请求占用合法设备的 nonce。
+		// 超出偏差窗口的请求会直接被拒绝，nonce 只需保留两倍窗口
+		if err := m.nonce(r.Context(), deviceID, nonce, now.Add(2*m.skew)); err != nil {
+			if errors.Is(err, logic.ErrNonceUsed) {
+				unauthorized(w, r, "重复的请求")
+				return
+			}
+			logx.WithContext(r.Context()).Errorf("记录设备 nonce 失败: %s, %v", deviceID, err)
+			httpx.WriteJsonCtx(r.Context(), w, http.StatusInternalServerError, map[string]interface{}{
+				"code": http.StatusInternalServerError,
+				"msg":  "校验请求失败",
+			})
+			return
+		}
+
+		ctx := tenant.WithCanteen(auth.WithDevice(r.Context(), deviceID), canteenID)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	httpx.WriteJsonCtx(r.Context(), w, http.StatusUnauthorized, map[string]interface{}{
+		"code": http.StatusUnauthorized,
+		"msg":  msg,
+	})
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/devicesign"
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/tenant"
+)
+
+func deviceSecret(ctx context.Context, deviceID string) (string, string, error) {
+	if deviceID == "scale-1" {
+		return "secret", "north", nil
+	}
+	return "", "", errors.New("设备不存在")
+}
+
+// memoryNonces 测试用的 nonce 记录，数据库实现见 logic.UseDeviceNonce
+type memoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (n *memoryNonces) use(ctx context.Context, deviceID, nonce string, expiresAt time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.seen[deviceID+"\n"+nonce] {
+		return logic.ErrNonceUsed
+	}
+	n.seen[deviceID+"\n"+nonce] = true
+	return nil
+}
+
+func TestDeviceSignMiddleware(t *testing.T) {
+	nonces := &memoryNonces{seen: map[string]bool{}}
+	m := NewDeviceSignMiddleware(deviceSecret, nonces.use, 5*time.Minute)
+	var gotDevice, gotCanteen string
+	handler := m.Handle(func(w http.ResponseWriter, r *http.Request) {
+		gotDevice, _ = auth.DeviceIDFromContext(r.Context())
+		gotCanteen = tenant.CanteenID(r.Context())
+	})
+
+	const path = "/api/device/heartbeat"
+	body := `{"firmware":"1.2.0"}`
+	request := func(deviceID, secret string, ts time.Time, nonce string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set(devicesign.HeaderDeviceID, deviceID)
+		r.Header.Set(devicesign.HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+		r.Header.Set(devicesign.HeaderNonce, nonce)
+		r.Header.Set(devicesign.HeaderSignature, devicesign.Sign(secret, http.MethodPost, path, ts.Unix(), nonce, []byte(body)))
+		return r
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		r          *http.Request
+		wantStatus int
+	}{
+		{"缺少签名", httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)), http.StatusUnauthorized},
+		{"签名密钥错误", request("scale-1", "wrong", now, "n1"), http.StatusUnauthorized},
+		{"设备不存在", request("scale-9", "secret", now, "n2"), http.StatusUnauthorized},
+		{"时间戳太早", request("scale-1", "secret", now.Add(-6*time.Minute), "n3"), http.StatusUnauthorized},
+		{"时间戳太晚", request("scale-1", "secret", now.Add(6*time.Minute), "n4"), http.StatusUnauthorized},
+		{"nonce 过长", request("scale-1", "secret", now, strings.Repeat("n", maxNonceLen+1)), http.StatusUnauthorized},
+		{"窗口内的时钟偏差", request("scale-1", "secret", now.Add(-4*time.Minute), "n5"), http.StatusOK},
+		{"签名正确", request("scale-1", "secret", now, "n6"), http.StatusOK},
+		{"重放", request("scale-1", "secret", now, "n6"), http.StatusUnauthorized},
+		// 签名错误的请求不占用 nonce
+		{"签名错误后使用同一 nonce", request("scale-1", "secret", now, "n1"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDevice, gotCanteen = "", ""
+			w := httptest.NewRecorder()
+			handler(w, tt.r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && (gotDevice != "scale-1" || gotCanteen != "north") {
+				t.Fatalf("device = %q, canteen = %q", gotDevice, gotCanteen)
+			}
+		})
+	}
+
+	// 记录 nonce 失败时拒绝请求，不放行
+	failing := NewDeviceSignMiddleware(deviceSecret, func(context.Context, string, string, time.Time) error {
+		return errors.New("数据库不可用")
+	}, 5*time.Minute)
+	w := httptest.NewRecorder()
+	failing.Handle(func(http.ResponseWriter, *http.Request) { t.Fatal("不应放行") })(w, request("scale-1", "secret", now, "n7"))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// hqRole 总部管理员的角色，总部工作人员不属于任何食堂
+const hqRole = "manager"
+
+// WorkerFunc 根据工作人员ID查询在岗的工作人员，不存在或已停用时返回错误
+type WorkerFunc func(ctx context.Context, workerID string) (*model.Worker, error)
+
+// StaffAuthMiddleware 校验工作人员令牌对应的工作人员和角色，并把工作人员所属食堂写入请求上下文。
+// 令牌签名由 go-zero 的 JWT 中间件校验，这里只处理声明中的 worker_id。
+// 食堂工作人员只能访问本食堂，X-Canteen-ID 请求头与所属食堂不一致时拒绝；
+// 总部工作人员通过 X-Canteen-ID 请求头选择食堂
+type StaffAuthMiddleware struct {
+	worker WorkerFunc
+	roles  map[string]bool
+	hq     bool
+}
+
+// NewStaffAuthMiddleware 只允许指定角色的工作人员访问按食堂隔离的接口，无法确定食堂时拒绝
+func NewStaffAuthMiddleware(worker WorkerFunc, roles ...string) *StaffAuthMiddleware {
+	m := &StaffAuthMiddleware{worker: worker, roles: make(map[string]bool, len(roles))}
+	for _, role := range roles {
+		m.roles[role] = true
+	}
+	return m
+}
+
+// NewHQAuthMiddleware 只允许总部管理员访问跨食堂的接口，X-Canteen-ID 请求头可选
+func NewHQAuthMiddleware(worker WorkerFunc) *StaffAuthMiddleware {
+	m := NewStaffAuthMiddleware(worker, hqRole)
+	m.hq = true
+	return m
+}
+
+func (m *StaffAuthMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		workerID, err := auth.WorkerIDFromContext(r.Context())
+		if err != nil {
+			unauthorized(w, r, err.Error())
+			return
+		}
+		worker, err := m.worker(r.Context(), workerID)
+		if err != nil {
+			logx.WithContext(r.Context()).Infof("工作人员令牌校验失败: %s, %v", workerID, err)
+			unauthorized(w, r, "工作人员不存在或已停用")
+			return
+		}
+		if !m.roles[worker.Role] {
+			forbidden(w, r, "无权访问")
+			return
+		}
+
+		canteenID := r.Header.Get(tenant.Header)
+		switch {
+		case m.hq && worker.CanteenID != "":
+			forbidden(w, r, "仅限总部管理员")
+			return
+		case worker.CanteenID != "" && canteenID != "" && canteenID != worker.CanteenID:
+			forbidden(w, r, "不能访问其他食堂")
+			return
+		case worker.CanteenID != "":
+			canteenID = worker.CanteenID
+		case canteenID == "" && !m.hq:
+			httpx.WriteJsonCtx(r.Context(), w, http.StatusBadRequest, map[string]interface{}{
+				"code": http.StatusBadRequest,
+				"msg":  "总部工作人员需要通过 " + tenant.Header + " 请求头指定食堂",
+			})
+			return
+		}
+
+		ctx := r.Context()
+		if canteenID != "" {
+			ctx = tenant.WithCanteen(ctx, canteenID)
+		}
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request, msg string) {
+	httpx.WriteJsonCtx(r.Context(), w, http.StatusForbidden, map[string]interface{}{
+		"code": http.StatusForbidden,
+		"msg":  msg,
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/model"
+)
+
+var testWorkers = map[string]*model.Worker{
+	"north-staff": {ID: "north-staff", CanteenID: "north", Role: "staff"},
+	"north-gc":    {ID: "north-gc", CanteenID: "north", Role: "gc"},
+	"hq-manager":  {ID: "hq-manager", Role: "manager"},
+}
+
+func findWorker(ctx context.Context, workerID string) (*model.Worker, error) {
+	if w, ok := testWorkers[workerID]; ok {
+		return w, nil
+	}
+	return nil, errors.New("工作人员不存在")
+}
+
+func TestStaffAuthMiddleware(t *testing.T) {
+	staff := NewStaffAuthMiddleware(findWorker, "staff", "manager")
+	hq := NewHQAuthMiddleware(findWorker)
+
+	tests := []struct {
+		name        string
+		m           *StaffAuthMiddleware
+		workerID    string
+		header      string
+		wantStatus  int
+		wantCanteen string
+	}{
+		{"没有令牌声明", staff, "", "", http.StatusUnauthorized, ""},
+		{"工作人员不存在", staff, "nobody", "", http.StatusUnauthorized, ""},
+		{"角色无权访问", staff, "north-gc", "", http.StatusForbidden, ""},
+		{"食堂取自工作人员", staff, "north-staff", "", http.StatusOK, "north"},
+		{"请求头与所属食堂一致", staff, "north-staff", "north", http.StatusOK, "north"},
+		{"不能访问其他食堂", staff, "north-staff", "south", http.StatusForbidden, ""},
+		{"总部工作人员必须选择食堂", staff, "hq-manager", "", http.StatusBadRequest, ""},
+		{"总部工作人员选择食堂", staff, "hq-manager", "south", http.StatusOK, "south"},
+		{"食堂工作人员不能访问总部接口", hq, "north-staff", "", http.StatusForbidden, ""},
+		{"总部接口不限食堂", hq, "hq-manager", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCanteen string
+			handler := tt.m.Handle(func(w http.ResponseWriter, r *http.Request) {
+				gotCanteen = tenant.CanteenID(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/plate/list", nil)
+			if tt.workerID != "" {
+				// go-zero 的 JWT 中间件以声明名作为 key 写入上下文
+				r = r.WithContext(context.WithValue(r.Context(), auth.ClaimWorkerID, tt.workerID))
+			}
+			if tt.header != "" {
+				r.Header.Set(tenant.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("状态码 = %d，应为 %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if gotCanteen != tt.wantCanteen {
+				t.Errorf("食堂 = %q，应为 %q", gotCanteen, tt.wantCanteen)
+			}
+		})
+	}
+}
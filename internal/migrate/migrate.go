@@ -0,0 +1,544 @@
+// Package migrate 版本化的数据库迁移。每个版本的 up/down SQL 按数据库类型放在 sql/<dialect>/ 下，
+// 编译进程序；已执行的版本记录在 schema_migrations 表中，schema_migrations_lock 表防止多个进程同时迁移
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var files embed.FS
+
+var (
+	// ErrLocked 其他进程正在迁移，或者上次迁移异常退出没有释放锁
+	ErrLocked = errors.New("数据库迁移已被锁定")
+	// ErrDirty 上次迁移执行到一半失败，需要人工修复后用 force 标记版本
+	ErrDirty = errors.New("数据库迁移未完成")
+	// ErrOutdated 数据库结构落后于程序
+	ErrOutdated = errors.New("数据库结构不是最新版本")
+	// ErrUnknownVersion 数据库中有程序不认识的版本，通常是用新版本程序迁移后又运行了旧版本
+	ErrUnknownVersion = errors.New("数据库结构版本高于程序")
+	// ErrUnknownSchema 没有迁移记录的已有数据库与初始版本的表结构不一致，不能确定从哪个版本开始迁移
+	ErrUnknownSchema = errors.New("数据库结构与初始版本不一致")
+)
+
+// baselineTable 改用版本化迁移之前 AutoMigrate 创建的表，存在时说明是已有的数据库
+const baselineTable = "users"
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"-"`
+	Down    string `json:"-"`
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Dirty     bool       `json:"dirty,omitempty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// record schema_migrations 中的一行
+type record struct {
+	Version   int
+	Name      string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// Load 读取某种数据库的全部迁移，按版本号排序。文件名为 <版本号>_<名称>.up.sql 和 .down.sql，版本号从 1 开始连续
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库类型: %s", dialect)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			return nil, fmt.Errorf("无效的迁移文件名: %s", name)
+		}
+		prefix, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("无效的迁移版本号: %s", name)
+		}
+		content, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("版本 %d 有多个迁移: %s, %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("迁移版本号不连续: 缺少版本 %d", i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("版本 %d 缺少 up 或 down 文件", m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+func cutDirection(name string) (string, string, bool) {
+	for _, direction := range []string{"up", "down"} {
+		if base, ok := strings.CutSuffix(name, "."+direction+".sql"); ok {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
+
+// splitStatements 把迁移文件拆成单条语句：语句以行尾的分号结束，忽略 -- 开头的注释行。
+// MySQL 驱动默认不允许一次执行多条语句，所以逐条执行
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Migrator 对一个数据库执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+	owner      string // 写入锁表，便于排查是谁持有锁
+}
+
+// New 按数据库类型加载迁移
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}, nil
+}
+
+// Migrations 全部迁移
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest 程序中最新的版本
+func (m *Migrator) Latest() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status 每个版本的执行状态，包括数据库中有、程序中没有的版本
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := records[mig.Version]; ok {
+			s.Applied, s.Dirty = true, r.Dirty
+			at := r.AppliedAt
+			s.AppliedAt = &at
+			delete(records, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range records {
+		at := r.AppliedAt
+		statuses = append(statuses, Status{Version: r.Version, Name: r.Name, Applied: true, Dirty: r.Dirty, AppliedAt: &at})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Version 数据库当前的版本（已执行的最大版本），没有执行过任何迁移时为 0
+func (m *Migrator) Version(ctx context.Context) (int, bool, error) {
+	records, err := m.records(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	version, dirty := current(records)
+	return version, dirty, nil
+}
+
+// Check 检查数据库结构与程序一致：有未执行的版本、未完成的迁移或者程序不认识的版本时返回错误，不做任何修改
+func (m *Migrator) Check(ctx context.Context) error {
+	records, err := m.records(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(records); err != nil {
+		return err
+	}
+	for _, mig := range m.migrations {
+		if _, ok := records[mig.Version]; !ok {
+			version, _ := current(records)
+			return fmt.Errorf("%w: 当前版本 %d，最新版本 %d，未执行 %d_%s", ErrOutdated, version, m.Latest(), mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// Up 执行未执行的迁移直到版本 to，to <= 0 时执行到最新版本，返回本次执行的迁移。
+// 没有迁移记录但已有表的数据库（由 AutoMigrate 建表）先核对表结构，与版本 1 一致时把版本 1 记为已执行，
+// 不重复建表；不一致时返回 ErrUnknownSchema，不做任何修改
+func (m *Migrator) Up(ctx context.Context, to int) ([]Migration, error) {
+	if to <= 0 || to > m.Latest() {
+		to = m.Latest()
+	}
+
+	var applied []Migration
+	err := m.withLock(ctx, func(records map[int]record) error {
+		if err := m.verify(records); err != nil {
+			return err
+		}
+		if len(records) == 0 && m.db.WithContext(ctx).Migrator().HasTable(baselineTable) {
+			if err := m.verifyBaseline(ctx); err != nil {
+				return err
+			}
+			if err := m.insertRecord(m.db.WithContext(ctx), m.migrations[0], false); err != nil {
+				return err
+			}
+			records[m.migrations[0].Version] = record{}
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > to {
+				break
+			}
+			if _, ok := records[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mig, true); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 按版本从高到低回滚已执行的迁移，直到数据库版本为 to，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, to int) ([]Migration, error) {
+	if to < 0 {
+		return nil, fmt.Errorf("无效的目标版本: %d", to)
+	}
+
+	var reverted []Migration
+	err := m.withLock(ctx, func(records map[int]record) error {
+		if err := m.verify(records); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version <= to {
+				break
+			}
+			if _, ok := records[mig.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, mig, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force 把数据库版本标记为 version：version 及以下的版本记为已执行，以上的版本删除记录，不执行任何 SQL。
+// 用于迁移执行到一半失败、人工修复表结构之后
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("无效的版本: %d，最新版本 %d", version, m.Latest())
+	}
+	return m.withLock(ctx, func(records map[int]record) error {
+		return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("DELETE FROM schema_migrations WHERE version > ?", version).Error; err != nil {
+				return err
+			}
+			for _, mig := range m.migrations {
+				if mig.Version > version {
+					break
+				}
+				if _, ok := records[mig.Version]; ok {
+					if err := tx.Exec("UPDATE schema_migrations SET dirty = ? WHERE version = ?", false, mig.Version).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err := m.insertRecord(tx, mig, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Unlock 强制释放迁移锁，用于迁移进程异常退出之后
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if !m.db.WithContext(ctx).Migrator().HasTable("schema_migrations_lock") {
+		return nil
+	}
+	return m.db.WithContext(ctx).Exec("DELETE FROM schema_migrations_lock WHERE id = ?", 1).Error
+}
+
+// apply 执行一个迁移并更新记录。SQLite 和 PostgreSQL 的 DDL 支持事务，整个迁移在一个事务中执行；
+// MySQL 的 DDL 会隐式提交，先把版本记为未完成（dirty），全部语句执行成功后再清除
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) error {
+	sql, direction := mig.Up, "up"
+	if !up {
+		sql, direction = mig.Down, "down"
+	}
+	statements := splitStatements(sql)
+	run := func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("执行迁移 %d_%s.%s 失败: %w", mig.Version, mig.Name, direction, err)
+			}
+		}
+		return nil
+	}
+	db := m.db.WithContext(ctx)
+
+	if m.dialect != "mysql" {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := run(tx); err != nil {
+				return err
+			}
+			if up {
+				return m.insertRecord(tx, mig, false)
+			}
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+		})
+	}
+
+	if up {
+		if err := m.insertRecord(db, mig, true); err != nil {
+			return err
+		}
+	} else if err := db.Exec("UPDATE schema_migrations SET dirty = ? WHERE version = ?", true, mig.Version).Error; err != nil {
+		return err
+	}
+	if err := run(db); err != nil {
+		return err
+	}
+	if up {
+		return db.Exec("UPDATE schema_migrations SET dirty = ? WHERE version = ?", false, mig.Version).Error
+	}
+	return db.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+}
+
+func (m *Migrator) insertRecord(db *gorm.DB, mig Migration, dirty bool) error {
+	return db.Exec("INSERT INTO schema_migrations (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)",
+		mig.Version, mig.Name, dirty, time.Now()).Error
+}
+
+// withLock 持有迁移锁执行 fn，fn 拿到加锁后读取的迁移记录
+func (m *Migrator) withLock(ctx context.Context, fn func(records map[int]record) error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	db := m.db.WithContext(ctx)
+	if err := db.Exec("INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (?, ?, ?)", 1, m.owner, time.Now()).Error; err != nil {
+		// 主键冲突说明锁已被持有，各数据库的错误不同，统一查询锁表确认
+		var lock struct {
+			Owner    string
+			LockedAt time.Time
+		}
+		if db.Raw("SELECT owner, locked_at FROM schema_migrations_lock WHERE id = ?", 1).Scan(&lock).RowsAffected > 0 {
+			return fmt.Errorf("%w: 由 %s 于 %s 加锁", ErrLocked, lock.Owner, lock.LockedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	defer func() {
+		// 迁移的 ctx 可能已经取消，释放锁不使用 ctx
+		m.db.Exec("DELETE FROM schema_migrations_lock WHERE id = ? AND owner = ?", 1, m.owner)
+	}()
+
+	records, err := m.records(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(records)
+}
+
+// ensureTables 创建迁移记录表和锁表
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	timestamp := map[string]string{"mysql": "datetime(3)", "postgres": "timestamptz"}[m.dialect]
+	if timestamp == "" {
+		timestamp = "datetime"
+	}
+	db := m.db.WithContext(ctx)
+	if err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint NOT NULL PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    dirty boolean NOT NULL DEFAULT false,
+    applied_at %s NOT NULL
+)`, timestamp)).Error; err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	if err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id integer NOT NULL PRIMARY KEY,
+    owner varchar(255) NOT NULL,
+    locked_at %s NOT NULL
+)`, timestamp)).Error; err != nil {
+		return fmt.Errorf("创建迁移锁表失败: %w", err)
+	}
+	return nil
+}
+
+// records 已执行的迁移，还没有迁移记录表时为空
+func (m *Migrator) records(ctx context.Context) (map[int]record, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable("schema_migrations") {
+		return map[int]record{}, nil
+	}
+	var rows []record
+	if err := db.Raw("SELECT version, name, dirty, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	records := make(map[int]record, len(rows))
+	for _, r := range rows {
+		records[r.Version] = r
+	}
+	return records, nil
+}
+
+// verify 检查没有未完成的迁移，也没有程序不认识的版本
+func (m *Migrator) verify(records map[int]record) error {
+	for _, r := range records {
+		if r.Dirty {
+			return fmt.Errorf("%w: 版本 %d_%s 执行失败，请人工修复后执行 migrate force", ErrDirty, r.Version, r.Name)
+		}
+		if r.Version > m.Latest() {
+			return fmt.Errorf("%w: 数据库版本 %d，程序最新版本 %d", ErrUnknownVersion, r.Version, m.Latest())
+		}
+	}
+	return nil
+}
+
+// verifyBaseline 核对没有迁移记录的已有数据库：表和列必须与版本 1 建立的完全一致。
+// 之后的版本会新增表和列，数据库已经有其中一部分时（例如用开发中的版本 AutoMigrate 过）不能自动判断版本，
+// 需要人工核对后用 migrate force 标记
+func (m *Migrator) verifyBaseline(ctx context.Context) error {
+	want := schemaOf(splitStatements(m.migrations[0].Up))
+	migrator := m.db.WithContext(ctx).Migrator()
+	tables, err := migrator.GetTables()
+	if err != nil {
+		return fmt.Errorf("查询数据库表失败: %w", err)
+	}
+
+	var problems []string
+	have := map[string]bool{}
+	for _, table := range tables {
+		if table == "schema_migrations" || table == "schema_migrations_lock" || strings.HasPrefix(table, "sqlite_") {
+			continue
+		}
+		have[table] = true
+		columns, ok := want[table]
+		if !ok {
+			problems = append(problems, "多出表 "+table)
+			continue
+		}
+		types, err := migrator.ColumnTypes(table)
+		if err != nil {
+			return fmt.Errorf("查询表 %s 的列失败: %w", table, err)
+		}
+		got := map[string]bool{}
+		for _, column := range types {
+			got[column.Name()] = true
+			if !columns.Columns[column.Name()] {
+				problems = append(problems, fmt.Sprintf("多出列 %s.%s", table, column.Name()))
+			}
+		}
+		for _, column := range columns.sortedColumns() {
+			if !got[column] {
+				problems = append(problems, fmt.Sprintf("缺少列 %s.%s", table, column))
+			}
+		}
+	}
+	for _, table := range sortedKeys(want) {
+		if !have[table] {
+			problems = append(problems, "缺少表 "+table)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s；请核对后用 migrate force 标记版本", ErrUnknownSchema, strings.Join(problems, "，"))
+	}
+	return nil
+}
+
+// current 已执行的最大版本，以及其中是否有未完成的迁移
+func current(records map[int]record) (int, bool) {
+	var version int
+	var dirty bool
+	for _, r := range records {
+		if r.Version > version {
+			version = r.Version
+		}
+		dirty = dirty || r.Dirty
+	}
+	return version, dirty
+}
@@ -0,0 +1,473 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// models 与迁移对应的全部模型，新增模型或字段时同时新增迁移
+var models = []interface{}{
+	&model.Canteen{}, &model.User{}, &model.Wallet{}, &model.Transaction{},
+	&model.LedgerAccount{}, &model.JournalEntry{}, &model.JournalLine{}, &model.AccountClosure{},
+	&model.Plate{}, &model.Food{}, &model.FoodStock{}, &model.StockMovement{}, &model.CalendarDay{},
+	&model.Soup{}, &model.Cauldron{}, &model.CauldronEvent{}, &model.Order{}, &model.OrderItem{},
+	&model.PlateDepot{}, &model.Tableware{}, &model.TablewareStock{}, &model.TablewareMovement{},
+	&model.Station{}, &model.Device{}, &model.Worker{}, &model.ExceptionLog{}, &model.GCProcessLog{},
+	&model.PlateEvent{}, &model.WeightReading{}, &model.Notification{}, &model.NotificationPreference{},
+	&model.NotificationEvent{}, &model.DeviceNonce{}, &model.WalletRepair{},
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	t.Helper()
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLoadDialectsInSync(t *testing.T) {
+	var want []Migration
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		// 各数据库的版本号和名称必须一致
+		for i := range migrations {
+			migrations[i].Up, migrations[i].Down = "", ""
+		}
+		if want == nil {
+			want = migrations
+			continue
+		}
+		if !reflect.DeepEqual(migrations, want) {
+			t.Fatalf("%s migrations = %+v, want %+v", dialect, migrations, want)
+		}
+	}
+}
+
+func TestLoadUnknownDialect(t *testing.T) {
+	if _, err := Load("sqlserver"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	got := splitStatements(`-- comment
+CREATE TABLE a (
+    id integer,
+    name varchar(10) DEFAULT 'x;y'
+);
+
+CREATE INDEX idx_a ON a(id);
+DROP TABLE b`)
+	want := []string{
+		"CREATE TABLE a (\n    id integer,\n    name varchar(10) DEFAULT 'x;y'\n)",
+		"CREATE INDEX idx_a ON a(id)",
+		"DROP TABLE b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("statements = %q", got)
+	}
+}
+
+func TestUpMatchesModels(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db)
+
+	if err := m.Check(ctx); !errors.Is(err, ErrOutdated) {
+		t.Fatalf("check before up = %v, want ErrOutdated", err)
+	}
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != m.Latest() {
+		t.Fatalf("applied %d migrations, want %d", len(applied), m.Latest())
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("check after up = %v", err)
+	}
+
+	assertModels(t, db)
+
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("second up = %v, %v", applied, err)
+	}
+}
+
+// assertModels 迁移后的表结构包含模型的全部列和索引
+func assertModels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	migrator := db.Migrator()
+	for _, value := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(value); err != nil {
+			t.Fatal(err)
+		}
+		if !migrator.HasTable(stmt.Table) {
+			t.Errorf("table %s missing", stmt.Table)
+			continue
+		}
+		for _, column := range stmt.Schema.DBNames {
+			if !migrator.HasColumn(value, column) {
+				t.Errorf("column %s.%s missing", stmt.Table, column)
+			}
+		}
+		for _, idx := range stmt.Schema.ParseIndexes() {
+			if !migrator.HasIndex(value, idx.Name) {
+				t.Errorf("index %s on %s missing", idx.Name, stmt.Table)
+			}
+		}
+	}
+}
+
+func TestDownDropsTables(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db)
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := m.Down(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != m.Latest() {
+		t.Fatalf("reverted %d migrations, want %d", len(reverted), m.Latest())
+	}
+	for _, value := range models {
+		if db.Migrator().HasTable(value) {
+			t.Errorf("table for %T not dropped", value)
+		}
+	}
+	if version, _, err := m.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("version = %d, %v", version, err)
+	}
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+}
+
+func TestUpAdoptsReleasedDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	// 改用版本化迁移之前最后发布的版本由 AutoMigrate 建表的数据库
+	if err := db.AutoMigrate(releasedModels...); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&releasedUser{ID: "u1", Username: "alice"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&releasedWallet{UserID: "u1", Balance: 12.5}).Error; err != nil {
+		t.Fatal(err)
+	}
+	m := newMigrator(t, db)
+
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != m.Latest()-1 {
+		t.Fatalf("applied %d migrations, want %d", len(applied), m.Latest()-1)
+	}
+	for _, mig := range applied {
+		if mig.Version == 1 {
+			t.Fatal("baseline migration should not run on an existing database")
+		}
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("check = %v", err)
+	}
+	assertModels(t, db)
+
+	// 已有数据保留，新增列取默认值
+	var wallet model.Wallet
+	if err := db.Where("user_id = ?", "u1").First(&wallet).Error; err != nil {
+		t.Fatal(err)
+	}
+	if wallet.Balance != 12.5 || wallet.Status != "active" {
+		t.Fatalf("wallet = %+v", wallet)
+	}
+}
+
+func TestUpRejectsUnknownSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	// 用开发中的版本 AutoMigrate 过的数据库，已经有版本 1 之后的表和列
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	m := newMigrator(t, db)
+
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrUnknownSchema) {
+		t.Fatalf("up = %v, want ErrUnknownSchema", err)
+	}
+	if version, _, err := m.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("version = %d, %v", version, err)
+	}
+}
+
+// sqliteSchema sqlite 数据库中每张表的列定义和索引定义
+func sqliteSchema(t *testing.T, db *gorm.DB) map[string][]string {
+	t.Helper()
+	var tables []string
+	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE 'schema_migrations%'").Scan(&tables).Error; err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string][]string{}
+	for _, name := range tables {
+		var columns []struct {
+			Name    string
+			Type    string
+			NotNull bool    `gorm:"column:notnull"`
+			Default *string `gorm:"column:dflt_value"`
+			PK      int     `gorm:"column:pk"`
+		}
+		if err := db.Raw("SELECT * FROM pragma_table_info(?)", name).Scan(&columns).Error; err != nil {
+			t.Fatal(err)
+		}
+		var def []string
+		for _, c := range columns {
+			dflt := "<nil>"
+			if c.Default != nil {
+				// AutoMigrate 用双引号写字符串默认值，迁移文件用单引号，sqlite 中两者相同
+				dflt = strings.ReplaceAll(*c.Default, `"`, "'")
+			}
+			def = append(def, fmt.Sprintf("column %s %s notnull=%v default=%s pk=%d", c.Name, c.Type, c.NotNull, dflt, c.PK))
+		}
+		var indexes []struct {
+			Name   string
+			Unique bool
+		}
+		if err := db.Raw("SELECT name, \"unique\" FROM pragma_index_list(?) WHERE origin = 'c'", name).Scan(&indexes).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, idx := range indexes {
+			var columns []string
+			if err := db.Raw("SELECT name FROM pragma_index_info(?) ORDER BY seqno", idx.Name).Scan(&columns).Error; err != nil {
+				t.Fatal(err)
+			}
+			def = append(def, fmt.Sprintf("index %s unique=%v %v", idx.Name, idx.Unique, columns))
+		}
+		sort.Strings(def)
+		schema[name] = def
+	}
+	return schema
+}
+
+func TestInitMatchesReleasedSchema(t *testing.T) {
+	released := openDB(t)
+	if err := released.AutoMigrate(releasedModels...); err != nil {
+		t.Fatal(err)
+	}
+	db := openDB(t)
+	if _, err := newMigrator(t, db).Up(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, want := sqliteSchema(t, db), sqliteSchema(t, released)
+	if !reflect.DeepEqual(got, want) {
+		for _, name := range sortedKeys(want) {
+			if !reflect.DeepEqual(got[name], want[name]) {
+				t.Errorf("table %s:\n got  %q\n want %q", name, got[name], want[name])
+			}
+		}
+		for _, name := range sortedKeys(got) {
+			if _, ok := want[name]; !ok {
+				t.Errorf("unexpected table %s", name)
+			}
+		}
+	}
+}
+
+// TestDialectsSameSchema 各数据库的迁移建立相同的表、列和索引，每个版本的 down 正好撤销它的 up。
+// sqlite 的迁移由 TestUpMatchesModels 在真实数据库上检查，mysql 和 postgres 的迁移在这里与它比较，
+// 在真实数据库上的检查见 TestLiveDatabases
+func TestDialectsSameSchema(t *testing.T) {
+	var want map[string]*table
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ups []string
+		for i, mig := range migrations {
+			before := schemaOf(ups)
+			ups = append(ups, splitStatements(mig.Up)...)
+			reverted := schemaOf(append(append([]string{}, ups...), splitStatements(mig.Down)...))
+			if !reflect.DeepEqual(reverted, before) {
+				t.Errorf("%s: down of version %d does not revert its up", dialect, migrations[i].Version)
+			}
+		}
+		schema := schemaOf(ups)
+		if want == nil {
+			want = schema
+			continue
+		}
+		for _, name := range sortedKeys(want) {
+			if !reflect.DeepEqual(schema[name], want[name]) {
+				t.Errorf("%s: table %s = %+v, want %+v", dialect, name, schema[name], want[name])
+			}
+		}
+		for _, name := range sortedKeys(schema) {
+			if _, ok := want[name]; !ok {
+				t.Errorf("%s: unexpected table %s", dialect, name)
+			}
+		}
+	}
+}
+
+// TestLiveDatabases 在真实的 mysql 和 postgres 上执行迁移，数据库由环境变量指定，未设置时跳过：
+//
+//	MIGRATE_TEST_MYSQL_DSN="user:pass@tcp(127.0.0.1:3306)/fenrir_test?charset=utf8mb4&parseTime=True&loc=Local"
+//	MIGRATE_TEST_POSTGRES_DSN="host=127.0.0.1 user=postgres password=pass dbname=fenrir_test sslmode=disable"
+//
+// 测试会删除库中迁移建立的全部表，只能用空的测试库
+func TestLiveDatabases(t *testing.T) {
+	dialectors := map[string]func(string) gorm.Dialector{
+		"MIGRATE_TEST_MYSQL_DSN":    mysql.Open,
+		"MIGRATE_TEST_POSTGRES_DSN": postgres.Open,
+	}
+	for env, open := range dialectors {
+		t.Run(env, func(t *testing.T) {
+			dsn := os.Getenv(env)
+			if dsn == "" {
+				t.Skipf("%s not set", env)
+			}
+			db, err := gorm.Open(open(dsn), &gorm.Config{Logger: logger.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			m := newMigrator(t, db)
+			t.Cleanup(func() {
+				if _, err := m.Down(ctx, 0); err != nil {
+					t.Errorf("cleanup: %v", err)
+				}
+			})
+
+			if _, err := m.Up(ctx, 0); err != nil {
+				t.Fatal(err)
+			}
+			assertModels(t, db)
+			if _, err := m.Down(ctx, 0); err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range models {
+				if db.Migrator().HasTable(value) {
+					t.Errorf("table for %T not dropped", value)
+				}
+			}
+
+			// 只有版本 1 的表、没有迁移记录的数据库视为改用版本化迁移之前的数据库
+			if _, err := m.Up(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Exec("DELETE FROM schema_migrations").Error; err != nil {
+				t.Fatal(err)
+			}
+			applied, err := m.Up(ctx, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) != m.Latest()-1 {
+				t.Fatalf("applied %d migrations after adopting, want %d", len(applied), m.Latest()-1)
+			}
+			assertModels(t, db)
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db)
+	if err := m.ensureTables(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other:1', CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("up = %v, want ErrLocked", err)
+	}
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("up after unlock: %v", err)
+	}
+	var locks int64
+	db.Table("schema_migrations_lock").Count(&locks)
+	if locks != 0 {
+		t.Fatalf("lock not released, %d rows", locks)
+	}
+}
+
+func TestDirtyAndForce(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db)
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("UPDATE schema_migrations SET dirty = ?", true)
+
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrDirty) {
+		t.Fatalf("up = %v, want ErrDirty", err)
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrDirty) {
+		t.Fatalf("check = %v, want ErrDirty", err)
+	}
+	if err := m.Force(ctx, m.Latest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("check after force = %v", err)
+	}
+}
+
+func TestCheckRejectsUnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db)
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	future := Migration{Version: m.Latest() + 1, Name: "future"}
+	if err := m.insertRecord(db, future, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("check = %v, want ErrUnknownVersion", err)
+	}
+}
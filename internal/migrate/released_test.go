@@ -0,0 +1,192 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type releasedUser struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Username  string         `gorm:"type:varchar(100);uniqueIndex" json:"username"`
+	Phone     string         `gorm:"type:varchar(20);index" json:"phone,omitempty"`
+	Email     string         `gorm:"type:varchar(100)" json:"email,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	Wallet *releasedWallet `gorm:"foreignKey:UserID" json:"wallet,omitempty"`
+	Orders []releasedOrder `gorm:"foreignKey:UserID" json:"orders,omitempty"`
+}
+
+type releasedWallet struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"user_id"`
+	Balance   float64        `gorm:"type:decimal(10,2);default:0" json:"balance"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	User         *releasedUser         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Transactions []releasedTransaction `gorm:"foreignKey:WalletID" json:"transactions,omitempty"`
+}
+
+type releasedTransaction struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	WalletID  uint      `gorm:"index;not null" json:"wallet_id"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"` // "charge", "consume", "refund"
+	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Balance   float64   `gorm:"type:decimal(10,2);not null" json:"balance"` // 交易后余额
+	OrderID   string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	Remark    string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 关联
+	Wallet *releasedWallet `gorm:"foreignKey:WalletID" json:"wallet,omitempty"`
+}
+
+type releasedPlate struct {
+	ID          string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	QRCode      string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"qr_code"`
+	RFIDTag     string         `gorm:"type:varchar(255);uniqueIndex" json:"rfid_tag,omitempty"`
+	Weight      float64        `gorm:"type:decimal(8,2);default:0" json:"weight"` // 当前重量（克）
+	IsBound     bool           `gorm:"default:false;index" json:"is_bound"`
+	BoundUserID string         `gorm:"type:varchar(64);index" json:"bound_user_id,omitempty"`
+	BoundAt     *time.Time     `json:"bound_at,omitempty"`
+	Status      string         `gorm:"type:varchar(20);default:'available'" json:"status"` // available, in_use, cleaning, maintenance
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	BoundUser *releasedUser   `gorm:"foreignKey:BoundUserID" json:"bound_user,omitempty"`
+	Orders    []releasedOrder `gorm:"foreignKey:PlateID" json:"orders,omitempty"`
+}
+
+type releasedFood struct {
+	ID          string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Price       float64   `gorm:"type:decimal(8,2);not null" json:"price"`    // 单价（每100克）
+	Category    string    `gorm:"type:varchar(50)" json:"category,omitempty"` // 菜品分类
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	IsAvailable bool      `gorm:"default:true" json:"is_available"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 关联
+	OrderItems []releasedOrderItem `gorm:"foreignKey:FoodID" json:"order_items,omitempty"`
+}
+
+type releasedOrder struct {
+	ID         string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	UserID     string         `gorm:"type:varchar(64);index;not null" json:"user_id"`
+	PlateID    string         `gorm:"type:varchar(64);index;not null" json:"plate_id"`
+	TotalPrice float64        `gorm:"type:decimal(10,2);not null" json:"total_price"`
+	Status     string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, paid, completed, cancelled
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	User       *releasedUser       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Plate      *releasedPlate      `gorm:"foreignKey:PlateID" json:"plate,omitempty"`
+	OrderItems []releasedOrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+}
+
+type releasedOrderItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrderID   string    `gorm:"type:varchar(64);index;not null" json:"order_id"`
+	FoodID    string    `gorm:"type:varchar(64);index;not null" json:"food_id"`
+	FoodName  string    `gorm:"type:varchar(100);not null" json:"food_name"`
+	Weight    float64   `gorm:"type:decimal(8,2);not null" json:"weight"`     // 重量（克）
+	UnitPrice float64   `gorm:"type:decimal(8,2);not null" json:"unit_price"` // 单价
+	Price     float64   `gorm:"type:decimal(10,2);not null" json:"price"`     // 总价
+	CreatedAt time.Time `json:"created_at"`
+
+	// 关联
+	Order *releasedOrder `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Food  *releasedFood  `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+}
+
+type releasedPlateDepot struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Location  string         `gorm:"type:varchar(255)" json:"location,omitempty"`
+	Capacity  int            `gorm:"default:100" json:"capacity"`
+	Available int            `gorm:"default:100" json:"available"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+type releasedWorker struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Role      string         `gorm:"type:varchar(50);not null" json:"role"` // "staff", "manager", "gc"
+	Phone     string         `gorm:"type:varchar(20)" json:"phone,omitempty"`
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+type releasedExceptionLog struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	WorkerID  string         `gorm:"type:varchar(64);index;not null" json:"worker_id"`
+	PlateID   string         `gorm:"type:varchar(64);index" json:"plate_id,omitempty"`
+	Exception string         `gorm:"type:text;not null" json:"exception"`
+	Action    string         `gorm:"type:varchar(255);not null" json:"action"`
+	Status    string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, resolved
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	Worker *releasedWorker `gorm:"foreignKey:WorkerID" json:"worker,omitempty"`
+	Plate  *releasedPlate  `gorm:"foreignKey:PlateID" json:"plate,omitempty"`
+}
+
+type releasedGCProcessLog struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	PlateID   string         `gorm:"type:varchar(64);index;not null" json:"plate_id"`
+	Type      string         `gorm:"type:varchar(20);not null" json:"type"`            // "plate", "food_waste"
+	Status    string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, processing, completed
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	Plate *releasedPlate `gorm:"foreignKey:PlateID" json:"plate,omitempty"`
+}
+
+// releasedModels 改用版本化迁移之前最后发布的版本中的模型，按当时 AutoMigrate 的顺序。
+// 版本 1 的迁移必须建立与之完全一致的表结构，这样的已有数据库才能直接记为版本 1。不要修改这些模型
+var releasedModels = []interface{}{
+	&releasedUser{}, &releasedWallet{}, &releasedTransaction{}, &releasedPlate{}, &releasedFood{},
+	&releasedOrder{}, &releasedOrderItem{}, &releasedPlateDepot{}, &releasedWorker{},
+	&releasedExceptionLog{}, &releasedGCProcessLog{},
+}
+
+func (releasedUser) TableName() string { return "users" }
+
+func (releasedWallet) TableName() string { return "wallets" }
+
+func (releasedTransaction) TableName() string { return "transactions" }
+
+func (releasedPlate) TableName() string { return "plates" }
+
+func (releasedFood) TableName() string { return "foods" }
+
+func (releasedOrder) TableName() string { return "orders" }
+
+func (releasedOrderItem) TableName() string { return "order_items" }
+
+func (releasedPlateDepot) TableName() string { return "plate_depots" }
+
+func (releasedWorker) TableName() string { return "workers" }
+
+func (releasedExceptionLog) TableName() string { return "exception_logs" }
+
+func (releasedGCProcessLog) TableName() string { return "gc_process_logs" }
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// table 迁移 SQL 建立的一张表的列和索引
+type table struct {
+	Columns map[string]bool
+	Indexes map[string]bool
+}
+
+func (t *table) sortedColumns() []string {
+	return sortedKeys(t.Columns)
+}
+
+const ident = "[`\"](\\w+)[`\"]"
+
+var (
+	createTableRe = regexp.MustCompile(`^CREATE TABLE (?:IF NOT EXISTS )?` + ident + ` \(`)
+	createIndexRe = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (?:IF NOT EXISTS )?` + ident + ` ON ` + ident)
+	inlineIndexRe = regexp.MustCompile(`^(?:UNIQUE )?INDEX ` + ident)
+	columnRe      = regexp.MustCompile(`^` + ident + ` `)
+	dropTableRe   = regexp.MustCompile(`^DROP TABLE (?:IF EXISTS )?` + ident)
+	dropIndexRe   = regexp.MustCompile(`^DROP INDEX (?:IF EXISTS )?` + ident)
+	addColumnRe   = regexp.MustCompile(`^ALTER TABLE ` + ident + ` ADD COLUMN ` + ident)
+	dropColumnRe  = regexp.MustCompile(`^ALTER TABLE ` + ident + ` DROP COLUMN (?:IF EXISTS )?` + ident)
+)
+
+// schemaOf 依次执行迁移语句得到的表结构（表名 -> 列和索引）。只识别迁移文件中用到的建表、删表、
+// 增删列和索引语句，其他语句（例如数据更新）忽略；用于核对已有数据库和比较各数据库的迁移
+func schemaOf(statements []string) map[string]*table {
+	tables := map[string]*table{}
+	indexTable := map[string]string{} // 索引名 -> 表名，删除索引的语句不一定带表名
+	for _, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+			t := &table{Columns: map[string]bool{}, Indexes: map[string]bool{}}
+			tables[m[1]] = t
+			for _, line := range strings.Split(stmt, "\n")[1:] {
+				line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+				if c := columnRe.FindStringSubmatch(line); c != nil {
+					t.Columns[c[1]] = true
+				} else if i := inlineIndexRe.FindStringSubmatch(line); i != nil {
+					t.Indexes[i[1]] = true
+					indexTable[i[1]] = m[1]
+				}
+			}
+		} else if m := createIndexRe.FindStringSubmatch(stmt); m != nil {
+			if t := tables[m[2]]; t != nil {
+				t.Indexes[m[1]] = true
+				indexTable[m[1]] = m[2]
+			}
+		} else if m := dropTableRe.FindStringSubmatch(stmt); m != nil {
+			delete(tables, m[1])
+		} else if m := dropIndexRe.FindStringSubmatch(stmt); m != nil {
+			if t := tables[indexTable[m[1]]]; t != nil {
+				delete(t.Indexes, m[1])
+			}
+		} else if m := addColumnRe.FindStringSubmatch(stmt); m != nil {
+			if t := tables[m[1]]; t != nil {
+				t.Columns[m[2]] = true
+			}
+		} else if m := dropColumnRe.FindStringSubmatch(stmt); m != nil {
+			if t := tables[m[1]]; t != nil {
+				delete(t.Columns, m[2])
+			}
+		}
+	}
+	return tables
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+-- 按外键依赖的相反顺序删除新增的表，再删除初始表上新增的索引和列
+
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "weight_readings";
+DROP TABLE IF EXISTS "plate_events";
+DROP TABLE IF EXISTS "devices";
+DROP TABLE IF EXISTS "stations";
+DROP TABLE IF EXISTS "tableware_movements";
+DROP TABLE IF EXISTS "tableware_stocks";
+DROP TABLE IF EXISTS "tablewares";
+DROP TABLE IF EXISTS "cauldron_events";
+DROP TABLE IF EXISTS "cauldrons";
+DROP TABLE IF EXISTS "soups";
+DROP TABLE IF EXISTS "calendar_days";
+DROP TABLE IF EXISTS "stock_movements";
+DROP TABLE IF EXISTS "food_stocks";
+DROP TABLE IF EXISTS "account_closures";
+DROP TABLE IF EXISTS "journal_lines";
+DROP TABLE IF EXISTS "journal_entries";
+DROP TABLE IF EXISTS "ledger_accounts";
+DROP TABLE IF EXISTS "canteens";
+
+DROP INDEX IF EXISTS "idx_exception_logs_reading_id";
+DROP INDEX IF EXISTS "idx_exception_logs_order_id";
+DROP INDEX IF EXISTS "idx_exception_logs_device_id";
+ALTER TABLE "exception_logs" DROP COLUMN IF EXISTS "reading_id";
+ALTER TABLE "exception_logs" DROP COLUMN IF EXISTS "order_id";
+ALTER TABLE "exception_logs" DROP COLUMN IF EXISTS "device_id";
+
+DROP INDEX IF EXISTS "idx_workers_canteen_id";
+ALTER TABLE "workers" DROP COLUMN IF EXISTS "canteen_id";
+
+DROP INDEX IF EXISTS "idx_plate_depots_canteen_id";
+ALTER TABLE "plate_depots" DROP COLUMN IF EXISTS "canteen_id";
+
+DROP INDEX IF EXISTS "idx_order_items_station_id";
+DROP INDEX IF EXISTS "idx_order_items_reading_id";
+DROP INDEX IF EXISTS "idx_order_items_cauldron_id";
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "held";
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "reading_id";
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "cauldron_id";
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "station_id";
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "unit";
+
+DROP INDEX IF EXISTS "idx_orders_canteen_id";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "canteen_id";
+
+DROP INDEX IF EXISTS "idx_foods_canteen_id";
+ALTER TABLE "foods" DROP COLUMN IF EXISTS "canteen_id";
+
+DROP INDEX IF EXISTS "idx_plates_depot_id";
+DROP INDEX IF EXISTS "idx_plates_canteen_id";
+ALTER TABLE "plates" DROP COLUMN IF EXISTS "depot_id";
+ALTER TABLE "plates" DROP COLUMN IF EXISTS "canteen_id";
+
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE "wallets" DROP COLUMN IF EXISTS "status";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "erased_at";
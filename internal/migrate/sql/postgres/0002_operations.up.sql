@@ -0,0 +1,368 @@
+-- 在初始表结构上增加多食堂、记账、库存、设备、称重、通知等功能的表和列
+
+ALTER TABLE "users" ADD COLUMN "erased_at" timestamptz;
+
+ALTER TABLE "wallets" ADD COLUMN "status" varchar(20) DEFAULT 'active';
+
+ALTER TABLE "transactions" ADD COLUMN "expires_at" timestamptz;
+
+ALTER TABLE "plates" ADD COLUMN "canteen_id" varchar(64);
+ALTER TABLE "plates" ADD COLUMN "depot_id" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_plates_depot_id" ON "plates" ("depot_id");
+CREATE INDEX IF NOT EXISTS "idx_plates_canteen_id" ON "plates" ("canteen_id");
+
+ALTER TABLE "foods" ADD COLUMN "canteen_id" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_foods_canteen_id" ON "foods" ("canteen_id");
+
+ALTER TABLE "orders" ADD COLUMN "canteen_id" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_orders_canteen_id" ON "orders" ("canteen_id");
+
+ALTER TABLE "order_items" ADD COLUMN "unit" varchar(10) DEFAULT 'g';
+ALTER TABLE "order_items" ADD COLUMN "station_id" varchar(64);
+ALTER TABLE "order_items" ADD COLUMN "cauldron_id" varchar(64);
+ALTER TABLE "order_items" ADD COLUMN "reading_id" bigint;
+ALTER TABLE "order_items" ADD COLUMN "held" boolean DEFAULT false;
+CREATE INDEX IF NOT EXISTS "idx_order_items_station_id" ON "order_items" ("station_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_reading_id" ON "order_items" ("reading_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_cauldron_id" ON "order_items" ("cauldron_id");
+
+ALTER TABLE "plate_depots" ADD COLUMN "canteen_id" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_plate_depots_canteen_id" ON "plate_depots" ("canteen_id");
+
+ALTER TABLE "workers" ADD COLUMN "canteen_id" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_workers_canteen_id" ON "workers" ("canteen_id");
+
+ALTER TABLE "exception_logs" ADD COLUMN "device_id" varchar(64);
+ALTER TABLE "exception_logs" ADD COLUMN "order_id" varchar(64);
+ALTER TABLE "exception_logs" ADD COLUMN "reading_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_exception_logs_reading_id" ON "exception_logs" ("reading_id");
+CREATE INDEX IF NOT EXISTS "idx_exception_logs_order_id" ON "exception_logs" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_exception_logs_device_id" ON "exception_logs" ("device_id");
+
+CREATE TABLE "canteens" (
+    "id" varchar(64),
+    "name" varchar(100) NOT NULL,
+    "location" varchar(255),
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_canteens_deleted_at" ON "canteens" ("deleted_at");
+
+CREATE TABLE "ledger_accounts" (
+    "id" varchar(100),
+    "type" varchar(20) NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "owner_id" varchar(64),
+    "name" varchar(100),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ledger_accounts_owner_id" ON "ledger_accounts" ("owner_id");
+CREATE INDEX IF NOT EXISTS "idx_ledger_accounts_kind" ON "ledger_accounts" ("kind");
+
+CREATE TABLE "journal_entries" (
+    "id" bigserial,
+    "kind" varchar(20) NOT NULL,
+    "order_id" varchar(64),
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_journal_entries_created_at" ON "journal_entries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_journal_entries_order_id" ON "journal_entries" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_journal_entries_kind" ON "journal_entries" ("kind");
+
+CREATE TABLE "journal_lines" (
+    "id" bigserial,
+    "entry_id" bigint NOT NULL,
+    "account_id" varchar(100) NOT NULL,
+    "debit" decimal(12,2) DEFAULT 0,
+    "credit" decimal(12,2) DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_journal_entries_lines" FOREIGN KEY ("entry_id") REFERENCES "journal_entries"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_journal_lines_account_id" ON "journal_lines" ("account_id");
+CREATE INDEX IF NOT EXISTS "idx_journal_lines_entry_id" ON "journal_lines" ("entry_id");
+
+CREATE TABLE "account_closures" (
+    "id" bigserial,
+    "no" varchar(32) NOT NULL,
+    "user_id" varchar(64) NOT NULL,
+    "username" varchar(100),
+    "wallet_id" bigint,
+    "transaction_id" bigint,
+    "balance" decimal(10,2) DEFAULT 0,
+    "refundable" decimal(10,2) DEFAULT 0,
+    "subsidy" decimal(10,2) DEFAULT 0,
+    "expired_subsidy" decimal(10,2) DEFAULT 0,
+    "operator_type" varchar(20),
+    "operator_id" varchar(64),
+    "reason" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_account_closures_no" ON "account_closures" ("no");
+CREATE INDEX IF NOT EXISTS "idx_account_closures_wallet_id" ON "account_closures" ("wallet_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_account_closures_user_id" ON "account_closures" ("user_id");
+
+CREATE TABLE "food_stocks" (
+    "id" bigserial,
+    "canteen_id" varchar(64),
+    "food_id" varchar(64) NOT NULL,
+    "date" varchar(10) NOT NULL,
+    "period" varchar(20) NOT NULL,
+    "prepared" decimal(10,2) DEFAULT 0,
+    "remaining" decimal(10,2) DEFAULT 0,
+    "low_alerted" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_food_stocks_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_food_stock_period" ON "food_stocks" ("food_id","date","period");
+CREATE INDEX IF NOT EXISTS "idx_food_stocks_canteen_id" ON "food_stocks" ("canteen_id");
+
+CREATE TABLE "stock_movements" (
+    "id" bigserial,
+    "stock_id" bigint NOT NULL,
+    "food_id" varchar(64) NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "weight" decimal(10,2) NOT NULL,
+    "order_id" varchar(64),
+    "worker_id" varchar(64),
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_food_id" ON "stock_movements" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_stock_id" ON "stock_movements" ("stock_id");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_created_at" ON "stock_movements" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_worker_id" ON "stock_movements" ("worker_id");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_order_id" ON "stock_movements" ("order_id");
+
+CREATE TABLE "calendar_days" (
+    "date" varchar(10),
+    "type" varchar(20) NOT NULL,
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("date")
+);
+
+CREATE TABLE "soups" (
+    "id" varchar(64),
+    "canteen_id" varchar(64),
+    "food_id" varchar(64) NOT NULL,
+    "price_unit" varchar(10) NOT NULL,
+    "unit_price" decimal(8,2) NOT NULL,
+    "ladle_volume" decimal(8,2) DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_soups_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_soups_food_id" ON "soups" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_soups_canteen_id" ON "soups" ("canteen_id");
+
+CREATE TABLE "cauldrons" (
+    "id" varchar(64),
+    "canteen_id" varchar(64),
+    "station_id" varchar(64),
+    "soup_id" varchar(64),
+    "device_id" varchar(64),
+    "capacity" decimal(10,2) NOT NULL,
+    "level" decimal(10,2) DEFAULT 0,
+    "status" varchar(20) DEFAULT 'empty',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_cauldrons_soup" FOREIGN KEY ("soup_id") REFERENCES "soups"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_cauldrons_device_id" ON "cauldrons" ("device_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldrons_soup_id" ON "cauldrons" ("soup_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldrons_station_id" ON "cauldrons" ("station_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldrons_canteen_id" ON "cauldrons" ("canteen_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldrons_deleted_at" ON "cauldrons" ("deleted_at");
+
+CREATE TABLE "cauldron_events" (
+    "id" bigserial,
+    "cauldron_id" varchar(64) NOT NULL,
+    "soup_id" varchar(64),
+    "type" varchar(20) NOT NULL,
+    "volume" decimal(10,2) NOT NULL,
+    "level" decimal(10,2) NOT NULL,
+    "device_id" varchar(64),
+    "worker_id" varchar(64),
+    "plate_id" varchar(64),
+    "order_id" varchar(64),
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_soup_id" ON "cauldron_events" ("soup_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_cauldron_id" ON "cauldron_events" ("cauldron_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_created_at" ON "cauldron_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_order_id" ON "cauldron_events" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_plate_id" ON "cauldron_events" ("plate_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_worker_id" ON "cauldron_events" ("worker_id");
+CREATE INDEX IF NOT EXISTS "idx_cauldron_events_device_id" ON "cauldron_events" ("device_id");
+
+CREATE TABLE "tablewares" (
+    "id" varchar(64),
+    "canteen_id" varchar(64),
+    "name" varchar(100) NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "reorder_level" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tablewares_deleted_at" ON "tablewares" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_tablewares_canteen_id" ON "tablewares" ("canteen_id");
+
+CREATE TABLE "tableware_stocks" (
+    "id" bigserial,
+    "canteen_id" varchar(64),
+    "depot_id" varchar(64) NOT NULL,
+    "tableware_id" varchar(64) NOT NULL,
+    "on_hand" bigint DEFAULT 0,
+    "issued" bigint DEFAULT 0,
+    "lost" bigint DEFAULT 0,
+    "reorder_alerted" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tableware_stocks_tableware" FOREIGN KEY ("tableware_id") REFERENCES "tablewares"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tableware_stock" ON "tableware_stocks" ("depot_id","tableware_id");
+CREATE INDEX IF NOT EXISTS "idx_tableware_stocks_canteen_id" ON "tableware_stocks" ("canteen_id");
+
+CREATE TABLE "tableware_movements" (
+    "id" bigserial,
+    "stock_id" bigint NOT NULL,
+    "depot_id" varchar(64) NOT NULL,
+    "tableware_id" varchar(64) NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "quantity" bigint NOT NULL,
+    "worker_id" varchar(64),
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tableware_movements_created_at" ON "tableware_movements" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_tableware_movements_worker_id" ON "tableware_movements" ("worker_id");
+CREATE INDEX IF NOT EXISTS "idx_tableware_movements_tableware_id" ON "tableware_movements" ("tableware_id");
+CREATE INDEX IF NOT EXISTS "idx_tableware_movements_depot_id" ON "tableware_movements" ("depot_id");
+CREATE INDEX IF NOT EXISTS "idx_tableware_movements_stock_id" ON "tableware_movements" ("stock_id");
+
+CREATE TABLE "stations" (
+    "id" varchar(64),
+    "canteen_id" varchar(64),
+    "name" varchar(100) NOT NULL,
+    "food_id" varchar(64),
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_stations_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_stations_deleted_at" ON "stations" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_stations_food_id" ON "stations" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_stations_canteen_id" ON "stations" ("canteen_id");
+
+CREATE TABLE "devices" (
+    "id" varchar(64),
+    "canteen_id" varchar(64),
+    "station_id" varchar(64),
+    "type" varchar(20) NOT NULL,
+    "firmware" varchar(64),
+    "secret" varchar(128),
+    "status" varchar(20) DEFAULT 'offline',
+    "last_seen_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_stations_devices" FOREIGN KEY ("station_id") REFERENCES "stations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_devices_station_id" ON "devices" ("station_id");
+CREATE INDEX IF NOT EXISTS "idx_devices_canteen_id" ON "devices" ("canteen_id");
+CREATE INDEX IF NOT EXISTS "idx_devices_deleted_at" ON "devices" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_devices_status" ON "devices" ("status");
+
+CREATE TABLE "plate_events" (
+    "id" bigserial,
+    "plate_id" varchar(64) NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "prev_status" varchar(20),
+    "new_status" varchar(20),
+    "actor_type" varchar(20) NOT NULL,
+    "actor_id" varchar(64),
+    "user_id" varchar(64),
+    "order_id" varchar(64),
+    "exception_id" bigint,
+    "remark" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_plate_events_plate_id" ON "plate_events" ("plate_id");
+CREATE INDEX IF NOT EXISTS "idx_plate_events_created_at" ON "plate_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_plate_events_exception_id" ON "plate_events" ("exception_id");
+CREATE INDEX IF NOT EXISTS "idx_plate_events_order_id" ON "plate_events" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_plate_events_user_id" ON "plate_events" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_plate_events_actor_id" ON "plate_events" ("actor_id");
+
+CREATE TABLE "weight_readings" (
+    "id" bigserial,
+    "device_id" varchar(64) NOT NULL,
+    "station_id" varchar(64),
+    "plate_id" varchar(64),
+    "weight" decimal(8,2) NOT NULL,
+    "anomalies" varchar(100),
+    "status" varchar(20) NOT NULL,
+    "order_id" varchar(64),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_weight_readings_created_at" ON "weight_readings" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_weight_readings_order_id" ON "weight_readings" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_weight_readings_plate_id" ON "weight_readings" ("plate_id");
+CREATE INDEX IF NOT EXISTS "idx_weight_readings_station_id" ON "weight_readings" ("station_id");
+CREATE INDEX IF NOT EXISTS "idx_weight_readings_device_id" ON "weight_readings" ("device_id");
+
+CREATE TABLE "notifications" (
+    "id" bigserial,
+    "user_id" varchar(64) NOT NULL,
+    "kind" varchar(30) NOT NULL,
+    "channel" varchar(10) NOT NULL,
+    "address" varchar(100),
+    "subject" varchar(200),
+    "body" text,
+    "order_id" varchar(64),
+    "status" varchar(10) DEFAULT 'pending',
+    "attempts" bigint DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_error" varchar(255),
+    "sent_at" timestamptz,
+    "read_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_created_at" ON "notifications" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_notification_due" ON "notifications" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_notifications_order_id" ON "notifications" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+
+CREATE TABLE "notification_preferences" (
+    "user_id" varchar(64),
+    "kind" varchar(30),
+    "channels" varchar(50),
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id","kind")
+);
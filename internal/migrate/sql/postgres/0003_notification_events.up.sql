@@ -0,0 +1,16 @@
+-- 通知事件发件箱，与业务变更在同一个事务中写入
+
+CREATE TABLE "notification_events" (
+    "id" bigserial,
+    "user_id" varchar(64) NOT NULL,
+    "kind" varchar(30) NOT NULL,
+    "order_id" varchar(64),
+    "plate_id" varchar(64),
+    "amount" decimal(10,2) DEFAULT 0,
+    "balance" decimal(10,2) DEFAULT 0,
+    "claimed_until" timestamptz,
+    "processed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_event_due" ON "notification_events" ("claimed_until","processed_at");
@@ -0,0 +1,9 @@
+-- 设备签名请求用过的 nonce，多副本共享
+
+CREATE TABLE "device_nonces" (
+    "device_id" varchar(64),
+    "nonce" varchar(64),
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("device_id","nonce")
+);
+CREATE INDEX IF NOT EXISTS "idx_device_nonces_expires_at" ON "device_nonces" ("expires_at");
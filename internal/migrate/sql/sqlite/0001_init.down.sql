@@ -0,0 +1,13 @@
+-- 按外键依赖的相反顺序删除初始表结构的全部表
+
+DROP TABLE IF EXISTS `gc_process_logs`;
+DROP TABLE IF EXISTS `exception_logs`;
+DROP TABLE IF EXISTS `workers`;
+DROP TABLE IF EXISTS `plate_depots`;
+DROP TABLE IF EXISTS `order_items`;
+DROP TABLE IF EXISTS `orders`;
+DROP TABLE IF EXISTS `foods`;
+DROP TABLE IF EXISTS `plates`;
+DROP TABLE IF EXISTS `transactions`;
+DROP TABLE IF EXISTS `wallets`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,162 @@
+-- 初始表结构，与改用版本化迁移之前的版本由 AutoMigrate 创建的表结构一致，已有的数据库直接记为已执行
+
+CREATE TABLE `users` (
+    `id` varchar(64),
+    `username` varchar(100),
+    `phone` varchar(20),
+    `email` varchar(100),
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE INDEX `idx_users_phone` ON `users`(`phone`);
+CREATE UNIQUE INDEX `idx_users_username` ON `users`(`username`);
+
+CREATE TABLE `wallets` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` varchar(64) NOT NULL,
+    `balance` decimal(10,2) DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_users_wallet` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_wallets_deleted_at` ON `wallets`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_wallets_user_id` ON `wallets`(`user_id`);
+
+CREATE TABLE `transactions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `wallet_id` integer NOT NULL,
+    `type` varchar(20) NOT NULL,
+    `amount` decimal(10,2) NOT NULL,
+    `balance` decimal(10,2) NOT NULL,
+    `order_id` varchar(64),
+    `remark` varchar(255),
+    `created_at` datetime,
+    CONSTRAINT `fk_wallets_transactions` FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+CREATE INDEX `idx_transactions_order_id` ON `transactions`(`order_id`);
+CREATE INDEX `idx_transactions_wallet_id` ON `transactions`(`wallet_id`);
+
+CREATE TABLE `plates` (
+    `id` varchar(64),
+    `qr_code` varchar(255) NOT NULL,
+    `rf_id_tag` varchar(255),
+    `weight` decimal(8,2) DEFAULT 0,
+    `is_bound` numeric DEFAULT false,
+    `bound_user_id` varchar(64),
+    `bound_at` datetime,
+    `status` varchar(20) DEFAULT 'available',
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_plates_bound_user` FOREIGN KEY (`bound_user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_plates_deleted_at` ON `plates`(`deleted_at`);
+CREATE INDEX `idx_plates_bound_user_id` ON `plates`(`bound_user_id`);
+CREATE INDEX `idx_plates_is_bound` ON `plates`(`is_bound`);
+CREATE UNIQUE INDEX `idx_plates_rf_id_tag` ON `plates`(`rf_id_tag`);
+CREATE UNIQUE INDEX `idx_plates_qr_code` ON `plates`(`qr_code`);
+
+CREATE TABLE `foods` (
+    `id` varchar(64),
+    `name` varchar(100) NOT NULL,
+    `price` decimal(8,2) NOT NULL,
+    `category` varchar(50),
+    `description` text,
+    `is_available` numeric DEFAULT true,
+    `created_at` datetime,
+    `updated_at` datetime,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `orders` (
+    `id` varchar(64),
+    `user_id` varchar(64) NOT NULL,
+    `plate_id` varchar(64) NOT NULL,
+    `total_price` decimal(10,2) NOT NULL,
+    `status` varchar(20) DEFAULT 'pending',
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_plates_orders` FOREIGN KEY (`plate_id`) REFERENCES `plates`(`id`),
+    CONSTRAINT `fk_users_orders` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_orders_deleted_at` ON `orders`(`deleted_at`);
+CREATE INDEX `idx_orders_plate_id` ON `orders`(`plate_id`);
+CREATE INDEX `idx_orders_user_id` ON `orders`(`user_id`);
+
+CREATE TABLE `order_items` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `order_id` varchar(64) NOT NULL,
+    `food_id` varchar(64) NOT NULL,
+    `food_name` varchar(100) NOT NULL,
+    `weight` decimal(8,2) NOT NULL,
+    `unit_price` decimal(8,2) NOT NULL,
+    `price` decimal(10,2) NOT NULL,
+    `created_at` datetime,
+    CONSTRAINT `fk_foods_order_items` FOREIGN KEY (`food_id`) REFERENCES `foods`(`id`),
+    CONSTRAINT `fk_orders_order_items` FOREIGN KEY (`order_id`) REFERENCES `orders`(`id`)
+);
+CREATE INDEX `idx_order_items_food_id` ON `order_items`(`food_id`);
+CREATE INDEX `idx_order_items_order_id` ON `order_items`(`order_id`);
+
+CREATE TABLE `plate_depots` (
+    `id` varchar(64),
+    `name` varchar(100) NOT NULL,
+    `location` varchar(255),
+    `capacity` integer DEFAULT 100,
+    `available` integer DEFAULT 100,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_plate_depots_deleted_at` ON `plate_depots`(`deleted_at`);
+
+CREATE TABLE `workers` (
+    `id` varchar(64),
+    `name` varchar(100) NOT NULL,
+    `role` varchar(50) NOT NULL,
+    `phone` varchar(20),
+    `is_active` numeric DEFAULT true,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_workers_deleted_at` ON `workers`(`deleted_at`);
+
+CREATE TABLE `exception_logs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `worker_id` varchar(64) NOT NULL,
+    `plate_id` varchar(64),
+    `exception` text NOT NULL,
+    `action` varchar(255) NOT NULL,
+    `status` varchar(20) DEFAULT 'pending',
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_exception_logs_worker` FOREIGN KEY (`worker_id`) REFERENCES `workers`(`id`),
+    CONSTRAINT `fk_exception_logs_plate` FOREIGN KEY (`plate_id`) REFERENCES `plates`(`id`)
+);
+CREATE INDEX `idx_exception_logs_plate_id` ON `exception_logs`(`plate_id`);
+CREATE INDEX `idx_exception_logs_worker_id` ON `exception_logs`(`worker_id`);
+CREATE INDEX `idx_exception_logs_deleted_at` ON `exception_logs`(`deleted_at`);
+
+CREATE TABLE `gc_process_logs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `plate_id` varchar(64) NOT NULL,
+    `type` varchar(20) NOT NULL,
+    `status` varchar(20) DEFAULT 'pending',
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_gc_process_logs_plate` FOREIGN KEY (`plate_id`) REFERENCES `plates`(`id`)
+);
+CREATE INDEX `idx_gc_process_logs_deleted_at` ON `gc_process_logs`(`deleted_at`);
+CREATE INDEX `idx_gc_process_logs_plate_id` ON `gc_process_logs`(`plate_id`);
@@ -0,0 +1,61 @@
+-- 按外键依赖的相反顺序删除新增的表，再删除初始表上新增的索引和列
+
+DROP TABLE IF EXISTS `notification_preferences`;
+DROP TABLE IF EXISTS `notifications`;
+DROP TABLE IF EXISTS `weight_readings`;
+DROP TABLE IF EXISTS `plate_events`;
+DROP TABLE IF EXISTS `devices`;
+DROP TABLE IF EXISTS `stations`;
+DROP TABLE IF EXISTS `tableware_movements`;
+DROP TABLE IF EXISTS `tableware_stocks`;
+DROP TABLE IF EXISTS `tablewares`;
+DROP TABLE IF EXISTS `cauldron_events`;
+DROP TABLE IF EXISTS `cauldrons`;
+DROP TABLE IF EXISTS `soups`;
+DROP TABLE IF EXISTS `calendar_days`;
+DROP TABLE IF EXISTS `stock_movements`;
+DROP TABLE IF EXISTS `food_stocks`;
+DROP TABLE IF EXISTS `account_closures`;
+DROP TABLE IF EXISTS `journal_lines`;
+DROP TABLE IF EXISTS `journal_entries`;
+DROP TABLE IF EXISTS `ledger_accounts`;
+DROP TABLE IF EXISTS `canteens`;
+
+DROP INDEX IF EXISTS `idx_exception_logs_reading_id`;
+DROP INDEX IF EXISTS `idx_exception_logs_order_id`;
+DROP INDEX IF EXISTS `idx_exception_logs_device_id`;
+ALTER TABLE `exception_logs` DROP COLUMN `reading_id`;
+ALTER TABLE `exception_logs` DROP COLUMN `order_id`;
+ALTER TABLE `exception_logs` DROP COLUMN `device_id`;
+
+DROP INDEX IF EXISTS `idx_workers_canteen_id`;
+ALTER TABLE `workers` DROP COLUMN `canteen_id`;
+
+DROP INDEX IF EXISTS `idx_plate_depots_canteen_id`;
+ALTER TABLE `plate_depots` DROP COLUMN `canteen_id`;
+
+DROP INDEX IF EXISTS `idx_order_items_reading_id`;
+DROP INDEX IF EXISTS `idx_order_items_cauldron_id`;
+DROP INDEX IF EXISTS `idx_order_items_station_id`;
+ALTER TABLE `order_items` DROP COLUMN `held`;
+ALTER TABLE `order_items` DROP COLUMN `reading_id`;
+ALTER TABLE `order_items` DROP COLUMN `cauldron_id`;
+ALTER TABLE `order_items` DROP COLUMN `station_id`;
+ALTER TABLE `order_items` DROP COLUMN `unit`;
+
+DROP INDEX IF EXISTS `idx_orders_canteen_id`;
+ALTER TABLE `orders` DROP COLUMN `canteen_id`;
+
+DROP INDEX IF EXISTS `idx_foods_canteen_id`;
+ALTER TABLE `foods` DROP COLUMN `canteen_id`;
+
+DROP INDEX IF EXISTS `idx_plates_canteen_id`;
+DROP INDEX IF EXISTS `idx_plates_depot_id`;
+ALTER TABLE `plates` DROP COLUMN `depot_id`;
+ALTER TABLE `plates` DROP COLUMN `canteen_id`;
+
+ALTER TABLE `transactions` DROP COLUMN `expires_at`;
+
+ALTER TABLE `wallets` DROP COLUMN `status`;
+
+ALTER TABLE `users` DROP COLUMN `erased_at`;
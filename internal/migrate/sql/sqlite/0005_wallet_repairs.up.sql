@@ -0,0 +1,13 @@
+-- 钱包余额修复记录，余额按账本重新计算
+
+CREATE TABLE `wallet_repairs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `wallet_id` integer NOT NULL,
+    `user_id` varchar(64) NOT NULL,
+    `balance_before` decimal(10,2) NOT NULL,
+    `balance_after` decimal(10,2) NOT NULL,
+    `operator_id` varchar(64) NOT NULL,
+    `remark` varchar(255),
+    `created_at` datetime
+);
+CREATE INDEX `idx_wallet_repairs_wallet_id` ON `wallet_repairs`(`wallet_id`);
@@ -0,0 +1,260 @@
+// Package notify 把业务事件转换成发给用户的通知。
+//
+// 业务逻辑在引起通知的事务中把事件写入 notification_events 表，Service 领取这些事件，
+// 按用户的偏好选择渠道（站内信、邮件、短信），渲染模板后把每个渠道的一条通知写入
+// notifications 表（发件箱），并在同一个事务中把事件标记为已处理。后台任务逐条发送，
+// 失败时按指数退避重试，超过次数标记为 failed。事件和发件箱都在数据库中，进程重启或
+// 事件总线丢弃事件都不会丢失通知；事件总线只用于及时唤醒，不依赖它传递事件。
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/model"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+const (
+	batchSize   = 20
+	sendTimeout = 30 * time.Second
+	sendLease   = 2 * sendTimeout // 领取后在该时间内没有记录结果，视为发送中断，重新发送
+)
+
+// Message 发给一个地址的通知
+type Message struct {
+	To      string // 邮箱或手机号
+	Subject string
+	Body    string
+}
+
+// Sender 通知发送渠道
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config 发送配置
+type Config struct {
+	MaxAttempts  int           // 最多发送次数
+	RetryBase    time.Duration // 第一次重试的间隔，之后每次翻倍
+	PollInterval time.Duration // 检查通知事件和待发送通知的间隔
+}
+
+// Service 通知服务
+type Service struct {
+	logic     *logic.RestaurantLogic
+	bus       *event.Bus
+	templates *Templates
+	senders   map[string]Sender // 渠道名 -> 发送渠道，站内信不需要
+	cfg       Config
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService 创建通知服务，senders 中没有的渠道不会写入发件箱
+func NewService(l *logic.RestaurantLogic, bus *event.Bus, templates *Templates, senders map[string]Sender, cfg Config) *Service {
+	return &Service{
+		logic:     l,
+		bus:       bus,
+		templates: templates,
+		senders:   senders,
+		cfg:       cfg,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start 开始处理通知事件并发送通知
+func (s *Service) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	sub := s.bus.Subscribe()
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		defer sub.Cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if notifies(e) {
+					s.signal()
+				}
+			}
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			s.process(ctx)
+			s.deliver(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// Stop 停止服务，正在处理的事件和正在发送的通知会被中断，之后重新处理
+func (s *Service) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// notifies 总线上的事件是否可能写入了通知事件，是则立即处理，不用等到下次轮询
+func notifies(e event.Event) bool {
+	switch e.Type {
+	case event.OrderPaid, event.LowBalance, event.PlateUnbound, event.PlateGC:
+		return e.UserID != ""
+	}
+	return false
+}
+
+func (s *Service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process 把所有未处理的通知事件转换成通知写入发件箱
+func (s *Service) process(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := s.logic.ClaimNotificationEvents(ctx, time.Now(), sendLease, batchSize)
+		if err != nil {
+			logx.WithContext(ctx).Errorf("领取通知事件失败: %v", err)
+			return
+		}
+		for _, e := range due {
+			data := Data{At: e.CreatedAt, OrderID: e.OrderID, PlateID: e.PlateID, Amount: e.Amount, Balance: e.Balance}
+			notifications, err := s.build(ctx, e.UserID, e.Kind, data)
+			if err != nil {
+				// 不标记为已处理，lease 过后重新处理
+				logx.WithContext(ctx).Errorf("生成通知失败: event=%d user=%s kind=%s: %v", e.ID, e.UserID, e.Kind, err)
+				continue
+			}
+			if err := s.logic.CompleteNotificationEvent(ctx, e.ID, notifications); err != nil {
+				logx.WithContext(ctx).Errorf("写入通知失败: event=%d: %v", e.ID, err)
+			}
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// build 按用户偏好为一个事件生成每个渠道的通知，没有联系方式或没有配置的渠道跳过；
+// 用户已不存在时不生成通知
+func (s *Service) build(ctx context.Context, userID, kind string, data Data) ([]model.Notification, error) {
+	user, err := s.logic.NotificationRecipient(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.logic.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	channels := prefs[kind]
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	data.Username = user.Username
+	if data.At.IsZero() {
+		data.At = time.Now()
+	}
+	subject, body, err := s.templates.Render(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []model.Notification
+	for _, channel := range channels {
+		var address string
+		switch channel {
+		case logic.ChannelEmail:
+			address = user.Email
+		case logic.ChannelSMS:
+			address = user.Phone
+		}
+		if channel != logic.ChannelInbox && (address == "" || s.senders[channel] == nil) {
+			continue
+		}
+		notifications = append(notifications, model.Notification{
+			UserID:  userID,
+			Kind:    kind,
+			Channel: channel,
+			Address: address,
+			Subject: subject,
+			Body:    body,
+			OrderID: data.OrderID,
+		})
+	}
+	return notifications, nil
+}
+
+// deliver 发送所有到期的通知
+func (s *Service) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := s.logic.ClaimDueNotifications(ctx, time.Now(), sendLease, batchSize)
+		if err != nil {
+			logx.WithContext(ctx).Errorf("领取待发送通知失败: %v", err)
+			return
+		}
+		for _, n := range due {
+			s.send(ctx, n)
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// send 发送一条通知并记录结果
+func (s *Service) send(ctx context.Context, n model.Notification) {
+	var err error
+	if sender := s.senders[n.Channel]; sender == nil {
+		err = errors.New("渠道未配置: " + n.Channel)
+	} else {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err = sender.Send(sendCtx, Message{To: n.Address, Subject: n.Subject, Body: n.Body})
+		cancel()
+	}
+	if ctx.Err() != nil {
+		// 服务停止导致的中断不计入次数，lease 过后重新发送
+		return
+	}
+
+	var retryAt time.Time
+	if err != nil {
+		logx.WithContext(ctx).Errorf("发送通知失败: id=%d channel=%s attempt=%d: %v", n.ID, n.Channel, n.Attempts+1, err)
+		if n.Attempts+1 < s.cfg.MaxAttempts {
+			retryAt = time.Now().Add(s.cfg.RetryBase << n.Attempts)
+		}
+	}
+	if err := s.logic.RecordNotificationAttempt(ctx, n.ID, err, retryAt); err != nil {
+		logx.WithContext(ctx).Errorf("记录通知发送结果失败: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeSMTP 只实现发信所需命令的本地 SMTP 服务器，rejectRcpt 为 true 时拒绝收件人
+type fakeSMTP struct {
+	addr       string
+	rejectRcpt bool
+	mails      chan fakeMail
+}
+
+type fakeMail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func startFakeSMTP(t *testing.T, rejectRcpt bool) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{addr: ln.Addr().String(), rejectRcpt: rejectRcpt, mails: make(chan fakeMail, 4)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	var mail fakeMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			mail.auth = strings.TrimPrefix(line, "AUTH PLAIN ")
+			tp.PrintfLine("235 ok")
+		case "MAIL":
+			mail.from = line
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			if s.rejectRcpt {
+				tp.PrintfLine("550 no such user")
+				continue
+			}
+			mail.to = append(mail.to, line)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			tp.PrintfLine("250 queued")
+			s.mails <- mail
+			mail = fakeMail{}
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func newTestSMTPSender(t *testing.T, s *fakeSMTP) *SMTPSender {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.addr)
+	p, _ := strconv.Atoi(port)
+	return NewSMTPSender(SMTPConfig{Host: host, Port: p, Username: "canteen", Password: "secret", From: "canteen@example.com"})
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	server := startFakeSMTP(t, false)
+	sender := newTestSMTPSender(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg := Message{To: "student@example.com", Subject: "消费 12.50 元", Body: "您好：\n当前余额 7.50 元。"}
+	if err := sender.Send(ctx, msg); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+
+	var mail fakeMail
+	select {
+	case mail = <-server.mails:
+	case <-time.After(5 * time.Second):
+		t.Fatal("服务器没有收到邮件")
+	}
+
+	auth, _ := base64.StdEncoding.DecodeString(mail.auth)
+	if string(auth) != "\x00canteen\x00secret" {
+		t.Fatalf("认证信息不对: %q", auth)
+	}
+	if !strings.Contains(mail.from, "<canteen@example.com>") || len(mail.to) != 1 || !strings.Contains(mail.to[0], "<student@example.com>") {
+		t.Fatalf("信封不对: from=%q to=%q", mail.from, mail.to)
+	}
+
+	headers, body, ok := strings.Cut(mail.data, "\n\n")
+	if !ok {
+		t.Fatalf("邮件格式不对: %q", mail.data)
+	}
+	var subject string
+	for _, line := range strings.Split(headers, "\n") {
+		if v, ok := strings.CutPrefix(line, "Subject: "); ok {
+			subject, _ = new(mime.WordDecoder).DecodeHeader(v)
+		}
+	}
+	if subject != msg.Subject {
+		t.Fatalf("标题不对: %q", subject)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\n", ""))
+	if err != nil || string(decoded) != msg.Body {
+		t.Fatalf("正文不对: %q, %v", decoded, err)
+	}
+}
+
+func TestSMTPSenderRejected(t *testing.T) {
+	server := startFakeSMTP(t, true)
+	sender := newTestSMTPSender(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := sender.Send(ctx, Message{To: "nobody@example.com", Subject: "s", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("收件人被拒绝时应当返回错误, got %v", err)
+	}
+}
+
+func TestWebhookSender(t *testing.T) {
+	var got map[string]string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSender(server.URL, "token", nil)
+	if err := sender.Send(context.Background(), Message{To: "13800000000", Subject: "s", Body: "余额不足"}); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if got["phone"] != "13800000000" || got["content"] != "余额不足" {
+		t.Fatalf("请求体不对: %v", got)
+	}
+
+	status = http.StatusBadGateway
+	if err := sender.Send(context.Background(), Message{To: "13800000000", Body: "x"}); err == nil {
+		t.Fatal("网关返回 502 时应当失败")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatalf("加载内置模板失败: %v", err)
+	}
+	at := time.Date(2024, 9, 2, 12, 5, 0, 0, time.Local)
+	subject, body, err := templates.Render("order_paid", Data{Username: "张三", At: at, OrderID: "o1", PlateID: "P001", Amount: 12.5, Balance: 7.5})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if subject != "消费 12.50 元" || !strings.Contains(body, "张三") || !strings.Contains(body, "2024-09-02 12:05") || !strings.Contains(body, "7.50") {
+		t.Fatalf("渲染结果不对: %q / %q", subject, body)
+	}
+
+	// 自定义目录只覆盖同名模板
+	dir := t.TempDir()
+	custom := `{{define "subject"}}余额提醒{{end}}{{define "body"}}余额 {{printf "%.1f" .Balance}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "low_balance.tmpl"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err = LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("加载自定义模板失败: %v", err)
+	}
+	if subject, body, _ := templates.Render("low_balance", Data{Balance: 3}); subject != "余额提醒" || body != "余额 3.0" {
+		t.Fatalf("自定义模板没有生效: %q / %q", subject, body)
+	}
+	if subject, _, _ := templates.Render("order_paid", Data{Amount: 5}); subject != "消费 5.00 元" {
+		t.Fatalf("其他模板应当使用内置模板: %q", subject)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "order_paid.tmpl"), []byte(`{{define "subject"}}x{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplates(dir); err == nil {
+		t.Fatal("缺少 body 的模板应当报错")
+	}
+}
+
+func TestServiceProcessesEventsWithoutBus(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 事件只写入数据库，事件总线上没有任何消息，通知仍然生成
+	if err := db.Create(&model.User{ID: "u1", Username: "张三"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.NotificationEvent{UserID: "u1", Kind: logic.NotifyOrderPaid, OrderID: "o1", Amount: 12.5, Balance: 7.5}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 销户退款时用户已被删除，仍然收到退款通知
+	if err := db.Create(&model.User{ID: "u2", Username: "李四"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("id = ?", "u2").Delete(&model.User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.NotificationEvent{UserID: "u2", Kind: logic.NotifyRefundIssued, Amount: 30}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 用户已不存在的事件直接标记为已处理
+	if err := db.Create(&model.NotificationEvent{UserID: "gone", Kind: logic.NotifyOrderPaid}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(logic.NewRestaurantLogic(db), event.NewBus(), templates, nil, Config{MaxAttempts: 1, PollInterval: 10 * time.Millisecond})
+	s.Start()
+	defer s.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var pending int64
+		db.Model(&model.NotificationEvent{}).Where("processed_at IS NULL").Count(&pending)
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("还有 %d 个事件没有处理", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var notifications []model.Notification
+	db.Order("user_id").Find(&notifications)
+	if len(notifications) != 2 {
+		t.Fatalf("生成了 %d 条通知，应为 2", len(notifications))
+	}
+	if n := notifications[1]; n.UserID != "u2" || n.Kind != logic.NotifyRefundIssued || !strings.Contains(n.Body, "30.00 元已原路退还") {
+		t.Fatalf("退款通知 = %+v", n)
+	}
+	if n := notifications[0]; n.UserID != "u1" || n.Channel != logic.ChannelInbox || n.Status != "sent" || !strings.Contains(n.Body, "张三") {
+		t.Fatalf("通知 = %+v", n)
+	}
+}
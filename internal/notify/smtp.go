@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig 邮件服务器配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // 为空表示不认证
+	Password string
+	From     string
+}
+
+// SMTPSender 通过 SMTP 发送邮件。服务器支持 STARTTLS 时自动加密；
+// 认证使用 PLAIN，除本机外只在加密连接上进行
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender 创建邮件发送渠道
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send 发送一封纯文本邮件
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("邮件服务器认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("发件人被拒绝: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("收件人被拒绝: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := w.Write(buildMail(s.cfg.From, msg.To, msg.Subject, msg.Body, time.Now())); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMail 生成 UTF-8 纯文本邮件，标题按 RFC 2047 编码，正文 base64 编码
+func buildMail(from, to, subject, body string, at time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + at.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
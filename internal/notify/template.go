@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/logic"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// Data 模板数据
+type Data struct {
+	Username string
+	At       time.Time
+	OrderID  string
+	PlateID  string
+	Amount   float64 // 订单或退款金额
+	Balance  float64 // 钱包余额
+}
+
+// Templates 每种通知的模板，模板中定义 subject 和 body 两部分
+type Templates struct {
+	byKind map[string]*template.Template
+}
+
+// LoadTemplates 加载内置模板，dir 不为空时用其中的 <通知类型>.tmpl 覆盖对应的内置模板
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{byKind: make(map[string]*template.Template, len(logic.NotificationKinds))}
+	for _, kind := range logic.NotificationKinds {
+		name := kind + ".tmpl"
+		content, err := builtinTemplates.ReadFile("templates/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("缺少内置模板 %s: %w", name, err)
+		}
+		if dir != "" {
+			custom, err := os.ReadFile(filepath.Join(dir, name))
+			switch {
+			case err == nil:
+				content = custom
+			case !errors.Is(err, os.ErrNotExist):
+				return nil, fmt.Errorf("读取模板 %s 失败: %w", name, err)
+			}
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %w", name, err)
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+			return nil, fmt.Errorf("模板 %s 需要定义 subject 和 body", name)
+		}
+		t.byKind[kind] = tmpl
+	}
+	return t, nil
+}
+
+// Render 渲染通知的标题和正文
+func (t *Templates) Render(kind string, data Data) (string, string, error) {
+	tmpl, ok := t.byKind[kind]
+	if !ok {
+		return "", "", fmt.Errorf("未知的通知类型: %s", kind)
+	}
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("渲染通知标题失败: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", fmt.Errorf("渲染通知正文失败: %w", err)
+	}
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()), nil
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WebhookSender 通过 HTTP 网关发送短信。请求体为 {"phone": "...", "content": "..."}，
+// 配置了 token 时放在 Authorization: Bearer 请求头中，网关返回 2xx 视为成功
+type WebhookSender struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewWebhookSender 创建短信发送渠道
+func NewWebhookSender(url, token string, client *http.Client) *WebhookSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSender{url: url, token: token, client: client}
+}
+
+// Send 发送一条短信，短信只包含正文
+func (s *WebhookSender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{"phone": msg.To, "content": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建短信请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求短信网关失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("短信网关返回 %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package pdf 是一个极简的 PDF 生成器，只覆盖标签、小票这类版式固定的场景：
+// 矩形填充、单行文本和 A4 / 自定义尺寸页面。
+//
+// 中文使用 Adobe 预置的 STSong-Light 字体，不嵌入字体文件，由阅读器提供字形。
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 常用纸张尺寸（单位：pt，1pt = 1/72 英寸）
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// MM 将毫米换算为 pt
+func MM(v float64) float64 {
+	return v * 72 / 25.4
+}
+
+// Document PDF 文档
+type Document struct {
+	pages []*Page
+}
+
+// Page PDF 页面，坐标原点在左下角
+type Page struct {
+	Width  float64
+	Height float64
+	buf    bytes.Buffer
+}
+
+// New 创建空文档
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage 追加一页
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{Width: width, Height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Rect 填充黑色矩形
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.buf, "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// Text 以 Helvetica 字体在 (x, y) 处输出一行 ASCII 文本
+func (p *Page) Text(x, y, size float64, text string) {
+	fmt.Fprintf(&p.buf, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", num(size), num(x), num(y), escape(text))
+}
+
+// TextWidth 估算 Helvetica 文本宽度（按平均字宽 0.55em 计算）
+func TextWidth(text string, size float64) float64 {
+	return float64(len(text)) * size * 0.55
+}
+
+// UnicodeText 以 STSong-Light 字体在 (x, y) 处输出一行文本，可以包含中文。
+// ASCII 字符宽 0.5em，其他字符宽 1em，等宽排版时一个中文占两个英文字符的位置
+func (p *Page) UnicodeText(x, y, size float64, text string) {
+	var hex strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?' // UCS-2 编码只能表示基本平面的字符
+		}
+		fmt.Fprintf(&hex, "%04X", r)
+	}
+	fmt.Fprintf(&p.buf, "BT /F2 %s Tf %s %s Td <%s> Tj ET\n", num(size), num(x), num(y), hex.String())
+}
+
+// UnicodeTextWidth 计算 UnicodeText 输出的文本宽度
+func UnicodeTextWidth(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		if r < 0x80 {
+			width += 0.5
+		} else {
+			width++
+		}
+	}
+	return width * size
+}
+
+// Bytes 序列化为 PDF 文件内容
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: Catalog, 2: Pages, 3: Helvetica, 4-6: STSong-Light, 之后每页占用 Page + Contents 两个对象
+	const firstPageObj = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+i*2)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [5 0 R] >>")
+	obj("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 6 0 R /DW 1000 /W [1 95 500] >>")
+	obj("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 " +
+		"/Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(p.Width), num(p.Height), firstPageObj+i*2+1))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.buf.Len(), p.buf.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return r.Replace(s)
+}
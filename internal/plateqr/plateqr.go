@@ -0,0 +1,168 @@
+// Package plateqr 负责餐盘二维码的签名、校验与渲染。
+//
+// 二维码内容格式: FNR1.<plate_id>.<signature>
+// signature 为 HMAC-SHA256(secret, "FNR1."+plate_id) 的前 12 字节（base64url 编码），
+// 没有密钥就无法伪造出能绑定到任意餐盘的贴纸。
+package plateqr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/p-program/Fenrir/internal/pdf"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	payloadPrefix = "FNR1."
+	sigBytes      = 12
+)
+
+var (
+	ErrMalformedPayload = errors.New("二维码格式错误")
+	ErrInvalidSignature = errors.New("二维码签名无效")
+	ErrEmptySecret      = errors.New("二维码签名密钥不能为空")
+	ErrExampleSecret    = errors.New("二维码签名密钥不能使用示例配置中的 change-me 占位值")
+)
+
+// examplePrefix 示例配置中占位密钥的前缀
+const examplePrefix = "change-me"
+
+// Signer 二维码签名器
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner 创建签名器，密钥为空或沿用示例配置的占位值时任何人都能伪造二维码，直接报错
+func NewSigner(secret string) (*Signer, error) {
+	if strings.TrimSpace(secret) == "" {
+		return nil, ErrEmptySecret
+	}
+	if strings.HasPrefix(strings.TrimSpace(secret), examplePrefix) {
+		return nil, ErrExampleSecret
+	}
+	return &Signer{secret: []byte(secret)}, nil
+}
+
+// Payload 生成餐盘的签名二维码内容
+func (s *Signer) Payload(plateID string) string {
+	body := payloadPrefix + plateID
+	return body + "." + s.sign(body)
+}
+
+// Verify 校验二维码内容，返回餐盘ID
+func (s *Signer) Verify(payload string) (string, error) {
+	if !strings.HasPrefix(payload, payloadPrefix) {
+		return "", ErrMalformedPayload
+	}
+	i := strings.LastIndex(payload, ".")
+	if i <= len(payloadPrefix) {
+		return "", ErrMalformedPayload
+	}
+	body, sig := payload[:i], payload[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.sign(body))) {
+		return "", ErrInvalidSignature
+	}
+	return strings.TrimPrefix(body, payloadPrefix), nil
+}
+
+func (s *Signer) sign(body string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:sigBytes])
+}
+
+// PNG 将二维码内容渲染为 PNG
+func PNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// SVG 将二维码内容渲染为 SVG
+func SVG(payload string, size int) ([]byte, error) {
+	qr, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := qr.Bitmap()
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+// Label 标签内容
+type Label struct {
+	PlateID string // 人工可读的餐盘ID
+	Payload string // 二维码内容
+}
+
+// 标签版式：A4 纸，3 列 x 7 行，每个标签 60mm x 38mm
+const (
+	labelCols   = 3
+	labelRows   = 7
+	labelWidth  = 60.0
+	labelHeight = 38.0
+	qrSize      = 28.0
+	textSize    = 8.0
+)
+
+// LabelSheet 生成可打印的 PDF 标签页
+func LabelSheet(labels []Label) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("标签列表不能为空")
+	}
+
+	doc := pdf.New()
+	marginX := (pdf.A4Width - pdf.MM(labelWidth)*labelCols) / 2
+	marginY := (pdf.A4Height - pdf.MM(labelHeight)*labelRows) / 2
+	perPage := labelCols * labelRows
+
+	var page *pdf.Page
+	for i, label := range labels {
+		if i%perPage == 0 {
+			page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+		}
+		slot := i % perPage
+		col, row := slot%labelCols, slot/labelCols
+
+		// 标签左上角
+		left := marginX + float64(col)*pdf.MM(labelWidth)
+		top := pdf.A4Height - marginY - float64(row)*pdf.MM(labelHeight)
+
+		qr, err := qrcode.New(label.Payload, qrcode.Medium)
+		if err != nil {
+			return nil, fmt.Errorf("生成二维码失败: %s, %w", label.PlateID, err)
+		}
+		bitmap := qr.Bitmap()
+		module := pdf.MM(qrSize) / float64(len(bitmap))
+		qrLeft := left + (pdf.MM(labelWidth)-pdf.MM(qrSize))/2
+		qrTop := top - pdf.MM(2)
+		for y, line := range bitmap {
+			for x, dark := range line {
+				if dark {
+					page.Rect(qrLeft+float64(x)*module, qrTop-float64(y+1)*module, module, module)
+				}
+			}
+		}
+
+		textX := left + (pdf.MM(labelWidth)-pdf.TextWidth(label.PlateID, textSize))/2
+		page.Text(textX, qrTop-pdf.MM(qrSize)-pdf.MM(4), textSize, label.PlateID)
+	}
+
+	return doc.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package plateqr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func mustSigner(t *testing.T, secret string) *Signer {
+	t.Helper()
+	s, err := NewSigner(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewSignerRequiresSecret(t *testing.T) {
+	for _, secret := range []string{"", "  "} {
+		if _, err := NewSigner(secret); err != ErrEmptySecret {
+			t.Errorf("NewSigner(%q) = %v，应为 ErrEmptySecret", secret, err)
+		}
+	}
+	for _, secret := range []string{"change-me", "change-me-plate-qr-secret"} {
+		if _, err := NewSigner(secret); err != ErrExampleSecret {
+			t.Errorf("NewSigner(%q) = %v，应为 ErrExampleSecret", secret, err)
+		}
+	}
+}
+
+func TestSignerVerify(t *testing.T) {
+	s := mustSigner(t, "secret")
+	payload := s.Payload("plate.001")
+
+	plateID, err := s.Verify(payload)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if plateID != "plate.001" {
+		t.Fatalf("餐盘ID不一致: %s", plateID)
+	}
+
+	if _, err := mustSigner(t, "other").Verify(payload); err != ErrInvalidSignature {
+		t.Fatalf("不同密钥应当校验失败, got %v", err)
+	}
+	if _, err := s.Verify(payload[:len(payload)-1] + "x"); err != ErrInvalidSignature {
+		t.Fatalf("篡改签名应当校验失败, got %v", err)
+	}
+	if _, err := s.Verify("plate.001"); err != ErrMalformedPayload {
+		t.Fatalf("缺少前缀应当格式错误, got %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	payload := mustSigner(t, "secret").Payload("P0001")
+
+	png, err := PNG(payload, 128)
+	if err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Fatalf("PNG 渲染失败: %v", err)
+	}
+
+	svg, err := SVG(payload, 128)
+	if err != nil || !bytes.HasPrefix(svg, []byte("<svg")) {
+		t.Fatalf("SVG 渲染失败: %v", err)
+	}
+
+	var labels []Label
+	for i := 0; i < 25; i++ {
+		labels = append(labels, Label{PlateID: "P0001", Payload: payload})
+	}
+	doc, err := LabelSheet(labels)
+	if err != nil {
+		t.Fatalf("标签页生成失败: %v", err)
+	}
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) || !bytes.Contains(doc, []byte("/Count 2")) {
+		t.Fatalf("标签页应为两页 PDF")
+	}
+}
@@ -0,0 +1,60 @@
+package receipt
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// ESC/POS 指令
+var (
+	escInit       = []byte{0x1B, 0x40}             // ESC @ 初始化
+	escChinese    = []byte{0x1C, 0x26}             // FS & 进入汉字模式
+	escAlignLeft  = []byte{0x1B, 0x61, 0x00}       // ESC a 0
+	escAlignMid   = []byte{0x1B, 0x61, 0x01}       // ESC a 1
+	escSizeNormal = []byte{0x1D, 0x21, 0x00}       // GS ! 0
+	escSizeLarge  = []byte{0x1D, 0x21, 0x11}       // GS ! 倍高倍宽
+	escFeed       = []byte{0x1B, 0x64, 0x04}       // ESC d 4 走纸 4 行
+	escCut        = []byte{0x1D, 0x56, 0x42, 0x00} // GS V 66 0 走纸后半切
+)
+
+// ESCPOS 生成 ESC/POS 打印指令，文字为 GBK 编码，末尾走纸并切纸（没有切刀的打印机忽略）。
+// GBK 无法表示的字符打印为 ?
+func ESCPOS(r *Receipt) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+	b.Write(escChinese)
+	for _, l := range layout(r) {
+		if l.center {
+			b.Write(escAlignMid)
+		}
+		if l.large {
+			b.Write(escSizeLarge)
+		}
+		b.Write(gbk(l.text))
+		b.WriteByte('\n')
+		if l.large {
+			b.Write(escSizeNormal)
+		}
+		if l.center {
+			b.Write(escAlignLeft)
+		}
+	}
+	b.Write(escFeed)
+	b.Write(escCut)
+	return b.Bytes()
+}
+
+// gbk 把文本转换为 GBK 编码，无法表示的字符替换为 ?
+func gbk(s string) []byte {
+	encoder := simplifiedchinese.GBK.NewEncoder()
+	var out []byte
+	for _, r := range s {
+		encoded, err := encoder.Bytes([]byte(string(r)))
+		if err != nil {
+			encoded = []byte{'?'}
+		}
+		out = append(out, encoded...)
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package receipt
+
+import (
+	"github.com/p-program/Fenrir/internal/pdf"
+)
+
+// PDF 版式：58mm 宽，左右各留 5mm，行数决定页面高度
+const (
+	pdfWidth   = 58.0
+	pdfMargin  = 5.0
+	lineHeight = 1.4
+)
+
+// PDF 生成与纸质小票相同版式的 PDF
+func PDF(r *Receipt) []byte {
+	lines := layout(r)
+	// 一行 LineWidth 个 ASCII 字符，每个宽 0.5em
+	size := pdf.MM(pdfWidth-2*pdfMargin) / (LineWidth * 0.5)
+
+	height := pdf.MM(2 * pdfMargin)
+	for _, l := range lines {
+		height += lineSize(l, size) * lineHeight
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage(pdf.MM(pdfWidth), height)
+	left := pdf.MM(pdfMargin)
+	y := height - pdf.MM(pdfMargin)
+	for _, l := range lines {
+		s := lineSize(l, size)
+		y -= s * lineHeight
+		x := left
+		if l.center {
+			x += (pdf.MM(pdfWidth-2*pdfMargin) - pdf.UnicodeTextWidth(l.text, s)) / 2
+		}
+		page.UnicodeText(x, y, s, l.text)
+	}
+	return doc.Bytes()
+}
+
+func lineSize(l line, size float64) float64 {
+	if l.large {
+		return size * 2
+	}
+	return size
+}
@@ -0,0 +1,160 @@
+// Package receipt 生成订单小票。
+//
+// 版式按 58mm 热敏纸设计，每行 32 个字符宽度（一个中文占两个字符）。同一份版式可以输出为
+// 纯文本、ESC/POS 打印指令（GBK 编码）或 PDF，收银台处理争议时打印或下载。
+package receipt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineWidth 58mm 热敏纸每行可打印的字符宽度（12x24 点阵字体）
+const LineWidth = 32
+
+// Item 小票上的一个菜品
+type Item struct {
+	Name      string
+	Weight    float64 // 重量（克）或容量（毫升）
+	Unit      string  // g 或 ml
+	UnitPrice float64
+	PriceUnit string // 单价的计价单位：100g、100ml 或 勺
+	Price     float64
+}
+
+// Receipt 小票内容
+type Receipt struct {
+	Title      string // 食堂名称
+	OrderID    string
+	PlateID    string
+	Username   string
+	CreatedAt  time.Time
+	Items      []Item
+	Subtotal   float64  // 菜品金额合计
+	Discount   float64  // 优惠金额
+	Total      float64  // 实付金额
+	Balance    *float64 // 支付后余额，没有扣款记录时为空
+	RefundedAt *time.Time
+}
+
+// line 一行小票内容
+type line struct {
+	text   string
+	center bool
+	large  bool // 倍高倍宽，用于标题
+}
+
+// Text 生成纯文本小票，行尾为 \n
+func Text(r *Receipt) string {
+	var b strings.Builder
+	for _, l := range layout(r) {
+		if l.center {
+			b.WriteString(strings.Repeat(" ", (LineWidth-width(l.text))/2))
+		}
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// layout 按 58mm 小票排版
+func layout(r *Receipt) []line {
+	title := r.Title
+	if title == "" {
+		title = "智慧食堂"
+	}
+	separator := line{text: strings.Repeat("-", LineWidth)}
+
+	lines := []line{
+		{text: truncate(title, LineWidth/2), center: true, large: true},
+		{text: "消费小票", center: true},
+		separator,
+		{text: "订单号:"},
+	}
+	lines = append(lines, wrap(r.OrderID)...)
+	lines = append(lines, line{text: "餐盘: " + r.PlateID})
+	if r.Username != "" {
+		lines = append(lines, line{text: truncate("用户: "+r.Username, LineWidth)})
+	}
+	lines = append(lines, line{text: "时间: " + r.CreatedAt.Format("2006-01-02 15:04:05")}, separator)
+
+	for _, item := range r.Items {
+		lines = append(lines, line{text: truncate(item.Name, LineWidth)})
+		detail := fmt.Sprintf("  %s%s x %s/%s", number(item.Weight), item.Unit, money(item.UnitPrice), item.PriceUnit)
+		lines = append(lines, columns(detail, money(item.Price))...)
+	}
+
+	lines = append(lines, separator)
+	lines = append(lines, columns("合计", money(r.Subtotal))...)
+	if r.Discount > 0 {
+		lines = append(lines, columns("优惠", "-"+money(r.Discount))...)
+	}
+	lines = append(lines, columns("实付", money(r.Total))...)
+	if r.Balance != nil {
+		lines = append(lines, columns("余额", money(*r.Balance))...)
+	}
+	if r.RefundedAt != nil {
+		lines = append(lines, columns("已退款", money(r.Total))...)
+		lines = append(lines, line{text: "退款时间: " + r.RefundedAt.Format("2006-01-02 15:04")})
+	}
+	lines = append(lines, separator, line{text: "谢谢惠顾", center: true})
+	return lines
+}
+
+// columns 左右两栏，右栏右对齐；放不下时右栏换到下一行
+func columns(left, right string) []line {
+	gap := LineWidth - width(left) - width(right)
+	if gap < 1 {
+		return []line{{text: left}, {text: strings.Repeat(" ", LineWidth-width(right)) + right}}
+	}
+	return []line{{text: left + strings.Repeat(" ", gap) + right}}
+}
+
+// wrap 按纸宽折行，订单号等需要完整打印的内容使用
+func wrap(s string) []line {
+	var lines []line
+	for width(s) > LineWidth {
+		head := truncate(s, LineWidth)
+		lines = append(lines, line{text: head})
+		s = s[len(head):]
+	}
+	return append(lines, line{text: s})
+}
+
+// width 打印宽度：ASCII 占 1，其他字符（GBK 双字节）占 2
+func width(s string) int {
+	w := 0
+	for _, r := range s {
+		if r < 0x80 {
+			w++
+		} else {
+			w += 2
+		}
+	}
+	return w
+}
+
+// truncate 截断到指定打印宽度
+func truncate(s string, max int) string {
+	w := 0
+	for i, r := range s {
+		rw := width(string(r))
+		if w+rw > max {
+			return s[:i]
+		}
+		w += rw
+	}
+	return s
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// number 最多两位小数，去掉末尾的 0
+func number(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
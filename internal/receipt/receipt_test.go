@@ -0,0 +1,103 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func testReceipt() *Receipt {
+	balance := 87.5
+	return &Receipt{
+		Title:     "北区食堂",
+		OrderID:   "0ea6a308-cb0b-4609-b75b-0b34caa6fc69",
+		PlateID:   "P002",
+		Username:  "张三",
+		CreatedAt: time.Date(2024, 9, 2, 12, 5, 30, 0, time.Local),
+		Items: []Item{
+			{Name: "红烧肉", Weight: 200, Unit: "g", UnitPrice: 6, PriceUnit: "100g", Price: 12},
+			{Name: "番茄蛋汤", Weight: 250, Unit: "ml", UnitPrice: 1, PriceUnit: "勺", Price: 0.5},
+		},
+		Subtotal: 12.5,
+		Total:    12.5,
+		Balance:  &balance,
+	}
+}
+
+func TestText(t *testing.T) {
+	text := Text(testReceipt())
+	for _, want := range []string{
+		"北区食堂",
+		"0ea6a308-cb0b-4609-b75b-0b34caa6\nfc69\n",
+		"2024-09-02 12:05:30",
+		"  200g x 6.00/100g",
+		"  250ml x 1.00/勺",
+		"余额",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("小票缺少 %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "优惠") || strings.Contains(text, "退款") {
+		t.Fatalf("没有优惠和退款时不应打印:\n%s", text)
+	}
+
+	for _, l := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if width(l) > LineWidth {
+			t.Fatalf("超出纸宽: %q", l)
+		}
+	}
+	if !strings.Contains(text, "实付"+strings.Repeat(" ", LineWidth-width("实付")-len("12.50"))+"12.50\n") {
+		t.Fatalf("金额应当右对齐:\n%s", text)
+	}
+}
+
+func TestTextDiscountAndRefund(t *testing.T) {
+	r := testReceipt()
+	r.Discount = 2.5
+	r.Total = 10
+	refundedAt := r.CreatedAt.Add(time.Hour)
+	r.RefundedAt = &refundedAt
+
+	text := Text(r)
+	if !strings.Contains(text, "-2.50\n") || !strings.Contains(text, "已退款") || !strings.Contains(text, "2024-09-02 13:05") {
+		t.Fatalf("优惠或退款信息不对:\n%s", text)
+	}
+}
+
+func TestColumnsWrap(t *testing.T) {
+	lines := columns(strings.Repeat("菜", 15), "123.00")
+	if len(lines) != 2 || width(lines[1].text) != LineWidth || !strings.HasSuffix(lines[1].text, "123.00") {
+		t.Fatalf("放不下时金额应换行右对齐: %+v", lines)
+	}
+}
+
+func TestESCPOS(t *testing.T) {
+	r := testReceipt()
+	r.Items[0].Name = "红烧肉🍖"
+	out := ESCPOS(r)
+	if !bytes.HasPrefix(out, []byte{0x1B, 0x40, 0x1C, 0x26}) || !bytes.HasSuffix(out, []byte{0x1D, 0x56, 0x42, 0x00}) {
+		t.Fatalf("缺少初始化或切纸指令: % x", out)
+	}
+	name, _ := simplifiedchinese.GBK.NewEncoder().String("红烧肉")
+	if !bytes.Contains(out, []byte(name+"?\n")) {
+		t.Fatalf("菜品名应为 GBK 编码，无法表示的字符替换为 ?")
+	}
+	if !bytes.Contains(out, append([]byte{0x1B, 0x61, 0x01, 0x1D, 0x21, 0x11}, gbk("北区食堂")...)) {
+		t.Fatalf("标题应当居中放大")
+	}
+}
+
+func TestPDF(t *testing.T) {
+	doc := PDF(testReceipt())
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) || !bytes.Contains(doc, []byte("/STSong-Light")) {
+		t.Fatalf("应为使用中文字体的 PDF")
+	}
+	// 红烧肉 的 UCS-2 编码
+	if !bytes.Contains(doc, []byte("<7EA270E78089>")) {
+		t.Fatalf("PDF 中缺少菜品名")
+	}
+}
@@ -0,0 +1,97 @@
+// Package reconcile 定时核对钱包余额与交易流水，并提供 reconcile 命令共用的修复流程。
+//
+// 定时任务只生成对账报告并记录日志，不修改数据；发现问题后使用 reconcile 命令查看明细，
+// 确认后再用 -repair 修复（Repair）。
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Job 定时对账
+type Job struct {
+	logic    *logic.RestaurantLogic
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewJob 创建定时对账，interval 为 0 时不启动
+func NewJob(l *logic.RestaurantLogic, interval time.Duration) *Job {
+	return &Job{
+		logic:    l,
+		interval: interval,
+	}
+}
+
+// Start 开始定时对账
+func (j *Job) Start() {
+	if j.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止对账
+func (j *Job) Stop() {
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	<-j.done
+}
+
+func (j *Job) run(ctx context.Context) {
+	report, err := j.logic.Reconcile(ctx)
+	if err != nil {
+		logx.WithContext(ctx).Errorf("对账失败: %v", err)
+		return
+	}
+	if report.OK() {
+		logx.WithContext(ctx).Infof("对账完成：%d 个钱包、%d 个订单、%d 条交易，没有发现问题",
+			report.Wallets, report.Orders, report.Transactions)
+		return
+	}
+
+	logger := logx.WithContext(ctx)
+	logger.Errorf("对账发现问题：余额与流水不一致 %d 个钱包，余额与账本不一致 %d 个钱包，订单问题 %d 个，孤立交易 %d 条",
+		len(report.Mismatches), len(report.Ledger), len(report.OrderIssues), len(report.Orphans))
+	for _, m := range report.Mismatches {
+		logger.Errorf("钱包余额与流水不一致: wallet=%d user=%s balance=%.2f computed=%.2f diff=%.2f",
+			m.WalletID, m.UserID, m.Balance, m.Computed, m.Diff)
+	}
+	for _, m := range report.Ledger {
+		logger.Errorf("钱包余额与账本不一致: wallet=%d user=%s balance=%.2f ledger=%.2f diff=%.2f",
+			m.WalletID, m.UserID, m.Balance, m.Computed, m.Diff)
+	}
+	for _, issue := range report.OrderIssues {
+		logger.Errorf("订单交易不一致: order=%s status=%s kind=%s total=%.2f count=%d amount=%.2f",
+			issue.OrderID, issue.Status, issue.Kind, issue.TotalPrice, issue.Count, issue.Amount)
+	}
+	for _, o := range report.Orphans {
+		logger.Errorf("孤立交易: id=%d wallet=%d type=%s order=%s amount=%.2f: %s",
+			o.TransactionID, o.WalletID, o.Type, o.OrderID, o.Amount, o.Reason)
+	}
+}
@@ -0,0 +1,50 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/model"
+)
+
+// RepairOptions 修复钱包的参数
+type RepairOptions struct {
+	OperatorID string  // 确认修复的管理员
+	MaxAdjust  float64 // 差额超过该值的钱包跳过，需要人工核实
+	Remark     string  // 修复记录和调整交易的备注，为空时使用默认备注
+}
+
+// Repair 修复对账报告中余额、账本和交易流水只有一项不一致的钱包，并在报告中标记为已修复：
+// 余额不同时改回账本余额，流水不同时补记调整交易。跳过和失败的钱包写入 log，不中断其他钱包的修复
+func Repair(ctx context.Context, l *logic.RestaurantLogic, report *logic.ReconcileReport, opts RepairOptions, log io.Writer) {
+	type repairFunc func(context.Context, logic.WalletMismatch, string, string) (*model.WalletRepair, error)
+	fix := func(m logic.WalletMismatch, repair repairFunc) {
+		if m.Repaired {
+			return
+		}
+		if math.Abs(m.Diff) > opts.MaxAdjust {
+			fmt.Fprintf(log, "跳过钱包 %d：差额 %.2f 超过 -max-adjust %.2f，请人工核实\n", m.WalletID, m.Diff, opts.MaxAdjust)
+			return
+		}
+		if _, err := repair(ctx, m, opts.OperatorID, opts.Remark); err != nil {
+			if errors.Is(err, logic.ErrWalletChanged) || errors.Is(err, logic.ErrLedgerDisputed) {
+				fmt.Fprintf(log, "跳过钱包 %d：%v\n", m.WalletID, err)
+				return
+			}
+			fmt.Fprintf(log, "修复钱包 %d 失败: %v\n", m.WalletID, err)
+			return
+		}
+		report.MarkRepaired(m.WalletID)
+	}
+
+	for _, m := range report.Ledger {
+		fix(m, l.RepairWalletMismatch)
+	}
+	for _, m := range report.Mismatches {
+		fix(m, l.RepairTransactionMismatch)
+	}
+}
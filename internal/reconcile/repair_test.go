@@ -0,0 +1,72 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRepair(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	l := logic.NewRestaurantLogic(db)
+	for _, v := range []interface{}{
+		&model.Worker{ID: "m1", Name: "管理员", Role: "manager"},
+		&model.User{ID: "u1", Username: "u1"}, &model.User{ID: "u2", Username: "u2"}, &model.User{ID: "u3", Username: "u3"},
+	} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, userID := range []string{"u1", "u2", "u3"} {
+		if _, err := l.ChargeWallet(ctx, userID, 50, logic.ChargeSourcePayment, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// u1 余额丢失了更新，u2 的交易流水丢了，u3 余额差额太大
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u1").Update("balance", 40)
+	db.Where("wallet_id IN (?)", db.Model(&model.Wallet{}).Select("id").Where("user_id = ?", "u2")).Delete(&model.Transaction{})
+	db.Model(&model.Wallet{}).Where("user_id = ?", "u3").Update("balance", 500)
+
+	report, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	Repair(ctx, l, report, RepairOptions{OperatorID: "m1", MaxAdjust: 100}, &log)
+
+	if got := report.Unresolved(); got != 2 {
+		t.Fatalf("未解决 %d 个，应为 u3 的 2 个: %+v", got, report)
+	}
+	if !strings.Contains(log.String(), "跳过钱包") {
+		t.Fatalf("没有记录跳过的钱包: %q", log.String())
+	}
+	after, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range append(after.Ledger, after.Mismatches...) {
+		if m.UserID != "u3" {
+			t.Fatalf("修复后 %s 仍不一致: %+v", m.UserID, m)
+		}
+	}
+}
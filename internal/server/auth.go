@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/middleware"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// canteenKey 调用方声明所属食堂的元数据，gRPC 元数据的键为小写
+var canteenKey = strings.ToLower(tenant.Header)
+
+// publicServices 不需要令牌的服务：健康检查和调试用的反射（只在 dev、test 模式下注册）
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// StaffAuth 校验 gRPC 调用的工作人员令牌，令牌与 REST 接口相同，放在 authorization 元数据中（Bearer <令牌>）。
+// 食堂规则与 REST 接口相同：食堂工作人员只能访问本食堂，x-canteen-id 与所属食堂不一致时拒绝；
+// 总部工作人员通过 x-canteen-id 选择食堂，不指定时只能订阅推送（不限食堂）
+type StaffAuth struct {
+	secret string
+	worker middleware.WorkerFunc
+}
+
+// NewStaffAuth 创建 gRPC 认证拦截器，secret 为 StaffAuth.AccessSecret
+func NewStaffAuth(secret string, worker middleware.WorkerFunc) *StaffAuth {
+	return &StaffAuth{secret: secret, worker: worker}
+}
+
+// Unary 普通接口的拦截器
+func (a *StaffAuth) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if public(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authenticate(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream 流式接口的拦截器
+func (a *StaffAuth) Stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if public(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := a.authenticate(ss.Context(), true)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+}
+
+func public(method string) bool {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate 校验令牌和工作人员，把工作人员ID和食堂写入上下文；anyCanteen 为 true 时总部工作人员可以不指定食堂
+func (a *StaffAuth) authenticate(ctx context.Context, anyCanteen bool) (context.Context, error) {
+	token, ok := strings.CutPrefix(first(ctx, "authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "缺少工作人员令牌")
+	}
+	workerID, err := auth.ParseStaffToken(a.secret, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	worker, err := a.worker(ctx, workerID)
+	if err != nil {
+		logx.WithContext(ctx).Infof("工作人员令牌校验失败: %s, %v", workerID, err)
+		return nil, status.Error(codes.Unauthenticated, "工作人员不存在或已停用")
+	}
+
+	canteenID := first(ctx, canteenKey)
+	switch {
+	case worker.CanteenID != "" && canteenID != "" && canteenID != worker.CanteenID:
+		return nil, status.Error(codes.PermissionDenied, "不能访问其他食堂")
+	case worker.CanteenID != "":
+		canteenID = worker.CanteenID
+	case canteenID == "" && !anyCanteen:
+		return nil, status.Error(codes.InvalidArgument, "总部工作人员需要通过 "+canteenKey+" 元数据指定食堂")
+	}
+
+	ctx = auth.WithWorker(ctx, workerID)
+	if canteenID != "" {
+		ctx = tenant.WithCanteen(ctx, canteenID)
+	}
+	return ctx, nil
+}
+
+// first 元数据中 key 的第一个值
+func first(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
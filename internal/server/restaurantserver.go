@@ -0,0 +1,226 @@
+// Package server 实现 rpc/restaurant.proto 定义的 gRPC 接口，与 REST 接口共用 ServiceContext 和业务逻辑
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/model"
+	"github.com/p-program/Fenrir/rpc/restaurant"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type RestaurantServer struct {
+	svcCtx *svc.ServiceContext
+	restaurant.UnimplementedRestaurantServer
+}
+
+func NewRestaurantServer(svcCtx *svc.ServiceContext) *RestaurantServer {
+	return &RestaurantServer{
+		svcCtx: svcCtx,
+	}
+}
+
+// ChargeWallet 钱包充值
+func (s *RestaurantServer) ChargeWallet(ctx context.Context, in *restaurant.ChargeWalletRequest) (*restaurant.Wallet, error) {
+	var expiresAt *time.Time
+	if in.ExpiresAt != "" {
+		day, err := time.ParseInLocation(time.DateOnly, in.ExpiresAt, time.Local)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "日期格式错误: %v", err)
+		}
+		// 有效期含当天，次日零点到期
+		end := day.AddDate(0, 0, 1)
+		expiresAt = &end
+	}
+
+	wallet, err := s.svcCtx.NewLogic().ChargeWallet(ctx, in.UserId, in.Amount, in.Source, expiresAt)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &restaurant.Wallet{UserId: wallet.UserID, Balance: wallet.Balance}, nil
+}
+
+// GetUserInfo 获取用户信息
+func (s *RestaurantServer) GetUserInfo(ctx context.Context, in *restaurant.GetUserInfoRequest) (*restaurant.UserInfo, error) {
+	if in.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "用户ID不能为空")
+	}
+	user, err := s.svcCtx.NewLogic().GetUserInfo(ctx, in.UserId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var balance float64
+	if user.Wallet != nil {
+		balance = user.Wallet.Balance
+	}
+	return &restaurant.UserInfo{UserId: user.ID, Username: user.Username, Balance: balance}, nil
+}
+
+// BindPlate 绑定餐盘
+func (s *RestaurantServer) BindPlate(ctx context.Context, in *restaurant.BindPlateRequest) (*restaurant.Plate, error) {
+	plateID, err := s.svcCtx.ResolvePlateID(in.PlateId, in.QrPayload)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	plate, err := s.svcCtx.NewLogic().BindPlate(ctx, in.UserId, plateID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPlate(plate), nil
+}
+
+// UnbindPlate 解绑餐盘
+func (s *RestaurantServer) UnbindPlate(ctx context.Context, in *restaurant.UnbindPlateRequest) (*restaurant.Empty, error) {
+	if err := s.svcCtx.NewLogic().UnbindPlate(ctx, in.UserId, in.PlateId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &restaurant.Empty{}, nil
+}
+
+// GetPlateInfo 获取餐盘信息
+func (s *RestaurantServer) GetPlateInfo(ctx context.Context, in *restaurant.GetPlateInfoRequest) (*restaurant.Plate, error) {
+	if in.PlateId == "" {
+		return nil, status.Error(codes.InvalidArgument, "餐盘ID不能为空")
+	}
+	plate, err := s.svcCtx.NewLogic().GetPlateInfo(ctx, in.PlateId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPlate(plate), nil
+}
+
+// GetPlateList 获取餐盘列表
+func (s *RestaurantServer) GetPlateList(ctx context.Context, in *restaurant.GetPlateListRequest) (*restaurant.PlateList, error) {
+	plates, err := s.svcCtx.NewLogic().GetPlateList(ctx, in.IsBound)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	list := &restaurant.PlateList{}
+	for i := range plates {
+		list.Plates = append(list.Plates, toPlate(&plates[i]))
+	}
+	return list, nil
+}
+
+// CreateOrder 创建订单
+func (s *RestaurantServer) CreateOrder(ctx context.Context, in *restaurant.CreateOrderRequest) (*restaurant.Order, error) {
+	var foods []logic.OrderFood
+	for _, food := range in.Foods {
+		foods = append(foods, logic.OrderFood{
+			FoodID: food.FoodId,
+			Weight: food.Weight,
+			Ladles: int(food.Ladles),
+		})
+	}
+
+	order, err := s.svcCtx.NewLogic().CreateOrder(ctx, in.UserId, in.PlateId, foods)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toOrder(order), nil
+}
+
+// GetUserOrders 获取用户订单列表
+func (s *RestaurantServer) GetUserOrders(ctx context.Context, in *restaurant.GetUserOrdersRequest) (*restaurant.OrderList, error) {
+	page, pageSize := int(in.Page), int(in.PageSize)
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	orders, total, err := s.svcCtx.NewLogic().GetUserOrders(ctx, in.UserId, page, pageSize)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	list := &restaurant.OrderList{Total: total}
+	for i := range orders {
+		list.Orders = append(list.Orders, toOrder(&orders[i]))
+	}
+	return list, nil
+}
+
+// GetOrderInfo 获取订单信息
+func (s *RestaurantServer) GetOrderInfo(ctx context.Context, in *restaurant.GetOrderInfoRequest) (*restaurant.Order, error) {
+	if in.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "订单ID不能为空")
+	}
+	order, err := s.svcCtx.NewLogic().GetOrderInfo(ctx, in.OrderId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toOrder(order), nil
+}
+
+// HandleException 处理异常
+func (s *RestaurantServer) HandleException(ctx context.Context, in *restaurant.HandleExceptionRequest) (*restaurant.Empty, error) {
+	if err := s.svcCtx.NewLogic().HandleException(ctx, in.WorkerId, in.PlateId, in.Exception, in.Action); err != nil {
+		return nil, toStatus(err)
+	}
+	return &restaurant.Empty{}, nil
+}
+
+// ProcessGC 处理GC
+func (s *RestaurantServer) ProcessGC(ctx context.Context, in *restaurant.ProcessGCRequest) (*restaurant.Empty, error) {
+	if err := s.svcCtx.NewLogic().ProcessGC(ctx, in.PlateId, in.Type, in.WorkerId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &restaurant.Empty{}, nil
+}
+
+// toStatus 把业务错误转换为 gRPC 状态：记录不存在为 NotFound，其余业务校验失败为 FailedPrecondition
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if st := status.FromContextError(err); st.Code() != codes.Unknown {
+		return st.Err()
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.FailedPrecondition, err.Error())
+}
+
+func toPlate(plate *model.Plate) *restaurant.Plate {
+	return &restaurant.Plate{
+		PlateId:     plate.ID,
+		QrCode:      plate.QRCode,
+		Weight:      plate.Weight,
+		IsBound:     plate.IsBound,
+		BoundUserId: plate.BoundUserID,
+		Status:      plate.Status,
+	}
+}
+
+func toOrder(order *model.Order) *restaurant.Order {
+	out := &restaurant.Order{
+		OrderId:    order.ID,
+		UserId:     order.UserID,
+		PlateId:    order.PlateID,
+		TotalPrice: order.TotalPrice,
+		Status:     order.Status,
+		CreatedAt:  timestamppb.New(order.CreatedAt),
+	}
+	for _, item := range order.OrderItems {
+		out.Foods = append(out.Foods, &restaurant.OrderItem{
+			FoodId: item.FoodID,
+			Name:   item.FoodName,
+			Weight: item.Weight,
+			Price:  item.Price,
+		})
+	}
+	return out
+}
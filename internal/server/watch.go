@@ -0,0 +1,106 @@
+package server
+
+import (
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/tenant"
+	"github.com/p-program/Fenrir/rpc/restaurant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// plateEventTypes 推送的餐盘事件
+var plateEventTypes = map[string]restaurant.PlateEventType{
+	event.PlateBound:   restaurant.PlateEventType_PLATE_BOUND,
+	event.PlateUnbound: restaurant.PlateEventType_PLATE_UNBOUND,
+	event.PlateStatus:  restaurant.PlateEventType_PLATE_STATUS,
+	event.PlateGC:      restaurant.PlateEventType_PLATE_GC,
+}
+
+// orderEventTypes 推送的订单事件
+var orderEventTypes = map[string]restaurant.OrderEventType{
+	event.ItemAdded: restaurant.OrderEventType_ORDER_ITEM_ADDED,
+	event.OrderPaid: restaurant.OrderEventType_ORDER_PAID,
+}
+
+// WatchPlateEvents 推送餐盘绑定、解绑、状态变化和回收事件
+func (s *RestaurantServer) WatchPlateEvents(in *restaurant.WatchPlateEventsRequest, stream grpc.ServerStreamingServer[restaurant.PlateEvent]) error {
+	plates := make(map[string]bool, len(in.PlateIds))
+	for _, id := range in.PlateIds {
+		plates[id] = true
+	}
+
+	return s.watch(stream, func(e event.Event) error {
+		eventType, ok := plateEventTypes[e.Type]
+		if !ok || (len(plates) > 0 && !plates[e.PlateID]) || (in.UserId != "" && e.UserID != in.UserId) {
+			return nil
+		}
+		return stream.Send(&restaurant.PlateEvent{
+			Type:       eventType,
+			CanteenId:  e.CanteenID,
+			PlateId:    e.PlateID,
+			UserId:     e.UserID,
+			PrevStatus: e.PrevStatus,
+			Status:     e.NewStatus,
+			IsBound:    e.IsBound,
+			Auto:       e.Auto,
+			At:         timestamppb.New(e.At),
+		})
+	})
+}
+
+// WatchOrderEvents 推送菜品加入订单和支付事件
+func (s *RestaurantServer) WatchOrderEvents(in *restaurant.WatchOrderEventsRequest, stream grpc.ServerStreamingServer[restaurant.OrderEvent]) error {
+	return s.watch(stream, func(e event.Event) error {
+		eventType, ok := orderEventTypes[e.Type]
+		if !ok || (in.UserId != "" && e.UserID != in.UserId) || (in.PlateId != "" && e.PlateID != in.PlateId) {
+			return nil
+		}
+		return stream.Send(&restaurant.OrderEvent{
+			Type:      eventType,
+			CanteenId: e.CanteenID,
+			OrderId:   e.OrderID,
+			UserId:    e.UserID,
+			PlateId:   e.PlateID,
+			FoodName:  e.FoodName,
+			Weight:    e.Weight,
+			Amount:    e.Amount,
+			Total:     e.Total,
+			Balance:   e.Balance,
+			At:        timestamppb.New(e.At),
+		})
+	})
+}
+
+// watch 订阅事件总线直到客户端断开，只处理调用方所属食堂的事件（未声明食堂时不限）。
+// 客户端接收太慢、事件总线丢弃了事件时以 ResourceExhausted 结束推送，不发送有缺口的事件流，
+// 客户端需要重新订阅并通过查询接口补齐状态
+func (s *RestaurantServer) watch(stream grpc.ServerStream, send func(event.Event) error) error {
+	ctx := stream.Context()
+	canteenID := tenant.CanteenID(ctx)
+
+	sub := s.svcCtx.Bus.Subscribe()
+	defer sub.Cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			if dropped := sub.Dropped(); dropped > 0 {
+				return status.Errorf(codes.ResourceExhausted, "接收太慢，已丢弃 %d 个事件，请重新订阅", dropped)
+			}
+			if canteenID != "" && e.CanteenID != canteenID {
+				continue
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
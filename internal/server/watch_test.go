@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/svc"
+	"github.com/p-program/Fenrir/model"
+	"github.com/p-program/Fenrir/rpc/restaurant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+const testSecret = "staff-secret"
+
+var testWorkers = map[string]*model.Worker{
+	"north-staff": {ID: "north-staff", CanteenID: "north", Role: "staff"},
+	"hq-manager":  {ID: "hq-manager", Role: "manager"},
+}
+
+func findWorker(ctx context.Context, workerID string) (*model.Worker, error) {
+	if w, ok := testWorkers[workerID]; ok {
+		return w, nil
+	}
+	return nil, errors.New("工作人员不存在")
+}
+
+// withToken 在调用的元数据中带上工作人员令牌和可选的食堂
+func withToken(t *testing.T, secret, workerID, canteenID string) context.Context {
+	t.Helper()
+	token, err := auth.NewStaffToken(secret, 3600, workerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := []string{"authorization", "Bearer " + token}
+	if canteenID != "" {
+		md = append(md, "x-canteen-id", canteenID)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), md...)
+}
+
+// startServer 在内存连接上启动 gRPC 服务，只接入事件总线
+func startServer(t *testing.T) (restaurant.RestaurantClient, *event.Bus) {
+	t.Helper()
+	bus := event.NewBus()
+	listener := bufconn.Listen(1 << 20)
+	staffAuth := NewStaffAuth(testSecret, findWorker)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(staffAuth.Unary),
+		grpc.StreamInterceptor(staffAuth.Stream),
+	)
+	restaurant.RegisterRestaurantServer(s, NewRestaurantServer(&svc.ServiceContext{Bus: bus}))
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return restaurant.NewRestaurantClient(conn), bus
+}
+
+// publishUntil 重复发布事件直到 received 返回 true，等待服务端订阅生效
+func publishUntil(t *testing.T, bus *event.Bus, received <-chan struct{}, events ...event.Event) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		bus.Publish(events...)
+		select {
+		case <-received:
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("没有收到推送")
+		}
+	}
+}
+
+func TestWatchPlateEvents(t *testing.T) {
+	client, bus := startServer(t)
+	ctx, cancel := context.WithCancel(withToken(t, testSecret, "north-staff", ""))
+	defer cancel()
+
+	stream, err := client.WatchPlateEvents(ctx, &restaurant.WatchPlateEventsRequest{PlateIds: []string{"P1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan *restaurant.PlateEvent, 16)
+	received := make(chan struct{}, 16)
+	go func() {
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			got <- e
+			received <- struct{}{}
+		}
+	}()
+
+	publishUntil(t, bus, received,
+		event.Event{Type: event.PlateBound, CanteenID: "south", PlateID: "P1"}, // 其他食堂
+		event.Event{Type: event.PlateBound, CanteenID: "north", PlateID: "P2"}, // 其他餐盘
+		event.Event{Type: event.OrderPaid, CanteenID: "north", PlateID: "P1"},  // 订单事件
+		event.Event{Type: event.PlateBound, CanteenID: "north", PlateID: "P1", UserID: "u1",
+			PrevStatus: "available", NewStatus: "in_use", IsBound: true},
+	)
+	e := <-got
+	if e.Type != restaurant.PlateEventType_PLATE_BOUND || e.PlateId != "P1" || e.UserId != "u1" ||
+		e.CanteenId != "north" || e.Status != "in_use" || !e.IsBound || e.At == nil {
+		t.Errorf("推送 = %v", e)
+	}
+}
+
+func TestWatchOrderEvents(t *testing.T) {
+	client, bus := startServer(t)
+	ctx, cancel := context.WithCancel(withToken(t, testSecret, "hq-manager", ""))
+	defer cancel()
+
+	// 总部工作人员未指定食堂时不限食堂
+	stream, err := client.WatchOrderEvents(ctx, &restaurant.WatchOrderEventsRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan *restaurant.OrderEvent, 16)
+	received := make(chan struct{}, 16)
+	go func() {
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			got <- e
+			received <- struct{}{}
+		}
+	}()
+
+	publishUntil(t, bus, received,
+		event.Event{Type: event.OrderPaid, CanteenID: "south", UserID: "u2", OrderID: "O2"},
+		event.Event{Type: event.PlateBound, CanteenID: "south", UserID: "u1"},
+		event.Event{Type: event.OrderPaid, CanteenID: "south", UserID: "u1", OrderID: "O1", Amount: 12.5, Balance: 30},
+	)
+	e := <-got
+	if e.Type != restaurant.OrderEventType_ORDER_PAID || e.OrderId != "O1" || e.Amount != 12.5 || e.Balance != 30 {
+		t.Errorf("推送 = %v", e)
+	}
+}
+
+func TestStaffAuth(t *testing.T) {
+	client, _ := startServer(t)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"没有令牌", metadata.AppendToOutgoingContext(context.Background(), "x-canteen-id", "north"), codes.Unauthenticated},
+		{"令牌签名错误", withToken(t, "other-secret", "north-staff", ""), codes.Unauthenticated},
+		{"工作人员不存在", withToken(t, testSecret, "nobody", ""), codes.Unauthenticated},
+		{"不能访问其他食堂", withToken(t, testSecret, "north-staff", "south"), codes.PermissionDenied},
+		{"总部工作人员必须指定食堂", withToken(t, testSecret, "hq-manager", ""), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetPlateInfo(tt.ctx, &restaurant.GetPlateInfoRequest{PlateId: "P1"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("GetPlateInfo = %v，应为 %s", err, tt.want)
+			}
+
+			if tt.want == codes.InvalidArgument {
+				// 总部工作人员订阅推送可以不指定食堂，见 TestWatchOrderEvents
+				return
+			}
+			// 流式接口的错误在第一次接收时返回
+			stream, err := client.WatchPlateEvents(tt.ctx, &restaurant.WatchPlateEventsRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("WatchPlateEvents = %v，应为 %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchEndsWhenEventsDropped(t *testing.T) {
+	client, bus := startServer(t)
+	ctx, cancel := context.WithCancel(withToken(t, testSecret, "north-staff", ""))
+	defer cancel()
+
+	stream, err := client.WatchPlateEvents(ctx, &restaurant.WatchPlateEventsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 确认订阅已生效
+	received := make(chan struct{}, 1)
+	go func() {
+		if _, err := stream.Recv(); err == nil {
+			received <- struct{}{}
+		}
+	}()
+	publishUntil(t, bus, received, event.Event{Type: event.PlateBound, CanteenID: "north", PlateID: "P0"})
+
+	// 客户端不接收时，服务端发送受流量控制阻塞，订阅缓冲很快写满，之后的事件被丢弃
+	for i := 0; i < 100000; i++ {
+		bus.Publish(event.Event{Type: event.PlateBound, CanteenID: "north", PlateID: fmt.Sprintf("P%d", i)})
+	}
+	for {
+		_, err := stream.Recv()
+		if err == nil {
+			continue
+		}
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("推送结束 = %v，应为 ResourceExhausted", err)
+		}
+		return
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("查询订单失败: %w", gorm.ErrRecordNotFound), codes.NotFound},
+		{errors.New("余额不足"), codes.FailedPrecondition},
+		{context.Canceled, codes.Canceled},
+		{status.Error(codes.InvalidArgument, "日期格式错误"), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if got := status.Code(toStatus(tt.err)); got != tt.want {
+			t.Errorf("toStatus(%v) = %s，应为 %s", tt.err, got, tt.want)
+		}
+	}
+}
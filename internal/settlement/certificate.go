@@ -0,0 +1,112 @@
+package settlement
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/pdf"
+)
+
+// Certificate 销户证明内容
+type Certificate struct {
+	No       string // 证明编号
+	Issuer   string // 出具单位
+	UserID   string
+	Username string
+	ClosedAt time.Time
+	Reason   string
+	Result
+}
+
+// lines 证明正文，每项为一行
+func (c *Certificate) lines() []string {
+	lines := []string{
+		"编号：" + c.No,
+		"",
+		"用户：" + c.Username,
+		"用户ID：" + c.UserID,
+		"销户时间：" + c.ClosedAt.Format("2006-01-02 15:04:05"),
+	}
+	if c.Reason != "" {
+		lines = append(lines, "销户原因："+c.Reason)
+	}
+	lines = append(lines,
+		"",
+		"销户时钱包余额："+money(c.Balance)+" 元",
+		"  其中自有资金："+money(c.Refundable)+" 元",
+		"  未过期补贴："+money(c.Subsidy)+" 元",
+		"  已过期补贴："+money(c.Expired)+" 元",
+		"",
+		"应退还自有资金："+money(c.Refundable)+" 元",
+		"收回补贴："+money(c.Subsidy+c.Expired)+" 元",
+		"",
+		"该用户的钱包已结清并注销，餐盘已解绑，此后不能再下单或充值。",
+	)
+	return lines
+}
+
+func (c *Certificate) issuer() string {
+	if c.Issuer == "" {
+		return "智慧食堂"
+	}
+	return c.Issuer
+}
+
+// Text 生成纯文本销户证明
+func Text(c *Certificate) string {
+	var b strings.Builder
+	b.WriteString(c.issuer() + " 账户销户证明\n\n")
+	for _, l := range c.lines() {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	b.WriteString("\n" + c.issuer() + "\n")
+	b.WriteString(c.ClosedAt.Format("2006年01月02日") + "\n")
+	return b.String()
+}
+
+// PDF A4 版式的销户证明
+const (
+	certMargin   = 25.0 // mm
+	certTitle    = 20.0 // pt
+	certFontSize = 12.0
+	certLeading  = 1.8
+)
+
+// PDF 生成 A4 纸的销户证明
+func PDF(c *Certificate) []byte {
+	doc := pdf.New()
+	page := doc.AddPage(pdf.A4Width, pdf.A4Height)
+	left := pdf.MM(certMargin)
+	right := pdf.A4Width - pdf.MM(certMargin)
+
+	title := "账户销户证明"
+	y := pdf.A4Height - pdf.MM(certMargin) - certTitle
+	page.UnicodeText((pdf.A4Width-pdf.UnicodeTextWidth(title, certTitle))/2, y, certTitle, title)
+	y -= certTitle * 0.8
+	issuer := c.issuer()
+	page.UnicodeText((pdf.A4Width-pdf.UnicodeTextWidth(issuer, certFontSize))/2, y, certFontSize, issuer)
+	y -= certFontSize * certLeading
+	page.Rect(left, y, right-left, 0.8)
+	y -= certFontSize * certLeading
+
+	for _, l := range c.lines() {
+		y -= certFontSize * certLeading
+		if l != "" {
+			page.UnicodeText(left, y, certFontSize, l)
+		}
+	}
+
+	// 落款
+	y -= certFontSize * certLeading * 3
+	for _, l := range []string{issuer, c.ClosedAt.Format("2006年01月02日")} {
+		page.UnicodeText(right-pdf.UnicodeTextWidth(l, certFontSize), y, certFontSize, l)
+		y -= certFontSize * certLeading
+	}
+	return doc.Bytes()
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
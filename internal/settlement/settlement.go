@@ -0,0 +1,182 @@
+// Package settlement 计算销户时钱包余额的构成并生成销户证明。
+//
+// 钱包余额由用户自己充值的资金和学校发放的补贴组成，补贴可以设置有效期。按交易流水依次
+// 重放：消费优先使用未过期、最早到期的补贴，不足部分使用自有资金，自有资金也不够时才动用
+// 已过期的补贴；订单退款按原扣款的来源退回。销户时只有自有资金退还给用户，剩余的补贴（无论
+// 是否过期）收回补贴资金池。
+//
+// 金额按分计算，避免浮点误差。
+package settlement
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Entry 一条钱包交易
+type Entry struct {
+	At        time.Time
+	Type      string // charge, subsidy, consume, refund, adjust ...
+	Amount    float64
+	OrderID   string
+	ExpiresAt *time.Time // 补贴的到期时间，为空表示长期有效
+}
+
+// Result 钱包余额的构成，Refundable + Subsidy + Expired = Balance
+type Result struct {
+	Balance    float64 `json:"balance"`
+	Refundable float64 `json:"refundable"`      // 自有资金，销户时退还
+	Subsidy    float64 `json:"subsidy"`         // 未过期的补贴
+	Expired    float64 `json:"expired_subsidy"` // 已过期的补贴
+}
+
+// lot 一笔补贴的剩余金额
+type lot struct {
+	remaining int64
+	expiresAt *time.Time
+}
+
+func (l *lot) expired(at time.Time) bool {
+	return l.expiresAt != nil && !at.Before(*l.expiresAt)
+}
+
+// portion 一笔扣款从某个资金来源扣除的金额，lot 为 -1 表示自有资金
+type portion struct {
+	lot    int
+	amount int64
+}
+
+type wallet struct {
+	self   int64
+	lots   []*lot
+	orders map[string][]portion // 订单扣款的来源，退款时按原路退回
+}
+
+// Settle 按发生顺序重放交易，计算 at 时刻钱包余额的构成
+func Settle(entries []Entry, at time.Time) Result {
+	w := &wallet{orders: make(map[string][]portion)}
+	var balance int64
+	for _, e := range entries {
+		amount := cents(e.Amount)
+		balance += amount
+		switch {
+		case e.Type == "subsidy" && amount > 0:
+			w.lots = append(w.lots, &lot{remaining: amount, expiresAt: e.ExpiresAt})
+		case e.Type == "refund" && amount > 0 && len(w.orders[e.OrderID]) > 0:
+			w.refund(e.OrderID, amount)
+		case amount < 0:
+			portions := w.spend(-amount, e.At)
+			if e.Type == "consume" && e.OrderID != "" {
+				w.orders[e.OrderID] = append(w.orders[e.OrderID], portions...)
+			}
+		default:
+			w.self += amount
+		}
+	}
+
+	// 自有资金为负说明有扣款无法从补贴中扣除（例如负数调整），用剩余补贴抵扣
+	if w.self < 0 {
+		deficit := -w.self
+		w.self = 0
+		for _, expired := range []bool{true, false} {
+			for _, l := range w.lots {
+				if l.expired(at) != expired || deficit == 0 {
+					continue
+				}
+				take := min(l.remaining, deficit)
+				l.remaining -= take
+				deficit -= take
+			}
+		}
+		w.self = -deficit
+	}
+
+	r := Result{Balance: yuan(balance), Refundable: yuan(max(w.self, 0))}
+	var subsidy, expired int64
+	for _, l := range w.lots {
+		if l.expired(at) {
+			expired += l.remaining
+		} else {
+			subsidy += l.remaining
+		}
+	}
+	r.Subsidy = yuan(subsidy)
+	r.Expired = yuan(expired)
+	return r
+}
+
+// spend 扣款：先用未过期的补贴（最早到期的优先），再用自有资金，最后用已过期的补贴
+func (w *wallet) spend(amount int64, at time.Time) []portion {
+	var portions []portion
+	take := func(i int, l *lot) {
+		n := min(l.remaining, amount)
+		if n <= 0 {
+			return
+		}
+		l.remaining -= n
+		amount -= n
+		portions = append(portions, portion{lot: i, amount: n})
+	}
+
+	order := make([]int, len(w.lots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ea, eb := w.lots[order[a]].expiresAt, w.lots[order[b]].expiresAt
+		if ea == nil || eb == nil {
+			return eb == nil && ea != nil
+		}
+		return ea.Before(*eb)
+	})
+	for _, i := range order {
+		if l := w.lots[i]; !l.expired(at) {
+			take(i, l)
+		}
+	}
+
+	if n := min(max(w.self, 0), amount); n > 0 {
+		w.self -= n
+		amount -= n
+		portions = append(portions, portion{lot: -1, amount: n})
+	}
+
+	for _, i := range order {
+		if l := w.lots[i]; l.expired(at) {
+			take(i, l)
+		}
+	}
+
+	if amount > 0 {
+		w.self -= amount
+		portions = append(portions, portion{lot: -1, amount: amount})
+	}
+	return portions
+}
+
+// refund 订单退款按扣款时的来源退回，补贴退回原来那笔（到期时间不变）
+func (w *wallet) refund(orderID string, amount int64) {
+	portions := w.orders[orderID]
+	for i := len(portions) - 1; i >= 0 && amount > 0; i-- {
+		p := &portions[i]
+		n := min(p.amount, amount)
+		if p.lot < 0 {
+			w.self += n
+		} else {
+			w.lots[p.lot].remaining += n
+		}
+		p.amount -= n
+		amount -= n
+	}
+	// 退款超过扣款的部分计入自有资金
+	w.self += amount
+}
+
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func yuan(c int64) float64 {
+	return float64(c) / 100
+}
@@ -0,0 +1,133 @@
+package settlement
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func expires(s string) *time.Time {
+	t := at(s)
+	return &t
+}
+
+func TestSettleSelfFundedOnly(t *testing.T) {
+	r := Settle([]Entry{
+		{At: at("2024-09-01"), Type: "charge", Amount: 100},
+		{At: at("2024-09-02"), Type: "consume", Amount: -12.5, OrderID: "o1"},
+	}, at("2025-06-30"))
+	if r != (Result{Balance: 87.5, Refundable: 87.5}) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestSettleSubsidyUsedFirst(t *testing.T) {
+	r := Settle([]Entry{
+		{At: at("2024-09-01"), Type: "charge", Amount: 100},
+		{At: at("2024-09-01"), Type: "subsidy", Amount: 50},
+		{At: at("2024-09-02"), Type: "consume", Amount: -30, OrderID: "o1"},
+	}, at("2025-06-30"))
+	if r != (Result{Balance: 120, Refundable: 100, Subsidy: 20}) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestSettleExpiredSubsidy(t *testing.T) {
+	entries := []Entry{
+		{At: at("2024-09-01"), Type: "charge", Amount: 100},
+		{At: at("2024-09-01"), Type: "subsidy", Amount: 50, ExpiresAt: expires("2025-01-01")},
+		{At: at("2024-09-01"), Type: "subsidy", Amount: 50, ExpiresAt: expires("2024-10-01")},
+		// 先扣最早到期的补贴
+		{At: at("2024-09-02"), Type: "consume", Amount: -30, OrderID: "o1"},
+		// 过期后不再使用 10-01 到期的补贴
+		{At: at("2024-10-02"), Type: "consume", Amount: -60, OrderID: "o2"},
+	}
+
+	r := Settle(entries, at("2024-12-01"))
+	if r != (Result{Balance: 110, Refundable: 90, Expired: 20}) {
+		t.Fatalf("result = %+v", r)
+	}
+
+	// 两笔补贴都过期了
+	r = Settle(entries, at("2025-06-30"))
+	if r != (Result{Balance: 110, Refundable: 90, Expired: 20}) {
+		t.Fatalf("result = %+v", r)
+	}
+
+	// 自有资金不够时才使用过期补贴
+	entries = append(entries, Entry{At: at("2025-02-01"), Type: "consume", Amount: -100, OrderID: "o3"})
+	r = Settle(entries, at("2025-06-30"))
+	if r != (Result{Balance: 10, Expired: 10}) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestSettleRefundReturnsToSource(t *testing.T) {
+	r := Settle([]Entry{
+		{At: at("2024-09-01"), Type: "charge", Amount: 100},
+		{At: at("2024-09-01"), Type: "subsidy", Amount: 20, ExpiresAt: expires("2024-10-01")},
+		{At: at("2024-09-02"), Type: "consume", Amount: -30, OrderID: "o1"},
+		{At: at("2024-09-03"), Type: "refund", Amount: 30, OrderID: "o1"},
+	}, at("2025-06-30"))
+	// 退回的 20 元补贴仍按原到期时间作废
+	if r != (Result{Balance: 120, Refundable: 100, Expired: 20}) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestSettleNegativeAdjust(t *testing.T) {
+	r := Settle([]Entry{
+		{At: at("2024-09-01"), Type: "subsidy", Amount: 50},
+		{At: at("2024-09-02"), Type: "adjust", Amount: -10},
+	}, at("2025-06-30"))
+	if r != (Result{Balance: 40, Subsidy: 40}) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func testCertificate() *Certificate {
+	return &Certificate{
+		No:       "20250630-000012",
+		Issuer:   "北区食堂",
+		UserID:   "u1",
+		Username: "张三",
+		ClosedAt: time.Date(2025, 6, 30, 10, 0, 0, 0, time.Local),
+		Reason:   "毕业离校",
+		Result:   Result{Balance: 110, Refundable: 90, Subsidy: 15, Expired: 5},
+	}
+}
+
+func TestCertificateText(t *testing.T) {
+	text := Text(testCertificate())
+	for _, want := range []string{
+		"北区食堂 账户销户证明",
+		"编号：20250630-000012",
+		"销户原因：毕业离校",
+		"应退还自有资金：90.00 元",
+		"收回补贴：20.00 元",
+		"2025年06月30日",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("证明缺少 %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCertificatePDF(t *testing.T) {
+	out := PDF(testCertificate())
+	if !bytes.HasPrefix(out, []byte("%PDF-")) || !bytes.HasSuffix(bytes.TrimSpace(out), []byte("%%EOF")) {
+		t.Fatalf("不是有效的 PDF")
+	}
+	if !bytes.Contains(out, []byte("/MediaBox [0 0 595.28 841.89]")) {
+		t.Fatalf("应为 A4 页面")
+	}
+}
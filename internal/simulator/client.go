@@ -0,0 +1,160 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/p-program/Fenrir/devicesign"
+)
+
+// Client 调用餐厅 API，每个请求的耗时和结果按步骤计入 Recorder
+type Client struct {
+	baseURL  string
+	token    string
+	http     *http.Client
+	recorder *Recorder
+}
+
+// NewClient 创建客户端，请求都带上工作人员令牌（食堂由令牌对应的工作人员决定），timeout 为单个请求的超时时间
+func NewClient(baseURL, token string, timeout time.Duration, recorder *Recorder) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		http:     &http.Client{Timeout: timeout},
+		recorder: recorder,
+	}
+}
+
+// response 接口的统一响应格式
+type response struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// device 给请求签名的设备，为空时不签名
+type device struct {
+	id, secret string
+}
+
+// call 发送请求并把 data 解析到 out，HTTP 状态不是 2xx 或 code 不为 0 时返回错误
+func (c *Client) call(ctx context.Context, step, method, path string, body interface{}, signer *device, out interface{}) error {
+	start := time.Now()
+	err := c.do(ctx, method, path, body, signer, out)
+	c.recorder.Record(step, time.Since(start), err)
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, signer *device, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if signer != nil {
+		if err := devicesign.SignRequest(req, signer.id, signer.secret); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var r response
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("%s %s: 响应格式错误: %w", method, path, err)
+	}
+	if r.Code != 0 {
+		return fmt.Errorf("%s %s: %d %s", method, path, r.Code, r.Msg)
+	}
+	if out != nil && len(r.Data) > 0 {
+		if err := json.Unmarshal(r.Data, out); err != nil {
+			return fmt.Errorf("%s %s: 解析 data 失败: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// orderInfo 订单接口返回的订单，只取模拟需要的字段
+type orderInfo struct {
+	OrderID    string  `json:"order_id"`
+	TotalPrice float64 `json:"total_price"`
+	Status     string  `json:"status"`
+}
+
+// weighResult 称重上报的结果
+type weighResult struct {
+	ReadingID uint       `json:"reading_id"`
+	Status    string     `json:"status"`
+	Order     *orderInfo `json:"order"`
+}
+
+func (c *Client) bind(ctx context.Context, userID, plateID string) error {
+	return c.call(ctx, StepBind, http.MethodPost, "/api/plate/bind",
+		map[string]string{"user_id": userID, "plate_id": plateID}, nil, nil)
+}
+
+func (c *Client) unbind(ctx context.Context, userID, plateID string) error {
+	return c.call(ctx, StepUnbind, http.MethodPost, "/api/plate/unbind",
+		map[string]string{"user_id": userID, "plate_id": plateID}, nil, nil)
+}
+
+func (c *Client) weigh(ctx context.Context, station Station, plateID string, weight float64) (*weighResult, error) {
+	var result weighResult
+	err := c.call(ctx, StepWeigh, http.MethodPost, "/api/station/weight",
+		map[string]interface{}{"plate_id": plateID, "weight": weight}, &device{station.DeviceID, station.Secret}, &result)
+	return &result, err
+}
+
+// paid 查询订单并确认已扣款，订单不是 paid 状态时同样计为失败
+func (c *Client) paid(ctx context.Context, orderID string) (*orderInfo, error) {
+	start := time.Now()
+	var order orderInfo
+	err := c.do(ctx, http.MethodGet, "/api/order/info/"+orderID, nil, nil, &order)
+	if err == nil && order.Status != "paid" {
+		err = fmt.Errorf("订单 %s 状态为 %s，没有扣款", orderID, order.Status)
+	}
+	c.recorder.Record(StepPay, time.Since(start), err)
+	return &order, err
+}
+
+func (c *Client) returnTableware(ctx context.Context, depotID, tablewareID, workerID string) error {
+	return c.call(ctx, StepReturn, http.MethodPost, "/api/tableware/return", map[string]interface{}{
+		"depot_id":     depotID,
+		"tableware_id": tablewareID,
+		"worker_id":    workerID,
+		"quantity":     1,
+	}, nil, nil)
+}
+
+func (c *Client) gc(ctx context.Context, plateID, gcType, workerID string) error {
+	return c.call(ctx, StepGC, http.MethodPost, "/api/gc/process",
+		map[string]string{"plate_id": plateID, "type": gcType, "worker_id": workerID}, nil, nil)
+}
@@ -0,0 +1,245 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Result 一次午高峰模拟的结果
+type Result struct {
+	Diners        int         `json:"diners"`         // 到达的人数
+	Completed     int         `json:"completed"`      // 走完整个用餐流程的人数
+	Failed        int         `json:"failed"`         // 中途有请求失败的人数
+	Orders        int         `json:"orders"`         // 称重后直接扣款的订单数
+	Held          int         `json:"held"`           // 读数可疑、挂起待审核的订单数
+	Rejected      int         `json:"rejected"`       // 被丢弃的读数
+	Revenue       float64     `json:"revenue"`        // 已扣款的金额
+	ResetFailures int         `json:"reset_failures"` // 失败后复位餐盘也失败的次数
+	Elapsed       float64     `json:"elapsed_s"`      // 耗时（秒）
+	Throughput    float64     `json:"throughput"`     // 每秒完成用餐的人数
+	Steps         []StepStats `json:"steps"`
+}
+
+// diner 一个人的用餐安排，运行前按随机数种子生成
+type diner struct {
+	arrival  time.Duration
+	stations []Station
+	weights  []float64
+}
+
+// idlePlate 空闲队列中的餐盘
+// 异常检测按餐盘查询最近的读数，不区分绑定的用户，刚称过重的餐盘马上给下一个人用时会被误判，
+// 所以餐盘放回后要等最后一次称重超过 StationGap 才能再次使用
+type idlePlate struct {
+	Plate
+	ready time.Time
+}
+
+// rush 一次模拟的运行状态
+type rush struct {
+	client   *Client
+	fixture  *Fixture
+	scenario Scenario
+	users    chan string
+	plates   chan idlePlate
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Run 按场景回放午高峰：每个人按到达时间进场，取一个空闲的用户和餐盘，依次绑定餐盘、在若干档口称重下单并确认扣款、
+// 用餐后解绑、把餐碗送回餐盘所属的托管处、清理厨余和餐盘，餐盘随后回到空闲队列给后来的人使用。
+// 同一用户同时只能绑定一个餐盘，所以用户和餐盘都不够时后到的人排队等待。ctx 取消后不再放人进场，等待场内的人走完流程
+func Run(ctx context.Context, client *Client, f *Fixture, s Scenario) (*Result, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if len(f.Users) == 0 || len(f.Plates) == 0 || len(f.Stations) == 0 {
+		return nil, fmt.Errorf("演示数据中没有用户、餐盘或档口")
+	}
+
+	r := &rush{
+		client:   client,
+		fixture:  f,
+		scenario: s,
+		users:    make(chan string, len(f.Users)),
+		plates:   make(chan idlePlate, len(f.Plates)),
+	}
+	for _, user := range f.Users {
+		r.users <- user
+	}
+	for _, plate := range f.Plates {
+		r.plates <- idlePlate{Plate: plate}
+	}
+
+	diners := plan(f, s)
+	sem := make(chan struct{}, s.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+arrive:
+	for _, d := range diners {
+		if sleep(ctx, time.Until(start.Add(d.arrival))) != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break arrive
+		}
+		r.mu.Lock()
+		r.result.Diners++
+		r.mu.Unlock()
+		wg.Add(1)
+		go func(d diner) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := r.dine(context.WithoutCancel(ctx), d)
+			r.mu.Lock()
+			if err != nil {
+				r.result.Failed++
+			} else {
+				r.result.Completed++
+			}
+			r.mu.Unlock()
+		}(d)
+	}
+	wg.Wait()
+
+	result := r.result
+	elapsed := time.Since(start)
+	result.Elapsed = math.Round(elapsed.Seconds()*1000) / 1000
+	result.Throughput = math.Round(float64(result.Completed)/elapsed.Seconds()*100) / 100
+	result.Revenue = math.Round(result.Revenue*100) / 100
+	result.Steps = client.recorder.Summary()
+	return &result, nil
+}
+
+// plan 生成每个人的到达时间、去的档口和打菜重量
+func plan(f *Fixture, s Scenario) []diner {
+	rnd := rand.New(rand.NewSource(s.Seed))
+	arrivals := Arrivals(s.Diners, s.Duration, s.Peak, rnd)
+	maxStations := min(s.MaxStations, len(f.Stations))
+
+	diners := make([]diner, len(arrivals))
+	for i, at := range arrivals {
+		d := diner{arrival: at}
+		for _, k := range rnd.Perm(len(f.Stations))[:1+rnd.Intn(maxStations)] {
+			d.stations = append(d.stations, f.Stations[k])
+			weight := s.MinWeight + rnd.Float64()*(s.MaxWeight-s.MinWeight)
+			d.weights = append(d.weights, math.Round(weight*10)/10)
+		}
+		diners[i] = d
+	}
+	return diners
+}
+
+// dine 一个人的用餐流程，返回第一个失败的请求
+func (r *rush) dine(ctx context.Context, d diner) error {
+	s, f := r.scenario, r.fixture
+	user := <-r.users
+	defer func() { r.users <- user }()
+	idle := <-r.plates
+	sleep(ctx, time.Until(idle.ready))
+	plate := idle.Plate
+	// 最后一次称重的时间，失败时同样按这个时间放回空闲队列
+	var weighed time.Time
+
+	if err := r.client.bind(ctx, user, plate.ID); err != nil {
+		r.reset(ctx, plate, weighed)
+		return err
+	}
+	for i, station := range d.stations {
+		// 同一餐盘在不同档口的称重间隔过短会被挂起，第二个档口起至少间隔 StationGap
+		wait := s.Think
+		if i > 0 {
+			wait = max(s.Think, s.StationGap)
+		}
+		sleep(ctx, wait)
+
+		result, err := r.client.weigh(ctx, station, plate.ID, d.weights[i])
+		weighed = time.Now()
+		if err != nil {
+			r.reset(ctx, plate, weighed)
+			return err
+		}
+		switch result.Status {
+		case "held":
+			r.count(func(res *Result) { res.Held++ })
+		case "rejected":
+			r.count(func(res *Result) { res.Rejected++ })
+		}
+		if result.Status != "accepted" || result.Order == nil {
+			continue
+		}
+		order, err := r.client.paid(ctx, result.Order.OrderID)
+		if err != nil {
+			r.reset(ctx, plate, weighed)
+			return err
+		}
+		r.count(func(res *Result) {
+			res.Orders++
+			res.Revenue += order.TotalPrice
+		})
+	}
+
+	sleep(ctx, s.Eat)
+	steps := []func() error{
+		func() error { return r.client.unbind(ctx, user, plate.ID) },
+		func() error { return r.client.returnTableware(ctx, plate.DepotID, f.TablewareID, f.WorkerID) },
+		func() error { return r.client.gc(ctx, plate.ID, "food_waste", f.WorkerID) },
+		func() error { return r.client.gc(ctx, plate.ID, "plate", f.WorkerID) },
+	}
+	for _, step := range steps {
+		sleep(ctx, s.Think)
+		if err := step(); err != nil {
+			r.reset(ctx, plate, weighed)
+			return err
+		}
+	}
+	r.release(plate, weighed)
+	return nil
+}
+
+// reset 流程中途失败时把餐盘清理复位后放回空闲队列，复位请求不计入统计
+// 复位失败的餐盘同样放回，之后使用它的人会在绑定时失败，不会因为餐盘耗尽而卡住
+func (r *rush) reset(ctx context.Context, plate Plate, weighed time.Time) {
+	err := r.client.do(ctx, http.MethodPost, "/api/gc/process",
+		map[string]string{"plate_id": plate.ID, "type": "plate", "worker_id": r.fixture.WorkerID}, nil, nil)
+	if err != nil {
+		r.count(func(res *Result) { res.ResetFailures++ })
+	}
+	r.release(plate, weighed)
+}
+
+// release 把餐盘放回空闲队列
+func (r *rush) release(plate Plate, weighed time.Time) {
+	r.plates <- idlePlate{Plate: plate, ready: weighed.Add(r.scenario.StationGap)}
+}
+
+func (r *rush) count(update func(*Result)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	update(&r.result)
+}
+
+// sleep 等待 d，ctx 取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Scenario 午高峰的参数
+type Scenario struct {
+	Diners      int           `json:"diners"`       // 用餐人数
+	Duration    time.Duration `json:"duration"`     // 所有人到达的时间跨度
+	Peak        float64       `json:"peak"`         // 到达人数最多的时刻在跨度中的位置，0-1
+	Concurrency int           `json:"concurrency"`  // 同时在用餐流程中的人数上限，到达时已满则排队
+	MaxStations int           `json:"max_stations"` // 每人最多去几个档口，实际数量在 1 到该值之间随机
+	MinWeight   float64       `json:"min_weight"`   // 每次打菜的重量范围（克）
+	MaxWeight   float64       `json:"max_weight"`
+	Think       time.Duration `json:"think"`       // 步骤之间的间隔
+	StationGap  time.Duration `json:"station_gap"` // 两次称重之间的最短间隔，应大于异常检测的 StationWindow
+	Eat         time.Duration `json:"eat"`         // 用餐时长
+	Seed        int64         `json:"seed"`        // 随机数种子，相同的种子产生相同的到达时间、档口和重量
+}
+
+// Validate 检查参数
+func (s Scenario) Validate() error {
+	switch {
+	case s.Diners <= 0:
+		return errors.New("用餐人数必须大于0")
+	case s.Duration < 0:
+		return errors.New("到达时间跨度不能为负")
+	case s.Peak < 0 || s.Peak > 1:
+		return errors.New("高峰位置必须在 0 到 1 之间")
+	case s.Concurrency <= 0:
+		return errors.New("并发人数必须大于0")
+	case s.MaxStations <= 0:
+		return errors.New("每人至少去一个档口")
+	case s.MinWeight <= 0 || s.MaxWeight < s.MinWeight:
+		return errors.New("打菜重量范围无效")
+	}
+	return nil
+}
+
+// Arrivals 生成 n 个人相对开始时间的到达时刻，按三角分布：从 0 逐渐增加到 peak 处最多，再逐渐减少到 duration，
+// 近似食堂午高峰下课后集中到达的情况。结果按时间排序
+func Arrivals(n int, duration time.Duration, peak float64, rnd *rand.Rand) []time.Duration {
+	arrivals := make([]time.Duration, n)
+	for i := range arrivals {
+		arrivals[i] = time.Duration(triangular(rnd.Float64(), peak) * float64(duration))
+	}
+	sort.Slice(arrivals, func(i, j int) bool { return arrivals[i] < arrivals[j] })
+	return arrivals
+}
+
+// triangular 把 [0,1) 上的均匀分布 u 变换为 [0,1] 上众数为 c 的三角分布
+func triangular(u, c float64) float64 {
+	if u < c {
+		return math.Sqrt(u * c)
+	}
+	return 1 - math.Sqrt((1-u)*(1-c))
+}
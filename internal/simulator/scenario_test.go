@@ -0,0 +1,43 @@
+package simulator
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestArrivals(t *testing.T) {
+	const n = 10000
+	duration := 100 * time.Second
+	arrivals := Arrivals(n, duration, 0.3, rand.New(rand.NewSource(1)))
+	if len(arrivals) != n {
+		t.Fatalf("len = %d", len(arrivals))
+	}
+
+	var buckets [10]int
+	for i, at := range arrivals {
+		if at < 0 || at > duration {
+			t.Fatalf("arrival %v out of range", at)
+		}
+		if i > 0 && at < arrivals[i-1] {
+			t.Fatal("arrivals not sorted")
+		}
+		buckets[int(at/(duration/10))%10]++
+	}
+	// 众数在 30% 处，第 3 段（20%-30%）和第 4 段（30%-40%）的人数最多，两端最少
+	for i, count := range buckets {
+		if i != 2 && i != 3 && (count > buckets[2] || count > buckets[3]) {
+			t.Errorf("bucket %d has %d arrivals, more than the peak %v", i, count, buckets)
+		}
+	}
+	if buckets[0] > buckets[2]/2 || buckets[9] > buckets[3]/2 {
+		t.Errorf("arrivals not concentrated around the peak: %v", buckets)
+	}
+
+	again := Arrivals(n, duration, 0.3, rand.New(rand.NewSource(1)))
+	for i := range arrivals {
+		if arrivals[i] != again[i] {
+			t.Fatal("same seed should produce the same arrivals")
+		}
+	}
+}
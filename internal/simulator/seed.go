@@ -0,0 +1,196 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/tenant"
+)
+
+// dishes 生成菜品时使用的菜名
+var dishes = []string{
+	"红烧肉", "宫保鸡丁", "番茄炒蛋", "鱼香肉丝", "麻婆豆腐", "清炒时蔬", "糖醋排骨", "土豆牛腩",
+	"干煸豆角", "回锅肉", "蒜蓉西兰花", "可乐鸡翅", "酸菜鱼", "米饭", "炒面", "蛋炒饭",
+}
+
+// SeedConfig 演示数据的规模，全部数据的ID都以 Prefix 开头，同一个数据库中可以多次生成互不冲突
+type SeedConfig struct {
+	Prefix   string  `json:"prefix"`   // 同时作为食堂ID
+	Users    int     `json:"users"`    // 用户数量，每人一个钱包
+	Balance  float64 `json:"balance"`  // 每个用户的充值金额
+	Foods    int     `json:"foods"`    // 菜品数量
+	Stations int     `json:"stations"` // 档口数量，每个档口一台电子秤，菜品轮流分配
+	Depots   int     `json:"depots"`   // 托管处数量
+	Plates   int     `json:"plates"`   // 餐盘数量，平均分配到各托管处
+	Bowls    int     `json:"bowls"`    // 每个托管处发放中的餐碗数量，用餐后逐个送回
+	Seed     int64   `json:"seed"`     // 随机数种子，决定菜品单价
+}
+
+// Validate 检查规模
+func (c SeedConfig) Validate() error {
+	switch {
+	case c.Prefix == "":
+		return errors.New("前缀不能为空")
+	case c.Users <= 0 || c.Foods <= 0 || c.Stations <= 0 || c.Depots <= 0 || c.Plates <= 0:
+		return errors.New("用户、菜品、档口、托管处和餐盘数量都必须大于0")
+	case c.Balance <= 0:
+		return errors.New("充值金额必须大于0")
+	case c.Bowls < 0:
+		return errors.New("餐碗数量不能为负")
+	}
+	return nil
+}
+
+// Station 模拟用的档口，电子秤密钥只在登记时返回，用于给称重请求签名
+type Station struct {
+	ID       string `json:"id"`
+	FoodID   string `json:"food_id"`
+	DeviceID string `json:"device_id"`
+	Secret   string `json:"-"`
+}
+
+// Plate 模拟用的餐盘，用餐后送回所属托管处
+type Plate struct {
+	ID      string `json:"id"`
+	DepotID string `json:"depot_id"`
+}
+
+// Fixture 生成的演示数据
+type Fixture struct {
+	CanteenID   string    `json:"canteen_id"`
+	WorkerID    string    `json:"worker_id"`
+	TablewareID string    `json:"tableware_id"`
+	Users       []string  `json:"users"`
+	Foods       []string  `json:"foods"`
+	Depots      []string  `json:"depots"`
+	Plates      []Plate   `json:"plates"`
+	Stations    []Station `json:"stations"`
+}
+
+// Seed 直接通过业务逻辑写入演示数据：一个食堂及其中的用户和钱包、菜品、托管处、餐盘、档口和电子秤、工作人员和餐碗
+func Seed(ctx context.Context, l *logic.RestaurantLogic, cfg SeedConfig) (*Fixture, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	p := cfg.Prefix
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	f := &Fixture{CanteenID: p}
+
+	if _, err := l.CreateCanteen(ctx, p, "模拟食堂 "+p, "simulator"); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithCanteen(ctx, p)
+
+	for i := 1; i <= cfg.Users; i++ {
+		id := fmt.Sprintf("%s-user-%04d", p, i)
+		if _, err := l.CreateUser(ctx, id, id, "", ""); err != nil {
+			return nil, err
+		}
+		if _, err := l.ChargeWallet(ctx, id, cfg.Balance, logic.ChargeSourcePayment, nil); err != nil {
+			return nil, fmt.Errorf("用户 %s 充值失败: %w", id, err)
+		}
+		f.Users = append(f.Users, id)
+	}
+
+	var foods [][]string
+	for i := 1; i <= cfg.Foods; i++ {
+		id := fmt.Sprintf("%s-food-%02d", p, i)
+		name := dishes[(i-1)%len(dishes)]
+		if i > len(dishes) {
+			name += strconv.Itoa((i-1)/len(dishes) + 1)
+		}
+		// 每100克 1.00 到 4.00 元
+		price := float64(100+rnd.Intn(301)) / 100
+		foods = append(foods, []string{id, name, strconv.FormatFloat(price, 'f', 2, 64), "dish"})
+		f.Foods = append(f.Foods, id)
+	}
+	if err := importCSV([]string{"id", "name", "price", "category"}, foods, func(data []byte) (*logic.PlateImportResult, error) {
+		return l.ImportFoods(ctx, bytes.NewReader(data), false)
+	}); err != nil {
+		return nil, fmt.Errorf("导入菜品失败: %w", err)
+	}
+
+	worker, err := l.CreateWorker(ctx, p+"-worker", "模拟工作人员", "gc", "")
+	if err != nil {
+		return nil, err
+	}
+	f.WorkerID = worker.ID
+	tableware, err := l.CreateTableware(ctx, p+"-bowl", "餐碗", "bowl", 0)
+	if err != nil {
+		return nil, err
+	}
+	f.TablewareID = tableware.ID
+
+	capacity := (cfg.Plates + cfg.Depots - 1) / cfg.Depots
+	for i := 1; i <= cfg.Depots; i++ {
+		id := fmt.Sprintf("%s-depot-%02d", p, i)
+		if _, err := l.CreatePlateDepot(ctx, id, fmt.Sprintf("托管处 %d", i), "", capacity); err != nil {
+			return nil, err
+		}
+		if cfg.Bowls > 0 {
+			if _, err := l.ReceiveTableware(ctx, id, tableware.ID, worker.ID, cfg.Bowls, "模拟数据"); err != nil {
+				return nil, err
+			}
+			if _, err := l.IssueTableware(ctx, id, tableware.ID, worker.ID, cfg.Bowls, "模拟数据"); err != nil {
+				return nil, err
+			}
+		}
+		f.Depots = append(f.Depots, id)
+	}
+
+	var plates [][]string
+	for i := 1; i <= cfg.Plates; i++ {
+		plate := Plate{ID: fmt.Sprintf("%s-plate-%04d", p, i), DepotID: f.Depots[(i-1)%len(f.Depots)]}
+		plates = append(plates, []string{plate.ID, plate.ID, plate.DepotID, "available"})
+		f.Plates = append(f.Plates, plate)
+	}
+	if err := importCSV([]string{"id", "rfid_tag", "depot_id", "status"}, plates, func(data []byte) (*logic.PlateImportResult, error) {
+		return l.ImportPlates(ctx, bytes.NewReader(data), false)
+	}); err != nil {
+		return nil, fmt.Errorf("导入餐盘失败: %w", err)
+	}
+
+	for i := 1; i <= cfg.Stations; i++ {
+		station := Station{
+			ID:       fmt.Sprintf("%s-station-%02d", p, i),
+			FoodID:   f.Foods[(i-1)%len(f.Foods)],
+			DeviceID: fmt.Sprintf("%s-scale-%02d", p, i),
+		}
+		if _, err := l.CreateStation(ctx, station.ID, fmt.Sprintf("档口 %d", i), station.FoodID); err != nil {
+			return nil, err
+		}
+		device, err := l.RegisterDevice(ctx, station.DeviceID, "scale", station.ID, "simulator")
+		if err != nil {
+			return nil, err
+		}
+		station.Secret = device.Secret
+		f.Stations = append(f.Stations, station)
+	}
+	return f, nil
+}
+
+// importCSV 把记录写成 CSV 交给批量导入，任意一行校验失败时返回第一个错误
+func importCSV(header []string, records [][]string, load func([]byte) (*logic.PlateImportResult, error)) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+	w.WriteAll(records)
+	if err := w.Error(); err != nil {
+		return err
+	}
+	result, err := load(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		e := result.Errors[0]
+		return fmt.Errorf("第 %d 行 %s: %s", e.Row, e.Field, e.Message)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package simulator
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 用餐流程的步骤，报告按这个顺序输出
+const (
+	StepBind   = "bind"   // 绑定餐盘
+	StepWeigh  = "weigh"  // 档口称重下单
+	StepPay    = "pay"    // 确认订单已扣款
+	StepUnbind = "unbind" // 解绑餐盘
+	StepReturn = "return" // 餐具送回托管处
+	StepGC     = "gc"     // 清理厨余和餐盘
+)
+
+var steps = []string{StepBind, StepWeigh, StepPay, StepUnbind, StepReturn, StepGC}
+
+// StepStats 一个步骤的请求统计，耗时单位为毫秒
+type StepStats struct {
+	Step       string  `json:"step"`
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	Mean       float64 `json:"mean_ms"`
+	P50        float64 `json:"p50_ms"`
+	P90        float64 `json:"p90_ms"`
+	P95        float64 `json:"p95_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+	FirstError string  `json:"first_error,omitempty"` // 第一个错误，便于排查
+}
+
+// Recorder 记录每个请求的耗时和结果，可以并发调用
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	first     map[string]string
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies: map[string][]time.Duration{},
+		errors:    map[string]int{},
+		first:     map[string]string{},
+	}
+}
+
+// Record 记录一个请求，失败的请求同样计入耗时
+func (r *Recorder) Record(step string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[step] = append(r.latencies[step], d)
+	if err != nil {
+		r.errors[step]++
+		if _, ok := r.first[step]; !ok {
+			r.first[step] = err.Error()
+		}
+	}
+}
+
+// Summary 按步骤汇总，最后一行 total 为全部请求
+func (r *Recorder) Summary() []StepStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var summary []StepStats
+	var all []time.Duration
+	var allErrors int
+	for _, step := range steps {
+		latencies := r.latencies[step]
+		if len(latencies) == 0 {
+			continue
+		}
+		s := stats(step, latencies, r.errors[step])
+		s.FirstError = r.first[step]
+		summary = append(summary, s)
+		all = append(all, latencies...)
+		allErrors += r.errors[step]
+	}
+	if len(all) > 0 {
+		summary = append(summary, stats("total", all, allErrors))
+	}
+	return summary
+}
+
+func stats(step string, latencies []time.Duration, errors int) StepStats {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	return StepStats{
+		Step:      step,
+		Requests:  len(sorted),
+		Errors:    errors,
+		ErrorRate: float64(errors) / float64(len(sorted)),
+		Mean:      ms(sum / time.Duration(len(sorted))),
+		P50:       ms(Percentile(sorted, 50)),
+		P90:       ms(Percentile(sorted, 90)),
+		P95:       ms(Percentile(sorted, 95)),
+		P99:       ms(Percentile(sorted, 99)),
+		Max:       ms(sorted[len(sorted)-1]),
+	}
+}
+
+// Percentile 已排序耗时的 p 分位数（最近秩法），p 取 0-100
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted)) + 0.999999)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
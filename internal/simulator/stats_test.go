@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{99.5, 100 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil) = %v", got)
+	}
+	if got := Percentile(sorted[:1], 99); got != time.Millisecond {
+		t.Errorf("Percentile of one = %v", got)
+	}
+}
+
+func TestRecorderSummary(t *testing.T) {
+	r := NewRecorder()
+	r.Record(StepWeigh, 30*time.Millisecond, nil)
+	r.Record(StepWeigh, 10*time.Millisecond, errors.New("first"))
+	r.Record(StepWeigh, 20*time.Millisecond, errors.New("second"))
+	r.Record(StepBind, 4*time.Millisecond, nil)
+
+	summary := r.Summary()
+	if len(summary) != 3 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	bind, weigh, total := summary[0], summary[1], summary[2]
+	if bind.Step != StepBind || weigh.Step != StepWeigh || total.Step != "total" {
+		t.Fatalf("steps = %s, %s, %s", bind.Step, weigh.Step, total.Step)
+	}
+	if weigh.Requests != 3 || weigh.Errors != 2 || weigh.FirstError != "first" {
+		t.Errorf("weigh = %+v", weigh)
+	}
+	if weigh.P50 != 20 || weigh.Max != 30 || weigh.Mean != 20 {
+		t.Errorf("weigh latency = %+v", weigh)
+	}
+	if total.Requests != 4 || total.Errors != 2 || total.ErrorRate != 0.5 {
+		t.Errorf("total = %+v", total)
+	}
+}
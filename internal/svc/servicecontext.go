@@ -1,8 +1,25 @@
 package svc
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/p-program/Fenrir/internal/anomaly"
+	"github.com/p-program/Fenrir/internal/auth"
+	"github.com/p-program/Fenrir/internal/autounbind"
 	"github.com/p-program/Fenrir/internal/config"
-	"github.com/p-program/Fenrir/model"
+	"github.com/p-program/Fenrir/internal/dashboard"
+	"github.com/p-program/Fenrir/internal/device"
+	"github.com/p-program/Fenrir/internal/event"
+	"github.com/p-program/Fenrir/internal/forecast"
+	"github.com/p-program/Fenrir/internal/logic"
+	"github.com/p-program/Fenrir/internal/migrate"
+	"github.com/p-program/Fenrir/internal/notify"
+	"github.com/p-program/Fenrir/internal/plateqr"
+	"github.com/p-program/Fenrir/internal/reconcile"
+	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -10,63 +27,215 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// 启动时等待迁移锁的次数和间隔
+const (
+	migrateLockAttempts = 30
+	migrateLockWait     = 2 * time.Second
+)
+
 type ServiceContext struct {
-	Config config.Config
-	DB     *gorm.DB
+	Config    config.Config
+	DB        *gorm.DB
+	PlateQR   *plateqr.Signer
+	Bus       *event.Bus
+	Dashboard *dashboard.Hub
+	Devices   *device.Watcher
+	Unbinder  *autounbind.Sweeper
+	Notifier  *notify.Service
+	Reconcile *reconcile.Job
+	Periods   logic.MealPeriods
+	Channels  []string // 默认通知渠道
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
+	signer, err := plateqr.NewSigner(c.PlateQR.Secret)
+	if err != nil {
+		panic("invalid plate qr config: " + err.Error())
+	}
+	if err := auth.CheckSecret(c.Auth.AccessSecret); err != nil {
+		panic("invalid auth config: " + err.Error())
+	}
+	if err := auth.CheckSecret(c.StaffAuth.AccessSecret); err != nil {
+		panic("invalid staff auth config: " + err.Error())
+	}
+
+	periods, err := logic.ParseMealPeriods(c.Inventory.LunchStart, c.Inventory.DinnerStart)
+	if err != nil {
+		panic("invalid inventory config: " + err.Error())
+	}
+
+	channels, err := logic.ParseChannels(c.Notify.DefaultChannels)
+	if err != nil {
+		panic("invalid notify config: " + err.Error())
+	}
+	templates, err := notify.LoadTemplates(c.Notify.TemplateDir)
+	if err != nil {
+		panic("invalid notify config: " + err.Error())
+	}
+
 	db := initDB(c)
+	// 启用账本前已有的钱包余额转为期初余额
+	if _, err := logic.NewRestaurantLogic(db).OpenLedger(context.Background()); err != nil {
+		panic("failed to open ledger: " + err.Error())
+	}
+
+	bus := event.NewBus()
 	return &ServiceContext{
-		Config: c,
-		DB:     db,
+		Config:    c,
+		DB:        db,
+		PlateQR:   signer,
+		Bus:       bus,
+		Dashboard: dashboard.NewHub(db, bus),
+		Devices: device.NewWatcher(
+			logic.NewRestaurantLogic(db).WithBus(bus),
+			time.Duration(c.Device.OfflineAfter)*time.Second,
+			time.Duration(c.Device.CheckInterval)*time.Second,
+		),
+		Unbinder: autounbind.NewSweeper(
+			logic.NewRestaurantLogic(db).WithBus(bus),
+			time.Duration(c.Plate.AutoUnbindAfter)*time.Minute,
+			time.Duration(c.Plate.CheckInterval)*time.Second,
+		),
+		Notifier: notify.NewService(
+			logic.NewRestaurantLogic(db).WithNotifyDefaults(channels),
+			bus,
+			templates,
+			notifySenders(c.Notify),
+			notify.Config{
+				MaxAttempts:  c.Notify.MaxAttempts,
+				RetryBase:    time.Duration(c.Notify.RetryBase) * time.Second,
+				PollInterval: time.Duration(c.Notify.PollInterval) * time.Second,
+			},
+		),
+		Reconcile: reconcile.NewJob(
+			logic.NewRestaurantLogic(db),
+			time.Duration(c.Reconcile.Interval)*time.Minute,
+		),
+		Periods:  periods,
+		Channels: channels,
 	}
 }
 
-func initDB(c config.Config) *gorm.DB {
-	var db *gorm.DB
-	var err error
+// NewLogic 创建处理请求用的业务逻辑实例，接入事件总线并按配置设置规则，REST 和 gRPC 接口共用
+func (s *ServiceContext) NewLogic() *logic.RestaurantLogic {
+	anomalyConf := s.Config.Anomaly
+	forecastConf := s.Config.Forecast
+	return logic.NewRestaurantLogic(s.DB).
+		WithBus(s.Bus).
+		WithLowBalanceThreshold(s.Config.Wallet.LowBalanceThreshold).
+		WithAnomalyRules(anomaly.Rules{
+			MaxPortion:     anomalyConf.MaxPortion,
+			StationWindow:  time.Duration(anomalyConf.StationWindow) * time.Second,
+			DriftTolerance: anomalyConf.DriftTolerance,
+		}).
+		WithInventory(s.Config.Inventory.LowStock, s.Periods).
+		WithForecast(forecast.Config{
+			Alpha:      forecastConf.Alpha,
+			MinSamples: forecastConf.MinSamples,
+		}, forecastConf.HistoryDays).
+		WithNotifyDefaults(s.Channels)
+}
 
-	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+// ResolvePlateID 根据餐盘ID或扫码内容确定要操作的餐盘，扫码时校验二维码签名
+func (s *ServiceContext) ResolvePlateID(plateID, qrPayload string) (string, error) {
+	if qrPayload == "" {
+		if plateID == "" {
+			return "", errors.New("餐盘ID不能为空")
+		}
+		return plateID, nil
 	}
 
-	switch c.Database.Type {
-	case "mysql":
-		db, err = gorm.Open(mysql.Open(c.Database.DSN), gormConfig)
-	case "postgres":
-		db, err = gorm.Open(postgres.Open(c.Database.DSN), gormConfig)
-	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(c.Database.DSN), gormConfig)
-	default:
-		// 默认使用 SQLite
-		db, err = gorm.Open(sqlite.Open("restaurant.db"), gormConfig)
+	verified, err := s.PlateQR.Verify(qrPayload)
+	if err != nil {
+		return "", err
+	}
+	if plateID != "" && plateID != verified {
+		return "", errors.New("二维码与餐盘ID不匹配")
 	}
+	return verified, nil
+}
+
+// notifySenders 按配置创建邮件、短信发送渠道
+func notifySenders(c config.NotifyConfig) map[string]notify.Sender {
+	senders := map[string]notify.Sender{}
+	if c.SMTP.Host != "" {
+		senders[logic.ChannelEmail] = notify.NewSMTPSender(notify.SMTPConfig{
+			Host:     c.SMTP.Host,
+			Port:     c.SMTP.Port,
+			Username: c.SMTP.Username,
+			Password: c.SMTP.Password,
+			From:     c.SMTP.From,
+		})
+	}
+	if c.SMS.URL != "" {
+		senders[logic.ChannelSMS] = notify.NewWebhookSender(c.SMS.URL, c.SMS.Token,
+			&http.Client{Timeout: time.Duration(c.SMS.Timeout) * time.Second})
+	}
+	return senders
+}
 
+func initDB(c config.Config) *gorm.DB {
+	db, err := OpenDB(c)
 	if err != nil {
 		panic("failed to connect database: " + err.Error())
 	}
 
-	// 自动迁移
-	if err := autoMigrate(db); err != nil {
+	if err := migrateDB(db, c.Database.Migrate); err != nil {
 		panic("failed to migrate database: " + err.Error())
 	}
 
 	return db
 }
 
-func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&model.User{},
-		&model.Wallet{},
-		&model.Transaction{},
-		&model.Plate{},
-		&model.Food{},
-		&model.Order{},
-		&model.OrderItem{},
-		&model.PlateDepot{},
-		&model.Worker{},
-		&model.ExceptionLog{},
-		&model.GCProcessLog{},
-	)
+// migrateDB 按 Database.Migrate 执行未执行的迁移，或者只检查表结构是否为最新版本。
+// 多个实例同时以 up 方式启动时，拿不到迁移锁的实例等待持有锁的实例迁移完成
+func migrateDB(db *gorm.DB, mode string) error {
+	m, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if mode == "check" {
+		return m.Check(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		applied, err := m.Up(ctx, 0)
+		for _, mig := range applied {
+			logx.Infof("已执行数据库迁移 %d_%s", mig.Version, mig.Name)
+		}
+		if errors.Is(err, migrate.ErrLocked) && attempt < migrateLockAttempts {
+			logx.Infof("%v，%s后重试", err, migrateLockWait)
+			time.Sleep(migrateLockWait)
+			continue
+		}
+		return err
+	}
+}
+
+// OpenDB 按配置连接数据库，不做迁移
+func OpenDB(c config.Config) (*gorm.DB, error) {
+	logLevel := map[string]logger.LogLevel{
+		"silent": logger.Silent,
+		"error":  logger.Error,
+		"warn":   logger.Warn,
+	}[c.Database.LogLevel]
+	if logLevel == 0 {
+		logLevel = logger.Info
+	}
+	gormConfig := &gorm.Config{
+		Logger: logger.Default.LogMode(logLevel),
+	}
+
+	switch c.Database.Type {
+	case "mysql":
+		return gorm.Open(mysql.Open(c.Database.DSN), gormConfig)
+	case "postgres":
+		return gorm.Open(postgres.Open(c.Database.DSN), gormConfig)
+	case "sqlite":
+		return gorm.Open(sqlite.Open(c.Database.DSN), gormConfig)
+	default:
+		// 默认使用 SQLite
+		return gorm.Open(sqlite.Open("restaurant.db"), gormConfig)
+	}
 }
@@ -0,0 +1,20 @@
+// Package tenant 在请求上下文中传递调用方所属的食堂。
+package tenant
+
+import "context"
+
+// Header 总部工作人员通过该请求头选择要操作的食堂；食堂工作人员和终端设备的食堂由其身份决定
+const Header = "X-Canteen-ID"
+
+type canteenKey struct{}
+
+// WithCanteen 将食堂ID写入上下文
+func WithCanteen(ctx context.Context, canteenID string) context.Context {
+	return context.WithValue(ctx, canteenKey{}, canteenID)
+}
+
+// CanteenID 读取上下文中的食堂ID，未指定时返回空字符串（不限食堂，供总部使用）
+func CanteenID(ctx context.Context) string {
+	id, _ := ctx.Value(canteenKey{}).(string)
+	return id
+}
@@ -14,18 +14,6 @@ type PlateManager struct {
 	Tablewares []Tableware
 }
 
-// Tableware 餐具
-// 餐具包括: 碗, 盘, 筷子, 勺子等
-type Tableware struct{}
-
-// Cauldron 装汤的大锅
-type Cauldron struct {
-	Soup []Soup
-}
-
-// Soup 汤
-type Soup struct{}
-
 // FoodGC 食物残渣处理（业务方法）
 func (w *Worker) FoodGC() {}
 
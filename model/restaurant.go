@@ -6,12 +6,24 @@ import (
 	"gorm.io/gorm"
 )
 
+// Canteen 食堂表，菜品、餐盘、托管处、工作人员和订单都归属于某个食堂；用户和钱包全校通用
+type Canteen struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Location  string         `gorm:"type:varchar(255)" json:"location,omitempty"`
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
 // User 用户表
 type User struct {
 	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
 	Username  string         `gorm:"type:varchar(100);uniqueIndex" json:"username"`
 	Phone     string         `gorm:"type:varchar(20);index" json:"phone,omitempty"`
 	Email     string         `gorm:"type:varchar(100)" json:"email,omitempty"`
+	ErasedAt  *time.Time     `json:"erased_at,omitempty"` // 个人信息匿名化的时间
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -21,11 +33,12 @@ type User struct {
 	Orders []Order `gorm:"foreignKey:UserID" json:"orders,omitempty"`
 }
 
-// Wallet 钱包表
+// Wallet 钱包表，余额是账本中该用户钱包科目余额的缓存，与记账凭证在同一事务中更新
 type Wallet struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"user_id"`
 	Balance   float64        `gorm:"type:decimal(10,2);default:0" json:"balance"`
+	Status    string         `gorm:"type:varchar(20);default:'active'" json:"status"` // "active", "frozen"（销户处理中）, "closed"（已销户）
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -37,25 +50,90 @@ type Wallet struct {
 
 // Transaction 交易记录表
 type Transaction struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	WalletID  uint       `gorm:"index;not null" json:"wallet_id"`
+	Type      string     `gorm:"type:varchar(20);not null" json:"type"` // "charge", "subsidy", "consume", "refund", "adjust"（对账调整）, "settlement"（销户结算）
+	Amount    float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Balance   float64    `gorm:"type:decimal(10,2);not null" json:"balance"` // 交易后余额
+	OrderID   string     `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 补贴的到期时间，为空表示长期有效
+	Remark    string     `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// 关联
+	Wallet *Wallet `gorm:"foreignKey:WalletID" json:"wallet,omitempty"`
+}
+
+// LedgerAccount 复式记账科目
+// 用户钱包每人一个科目（wallet:<user_id>），食堂营收每个食堂一个科目（revenue:<canteen_id>）
+type LedgerAccount struct {
+	ID        string    `gorm:"primaryKey;type:varchar(100)" json:"id"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`            // asset, liability, equity, income, expense
+	Kind      string    `gorm:"type:varchar(20);index;not null" json:"kind"`      // wallet, revenue, subsidy, refund, clearing, opening
+	OwnerID   string    `gorm:"type:varchar(64);index" json:"owner_id,omitempty"` // 用户ID或食堂ID
+	Name      string    `gorm:"type:varchar(100)" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalEntry 记账凭证，写入后不再修改，更正通过新的凭证冲销
+type JournalEntry struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	WalletID  uint      `gorm:"index;not null" json:"wallet_id"`
-	Type      string    `gorm:"type:varchar(20);not null" json:"type"` // "charge", "consume", "refund"
-	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
-	Balance   float64   `gorm:"type:decimal(10,2);not null" json:"balance"` // 交易后余额
+	Kind      string    `gorm:"type:varchar(20);index;not null" json:"kind"` // charge, subsidy, consume, refund, opening
 	OrderID   string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
 	Remark    string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
 
 	// 关联
-	Wallet *Wallet `gorm:"foreignKey:WalletID" json:"wallet,omitempty"`
+	Lines []JournalLine `gorm:"foreignKey:EntryID" json:"lines,omitempty"`
+}
+
+// JournalLine 凭证分录，一条凭证的借方合计等于贷方合计
+type JournalLine struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	EntryID   uint    `gorm:"index;not null" json:"entry_id"`
+	AccountID string  `gorm:"type:varchar(100);index;not null" json:"account_id"`
+	Debit     float64 `gorm:"type:decimal(12,2);default:0" json:"debit"`
+	Credit    float64 `gorm:"type:decimal(12,2);default:0" json:"credit"`
+}
+
+// AccountClosure 账户销户记录，同时是销户证明的内容
+type AccountClosure struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	No             string    `gorm:"type:varchar(32);uniqueIndex;not null" json:"no"` // 证明编号
+	UserID         string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"user_id"`
+	Username       string    `gorm:"type:varchar(100)" json:"username"`
+	WalletID       uint      `gorm:"index" json:"wallet_id"`
+	TransactionID  uint      `json:"transaction_id"`                                      // 销户结算交易
+	Balance        float64   `gorm:"type:decimal(10,2);default:0" json:"balance"`         // 销户时余额
+	Refundable     float64   `gorm:"type:decimal(10,2);default:0" json:"refundable"`      // 退还的自有资金
+	Subsidy        float64   `gorm:"type:decimal(10,2);default:0" json:"subsidy"`         // 收回的未过期补贴
+	ExpiredSubsidy float64   `gorm:"type:decimal(10,2);default:0" json:"expired_subsidy"` // 收回的已过期补贴
+	OperatorType   string    `gorm:"type:varchar(20)" json:"operator_type"`               // "worker", "system"（批量销户）
+	OperatorID     string    `gorm:"type:varchar(64)" json:"operator_id,omitempty"`
+	Reason         string    `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WalletRepair 钱包余额修复记录：钱包余额按账本重新计算，记录操作人和修复前后的余额
+type WalletRepair struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	WalletID      uint      `gorm:"index;not null" json:"wallet_id"`
+	UserID        string    `gorm:"type:varchar(64);not null" json:"user_id"`
+	BalanceBefore float64   `gorm:"type:decimal(10,2);not null" json:"balance_before"` // 修复前的钱包余额
+	BalanceAfter  float64   `gorm:"type:decimal(10,2);not null" json:"balance_after"`  // 修复后的钱包余额，即账本余额
+	OperatorID    string    `gorm:"type:varchar(64);not null" json:"operator_id"`      // 确认修复的管理员
+	Remark        string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // Plate 餐盘表
 type Plate struct {
 	ID          string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID   string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
 	QRCode      string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"qr_code"`
 	RFIDTag     string         `gorm:"type:varchar(255);uniqueIndex" json:"rfid_tag,omitempty"`
-	Weight      float64        `gorm:"type:decimal(8,2);default:0" json:"weight"` // 当前重量（克）
+	DepotID     string         `gorm:"type:varchar(64);index" json:"depot_id,omitempty"` // 所属托管处
+	Weight      float64        `gorm:"type:decimal(8,2);default:0" json:"weight"`        // 当前重量（克）
 	IsBound     bool           `gorm:"default:false;index" json:"is_bound"`
 	BoundUserID string         `gorm:"type:varchar(64);index" json:"bound_user_id,omitempty"`
 	BoundAt     *time.Time     `json:"bound_at,omitempty"`
@@ -72,6 +150,7 @@ type Plate struct {
 // Food 食物表
 type Food struct {
 	ID          string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID   string    `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
 	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
 	Price       float64   `gorm:"type:decimal(8,2);not null" json:"price"`    // 单价（每100克）
 	Category    string    `gorm:"type:varchar(50)" json:"category,omitempty"` // 菜品分类
@@ -84,13 +163,103 @@ type Food struct {
 	OrderItems []OrderItem `gorm:"foreignKey:FoodID" json:"order_items,omitempty"`
 }
 
+// FoodStock 菜品库存表，按日期和餐次记录厨房备餐量
+type FoodStock struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CanteenID  string    `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	FoodID     string    `gorm:"type:varchar(64);uniqueIndex:idx_food_stock_period;not null" json:"food_id"`
+	Date       string    `gorm:"type:varchar(10);uniqueIndex:idx_food_stock_period;not null" json:"date"`   // 2006-01-02
+	Period     string    `gorm:"type:varchar(20);uniqueIndex:idx_food_stock_period;not null" json:"period"` // breakfast, lunch, dinner
+	Prepared   float64   `gorm:"type:decimal(10,2);default:0" json:"prepared"`                              // 本餐次累计备餐量（克）
+	Remaining  float64   `gorm:"type:decimal(10,2);default:0" json:"remaining"`                             // 剩余量（克）
+	LowAlerted bool      `gorm:"default:false" json:"low_alerted"`                                          // 已发出低库存提醒
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// 关联
+	Food *Food `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+}
+
+// StockMovement 库存变动流水表（只追加）
+type StockMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	StockID   uint      `gorm:"index;not null" json:"stock_id"`
+	FoodID    string    `gorm:"type:varchar(64);index;not null" json:"food_id"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`     // "batch", "discard", "sale", "restore"
+	Weight    float64   `gorm:"type:decimal(10,2);not null" json:"weight"` // 变动量（克），出库为负
+	OrderID   string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	WorkerID  string    `gorm:"type:varchar(64);index" json:"worker_id,omitempty"`
+	Remark    string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Soup 汤品，按容量计价。每种汤对应一条 category 为 soup 的 Food 记录（ID 相同），
+// 订单明细和库存沿用菜品，Food.Price 保存折算后的每100毫升单价
+type Soup struct {
+	ID          string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID   string    `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	FoodID      string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"food_id"`
+	PriceUnit   string    `gorm:"type:varchar(10);not null" json:"price_unit"`     // "ml"（每100毫升）, "ladle"（每勺）
+	UnitPrice   float64   `gorm:"type:decimal(8,2);not null" json:"unit_price"`    // 按 PriceUnit 的单价
+	LadleVolume float64   `gorm:"type:decimal(8,2);default:0" json:"ladle_volume"` // 每勺容量（毫升）
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 关联
+	Food *Food `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+}
+
+// Cauldron 汤锅，放在档口，由打汤机（dispenser 设备）出汤
+type Cauldron struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	StationID string         `gorm:"type:varchar(64);index" json:"station_id,omitempty"`
+	SoupID    string         `gorm:"type:varchar(64);index" json:"soup_id,omitempty"`   // 当前装的汤，清空后保留
+	DeviceID  string         `gorm:"type:varchar(64);index" json:"device_id,omitempty"` // 打汤机
+	Capacity  float64        `gorm:"type:decimal(10,2);not null" json:"capacity"`       // 容量（毫升）
+	Level     float64        `gorm:"type:decimal(10,2);default:0" json:"level"`         // 当前汤量（毫升）
+	Status    string         `gorm:"type:varchar(20);default:'empty'" json:"status"`    // empty, serving
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	Soup *Soup `gorm:"foreignKey:SoupID" json:"soup,omitempty"`
+}
+
+// CauldronEvent 汤锅事件表（只追加）
+type CauldronEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CauldronID string    `gorm:"type:varchar(64);index;not null" json:"cauldron_id"`
+	SoupID     string    `gorm:"type:varchar(64);index" json:"soup_id,omitempty"`
+	Type       string    `gorm:"type:varchar(20);not null" json:"type"`     // "refill", "dispense", "empty"
+	Volume     float64   `gorm:"type:decimal(10,2);not null" json:"volume"` // 变动量（毫升），出汤和清空为负
+	Level      float64   `gorm:"type:decimal(10,2);not null" json:"level"`  // 变动后的汤量
+	DeviceID   string    `gorm:"type:varchar(64);index" json:"device_id,omitempty"`
+	WorkerID   string    `gorm:"type:varchar(64);index" json:"worker_id,omitempty"`
+	PlateID    string    `gorm:"type:varchar(64);index" json:"plate_id,omitempty"`
+	OrderID    string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	Remark     string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// CalendarDay 校历表，全校通用；没有登记的日期按学期中（term）处理
+type CalendarDay struct {
+	Date      string    `gorm:"primaryKey;type:varchar(10)" json:"date"` // 2006-01-02
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`   // "term", "exam", "vacation", "holiday"
+	Remark    string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // Order 订单表
 type Order struct {
 	ID         string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID  string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
 	UserID     string         `gorm:"type:varchar(64);index;not null" json:"user_id"`
 	PlateID    string         `gorm:"type:varchar(64);index;not null" json:"plate_id"`
 	TotalPrice float64        `gorm:"type:decimal(10,2);not null" json:"total_price"`
-	Status     string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, paid, completed, cancelled
+	Status     string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, held, paid, completed, cancelled, refunded
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -103,14 +272,19 @@ type Order struct {
 
 // OrderItem 订单明细表
 type OrderItem struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	OrderID   string    `gorm:"type:varchar(64);index;not null" json:"order_id"`
-	FoodID    string    `gorm:"type:varchar(64);index;not null" json:"food_id"`
-	FoodName  string    `gorm:"type:varchar(100);not null" json:"food_name"`
-	Weight    float64   `gorm:"type:decimal(8,2);not null" json:"weight"`     // 重量（克）
-	UnitPrice float64   `gorm:"type:decimal(8,2);not null" json:"unit_price"` // 单价
-	Price     float64   `gorm:"type:decimal(10,2);not null" json:"price"`     // 总价
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OrderID    string    `gorm:"type:varchar(64);index;not null" json:"order_id"`
+	FoodID     string    `gorm:"type:varchar(64);index;not null" json:"food_id"`
+	FoodName   string    `gorm:"type:varchar(100);not null" json:"food_name"`
+	Weight     float64   `gorm:"type:decimal(8,2);not null" json:"weight"`            // 重量（克），汤为容量（毫升）
+	Unit       string    `gorm:"type:varchar(10);default:'g'" json:"unit"`            // g 或 ml
+	UnitPrice  float64   `gorm:"type:decimal(8,2);not null" json:"unit_price"`        // 单价
+	Price      float64   `gorm:"type:decimal(10,2);not null" json:"price"`            // 总价
+	StationID  string    `gorm:"type:varchar(64);index" json:"station_id,omitempty"`  // 称重出餐的档口
+	CauldronID string    `gorm:"type:varchar(64);index" json:"cauldron_id,omitempty"` // 打汤的汤锅
+	ReadingID  uint      `gorm:"index" json:"reading_id,omitempty"`                   // 对应的称重读数
+	Held       bool      `gorm:"default:false" json:"held,omitempty"`                 // 读数可疑，等待审核
+	CreatedAt  time.Time `json:"created_at"`
 
 	// 关联
 	Order *Order `gorm:"foreignKey:OrderID" json:"order,omitempty"`
@@ -120,6 +294,7 @@ type OrderItem struct {
 // PlateDepot 餐盘托管处表
 type PlateDepot struct {
 	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
 	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
 	Location  string         `gorm:"type:varchar(255)" json:"location,omitempty"`
 	Capacity  int            `gorm:"default:100" json:"capacity"`
@@ -129,9 +304,90 @@ type PlateDepot struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
+// Tableware 餐具种类，包括碗、盘、筷子、勺子等。库存按托管处分别统计
+type Tableware struct {
+	ID           string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID    string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	Name         string         `gorm:"type:varchar(100);not null" json:"name"`
+	Kind         string         `gorm:"type:varchar(20);not null" json:"kind"` // "bowl", "plate", "chopsticks", "spoon", "cup", "other"
+	ReorderLevel int            `gorm:"default:0" json:"reorder_level"`        // 每个托管处在库加在用数量低于该值时提醒补购，0 表示不提醒
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TablewareStock 托管处的餐具库存
+type TablewareStock struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CanteenID      string    `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	DepotID        string    `gorm:"type:varchar(64);uniqueIndex:idx_tableware_stock;not null" json:"depot_id"`
+	TablewareID    string    `gorm:"type:varchar(64);uniqueIndex:idx_tableware_stock;not null" json:"tableware_id"`
+	OnHand         int       `gorm:"default:0" json:"on_hand"`             // 在托管处的数量
+	Issued         int       `gorm:"default:0" json:"issued"`              // 已发放到档口、餐区使用中的数量
+	Lost           int       `gorm:"default:0" json:"lost"`                // 累计丢失、损坏数量
+	ReorderAlerted bool      `gorm:"default:false" json:"reorder_alerted"` // 已发出补购提醒
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// 关联
+	Tableware *Tableware `gorm:"foreignKey:TablewareID" json:"tableware,omitempty"`
+}
+
+// TablewareMovement 餐具变动流水表（只追加）
+type TablewareMovement struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StockID     uint      `gorm:"index;not null" json:"stock_id"`
+	DepotID     string    `gorm:"type:varchar(64);index;not null" json:"depot_id"`
+	TablewareID string    `gorm:"type:varchar(64);index;not null" json:"tableware_id"`
+	Type        string    `gorm:"type:varchar(20);not null" json:"type"` // "receive", "issue", "return", "loss"
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	WorkerID    string    `gorm:"type:varchar(64);index" json:"worker_id,omitempty"`
+	Remark      string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// Station 出餐档口表，每个档口同一时间只供应一道菜
+type Station struct {
+	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	FoodID    string         `gorm:"type:varchar(64);index" json:"food_id,omitempty"` // 当前供应的菜品
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// 关联
+	Food    *Food    `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+	Devices []Device `gorm:"foreignKey:StationID" json:"devices,omitempty"`
+}
+
+// Device 终端设备表（档口上的电子秤、餐盘读卡器）
+type Device struct {
+	ID         string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID  string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
+	StationID  string         `gorm:"type:varchar(64);index" json:"station_id,omitempty"`
+	Type       string         `gorm:"type:varchar(20);not null" json:"type"` // "scale", "reader", "dispenser"
+	Firmware   string         `gorm:"type:varchar(64)" json:"firmware,omitempty"`
+	Secret     string         `gorm:"type:varchar(128)" json:"-"`                             // 设备密钥，只在登记和轮换时返回一次
+	Status     string         `gorm:"type:varchar(20);default:'offline';index" json:"status"` // online, offline, retired
+	LastSeenAt *time.Time     `json:"last_seen_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// DeviceNonce 设备签名请求用过的 nonce，在时间戳偏差窗口内拒绝重复使用；保存在数据库中，多副本共享
+type DeviceNonce struct {
+	DeviceID  string    `gorm:"primaryKey;type:varchar(64)" json:"device_id"`
+	Nonce     string    `gorm:"primaryKey;type:varchar(64)" json:"nonce"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"` // 过期后可以清理
+}
+
 // Worker 工作人员表
 type Worker struct {
 	ID        string         `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	CanteenID string         `gorm:"type:varchar(64);index" json:"canteen_id,omitempty"`
 	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
 	Role      string         `gorm:"type:varchar(50);not null" json:"role"` // "staff", "manager", "gc"
 	Phone     string         `gorm:"type:varchar(20)" json:"phone,omitempty"`
@@ -146,6 +402,9 @@ type ExceptionLog struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	WorkerID  string         `gorm:"type:varchar(64);index;not null" json:"worker_id"`
 	PlateID   string         `gorm:"type:varchar(64);index" json:"plate_id,omitempty"`
+	DeviceID  string         `gorm:"type:varchar(64);index" json:"device_id,omitempty"` // 设备离线等由系统发起的异常
+	OrderID   string         `gorm:"type:varchar(64);index" json:"order_id,omitempty"`  // 挂起待审核的订单
+	ReadingID uint           `gorm:"index" json:"reading_id,omitempty"`                 // 异常的称重读数
 	Exception string         `gorm:"type:text;not null" json:"exception"`
 	Action    string         `gorm:"type:varchar(255);not null" json:"action"`
 	Status    string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, resolved
@@ -171,3 +430,75 @@ type GCProcessLog struct {
 	// 关联
 	Plate *Plate `gorm:"foreignKey:PlateID" json:"plate,omitempty"`
 }
+
+// WeightReading 电子秤称重读数表，所有读数（包括被丢弃的）都会记录
+type WeightReading struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  string    `gorm:"type:varchar(64);index;not null" json:"device_id"`
+	StationID string    `gorm:"type:varchar(64);index" json:"station_id,omitempty"`
+	PlateID   string    `gorm:"type:varchar(64);index" json:"plate_id,omitempty"` // 为空表示空秤读数
+	Weight    float64   `gorm:"type:decimal(8,2);not null" json:"weight"`
+	Anomalies string    `gorm:"type:varchar(100)" json:"anomalies,omitempty"` // 命中的规则，逗号分隔
+	Status    string    `gorm:"type:varchar(20);not null" json:"status"`      // accepted, held, rejected, idle
+	OrderID   string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// PlateEvent 餐盘生命周期事件表（只追加，不修改、不删除）
+type PlateEvent struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PlateID     string    `gorm:"type:varchar(64);index;not null" json:"plate_id"`
+	Type        string    `gorm:"type:varchar(20);not null" json:"type"` // "created", "bind", "unbind", "order", "exception", "gc"
+	PrevStatus  string    `gorm:"type:varchar(20)" json:"prev_status,omitempty"`
+	NewStatus   string    `gorm:"type:varchar(20)" json:"new_status,omitempty"`
+	ActorType   string    `gorm:"type:varchar(20);not null" json:"actor_type"` // "user", "worker", "device", "system"
+	ActorID     string    `gorm:"type:varchar(64);index" json:"actor_id,omitempty"`
+	UserID      string    `gorm:"type:varchar(64);index" json:"user_id,omitempty"` // 事件发生时持有餐盘的用户
+	OrderID     string    `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	ExceptionID uint      `gorm:"index" json:"exception_id,omitempty"`
+	Remark      string    `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// Notification 用户通知（发件箱）。每个渠道一条记录，后台任务逐条发送并重试；
+// 站内信渠道的记录写入即送达，同时作为用户的收件箱
+type Notification struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        string     `gorm:"type:varchar(64);index;not null" json:"user_id"`
+	Kind          string     `gorm:"type:varchar(30);not null" json:"kind"`      // "order_paid", "refund_issued", "low_balance", "plate_auto_unbound"
+	Channel       string     `gorm:"type:varchar(10);not null" json:"channel"`   // "inbox", "email", "sms"
+	Address       string     `gorm:"type:varchar(100)" json:"address,omitempty"` // 邮箱或手机号
+	Subject       string     `gorm:"type:varchar(200)" json:"subject"`
+	Body          string     `gorm:"type:text" json:"body"`
+	OrderID       string     `gorm:"type:varchar(64);index" json:"order_id,omitempty"`
+	Status        string     `gorm:"type:varchar(10);default:'pending';index:idx_notification_due" json:"status"` // pending, sent, failed
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_notification_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:varchar(255)" json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	ReadAt        *time.Time `json:"read_at,omitempty"` // 站内信已读时间
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+}
+
+// NotificationEvent 待生成通知的业务事件，与引起它的业务变更在同一个事务中写入，不会因进程退出丢失。
+// 通知服务领取后按用户偏好生成通知写入发件箱，并在同一个事务中标记为已处理
+type NotificationEvent struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       string     `gorm:"type:varchar(64);not null" json:"user_id"`
+	Kind         string     `gorm:"type:varchar(30);not null" json:"kind"` // 通知类型，同 Notification.Kind
+	OrderID      string     `gorm:"type:varchar(64)" json:"order_id,omitempty"`
+	PlateID      string     `gorm:"type:varchar(64)" json:"plate_id,omitempty"`
+	Amount       float64    `gorm:"type:decimal(10,2);default:0" json:"amount"`
+	Balance      float64    `gorm:"type:decimal(10,2);default:0" json:"balance"`
+	ClaimedUntil time.Time  `gorm:"index:idx_notification_event_due" json:"claimed_until"` // 领取后在该时间之前没有处理完，视为处理中断
+	ProcessedAt  *time.Time `gorm:"index:idx_notification_event_due" json:"processed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// NotificationPreference 用户每种通知的接收渠道，没有记录时使用配置的默认渠道
+type NotificationPreference struct {
+	UserID    string    `gorm:"primaryKey;type:varchar(64)" json:"user_id"`
+	Kind      string    `gorm:"primaryKey;type:varchar(30)" json:"kind"`
+	Channels  string    `gorm:"type:varchar(50)" json:"channels"` // 逗号分隔，为空表示不接收
+	UpdatedAt time.Time `json:"updated_at"`
+}
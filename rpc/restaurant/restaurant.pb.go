@@ -0,0 +1,1888 @@
+// 智慧食堂 gRPC 接口，供自助机、闸机等非 Go 终端调用，与 REST 接口共用业务逻辑。
+// 调用方在 authorization 元数据中带上工作人员令牌（Bearer <令牌>），总部工作人员通过 x-canteen-id 元数据选择食堂，
+// 规则与 REST 接口相同。
+// 修改后重新生成：
+//   protoc -I rpc --go_out=. --go_opt=module=github.com/p-program/Fenrir \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/p-program/Fenrir rpc/restaurant.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: restaurant.proto
+
+package restaurant
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PlateEventType int32
+
+const (
+	PlateEventType_PLATE_EVENT_UNSPECIFIED PlateEventType = 0
+	PlateEventType_PLATE_BOUND             PlateEventType = 1
+	PlateEventType_PLATE_UNBOUND           PlateEventType = 2
+	PlateEventType_PLATE_STATUS            PlateEventType = 3 // 异常处理、导入等引起的状态变化
+	PlateEventType_PLATE_GC                PlateEventType = 4 // 回收，绑定中的餐盘同时解绑
+)
+
+// Enum value maps for PlateEventType.
+var (
+	PlateEventType_name = map[int32]string{
+		0: "PLATE_EVENT_UNSPECIFIED",
+		1: "PLATE_BOUND",
+		2: "PLATE_UNBOUND",
+		3: "PLATE_STATUS",
+		4: "PLATE_GC",
+	}
+	PlateEventType_value = map[string]int32{
+		"PLATE_EVENT_UNSPECIFIED": 0,
+		"PLATE_BOUND":             1,
+		"PLATE_UNBOUND":           2,
+		"PLATE_STATUS":            3,
+		"PLATE_GC":                4,
+	}
+)
+
+func (x PlateEventType) Enum() *PlateEventType {
+	p := new(PlateEventType)
+	*p = x
+	return p
+}
+
+func (x PlateEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PlateEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_restaurant_proto_enumTypes[0].Descriptor()
+}
+
+func (PlateEventType) Type() protoreflect.EnumType {
+	return &file_restaurant_proto_enumTypes[0]
+}
+
+func (x PlateEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PlateEventType.Descriptor instead.
+func (PlateEventType) EnumDescriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{0}
+}
+
+type OrderEventType int32
+
+const (
+	OrderEventType_ORDER_EVENT_UNSPECIFIED OrderEventType = 0
+	OrderEventType_ORDER_ITEM_ADDED        OrderEventType = 1 // 称重后加入一份菜品
+	OrderEventType_ORDER_PAID              OrderEventType = 2
+)
+
+// Enum value maps for OrderEventType.
+var (
+	OrderEventType_name = map[int32]string{
+		0: "ORDER_EVENT_UNSPECIFIED",
+		1: "ORDER_ITEM_ADDED",
+		2: "ORDER_PAID",
+	}
+	OrderEventType_value = map[string]int32{
+		"ORDER_EVENT_UNSPECIFIED": 0,
+		"ORDER_ITEM_ADDED":        1,
+		"ORDER_PAID":              2,
+	}
+)
+
+func (x OrderEventType) Enum() *OrderEventType {
+	p := new(OrderEventType)
+	*p = x
+	return p
+}
+
+func (x OrderEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_restaurant_proto_enumTypes[1].Descriptor()
+}
+
+func (OrderEventType) Type() protoreflect.EnumType {
+	return &file_restaurant_proto_enumTypes[1]
+}
+
+func (x OrderEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderEventType.Descriptor instead.
+func (OrderEventType) EnumDescriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{1}
+}
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_restaurant_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{0}
+}
+
+// 钱包充值，source 为 payment（用户支付，默认）或 subsidy（学校补贴）
+type ChargeWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // 补贴有效期至（含当天），格式 2006-01-02，为空表示长期有效
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChargeWalletRequest) Reset() {
+	*x = ChargeWalletRequest{}
+	mi := &file_restaurant_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChargeWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChargeWalletRequest) ProtoMessage() {}
+
+func (x *ChargeWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChargeWalletRequest.ProtoReflect.Descriptor instead.
+func (*ChargeWalletRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChargeWalletRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ChargeWalletRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ChargeWalletRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ChargeWalletRequest) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+type Wallet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Balance       float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_restaurant_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Wallet) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Wallet) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+type GetUserInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserInfoRequest) Reset() {
+	*x = GetUserInfoRequest{}
+	mi := &file_restaurant_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserInfoRequest) ProtoMessage() {}
+
+func (x *GetUserInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetUserInfoRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetUserInfoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type UserInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Balance       float64                `protobuf:"fixed64,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserInfo) Reset() {
+	*x = UserInfo{}
+	mi := &file_restaurant_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserInfo) ProtoMessage() {}
+
+func (x *UserInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserInfo.ProtoReflect.Descriptor instead.
+func (*UserInfo) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UserInfo) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserInfo) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserInfo) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+// 绑定餐盘，plate_id 与 qr_payload 至少提供一个
+type BindPlateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,2,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	QrPayload     string                 `protobuf:"bytes,3,opt,name=qr_payload,json=qrPayload,proto3" json:"qr_payload,omitempty"` // 扫码得到的餐盘二维码内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BindPlateRequest) Reset() {
+	*x = BindPlateRequest{}
+	mi := &file_restaurant_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindPlateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindPlateRequest) ProtoMessage() {}
+
+func (x *BindPlateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindPlateRequest.ProtoReflect.Descriptor instead.
+func (*BindPlateRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BindPlateRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BindPlateRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *BindPlateRequest) GetQrPayload() string {
+	if x != nil {
+		return x.QrPayload
+	}
+	return ""
+}
+
+type UnbindPlateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,2,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnbindPlateRequest) Reset() {
+	*x = UnbindPlateRequest{}
+	mi := &file_restaurant_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnbindPlateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnbindPlateRequest) ProtoMessage() {}
+
+func (x *UnbindPlateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnbindPlateRequest.ProtoReflect.Descriptor instead.
+func (*UnbindPlateRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UnbindPlateRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UnbindPlateRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+type GetPlateInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlateId       string                 `protobuf:"bytes,1,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPlateInfoRequest) Reset() {
+	*x = GetPlateInfoRequest{}
+	mi := &file_restaurant_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlateInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlateInfoRequest) ProtoMessage() {}
+
+func (x *GetPlateInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlateInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetPlateInfoRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetPlateInfoRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+// 不设置 is_bound 时返回全部餐盘
+type GetPlateListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsBound       *bool                  `protobuf:"varint,1,opt,name=is_bound,json=isBound,proto3,oneof" json:"is_bound,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPlateListRequest) Reset() {
+	*x = GetPlateListRequest{}
+	mi := &file_restaurant_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlateListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlateListRequest) ProtoMessage() {}
+
+func (x *GetPlateListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlateListRequest.ProtoReflect.Descriptor instead.
+func (*GetPlateListRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetPlateListRequest) GetIsBound() bool {
+	if x != nil && x.IsBound != nil {
+		return *x.IsBound
+	}
+	return false
+}
+
+type Plate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlateId       string                 `protobuf:"bytes,1,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	QrCode        string                 `protobuf:"bytes,2,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	Weight        float64                `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	IsBound       bool                   `protobuf:"varint,4,opt,name=is_bound,json=isBound,proto3" json:"is_bound,omitempty"`
+	BoundUserId   string                 `protobuf:"bytes,5,opt,name=bound_user_id,json=boundUserId,proto3" json:"bound_user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plate) Reset() {
+	*x = Plate{}
+	mi := &file_restaurant_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plate) ProtoMessage() {}
+
+func (x *Plate) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plate.ProtoReflect.Descriptor instead.
+func (*Plate) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Plate) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *Plate) GetQrCode() string {
+	if x != nil {
+		return x.QrCode
+	}
+	return ""
+}
+
+func (x *Plate) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Plate) GetIsBound() bool {
+	if x != nil {
+		return x.IsBound
+	}
+	return false
+}
+
+func (x *Plate) GetBoundUserId() string {
+	if x != nil {
+		return x.BoundUserId
+	}
+	return ""
+}
+
+func (x *Plate) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type PlateList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plates        []*Plate               `protobuf:"bytes,1,rep,name=plates,proto3" json:"plates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlateList) Reset() {
+	*x = PlateList{}
+	mi := &file_restaurant_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlateList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlateList) ProtoMessage() {}
+
+func (x *PlateList) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlateList.ProtoReflect.Descriptor instead.
+func (*PlateList) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PlateList) GetPlates() []*Plate {
+	if x != nil {
+		return x.Plates
+	}
+	return nil
+}
+
+// 点餐，汤品按容量（毫升，weight）或勺数（ladles）计价
+type OrderFood struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FoodId        string                 `protobuf:"bytes,1,opt,name=food_id,json=foodId,proto3" json:"food_id,omitempty"`
+	Weight        float64                `protobuf:"fixed64,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Ladles        int32                  `protobuf:"varint,3,opt,name=ladles,proto3" json:"ladles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderFood) Reset() {
+	*x = OrderFood{}
+	mi := &file_restaurant_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderFood) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderFood) ProtoMessage() {}
+
+func (x *OrderFood) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderFood.ProtoReflect.Descriptor instead.
+func (*OrderFood) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *OrderFood) GetFoodId() string {
+	if x != nil {
+		return x.FoodId
+	}
+	return ""
+}
+
+func (x *OrderFood) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *OrderFood) GetLadles() int32 {
+	if x != nil {
+		return x.Ladles
+	}
+	return 0
+}
+
+type CreateOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,2,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	Foods         []*OrderFood           `protobuf:"bytes,3,rep,name=foods,proto3" json:"foods,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrderRequest) Reset() {
+	*x = CreateOrderRequest{}
+	mi := &file_restaurant_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrderRequest) ProtoMessage() {}
+
+func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrderRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrderRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CreateOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetFoods() []*OrderFood {
+	if x != nil {
+		return x.Foods
+	}
+	return nil
+}
+
+type OrderItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FoodId        string                 `protobuf:"bytes,1,opt,name=food_id,json=foodId,proto3" json:"food_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Weight        float64                `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItem) Reset() {
+	*x = OrderItem{}
+	mi := &file_restaurant_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItem) ProtoMessage() {}
+
+func (x *OrderItem) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
+func (*OrderItem) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *OrderItem) GetFoodId() string {
+	if x != nil {
+		return x.FoodId
+	}
+	return ""
+}
+
+func (x *OrderItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OrderItem) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *OrderItem) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type Order struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,3,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	Foods         []*OrderItem           `protobuf:"bytes,4,rep,name=foods,proto3" json:"foods,omitempty"`
+	TotalPrice    float64                `protobuf:"fixed64,5,opt,name=total_price,json=totalPrice,proto3" json:"total_price,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_restaurant_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Order) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Order) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Order) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *Order) GetFoods() []*OrderItem {
+	if x != nil {
+		return x.Foods
+	}
+	return nil
+}
+
+func (x *Order) GetTotalPrice() float64 {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return 0
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// 分页从 1 开始，page_size 默认 10
+type GetUserOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserOrdersRequest) Reset() {
+	*x = GetUserOrdersRequest{}
+	mi := &file_restaurant_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserOrdersRequest) ProtoMessage() {}
+
+func (x *GetUserOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserOrdersRequest.ProtoReflect.Descriptor instead.
+func (*GetUserOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetUserOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetUserOrdersRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *GetUserOrdersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type OrderList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderList) Reset() {
+	*x = OrderList{}
+	mi := &file_restaurant_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderList) ProtoMessage() {}
+
+func (x *OrderList) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderList.ProtoReflect.Descriptor instead.
+func (*OrderList) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *OrderList) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *OrderList) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetOrderInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderInfoRequest) Reset() {
+	*x = GetOrderInfoRequest{}
+	mi := &file_restaurant_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderInfoRequest) ProtoMessage() {}
+
+func (x *GetOrderInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderInfoRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetOrderInfoRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type HandleExceptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkerId      string                 `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,2,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	Exception     string                 `protobuf:"bytes,3,opt,name=exception,proto3" json:"exception,omitempty"`
+	Action        string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HandleExceptionRequest) Reset() {
+	*x = HandleExceptionRequest{}
+	mi := &file_restaurant_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HandleExceptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandleExceptionRequest) ProtoMessage() {}
+
+func (x *HandleExceptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandleExceptionRequest.ProtoReflect.Descriptor instead.
+func (*HandleExceptionRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HandleExceptionRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *HandleExceptionRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *HandleExceptionRequest) GetException() string {
+	if x != nil {
+		return x.Exception
+	}
+	return ""
+}
+
+func (x *HandleExceptionRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+// type 为 plate（回收餐盘）或 food_waste（处理剩菜），worker_id 为空表示系统自动处理
+type ProcessGCRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlateId       string                 `protobuf:"bytes,1,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	WorkerId      string                 `protobuf:"bytes,3,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessGCRequest) Reset() {
+	*x = ProcessGCRequest{}
+	mi := &file_restaurant_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessGCRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessGCRequest) ProtoMessage() {}
+
+func (x *ProcessGCRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessGCRequest.ProtoReflect.Descriptor instead.
+func (*ProcessGCRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ProcessGCRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *ProcessGCRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ProcessGCRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+// 只推送指定餐盘或用户的事件，都为空时推送所属食堂的全部餐盘事件
+type WatchPlateEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlateIds      []string               `protobuf:"bytes,1,rep,name=plate_ids,json=plateIds,proto3" json:"plate_ids,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPlateEventsRequest) Reset() {
+	*x = WatchPlateEventsRequest{}
+	mi := &file_restaurant_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPlateEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPlateEventsRequest) ProtoMessage() {}
+
+func (x *WatchPlateEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPlateEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchPlateEventsRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchPlateEventsRequest) GetPlateIds() []string {
+	if x != nil {
+		return x.PlateIds
+	}
+	return nil
+}
+
+func (x *WatchPlateEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type PlateEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          PlateEventType         `protobuf:"varint,1,opt,name=type,proto3,enum=restaurant.PlateEventType" json:"type,omitempty"`
+	CanteenId     string                 `protobuf:"bytes,2,opt,name=canteen_id,json=canteenId,proto3" json:"canteen_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,3,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PrevStatus    string                 `protobuf:"bytes,5,opt,name=prev_status,json=prevStatus,proto3" json:"prev_status,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	IsBound       bool                   `protobuf:"varint,7,opt,name=is_bound,json=isBound,proto3" json:"is_bound,omitempty"`
+	Auto          bool                   `protobuf:"varint,8,opt,name=auto,proto3" json:"auto,omitempty"` // 超时未使用，系统自动解绑
+	At            *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlateEvent) Reset() {
+	*x = PlateEvent{}
+	mi := &file_restaurant_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlateEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlateEvent) ProtoMessage() {}
+
+func (x *PlateEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlateEvent.ProtoReflect.Descriptor instead.
+func (*PlateEvent) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PlateEvent) GetType() PlateEventType {
+	if x != nil {
+		return x.Type
+	}
+	return PlateEventType_PLATE_EVENT_UNSPECIFIED
+}
+
+func (x *PlateEvent) GetCanteenId() string {
+	if x != nil {
+		return x.CanteenId
+	}
+	return ""
+}
+
+func (x *PlateEvent) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *PlateEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PlateEvent) GetPrevStatus() string {
+	if x != nil {
+		return x.PrevStatus
+	}
+	return ""
+}
+
+func (x *PlateEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PlateEvent) GetIsBound() bool {
+	if x != nil {
+		return x.IsBound
+	}
+	return false
+}
+
+func (x *PlateEvent) GetAuto() bool {
+	if x != nil {
+		return x.Auto
+	}
+	return false
+}
+
+func (x *PlateEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+// 只推送指定用户或餐盘的事件，都为空时推送所属食堂的全部订单事件
+type WatchOrderEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,2,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrderEventsRequest) Reset() {
+	*x = WatchOrderEventsRequest{}
+	mi := &file_restaurant_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrderEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrderEventsRequest) ProtoMessage() {}
+
+func (x *WatchOrderEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrderEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrderEventsRequest) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WatchOrderEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchOrderEventsRequest) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+type OrderEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          OrderEventType         `protobuf:"varint,1,opt,name=type,proto3,enum=restaurant.OrderEventType" json:"type,omitempty"`
+	CanteenId     string                 `protobuf:"bytes,2,opt,name=canteen_id,json=canteenId,proto3" json:"canteen_id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,3,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlateId       string                 `protobuf:"bytes,5,opt,name=plate_id,json=plateId,proto3" json:"plate_id,omitempty"`
+	FoodName      string                 `protobuf:"bytes,6,opt,name=food_name,json=foodName,proto3" json:"food_name,omitempty"` // ORDER_ITEM_ADDED
+	Weight        float64                `protobuf:"fixed64,7,opt,name=weight,proto3" json:"weight,omitempty"`                   // ORDER_ITEM_ADDED，克
+	Amount        float64                `protobuf:"fixed64,8,opt,name=amount,proto3" json:"amount,omitempty"`                   // 菜品或订单金额
+	Total         float64                `protobuf:"fixed64,9,opt,name=total,proto3" json:"total,omitempty"`                     // 本次用餐（绑定餐盘以来）累计金额
+	Balance       float64                `protobuf:"fixed64,10,opt,name=balance,proto3" json:"balance,omitempty"`                // 支付后余额
+	At            *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_restaurant_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_restaurant_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_restaurant_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *OrderEvent) GetType() OrderEventType {
+	if x != nil {
+		return x.Type
+	}
+	return OrderEventType_ORDER_EVENT_UNSPECIFIED
+}
+
+func (x *OrderEvent) GetCanteenId() string {
+	if x != nil {
+		return x.CanteenId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetPlateId() string {
+	if x != nil {
+		return x.PlateId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetFoodName() string {
+	if x != nil {
+		return x.FoodName
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+var File_restaurant_proto protoreflect.FileDescriptor
+
+const file_restaurant_proto_rawDesc = "" +
+	"\n" +
+	"\x10restaurant.proto\x12\n" +
+	"restaurant\x1a\x1fgoogle/protobuf/timestamp.proto\"\a\n" +
+	"\x05Empty\"}\n" +
+	"\x13ChargeWalletRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\tR\texpiresAt\";\n" +
+	"\x06Wallet\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\"-\n" +
+	"\x12GetUserInfoRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"Y\n" +
+	"\bUserInfo\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x01R\abalance\"e\n" +
+	"\x10BindPlateRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x02 \x01(\tR\aplateId\x12\x1d\n" +
+	"\n" +
+	"qr_payload\x18\x03 \x01(\tR\tqrPayload\"H\n" +
+	"\x12UnbindPlateRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x02 \x01(\tR\aplateId\"0\n" +
+	"\x13GetPlateInfoRequest\x12\x19\n" +
+	"\bplate_id\x18\x01 \x01(\tR\aplateId\"B\n" +
+	"\x13GetPlateListRequest\x12\x1e\n" +
+	"\bis_bound\x18\x01 \x01(\bH\x00R\aisBound\x88\x01\x01B\v\n" +
+	"\t_is_bound\"\xaa\x01\n" +
+	"\x05Plate\x12\x19\n" +
+	"\bplate_id\x18\x01 \x01(\tR\aplateId\x12\x17\n" +
+	"\aqr_code\x18\x02 \x01(\tR\x06qrCode\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x01R\x06weight\x12\x19\n" +
+	"\bis_bound\x18\x04 \x01(\bR\aisBound\x12\"\n" +
+	"\rbound_user_id\x18\x05 \x01(\tR\vboundUserId\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\"6\n" +
+	"\tPlateList\x12)\n" +
+	"\x06plates\x18\x01 \x03(\v2\x11.restaurant.PlateR\x06plates\"T\n" +
+	"\tOrderFood\x12\x17\n" +
+	"\afood_id\x18\x01 \x01(\tR\x06foodId\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x01R\x06weight\x12\x16\n" +
+	"\x06ladles\x18\x03 \x01(\x05R\x06ladles\"u\n" +
+	"\x12CreateOrderRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x02 \x01(\tR\aplateId\x12+\n" +
+	"\x05foods\x18\x03 \x03(\v2\x15.restaurant.OrderFoodR\x05foods\"f\n" +
+	"\tOrderItem\x12\x17\n" +
+	"\afood_id\x18\x01 \x01(\tR\x06foodId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x01R\x06weight\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\"\xf7\x01\n" +
+	"\x05Order\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x03 \x01(\tR\aplateId\x12+\n" +
+	"\x05foods\x18\x04 \x03(\v2\x15.restaurant.OrderItemR\x05foods\x12\x1f\n" +
+	"\vtotal_price\x18\x05 \x01(\x01R\n" +
+	"totalPrice\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"`\n" +
+	"\x14GetUserOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"L\n" +
+	"\tOrderList\x12)\n" +
+	"\x06orders\x18\x01 \x03(\v2\x11.restaurant.OrderR\x06orders\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"0\n" +
+	"\x13GetOrderInfoRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"\x86\x01\n" +
+	"\x16HandleExceptionRequest\x12\x1b\n" +
+	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12\x19\n" +
+	"\bplate_id\x18\x02 \x01(\tR\aplateId\x12\x1c\n" +
+	"\texception\x18\x03 \x01(\tR\texception\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\"^\n" +
+	"\x10ProcessGCRequest\x12\x19\n" +
+	"\bplate_id\x18\x01 \x01(\tR\aplateId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1b\n" +
+	"\tworker_id\x18\x03 \x01(\tR\bworkerId\"O\n" +
+	"\x17WatchPlateEventsRequest\x12\x1b\n" +
+	"\tplate_ids\x18\x01 \x03(\tR\bplateIds\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\xa3\x02\n" +
+	"\n" +
+	"PlateEvent\x12.\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1a.restaurant.PlateEventTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"canteen_id\x18\x02 \x01(\tR\tcanteenId\x12\x19\n" +
+	"\bplate_id\x18\x03 \x01(\tR\aplateId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vprev_status\x18\x05 \x01(\tR\n" +
+	"prevStatus\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x19\n" +
+	"\bis_bound\x18\a \x01(\bR\aisBound\x12\x12\n" +
+	"\x04auto\x18\b \x01(\bR\x04auto\x12*\n" +
+	"\x02at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x02at\"M\n" +
+	"\x17WatchOrderEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x02 \x01(\tR\aplateId\"\xd3\x02\n" +
+	"\n" +
+	"OrderEvent\x12.\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1a.restaurant.OrderEventTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"canteen_id\x18\x02 \x01(\tR\tcanteenId\x12\x19\n" +
+	"\border_id\x18\x03 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x19\n" +
+	"\bplate_id\x18\x05 \x01(\tR\aplateId\x12\x1b\n" +
+	"\tfood_name\x18\x06 \x01(\tR\bfoodName\x12\x16\n" +
+	"\x06weight\x18\a \x01(\x01R\x06weight\x12\x16\n" +
+	"\x06amount\x18\b \x01(\x01R\x06amount\x12\x14\n" +
+	"\x05total\x18\t \x01(\x01R\x05total\x12\x18\n" +
+	"\abalance\x18\n" +
+	" \x01(\x01R\abalance\x12*\n" +
+	"\x02at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\x02at*q\n" +
+	"\x0ePlateEventType\x12\x1b\n" +
+	"\x17PLATE_EVENT_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vPLATE_BOUND\x10\x01\x12\x11\n" +
+	"\rPLATE_UNBOUND\x10\x02\x12\x10\n" +
+	"\fPLATE_STATUS\x10\x03\x12\f\n" +
+	"\bPLATE_GC\x10\x04*S\n" +
+	"\x0eOrderEventType\x12\x1b\n" +
+	"\x17ORDER_EVENT_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10ORDER_ITEM_ADDED\x10\x01\x12\x0e\n" +
+	"\n" +
+	"ORDER_PAID\x10\x022\xa0\a\n" +
+	"\n" +
+	"Restaurant\x12C\n" +
+	"\fChargeWallet\x12\x1f.restaurant.ChargeWalletRequest\x1a\x12.restaurant.Wallet\x12C\n" +
+	"\vGetUserInfo\x12\x1e.restaurant.GetUserInfoRequest\x1a\x14.restaurant.UserInfo\x12<\n" +
+	"\tBindPlate\x12\x1c.restaurant.BindPlateRequest\x1a\x11.restaurant.Plate\x12@\n" +
+	"\vUnbindPlate\x12\x1e.restaurant.UnbindPlateRequest\x1a\x11.restaurant.Empty\x12B\n" +
+	"\fGetPlateInfo\x12\x1f.restaurant.GetPlateInfoRequest\x1a\x11.restaurant.Plate\x12F\n" +
+	"\fGetPlateList\x12\x1f.restaurant.GetPlateListRequest\x1a\x15.restaurant.PlateList\x12@\n" +
+	"\vCreateOrder\x12\x1e.restaurant.CreateOrderRequest\x1a\x11.restaurant.Order\x12H\n" +
+	"\rGetUserOrders\x12 .restaurant.GetUserOrdersRequest\x1a\x15.restaurant.OrderList\x12B\n" +
+	"\fGetOrderInfo\x12\x1f.restaurant.GetOrderInfoRequest\x1a\x11.restaurant.Order\x12H\n" +
+	"\x0fHandleException\x12\".restaurant.HandleExceptionRequest\x1a\x11.restaurant.Empty\x12<\n" +
+	"\tProcessGC\x12\x1c.restaurant.ProcessGCRequest\x1a\x11.restaurant.Empty\x12Q\n" +
+	"\x10WatchPlateEvents\x12#.restaurant.WatchPlateEventsRequest\x1a\x16.restaurant.PlateEvent0\x01\x12Q\n" +
+	"\x10WatchOrderEvents\x12#.restaurant.WatchOrderEventsRequest\x1a\x16.restaurant.OrderEvent0\x01B,Z*github.com/p-program/Fenrir/rpc/restaurantb\x06proto3"
+
+var (
+	file_restaurant_proto_rawDescOnce sync.Once
+	file_restaurant_proto_rawDescData []byte
+)
+
+func file_restaurant_proto_rawDescGZIP() []byte {
+	file_restaurant_proto_rawDescOnce.Do(func() {
+		file_restaurant_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_restaurant_proto_rawDesc), len(file_restaurant_proto_rawDesc)))
+	})
+	return file_restaurant_proto_rawDescData
+}
+
+var file_restaurant_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_restaurant_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_restaurant_proto_goTypes = []any{
+	(PlateEventType)(0),             // 0: restaurant.PlateEventType
+	(OrderEventType)(0),             // 1: restaurant.OrderEventType
+	(*Empty)(nil),                   // 2: restaurant.Empty
+	(*ChargeWalletRequest)(nil),     // 3: restaurant.ChargeWalletRequest
+	(*Wallet)(nil),                  // 4: restaurant.Wallet
+	(*GetUserInfoRequest)(nil),      // 5: restaurant.GetUserInfoRequest
+	(*UserInfo)(nil),                // 6: restaurant.UserInfo
+	(*BindPlateRequest)(nil),        // 7: restaurant.BindPlateRequest
+	(*UnbindPlateRequest)(nil),      // 8: restaurant.UnbindPlateRequest
+	(*GetPlateInfoRequest)(nil),     // 9: restaurant.GetPlateInfoRequest
+	(*GetPlateListRequest)(nil),     // 10: restaurant.GetPlateListRequest
+	(*Plate)(nil),                   // 11: restaurant.Plate
+	(*PlateList)(nil),               // 12: restaurant.PlateList
+	(*OrderFood)(nil),               // 13: restaurant.OrderFood
+	(*CreateOrderRequest)(nil),      // 14: restaurant.CreateOrderRequest
+	(*OrderItem)(nil),               // 15: restaurant.OrderItem
+	(*Order)(nil),                   // 16: restaurant.Order
+	(*GetUserOrdersRequest)(nil),    // 17: restaurant.GetUserOrdersRequest
+	(*OrderList)(nil),               // 18: restaurant.OrderList
+	(*GetOrderInfoRequest)(nil),     // 19: restaurant.GetOrderInfoRequest
+	(*HandleExceptionRequest)(nil),  // 20: restaurant.HandleExceptionRequest
+	(*ProcessGCRequest)(nil),        // 21: restaurant.ProcessGCRequest
+	(*WatchPlateEventsRequest)(nil), // 22: restaurant.WatchPlateEventsRequest
+	(*PlateEvent)(nil),              // 23: restaurant.PlateEvent
+	(*WatchOrderEventsRequest)(nil), // 24: restaurant.WatchOrderEventsRequest
+	(*OrderEvent)(nil),              // 25: restaurant.OrderEvent
+	(*timestamppb.Timestamp)(nil),   // 26: google.protobuf.Timestamp
+}
+var file_restaurant_proto_depIdxs = []int32{
+	11, // 0: restaurant.PlateList.plates:type_name -> restaurant.Plate
+	13, // 1: restaurant.CreateOrderRequest.foods:type_name -> restaurant.OrderFood
+	15, // 2: restaurant.Order.foods:type_name -> restaurant.OrderItem
+	26, // 3: restaurant.Order.created_at:type_name -> google.protobuf.Timestamp
+	16, // 4: restaurant.OrderList.orders:type_name -> restaurant.Order
+	0,  // 5: restaurant.PlateEvent.type:type_name -> restaurant.PlateEventType
+	26, // 6: restaurant.PlateEvent.at:type_name -> google.protobuf.Timestamp
+	1,  // 7: restaurant.OrderEvent.type:type_name -> restaurant.OrderEventType
+	26, // 8: restaurant.OrderEvent.at:type_name -> google.protobuf.Timestamp
+	3,  // 9: restaurant.Restaurant.ChargeWallet:input_type -> restaurant.ChargeWalletRequest
+	5,  // 10: restaurant.Restaurant.GetUserInfo:input_type -> restaurant.GetUserInfoRequest
+	7,  // 11: restaurant.Restaurant.BindPlate:input_type -> restaurant.BindPlateRequest
+	8,  // 12: restaurant.Restaurant.UnbindPlate:input_type -> restaurant.UnbindPlateRequest
+	9,  // 13: restaurant.Restaurant.GetPlateInfo:input_type -> restaurant.GetPlateInfoRequest
+	10, // 14: restaurant.Restaurant.GetPlateList:input_type -> restaurant.GetPlateListRequest
+	14, // 15: restaurant.Restaurant.CreateOrder:input_type -> restaurant.CreateOrderRequest
+	17, // 16: restaurant.Restaurant.GetUserOrders:input_type -> restaurant.GetUserOrdersRequest
+	19, // 17: restaurant.Restaurant.GetOrderInfo:input_type -> restaurant.GetOrderInfoRequest
+	20, // 18: restaurant.Restaurant.HandleException:input_type -> restaurant.HandleExceptionRequest
+	21, // 19: restaurant.Restaurant.ProcessGC:input_type -> restaurant.ProcessGCRequest
+	22, // 20: restaurant.Restaurant.WatchPlateEvents:input_type -> restaurant.WatchPlateEventsRequest
+	24, // 21: restaurant.Restaurant.WatchOrderEvents:input_type -> restaurant.WatchOrderEventsRequest
+	4,  // 22: restaurant.Restaurant.ChargeWallet:output_type -> restaurant.Wallet
+	6,  // 23: restaurant.Restaurant.GetUserInfo:output_type -> restaurant.UserInfo
+	11, // 24: restaurant.Restaurant.BindPlate:output_type -> restaurant.Plate
+	2,  // 25: restaurant.Restaurant.UnbindPlate:output_type -> restaurant.Empty
+	11, // 26: restaurant.Restaurant.GetPlateInfo:output_type -> restaurant.Plate
+	12, // 27: restaurant.Restaurant.GetPlateList:output_type -> restaurant.PlateList
+	16, // 28: restaurant.Restaurant.CreateOrder:output_type -> restaurant.Order
+	18, // 29: restaurant.Restaurant.GetUserOrders:output_type -> restaurant.OrderList
+	16, // 30: restaurant.Restaurant.GetOrderInfo:output_type -> restaurant.Order
+	2,  // 31: restaurant.Restaurant.HandleException:output_type -> restaurant.Empty
+	2,  // 32: restaurant.Restaurant.ProcessGC:output_type -> restaurant.Empty
+	23, // 33: restaurant.Restaurant.WatchPlateEvents:output_type -> restaurant.PlateEvent
+	25, // 34: restaurant.Restaurant.WatchOrderEvents:output_type -> restaurant.OrderEvent
+	22, // [22:35] is the sub-list for method output_type
+	9,  // [9:22] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_restaurant_proto_init() }
+func file_restaurant_proto_init() {
+	if File_restaurant_proto != nil {
+		return
+	}
+	file_restaurant_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_restaurant_proto_rawDesc), len(file_restaurant_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_restaurant_proto_goTypes,
+		DependencyIndexes: file_restaurant_proto_depIdxs,
+		EnumInfos:         file_restaurant_proto_enumTypes,
+		MessageInfos:      file_restaurant_proto_msgTypes,
+	}.Build()
+	File_restaurant_proto = out.File
+	file_restaurant_proto_goTypes = nil
+	file_restaurant_proto_depIdxs = nil
+}
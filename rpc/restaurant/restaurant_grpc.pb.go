@@ -0,0 +1,603 @@
+// 智慧食堂 gRPC 接口，供自助机、闸机等非 Go 终端调用，与 REST 接口共用业务逻辑。
+// 调用方在 authorization 元数据中带上工作人员令牌（Bearer <令牌>），总部工作人员通过 x-canteen-id 元数据选择食堂，
+// 规则与 REST 接口相同。
+// 修改后重新生成：
+//   protoc -I rpc --go_out=. --go_opt=module=github.com/p-program/Fenrir \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/p-program/Fenrir rpc/restaurant.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: restaurant.proto
+
+package restaurant
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Restaurant_ChargeWallet_FullMethodName     = "/restaurant.Restaurant/ChargeWallet"
+	Restaurant_GetUserInfo_FullMethodName      = "/restaurant.Restaurant/GetUserInfo"
+	Restaurant_BindPlate_FullMethodName        = "/restaurant.Restaurant/BindPlate"
+	Restaurant_UnbindPlate_FullMethodName      = "/restaurant.Restaurant/UnbindPlate"
+	Restaurant_GetPlateInfo_FullMethodName     = "/restaurant.Restaurant/GetPlateInfo"
+	Restaurant_GetPlateList_FullMethodName     = "/restaurant.Restaurant/GetPlateList"
+	Restaurant_CreateOrder_FullMethodName      = "/restaurant.Restaurant/CreateOrder"
+	Restaurant_GetUserOrders_FullMethodName    = "/restaurant.Restaurant/GetUserOrders"
+	Restaurant_GetOrderInfo_FullMethodName     = "/restaurant.Restaurant/GetOrderInfo"
+	Restaurant_HandleException_FullMethodName  = "/restaurant.Restaurant/HandleException"
+	Restaurant_ProcessGC_FullMethodName        = "/restaurant.Restaurant/ProcessGC"
+	Restaurant_WatchPlateEvents_FullMethodName = "/restaurant.Restaurant/WatchPlateEvents"
+	Restaurant_WatchOrderEvents_FullMethodName = "/restaurant.Restaurant/WatchOrderEvents"
+)
+
+// RestaurantClient is the client API for Restaurant service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RestaurantClient interface {
+	// 钱包
+	ChargeWallet(ctx context.Context, in *ChargeWalletRequest, opts ...grpc.CallOption) (*Wallet, error)
+	GetUserInfo(ctx context.Context, in *GetUserInfoRequest, opts ...grpc.CallOption) (*UserInfo, error)
+	// 餐盘
+	BindPlate(ctx context.Context, in *BindPlateRequest, opts ...grpc.CallOption) (*Plate, error)
+	UnbindPlate(ctx context.Context, in *UnbindPlateRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetPlateInfo(ctx context.Context, in *GetPlateInfoRequest, opts ...grpc.CallOption) (*Plate, error)
+	GetPlateList(ctx context.Context, in *GetPlateListRequest, opts ...grpc.CallOption) (*PlateList, error)
+	// 订单
+	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	GetUserOrders(ctx context.Context, in *GetUserOrdersRequest, opts ...grpc.CallOption) (*OrderList, error)
+	GetOrderInfo(ctx context.Context, in *GetOrderInfoRequest, opts ...grpc.CallOption) (*Order, error)
+	// 工作人员
+	HandleException(ctx context.Context, in *HandleExceptionRequest, opts ...grpc.CallOption) (*Empty, error)
+	ProcessGC(ctx context.Context, in *ProcessGCRequest, opts ...grpc.CallOption) (*Empty, error)
+	// 事件推送，连接期间持续推送，不补发连接之前的事件；
+	// 客户端接收太慢、服务端丢弃了事件时以 RESOURCE_EXHAUSTED 结束，客户端重新订阅后用查询接口补齐
+	WatchPlateEvents(ctx context.Context, in *WatchPlateEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PlateEvent], error)
+	WatchOrderEvents(ctx context.Context, in *WatchOrderEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
+}
+
+type restaurantClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRestaurantClient(cc grpc.ClientConnInterface) RestaurantClient {
+	return &restaurantClient{cc}
+}
+
+func (c *restaurantClient) ChargeWallet(ctx context.Context, in *ChargeWalletRequest, opts ...grpc.CallOption) (*Wallet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Wallet)
+	err := c.cc.Invoke(ctx, Restaurant_ChargeWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) GetUserInfo(ctx context.Context, in *GetUserInfoRequest, opts ...grpc.CallOption) (*UserInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserInfo)
+	err := c.cc.Invoke(ctx, Restaurant_GetUserInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) BindPlate(ctx context.Context, in *BindPlateRequest, opts ...grpc.CallOption) (*Plate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Plate)
+	err := c.cc.Invoke(ctx, Restaurant_BindPlate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) UnbindPlate(ctx context.Context, in *UnbindPlateRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Restaurant_UnbindPlate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) GetPlateInfo(ctx context.Context, in *GetPlateInfoRequest, opts ...grpc.CallOption) (*Plate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Plate)
+	err := c.cc.Invoke(ctx, Restaurant_GetPlateInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) GetPlateList(ctx context.Context, in *GetPlateListRequest, opts ...grpc.CallOption) (*PlateList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlateList)
+	err := c.cc.Invoke(ctx, Restaurant_GetPlateList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, Restaurant_CreateOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) GetUserOrders(ctx context.Context, in *GetUserOrdersRequest, opts ...grpc.CallOption) (*OrderList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderList)
+	err := c.cc.Invoke(ctx, Restaurant_GetUserOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) GetOrderInfo(ctx context.Context, in *GetOrderInfoRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, Restaurant_GetOrderInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) HandleException(ctx context.Context, in *HandleExceptionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Restaurant_HandleException_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) ProcessGC(ctx context.Context, in *ProcessGCRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Restaurant_ProcessGC_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *restaurantClient) WatchPlateEvents(ctx context.Context, in *WatchPlateEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PlateEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Restaurant_ServiceDesc.Streams[0], Restaurant_WatchPlateEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPlateEventsRequest, PlateEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Restaurant_WatchPlateEventsClient = grpc.ServerStreamingClient[PlateEvent]
+
+func (c *restaurantClient) WatchOrderEvents(ctx context.Context, in *WatchOrderEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Restaurant_ServiceDesc.Streams[1], Restaurant_WatchOrderEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrderEventsRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Restaurant_WatchOrderEventsClient = grpc.ServerStreamingClient[OrderEvent]
+
+// RestaurantServer is the server API for Restaurant service.
+// All implementations must embed UnimplementedRestaurantServer
+// for forward compatibility.
+type RestaurantServer interface {
+	// 钱包
+	ChargeWallet(context.Context, *ChargeWalletRequest) (*Wallet, error)
+	GetUserInfo(context.Context, *GetUserInfoRequest) (*UserInfo, error)
+	// 餐盘
+	BindPlate(context.Context, *BindPlateRequest) (*Plate, error)
+	UnbindPlate(context.Context, *UnbindPlateRequest) (*Empty, error)
+	GetPlateInfo(context.Context, *GetPlateInfoRequest) (*Plate, error)
+	GetPlateList(context.Context, *GetPlateListRequest) (*PlateList, error)
+	// 订单
+	CreateOrder(context.Context, *CreateOrderRequest) (*Order, error)
+	GetUserOrders(context.Context, *GetUserOrdersRequest) (*OrderList, error)
+	GetOrderInfo(context.Context, *GetOrderInfoRequest) (*Order, error)
+	// 工作人员
+	HandleException(context.Context, *HandleExceptionRequest) (*Empty, error)
+	ProcessGC(context.Context, *ProcessGCRequest) (*Empty, error)
+	// 事件推送，连接期间持续推送，不补发连接之前的事件；
+	// 客户端接收太慢、服务端丢弃了事件时以 RESOURCE_EXHAUSTED 结束，客户端重新订阅后用查询接口补齐
+	WatchPlateEvents(*WatchPlateEventsRequest, grpc.ServerStreamingServer[PlateEvent]) error
+	WatchOrderEvents(*WatchOrderEventsRequest, grpc.ServerStreamingServer[OrderEvent]) error
+	mustEmbedUnimplementedRestaurantServer()
+}
+
+// UnimplementedRestaurantServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRestaurantServer struct{}
+
+func (UnimplementedRestaurantServer) ChargeWallet(context.Context, *ChargeWalletRequest) (*Wallet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChargeWallet not implemented")
+}
+func (UnimplementedRestaurantServer) GetUserInfo(context.Context, *GetUserInfoRequest) (*UserInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfo not implemented")
+}
+func (UnimplementedRestaurantServer) BindPlate(context.Context, *BindPlateRequest) (*Plate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BindPlate not implemented")
+}
+func (UnimplementedRestaurantServer) UnbindPlate(context.Context, *UnbindPlateRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnbindPlate not implemented")
+}
+func (UnimplementedRestaurantServer) GetPlateInfo(context.Context, *GetPlateInfoRequest) (*Plate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlateInfo not implemented")
+}
+func (UnimplementedRestaurantServer) GetPlateList(context.Context, *GetPlateListRequest) (*PlateList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlateList not implemented")
+}
+func (UnimplementedRestaurantServer) CreateOrder(context.Context, *CreateOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
+}
+func (UnimplementedRestaurantServer) GetUserOrders(context.Context, *GetUserOrdersRequest) (*OrderList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserOrders not implemented")
+}
+func (UnimplementedRestaurantServer) GetOrderInfo(context.Context, *GetOrderInfoRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderInfo not implemented")
+}
+func (UnimplementedRestaurantServer) HandleException(context.Context, *HandleExceptionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleException not implemented")
+}
+func (UnimplementedRestaurantServer) ProcessGC(context.Context, *ProcessGCRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessGC not implemented")
+}
+func (UnimplementedRestaurantServer) WatchPlateEvents(*WatchPlateEventsRequest, grpc.ServerStreamingServer[PlateEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPlateEvents not implemented")
+}
+func (UnimplementedRestaurantServer) WatchOrderEvents(*WatchOrderEventsRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrderEvents not implemented")
+}
+func (UnimplementedRestaurantServer) mustEmbedUnimplementedRestaurantServer() {}
+func (UnimplementedRestaurantServer) testEmbeddedByValue()                    {}
+
+// UnsafeRestaurantServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RestaurantServer will
+// result in compilation errors.
+type UnsafeRestaurantServer interface {
+	mustEmbedUnimplementedRestaurantServer()
+}
+
+func RegisterRestaurantServer(s grpc.ServiceRegistrar, srv RestaurantServer) {
+	// If the following call pancis, it indicates UnimplementedRestaurantServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Restaurant_ServiceDesc, srv)
+}
+
+func _Restaurant_ChargeWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChargeWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).ChargeWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_ChargeWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).ChargeWallet(ctx, req.(*ChargeWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_GetUserInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).GetUserInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_GetUserInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).GetUserInfo(ctx, req.(*GetUserInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_BindPlate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BindPlateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).BindPlate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_BindPlate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).BindPlate(ctx, req.(*BindPlateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_UnbindPlate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbindPlateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).UnbindPlate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_UnbindPlate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).UnbindPlate(ctx, req.(*UnbindPlateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_GetPlateInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlateInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).GetPlateInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_GetPlateInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).GetPlateInfo(ctx, req.(*GetPlateInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_GetPlateList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlateListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).GetPlateList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_GetPlateList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).GetPlateList(ctx, req.(*GetPlateListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_CreateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).CreateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_CreateOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).CreateOrder(ctx, req.(*CreateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_GetUserOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).GetUserOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_GetUserOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).GetUserOrders(ctx, req.(*GetUserOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_GetOrderInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).GetOrderInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_GetOrderInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).GetOrderInfo(ctx, req.(*GetOrderInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_HandleException_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandleExceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).HandleException(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_HandleException_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).HandleException(ctx, req.(*HandleExceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_ProcessGC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessGCRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestaurantServer).ProcessGC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Restaurant_ProcessGC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestaurantServer).ProcessGC(ctx, req.(*ProcessGCRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Restaurant_WatchPlateEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPlateEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RestaurantServer).WatchPlateEvents(m, &grpc.GenericServerStream[WatchPlateEventsRequest, PlateEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Restaurant_WatchPlateEventsServer = grpc.ServerStreamingServer[PlateEvent]
+
+func _Restaurant_WatchOrderEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RestaurantServer).WatchOrderEvents(m, &grpc.GenericServerStream[WatchOrderEventsRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Restaurant_WatchOrderEventsServer = grpc.ServerStreamingServer[OrderEvent]
+
+// Restaurant_ServiceDesc is the grpc.ServiceDesc for Restaurant service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Restaurant_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "restaurant.Restaurant",
+	HandlerType: (*RestaurantServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ChargeWallet",
+			Handler:    _Restaurant_ChargeWallet_Handler,
+		},
+		{
+			MethodName: "GetUserInfo",
+			Handler:    _Restaurant_GetUserInfo_Handler,
+		},
+		{
+			MethodName: "BindPlate",
+			Handler:    _Restaurant_BindPlate_Handler,
+		},
+		{
+			MethodName: "UnbindPlate",
+			Handler:    _Restaurant_UnbindPlate_Handler,
+		},
+		{
+			MethodName: "GetPlateInfo",
+			Handler:    _Restaurant_GetPlateInfo_Handler,
+		},
+		{
+			MethodName: "GetPlateList",
+			Handler:    _Restaurant_GetPlateList_Handler,
+		},
+		{
+			MethodName: "CreateOrder",
+			Handler:    _Restaurant_CreateOrder_Handler,
+		},
+		{
+			MethodName: "GetUserOrders",
+			Handler:    _Restaurant_GetUserOrders_Handler,
+		},
+		{
+			MethodName: "GetOrderInfo",
+			Handler:    _Restaurant_GetOrderInfo_Handler,
+		},
+		{
+			MethodName: "HandleException",
+			Handler:    _Restaurant_HandleException_Handler,
+		},
+		{
+			MethodName: "ProcessGC",
+			Handler:    _Restaurant_ProcessGC_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPlateEvents",
+			Handler:       _Restaurant_WatchPlateEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchOrderEvents",
+			Handler:       _Restaurant_WatchOrderEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "restaurant.proto",
+}